@@ -25,6 +25,16 @@ type BasicClient struct {
 	UserAgent string
 
 	HTTPClient *http.Client
+
+	// Hooks, if non-nil, are called as requests are made and responses
+	// received, to allow them to be logged or traced
+	Hooks Hooks
+
+	// SecurityIDCheck, if non-nil, is applied to the SecurityIDs in responses
+	// to Request() as ParseOptions.SecurityIDCheck is by
+	// ParseResponseWithOptions. Responses with SecurityIDs it rejects are
+	// treated as invalid.
+	SecurityIDCheck func(id SecurityID) error
 }
 
 // OfxVersion returns the OFX specification version this BasicClient will marshal
@@ -65,6 +75,14 @@ func (c *BasicClient) CarriageReturnNewLines() bool {
 	return c.CarriageReturn
 }
 
+func (c *BasicClient) hooks() Hooks {
+	return c.Hooks
+}
+
+func (c *BasicClient) securityIDCheck() func(id SecurityID) error {
+	return c.SecurityIDCheck
+}
+
 // RawRequest is a convenience wrapper around http.Post. It is exposed only for
 // when you need to read/inspect the raw HTTP response yourself.
 func (c *BasicClient) RawRequest(URL string, r io.Reader) (*http.Response, error) {
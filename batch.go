@@ -0,0 +1,213 @@
+package ofxgo
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+)
+
+// BatchOptions control the statement requests made for each account in a
+// Batch
+type BatchOptions struct {
+	DtStart        *Date // Passed as DTSTART for each account, if non-nil
+	DtEnd          *Date // Passed as DTEND for each account, if non-nil
+	IncludePending bool  // Request pending transactions for bank and credit card accounts
+	IncludeAll     bool  // Request statements for accounts which aren't ACTIVE (ordinarily skipped)
+}
+
+// BatchAccount is one account included in a Batch, along with the TRNUID and
+// CLTCOOKIE of the statement request made for it
+type BatchAccount struct {
+	AcctInfo  *AcctInfo
+	TrnUID    UID
+	CltCookie String
+}
+
+// Batch requests statements for many accounts at the same FI together, in as
+// few Requests as possible, and matches the statements returned back to the
+// accounts they were requested for
+type Batch struct {
+	Accounts []BatchAccount
+	Requests []*Request
+}
+
+// BatchResult is the result of the statement request for one BatchAccount.
+// Response is nil if the FI didn't return a response for the account's
+// transaction, and is otherwise one of *StatementResponse,
+// *CCStatementResponse, or *InvStatementResponse.
+type BatchResult struct {
+	Account  *BatchAccount
+	Response Message
+}
+
+// NewBatch returns a Batch with one statement request for each bank, credit
+// card, and investment account listed in accounts (which is usually obtained
+// by sending an AcctInfoRequest). template supplies the URL and SignonRequest
+// used for the batched request, and any messages already in it are ignored.
+// Accounts of other types (i.e. loans) are skipped, as are accounts which
+// aren't ACTIVE unless options.IncludeAll is set.
+func NewBatch(template *Request, accounts *AcctInfoResponse, options BatchOptions) (*Batch, error) {
+	var b Batch
+	request := Request{
+		URL:     template.URL,
+		Version: template.Version,
+		Signon:  template.Signon,
+	}
+
+	for i := range accounts.AcctInfo {
+		acctinfo := &accounts.AcctInfo[i]
+		uid, err := RandomUID()
+		if err != nil {
+			return nil, err
+		}
+		account := BatchAccount{
+			AcctInfo:  acctinfo,
+			TrnUID:    *uid,
+			CltCookie: String(strconv.Itoa(len(b.Accounts) + 1)),
+		}
+
+		if acctinfo.BankAcctInfo != nil {
+			if acctinfo.BankAcctInfo.SvcStatus != SvcStatusActive && !options.IncludeAll {
+				continue
+			}
+			request.Bank = append(request.Bank, &StatementRequest{
+				TrnUID:         account.TrnUID,
+				CltCookie:      account.CltCookie,
+				BankAcctFrom:   acctinfo.BankAcctInfo.BankAcctFrom,
+				DtStart:        options.DtStart,
+				DtEnd:          options.DtEnd,
+				Include:        acctinfo.BankAcctInfo.SupTxDl,
+				IncludePending: Boolean(options.IncludePending),
+			})
+		} else if acctinfo.CCAcctInfo != nil {
+			if acctinfo.CCAcctInfo.SvcStatus != SvcStatusActive && !options.IncludeAll {
+				continue
+			}
+			request.CreditCard = append(request.CreditCard, &CCStatementRequest{
+				TrnUID:         account.TrnUID,
+				CltCookie:      account.CltCookie,
+				CCAcctFrom:     acctinfo.CCAcctInfo.CCAcctFrom,
+				DtStart:        options.DtStart,
+				DtEnd:          options.DtEnd,
+				Include:        acctinfo.CCAcctInfo.SupTxDl,
+				IncludePending: Boolean(options.IncludePending),
+			})
+		} else if acctinfo.InvAcctInfo != nil {
+			if acctinfo.InvAcctInfo.SvcStatus != SvcStatusActive && !options.IncludeAll {
+				continue
+			}
+			request.InvStmt = append(request.InvStmt, &InvStatementRequest{
+				TrnUID:         account.TrnUID,
+				CltCookie:      account.CltCookie,
+				InvAcctFrom:    acctinfo.InvAcctInfo.InvAcctFrom,
+				DtStart:        options.DtStart,
+				DtEnd:          options.DtEnd,
+				Include:        true,
+				IncludeOO:      true,
+				IncludePos:     true,
+				IncludeBalance: true,
+			})
+		} else {
+			continue
+		}
+		b.Accounts = append(b.Accounts, account)
+	}
+
+	if len(b.Accounts) == 0 {
+		return nil, errors.New("No accounts to request statements for")
+	}
+	b.Requests = []*Request{&request}
+	return &b, nil
+}
+
+// Route splits the Batch's requests so each message set is sent to the URL
+// the FI advertised for it in its profile, as described in
+// ProfiledClient.Prepare
+func (b *Batch) Route(pc *ProfiledClient) error {
+	var routed []*Request
+	for _, request := range b.Requests {
+		requests, err := pc.Prepare(request)
+		if err != nil {
+			return err
+		}
+		routed = append(routed, requests...)
+	}
+	b.Requests = routed
+	return nil
+}
+
+// Send sends each of the Batch's requests with c and returns the matched
+// results, along with the first error encountered (if any). Results are still
+// returned for all accounts if an error occurs, but accounts in requests
+// which failed entirely will have nil Responses. Accounts whose individual
+// transactions failed have Responses with an ERROR Status.
+func (b *Batch) Send(c Client) ([]BatchResult, error) {
+	var responses []*Response
+	var firstErr error
+	for _, request := range b.Requests {
+		response, err := c.Request(request)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if response != nil {
+			responses = append(responses, response)
+		}
+	}
+	return b.Match(responses...), firstErr
+}
+
+// transactionIDs returns the TRNUID and CLTCOOKIE of a transaction wrapper
+// aggregate (*TRNRQ or *TRNRS), or ok=false if m doesn't have them
+func transactionIDs(m Message) (trnUID UID, cltCookie String, ok bool) {
+	v := reflect.ValueOf(m)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", "", false
+	}
+	uidField := v.FieldByName("TrnUID")
+	if !uidField.IsValid() || uidField.Type() != reflect.TypeOf(UID("")) {
+		return "", "", false
+	}
+	trnUID = uidField.Interface().(UID)
+	if cookieField := v.FieldByName("CltCookie"); cookieField.IsValid() && cookieField.Type() == reflect.TypeOf(String("")) {
+		cltCookie = cookieField.Interface().(String)
+	}
+	return trnUID, cltCookie, true
+}
+
+// Match pairs the statement responses in responses with the accounts they
+// were requested for, by TRNUID. Responses whose TRNUID doesn't match any
+// account are matched by CLTCOOKIE instead, for FIs which don't echo TRNUIDs
+// faithfully. One BatchResult is returned for each account, in the same order
+// as b.Accounts.
+func (b *Batch) Match(responses ...*Response) []BatchResult {
+	results := make([]BatchResult, len(b.Accounts))
+	byUID := make(map[UID]int)
+	byCookie := make(map[String]int)
+	for i := range b.Accounts {
+		results[i].Account = &b.Accounts[i]
+		byUID[b.Accounts[i].TrnUID] = i
+		byCookie[b.Accounts[i].CltCookie] = i
+	}
+
+	for _, response := range responses {
+		for _, messages := range [][]Message{response.Bank, response.CreditCard, response.InvStmt} {
+			for _, message := range messages {
+				trnUID, cltCookie, ok := transactionIDs(message)
+				if !ok {
+					continue
+				}
+				i, ok := byUID[trnUID]
+				if !ok {
+					i, ok = byCookie[cltCookie]
+				}
+				if ok && results[i].Response == nil {
+					results[i].Response = message
+				}
+			}
+		}
+	}
+	return results
+}
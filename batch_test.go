@@ -0,0 +1,124 @@
+package ofxgo
+
+import (
+	"testing"
+)
+
+func testAcctInfoResponse() *AcctInfoResponse {
+	return &AcctInfoResponse{
+		TrnUID: "1",
+		AcctInfo: []AcctInfo{
+			{
+				Desc: "Checking",
+				BankAcctInfo: &BankAcctInfo{
+					BankAcctFrom: BankAcct{BankID: "318398732", AcctID: "78346129", AcctType: AcctTypeChecking},
+					SupTxDl:      true,
+					SvcStatus:    SvcStatusActive,
+				},
+			},
+			{
+				Desc: "Closed savings",
+				BankAcctInfo: &BankAcctInfo{
+					BankAcctFrom: BankAcct{BankID: "318398732", AcctID: "78346130", AcctType: AcctTypeSavings},
+					SvcStatus:    SvcStatusAvail,
+				},
+			},
+			{
+				Desc: "Credit card",
+				CCAcctInfo: &CCAcctInfo{
+					CCAcctFrom: CCAcct{AcctID: "4222222222222"},
+					SupTxDl:    true,
+					SvcStatus:  SvcStatusActive,
+				},
+			},
+			{
+				Desc: "Brokerage",
+				InvAcctInfo: &InvAcctInfo{
+					InvAcctFrom: InvAcct{BrokerID: "example.com", AcctID: "12345"},
+					SvcStatus:   SvcStatusActive,
+				},
+			},
+		},
+	}
+}
+
+func TestNewBatch(t *testing.T) {
+	var template Request
+	template.URL = "https://ofx.example.com/"
+	template.Signon.UserID = "myusername"
+	template.Signon.UserPass = "Passw0rd"
+
+	dtStart := NewDateGMT(2017, 1, 1, 0, 0, 0, 0)
+	b, err := NewBatch(&template, testAcctInfoResponse(), BatchOptions{DtStart: dtStart})
+	if err != nil {
+		t.Fatalf("Unexpected error creating batch: %s\n", err)
+	}
+	if len(b.Accounts) != 3 {
+		t.Fatalf("Expected 3 accounts in batch (skipping inactive one), got %d\n", len(b.Accounts))
+	}
+	if len(b.Requests) != 1 {
+		t.Fatalf("Expected 1 request, got %d\n", len(b.Requests))
+	}
+	r := b.Requests[0]
+	if r.URL != template.URL || r.Signon.UserID != "myusername" {
+		t.Errorf("Request not created from template\n")
+	}
+	if len(r.Bank) != 1 || len(r.CreditCard) != 1 || len(r.InvStmt) != 1 {
+		t.Fatalf("Expected one statement request per account type, got %d, %d, %d\n", len(r.Bank), len(r.CreditCard), len(r.InvStmt))
+	}
+	stmtRequest := r.Bank[0].(*StatementRequest)
+	if stmtRequest.BankAcctFrom.AcctID != "78346129" || stmtRequest.DtStart != dtStart || !stmtRequest.Include {
+		t.Errorf("Bank statement request not filled in correctly: %+v\n", stmtRequest)
+	}
+	seen := make(map[UID]bool)
+	for _, account := range b.Accounts {
+		if seen[account.TrnUID] {
+			t.Errorf("Duplicate TRNUID %s in batch\n", account.TrnUID)
+		}
+		seen[account.TrnUID] = true
+	}
+
+	b, err = NewBatch(&template, testAcctInfoResponse(), BatchOptions{IncludeAll: true})
+	if err != nil {
+		t.Fatalf("Unexpected error creating batch: %s\n", err)
+	}
+	if len(b.Accounts) != 4 {
+		t.Errorf("Expected IncludeAll to include inactive account\n")
+	}
+
+	if _, err = NewBatch(&template, &AcctInfoResponse{TrnUID: "1"}, BatchOptions{}); err == nil {
+		t.Errorf("Expected error creating batch without accounts\n")
+	}
+}
+
+func TestBatchMatch(t *testing.T) {
+	var template Request
+	b, err := NewBatch(&template, testAcctInfoResponse(), BatchOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error creating batch: %s\n", err)
+	}
+
+	bankResponse := &StatementResponse{TrnUID: b.Accounts[0].TrnUID}
+	// Matched by CLTCOOKIE when the FI mangles the TRNUID
+	invResponse := &InvStatementResponse{TrnUID: "mangled", CltCookie: b.Accounts[2].CltCookie}
+	unknownResponse := &CCStatementResponse{TrnUID: "unknown"}
+
+	var response1, response2 Response
+	response1.InvStmt = append(response1.InvStmt, invResponse)
+	response2.Bank = append(response2.Bank, bankResponse)
+	response2.CreditCard = append(response2.CreditCard, unknownResponse)
+
+	results := b.Match(&response1, &response2)
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d\n", len(results))
+	}
+	if results[0].Account != &b.Accounts[0] || results[0].Response != bankResponse {
+		t.Errorf("Bank response not matched to its account\n")
+	}
+	if results[1].Response != nil {
+		t.Errorf("Expected no response for credit card account, got %v\n", results[1].Response)
+	}
+	if results[2].Response != invResponse {
+		t.Errorf("Investment response not matched to its account by CLTCOOKIE\n")
+	}
+}
@@ -1,9 +1,12 @@
 package ofxgo
 
 import (
+	"bytes"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // Client serves to aggregate OFX client settings that may be necessary to talk
@@ -27,6 +30,10 @@ type Client interface {
 	// (Version, AppID, AppVer fields), and the client's current time
 	// (DtClient). These are updated in place in the supplied Request object so
 	// they may later be inspected by the caller.
+	//
+	// If the server indicates the signon or any transaction failed, the
+	// Response is returned along with a *StatusError describing the first
+	// failure.
 	Request(r *Request) (*Response, error)
 
 	// RequestNoParse marshals a Request object into XML, makes an HTTP
@@ -85,7 +92,16 @@ func clientRequestNoParse(c Client, r *Request) (*http.Response, error) {
 		return nil, err
 	}
 
-	return c.RawRequest(r.URL, b)
+	hooks := clientHooks(c)
+	if hooks == nil {
+		return c.RawRequest(r.URL, b)
+	}
+
+	hooks.RequestMarshaled(redactedRequest(r), RedactCredentials(b.Bytes()))
+	start := time.Now()
+	response, err := c.RawRequest(r.URL, b)
+	hooks.RequestSent(r.URL, response, time.Since(start), err)
+	return response, err
 }
 
 // clientRequest can be used for building clients' Request methods if they
@@ -97,9 +113,53 @@ func clientRequest(c Client, r *Request) (*Response, error) {
 	}
 	defer response.Body.Close()
 
-	ofxresp, err := ParseResponse(response.Body)
+	hooks := clientHooks(c)
+	if hooks == nil {
+		ofxresp, err := DecodeResponse(response.Body)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateClientResponse(c, ofxresp); err != nil {
+			return nil, err
+		}
+		return ofxresp, ofxresp.statusErr()
+	}
+
+	// Read the whole body so it can be handed to the hooks before parsing
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	hooks.ResponseReceived(r.URL, RedactCredentials(body))
+
+	ofxresp, err := DecodeResponse(bytes.NewReader(body))
+	hooks.ResponseParsed(ofxresp, err)
 	if err != nil {
 		return nil, err
 	}
-	return ofxresp, nil
+	if err := validateClientResponse(c, ofxresp); err != nil {
+		hooks.ValidationFailed(ofxresp, err)
+		return nil, err
+	}
+	return ofxresp, ofxresp.statusErr()
+}
+
+// securityIDCheckedClient is implemented by Clients which can be configured
+// to check the SecurityIDs in responses
+type securityIDCheckedClient interface {
+	securityIDCheck() func(id SecurityID) error
+}
+
+// validateClientResponse validates a response received by c, including
+// checking its SecurityIDs if c is configured to
+func validateClientResponse(c Client, response *Response) error {
+	if ok, err := response.Valid(); !ok {
+		return err
+	}
+	if cc, ok := c.(securityIDCheckedClient); ok {
+		if check := cc.securityIDCheck(); check != nil {
+			return response.securityIDErr(check)
+		}
+	}
+	return nil
 }
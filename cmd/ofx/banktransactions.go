@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/store"
 	"os"
 )
 
@@ -11,12 +12,14 @@ var bankTransactionsCommand = command{
 	Name:        "transactions-bank",
 	Description: "Print bank transactions and balance",
 	Flags:       flag.NewFlagSet("transactions-bank", flag.ExitOnError),
-	CheckFlags:  checkServerFlags,
+	CheckFlags:  checkTransactionFlags,
 	Do:          bankTransactions,
 }
 
 func init() {
 	defineServerFlags(bankTransactionsCommand.Flags)
+	defineStoreFlags(bankTransactionsCommand.Flags)
+	defineOutputFlags(bankTransactionsCommand.Flags)
 	bankTransactionsCommand.Flags.StringVar(&bankID, "bankid", "", "BankID (from `get-accounts` subcommand)")
 	bankTransactionsCommand.Flags.StringVar(&acctID, "acctid", "", "AcctID (from `get-accounts` subcommand)")
 	bankTransactionsCommand.Flags.StringVar(&acctType, "accttype", "CHECKING", "AcctType (from `get-accounts` subcommand)")
@@ -37,14 +40,18 @@ func bankTransactions() {
 		os.Exit(1)
 	}
 
+	bankAcct := ofxgo.BankAcct{
+		BankID:   ofxgo.String(bankID),
+		AcctID:   ofxgo.String(acctID),
+		AcctType: acctTypeEnum,
+	}
+	st, dtStart := openStore(store.BankAccountKey(bankAcct))
+
 	statementRequest := ofxgo.StatementRequest{
-		TrnUID: *uid,
-		BankAcctFrom: ofxgo.BankAcct{
-			BankID:   ofxgo.String(bankID),
-			AcctID:   ofxgo.String(acctID),
-			AcctType: acctTypeEnum,
-		},
-		Include: true,
+		TrnUID:       *uid,
+		BankAcctFrom: bankAcct,
+		DtStart:      dtStart,
+		Include:      true,
 	}
 
 	query.Bank = append(query.Bank, &statementRequest)
@@ -60,10 +67,18 @@ func bankTransactions() {
 		os.Exit(1)
 	}
 
+	// Errors are returned by Request as a *ofxgo.StatusError, but the server
+	// may still have something to say, such as that the password will
+	// expire soon
 	if response.Signon.Status.Code != 0 {
 		meaning, _ := response.Signon.Status.CodeMeaning()
-		fmt.Printf("Nonzero signon status (%d: %s) with message: %s\n", response.Signon.Status.Code, meaning, response.Signon.Status.Message)
-		os.Exit(1)
+		fmt.Printf("Warning: nonzero signon status (%d: %s) with message: %s\n", response.Signon.Status.Code, meaning, response.Signon.Status.Message)
+	}
+
+	saveResponse(st, response)
+
+	if printStatements(response) {
+		return
 	}
 
 	if len(response.Bank) < 1 {
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/store"
 	"os"
 )
 
@@ -11,12 +12,14 @@ var ccTransactionsCommand = command{
 	Name:        "transactions-cc",
 	Description: "Print credit card transactions and balance",
 	Flags:       flag.NewFlagSet("transactions-cc", flag.ExitOnError),
-	CheckFlags:  checkServerFlags,
+	CheckFlags:  checkTransactionFlags,
 	Do:          ccTransactions,
 }
 
 func init() {
 	defineServerFlags(ccTransactionsCommand.Flags)
+	defineStoreFlags(ccTransactionsCommand.Flags)
+	defineOutputFlags(ccTransactionsCommand.Flags)
 	ccTransactionsCommand.Flags.StringVar(&acctID, "acctid", "", "AcctID (from `get-accounts` subcommand)")
 }
 
@@ -29,12 +32,16 @@ func ccTransactions() {
 		os.Exit(1)
 	}
 
+	ccAcct := ofxgo.CCAcct{
+		AcctID: ofxgo.String(acctID),
+	}
+	st, dtStart := openStore(store.CCAccountKey(ccAcct))
+
 	statementRequest := ofxgo.CCStatementRequest{
-		TrnUID: *uid,
-		CCAcctFrom: ofxgo.CCAcct{
-			AcctID: ofxgo.String(acctID),
-		},
-		Include: true,
+		TrnUID:     *uid,
+		CCAcctFrom: ccAcct,
+		DtStart:    dtStart,
+		Include:    true,
 	}
 	query.CreditCard = append(query.CreditCard, &statementRequest)
 
@@ -49,10 +56,18 @@ func ccTransactions() {
 		os.Exit(1)
 	}
 
+	// Errors are returned by Request as a *ofxgo.StatusError, but the server
+	// may still have something to say, such as that the password will
+	// expire soon
 	if response.Signon.Status.Code != 0 {
 		meaning, _ := response.Signon.Status.CodeMeaning()
-		fmt.Printf("Nonzero signon status (%d: %s) with message: %s\n", response.Signon.Status.Code, meaning, response.Signon.Status.Message)
-		os.Exit(1)
+		fmt.Printf("Warning: nonzero signon status (%d: %s) with message: %s\n", response.Signon.Status.Code, meaning, response.Signon.Status.Message)
+	}
+
+	saveResponse(st, response)
+
+	if printStatements(response) {
+		return
 	}
 
 	if len(response.CreditCard) < 1 {
@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"github.com/aclindsa/ofxgo/fidir"
 	"golang.org/x/term"
 	"os"
 )
@@ -26,9 +27,15 @@ var noIndentRequests bool
 var carriageReturn bool
 var dryrun bool
 var userAgent string
+var institution string
+
+// setFlags records which flags were explicitly passed on the command line, so
+// that settings looked up with -institution don't override them
+var setFlags = map[string]bool{}
 
 func defineServerFlags(f *flag.FlagSet) {
 	f.StringVar(&serverURL, "url", "", "Financial institution's OFX Server URL (see ofxhome.com if you don't know it)")
+	f.StringVar(&institution, "institution", "", "Name of financial institution to look up URL, ORG, FID, etc. for (see `list-institutions` subcommand)")
 	f.StringVar(&username, "username", "", "Your username at financial institution")
 	f.StringVar(&password, "password", "", "Your password at financial institution")
 	f.StringVar(&org, "org", "", "'ORG' for your financial institution")
@@ -43,8 +50,46 @@ func defineServerFlags(f *flag.FlagSet) {
 	f.BoolVar(&dryrun, "dryrun", false, "Don't send request - print content of request instead")
 }
 
+// applyInstitution fills in any server settings not explicitly set on the
+// command line from the -institution directory entry
+func applyInstitution() bool {
+	if len(institution) == 0 {
+		return true
+	}
+	inst, err := fidir.Default().ByName(institution)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return false
+	}
+	setString := func(name string, value *string, instValue string) {
+		if !setFlags[name] && len(instValue) > 0 {
+			*value = instValue
+		}
+	}
+	setString("url", &serverURL, inst.URL)
+	setString("org", &org, inst.Org)
+	setString("fid", &fid, inst.Fid)
+	setString("appid", &appID, inst.AppID)
+	setString("appver", &appVer, inst.AppVer)
+	setString("ofxversion", &ofxVersion, inst.OfxVersion)
+	setString("useragent", &userAgent, inst.UserAgent)
+	setString("bankid", &bankID, inst.BankID)
+	setString("brokerid", &brokerID, inst.BrokerID)
+	if !setFlags["noindent"] && inst.HasQuirk(fidir.QuirkNoIndent) {
+		noIndentRequests = true
+	}
+	if !setFlags["carriagereturn"] && inst.HasQuirk(fidir.QuirkCarriageReturn) {
+		carriageReturn = true
+	}
+	if inst.HasQuirk(fidir.QuirkClientUID) && len(clientUID) == 0 {
+		fmt.Printf("Error: %s requires -clientuid to be set\n", inst.Name)
+		return false
+	}
+	return true
+}
+
 func checkServerFlags() bool {
-	var ret bool = true
+	var ret bool = applyInstitution()
 	if len(serverURL) == 0 {
 		fmt.Println("Error: Server URL empty")
 		ret = false
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/aclindsa/ofxgo"
+	"io/ioutil"
+	"os"
+)
+
+var convertCommand = command{
+	Name:        "convert",
+	Description: "Convert an OFX response between SGML (1.x) and XML (2.x) versions",
+	Flags:       flag.NewFlagSet("convert", flag.ExitOnError),
+	CheckFlags:  checkConvertFlags,
+	Do:          convert,
+}
+
+var inputFilename, outputFilename, targetVersion string
+var lenient bool
+
+func init() {
+	convertCommand.Flags.StringVar(&inputFilename, "input", "", "The OFX file to convert")
+	convertCommand.Flags.StringVar(&outputFilename, "filename", "", "The file to save the converted response to (defaults to stdout)")
+	convertCommand.Flags.StringVar(&targetVersion, "ofxversion", "203", "OFX version to convert to")
+	convertCommand.Flags.BoolVar(&lenient, "lenient", false, "Attempt to repair malformed input rather than failing")
+}
+
+func checkConvertFlags() bool {
+	if len(inputFilename) == 0 {
+		fmt.Println("Error: Input file must be specified with -input")
+		return false
+	}
+	if _, err := ofxgo.NewOfxVersion(targetVersion); err != nil {
+		fmt.Println("Error:", err)
+		return false
+	}
+	return true
+}
+
+func convert() {
+	version, _ := ofxgo.NewOfxVersion(targetVersion)
+
+	file, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Println("Error opening input file:", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	response, diagnostics, err := ofxgo.ParseResponseWithOptions(file, ofxgo.ParseOptions{Lenient: lenient})
+	for _, d := range diagnostics {
+		fmt.Fprintln(os.Stderr, "Warning:", d)
+	}
+	if err != nil {
+		fmt.Println("Error parsing response:", err)
+		os.Exit(1)
+	}
+
+	converted, diagnostics, err := response.Convert(version)
+	for _, d := range diagnostics {
+		fmt.Fprintln(os.Stderr, "Warning:", d)
+	}
+	if err != nil {
+		fmt.Println("Error converting response:", err)
+		os.Exit(1)
+	}
+
+	b, err := converted.Marshal()
+	if err != nil {
+		fmt.Println("Error marshalling converted response:", err)
+		os.Exit(1)
+	}
+
+	if len(outputFilename) == 0 {
+		os.Stdout.Write(b.Bytes())
+	} else if err := ioutil.WriteFile(outputFilename, b.Bytes(), 0644); err != nil {
+		fmt.Println("Error writing converted response:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/csvimport"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var csvToOFXCommand = command{
+	Name:        "csv-to-ofx",
+	Description: "Convert a CSV bank or credit card download to an OFX statement",
+	Flags:       flag.NewFlagSet("csv-to-ofx", flag.ExitOnError),
+	CheckFlags:  checkCSVToOFXFlags,
+	Do:          csvToOFX,
+}
+
+var csvOptions csvimport.Options
+var csvCreditCard bool
+var csvDateFormats, csvDebitIndicators, csvOrg, csvFid string
+
+func init() {
+	f := csvToOFXCommand.Flags
+	f.StringVar(&inputFilename, "input", "", "The CSV file to convert")
+	f.StringVar(&outputFilename, "filename", "", "The file to save the OFX statement to (defaults to stdout)")
+	f.StringVar(&bankID, "bankid", "", "BankID of the account (for bank accounts)")
+	f.StringVar(&acctID, "acctid", "", "AcctID of the account")
+	f.StringVar(&acctType, "accttype", "CHECKING", "AcctType of the account (for bank accounts)")
+	f.BoolVar(&csvCreditCard, "cc", false, "The CSV is for a credit card account rather than a bank account")
+	f.StringVar(&csvOrg, "org", "", "FI>ORG to write in the statement's signon")
+	f.StringVar(&csvFid, "fid", "", "FI>FID to write in the statement's signon")
+
+	f.StringVar(&csvOptions.Columns.Date, "date", "Date", "Column holding transaction dates (a header, or a number starting at 1 with -noheader)")
+	f.StringVar(&csvOptions.Columns.Amount, "amount", "", "Column holding signed transaction amounts")
+	f.StringVar(&csvOptions.Columns.Debit, "debit", "", "Column holding debit amounts")
+	f.StringVar(&csvOptions.Columns.Credit, "credit", "", "Column holding credit amounts")
+	f.StringVar(&csvOptions.Columns.Indicator, "indicator", "", "Column saying whether unsigned amounts are debits or credits")
+	f.StringVar(&csvOptions.Columns.Name, "name", "", "Column holding payee names")
+	f.StringVar(&csvOptions.Columns.Memo, "memo", "", "Column holding memos")
+	f.StringVar(&csvOptions.Columns.CheckNum, "checknum", "", "Column holding check numbers")
+	f.StringVar(&csvOptions.Columns.RefNum, "refnum", "", "Column holding reference numbers")
+	f.StringVar(&csvOptions.Columns.FiTID, "fitid", "", "Column holding unique transaction IDs (synthesized if not given)")
+	f.StringVar(&csvOptions.Columns.Balance, "balance", "", "Column holding the running balance")
+	f.StringVar(&csvDateFormats, "dateformat", "", "Comma-separated list of date formats, in Go's time.Parse form (i.e. 01/02/2006)")
+	f.StringVar(&csvDebitIndicators, "debitindicators", "", "Comma-separated list of -indicator values marking debits (defaults to debit,dr,d)")
+	f.BoolVar(&csvOptions.InvertSign, "invert", false, "Negate amounts (for CSVs where debits are positive)")
+	f.BoolVar(&csvOptions.DecimalComma, "decimalcomma", false, "Amounts use ',' as the decimal separator")
+	f.BoolVar(&csvOptions.NoHeader, "noheader", false, "The CSV has no header row")
+	f.IntVar(&csvOptions.SkipRows, "skip", 0, "Number of rows to skip before the header")
+	f.StringVar(&csvOptions.Currency, "currency", csvimport.DefaultCurrency, "Currency of the account")
+}
+
+func checkCSVToOFXFlags() bool {
+	ret := true
+	if len(inputFilename) == 0 {
+		fmt.Println("Error: Input file must be specified with -input")
+		ret = false
+	}
+	if len(acctID) == 0 {
+		fmt.Println("Error: Account ID must be specified with -acctid")
+		ret = false
+	}
+	if len(csvOptions.Columns.Amount) == 0 && len(csvOptions.Columns.Debit) == 0 && len(csvOptions.Columns.Credit) == 0 {
+		fmt.Println("Error: At least one of -amount, -debit, and -credit must be specified")
+		ret = false
+	}
+	return ret
+}
+
+func csvToOFX() {
+	if len(csvDateFormats) > 0 {
+		csvOptions.DateFormats = strings.Split(csvDateFormats, ",")
+	}
+	if len(csvDebitIndicators) > 0 {
+		csvOptions.DebitIndicators = strings.Split(csvDebitIndicators, ",")
+	}
+
+	file, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Println("Error opening input file:", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	var statement ofxgo.Message
+	if csvCreditCard {
+		statement, err = csvimport.NewCCStatementResponse(file, ofxgo.CCAcct{AcctID: ofxgo.String(acctID)}, &csvOptions)
+	} else {
+		acctTypeEnum, typeErr := ofxgo.NewAcctType(acctType)
+		if typeErr != nil {
+			fmt.Println("Error parsing accttype:", typeErr)
+			os.Exit(1)
+		}
+		statement, err = csvimport.NewStatementResponse(file, ofxgo.BankAcct{
+			BankID:   ofxgo.String(bankID),
+			AcctID:   ofxgo.String(acctID),
+			AcctType: acctTypeEnum,
+		}, &csvOptions)
+	}
+	if err != nil {
+		fmt.Println("Error reading CSV:", err)
+		os.Exit(1)
+	}
+
+	response, err := csvimport.NewResponse(csvOrg, csvFid, statement)
+	if err != nil {
+		fmt.Println("Error creating response:", err)
+		os.Exit(1)
+	}
+	b, err := response.Marshal()
+	if err != nil {
+		fmt.Println("Error marshalling response:", err)
+		os.Exit(1)
+	}
+
+	if len(outputFilename) == 0 {
+		os.Stdout.Write(b.Bytes())
+	} else if err := ioutil.WriteFile(outputFilename, b.Bytes(), 0644); err != nil {
+		fmt.Println("Error writing OFX statement:", err)
+		os.Exit(1)
+	}
+}
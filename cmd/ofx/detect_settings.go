@@ -156,8 +156,10 @@ func tryProfile(appID, appVer, version string, noindent bool) bool {
 	}
 	query.Prof = append(query.Prof, &profileRequest)
 
+	// A StatusError means the server understood the request, even if it
+	// rejected it, which is all we're trying to find out
 	_, err = client.Request(&query)
-	if err == nil {
+	if _, ok := err.(*ofxgo.StatusError); err == nil || ok {
 		return true
 	}
 
@@ -166,5 +168,6 @@ func tryProfile(appID, appVer, version string, noindent bool) bool {
 	query.Signon.UserPass = ofxgo.String(anonymous)
 
 	_, err = client.Request(&query)
-	return err == nil
+	_, ok := err.(*ofxgo.StatusError)
+	return err == nil || ok
 }
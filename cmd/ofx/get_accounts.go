@@ -46,10 +46,12 @@ func getAccounts() {
 		os.Exit(1)
 	}
 
+	// Errors are returned by Request as a *ofxgo.StatusError, but the server
+	// may still have something to say, such as that the password will
+	// expire soon
 	if response.Signon.Status.Code != 0 {
 		meaning, _ := response.Signon.Status.CodeMeaning()
-		fmt.Printf("Nonzero signon status (%d: %s) with message: %s\n", response.Signon.Status.Code, meaning, response.Signon.Status.Message)
-		os.Exit(1)
+		fmt.Printf("Warning: nonzero signon status (%d: %s) with message: %s\n", response.Signon.Status.Code, meaning, response.Signon.Status.Message)
 	}
 
 	if len(response.Signup) < 1 {
@@ -55,8 +55,11 @@ func invDownload() {
 		IncludeOO:      true,
 		IncludePos:     true,
 		IncludeBalance: true,
-		Include401K:    true,
-		Include401KBal: true,
+	}
+	// 401(k) information is only supported by OFX 1.5.1 and later
+	if client.OfxVersion() >= ofxgo.OfxVersion151 {
+		statementRequest.Include401K = true
+		statementRequest.Include401KBal = true
 	}
 	query.InvStmt = append(query.InvStmt, &statementRequest)
 
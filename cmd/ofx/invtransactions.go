@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/store"
 	"os"
 )
 
@@ -11,12 +12,14 @@ var invTransactionsCommand = command{
 	Name:        "transactions-inv",
 	Description: "Print investment transactions",
 	Flags:       flag.NewFlagSet("transactions-inv", flag.ExitOnError),
-	CheckFlags:  checkServerFlags,
+	CheckFlags:  checkTransactionFlags,
 	Do:          invTransactions,
 }
 
 func init() {
 	defineServerFlags(invTransactionsCommand.Flags)
+	defineStoreFlags(invTransactionsCommand.Flags)
+	defineOutputFlags(invTransactionsCommand.Flags)
 	invTransactionsCommand.Flags.StringVar(&acctID, "acctid", "", "AcctID (from `get-accounts` subcommand)")
 	invTransactionsCommand.Flags.StringVar(&brokerID, "brokerid", "", "BrokerID (from `get-accounts` subcommand)")
 }
@@ -30,18 +33,25 @@ func invTransactions() {
 		os.Exit(1)
 	}
 
+	invAcct := ofxgo.InvAcct{
+		BrokerID: ofxgo.String(brokerID),
+		AcctID:   ofxgo.String(acctID),
+	}
+	st, dtStart := openStore(store.InvAccountKey(invAcct))
+
 	statementRequest := ofxgo.InvStatementRequest{
-		TrnUID: *uid,
-		InvAcctFrom: ofxgo.InvAcct{
-			BrokerID: ofxgo.String(brokerID),
-			AcctID:   ofxgo.String(acctID),
-		},
+		TrnUID:         *uid,
+		InvAcctFrom:    invAcct,
+		DtStart:        dtStart,
 		Include:        true,
 		IncludeOO:      true,
 		IncludePos:     true,
 		IncludeBalance: true,
-		Include401K:    true,
-		Include401KBal: true,
+	}
+	// 401(k) information is only supported by OFX 1.5.1 and later
+	if client.OfxVersion() >= ofxgo.OfxVersion151 {
+		statementRequest.Include401K = true
+		statementRequest.Include401KBal = true
 	}
 	query.InvStmt = append(query.InvStmt, &statementRequest)
 
@@ -52,13 +62,22 @@ func invTransactions() {
 
 	response, err := client.Request(query)
 	if err != nil {
+		fmt.Println("Error requesting account statement:", err)
 		os.Exit(1)
 	}
 
+	// Errors are returned by Request as a *ofxgo.StatusError, but the server
+	// may still have something to say, such as that the password will
+	// expire soon
 	if response.Signon.Status.Code != 0 {
 		meaning, _ := response.Signon.Status.CodeMeaning()
-		fmt.Printf("Nonzero signon status (%d: %s) with message: %s\n", response.Signon.Status.Code, meaning, response.Signon.Status.Message)
-		os.Exit(1)
+		fmt.Printf("Warning: nonzero signon status (%d: %s) with message: %s\n", response.Signon.Status.Code, meaning, response.Signon.Status.Message)
+	}
+
+	saveResponse(st, response)
+
+	if printStatements(response) {
+		return
 	}
 
 	if len(response.InvStmt) < 1 {
@@ -66,6 +85,16 @@ func invTransactions() {
 		return
 	}
 
+	// Print tickers rather than CUSIPs for the securities the response
+	// describes
+	securities := ofxgo.NewSecurityMaster(response)
+	security := func(id ofxgo.SecurityID) string {
+		if s, ok := securities.Lookup(id); ok && len(s.SecurityInfo().Ticker) > 0 {
+			return string(s.SecurityInfo().Ticker)
+		}
+		return fmt.Sprintf("%s %s", id.UniqueIDType, id.UniqueID)
+	}
+
 	if stmt, ok := response.InvStmt[0].(*ofxgo.InvStatementResponse); ok {
 		availCash := stmt.InvBal.AvailCash
 		if availCash.IsInt() && availCash.Num().Int64() != 0 {
@@ -82,15 +111,15 @@ func invTransactions() {
 			fmt.Printf("%-14s", t.TransactionType())
 			switch tran := t.(type) {
 			case ofxgo.BuyDebt:
-				printInvBuy(stmt.CurDef, &tran.InvBuy)
+				printInvBuy(stmt.CurDef, &tran.InvBuy, security)
 			case ofxgo.BuyMF:
-				printInvBuy(stmt.CurDef, &tran.InvBuy)
+				printInvBuy(stmt.CurDef, &tran.InvBuy, security)
 			case ofxgo.BuyOpt:
-				printInvBuy(stmt.CurDef, &tran.InvBuy)
+				printInvBuy(stmt.CurDef, &tran.InvBuy, security)
 			case ofxgo.BuyOther:
-				printInvBuy(stmt.CurDef, &tran.InvBuy)
+				printInvBuy(stmt.CurDef, &tran.InvBuy, security)
 			case ofxgo.BuyStock:
-				printInvBuy(stmt.CurDef, &tran.InvBuy)
+				printInvBuy(stmt.CurDef, &tran.InvBuy, security)
 			case ofxgo.ClosureOpt:
 				printInvTran(&tran.InvTran)
 				fmt.Printf("%s %s contracts (%d shares each)\n", tran.OptAction, tran.Units, tran.ShPerCtrct)
@@ -100,23 +129,20 @@ func invTransactions() {
 				if ok, _ := tran.Currency.Valid(); ok {
 					currency = tran.Currency.CurSym
 				}
-				fmt.Printf(" %s %s %s (%s %s)\n", tran.IncomeType, tran.Total, currency, tran.SecID.UniqueIDType, tran.SecID.UniqueID)
-				// TODO print ticker instead of CUSIP
+				fmt.Printf(" %s %s %s (%s)\n", tran.IncomeType, tran.Total, currency, security(tran.SecID))
 			case ofxgo.InvExpense:
 				printInvTran(&tran.InvTran)
 				currency := stmt.CurDef
 				if ok, _ := tran.Currency.Valid(); ok {
 					currency = tran.Currency.CurSym
 				}
-				fmt.Printf(" %s %s (%s %s)\n", tran.Total, currency, tran.SecID.UniqueIDType, tran.SecID.UniqueID)
-				// TODO print ticker instead of CUSIP
+				fmt.Printf(" %s %s (%s)\n", tran.Total, currency, security(tran.SecID))
 			case ofxgo.JrnlFund:
 				printInvTran(&tran.InvTran)
 				fmt.Printf(" %s %s (%s -> %s)\n", tran.Total, stmt.CurDef, tran.SubAcctFrom, tran.SubAcctTo)
 			case ofxgo.JrnlSec:
 				printInvTran(&tran.InvTran)
-				fmt.Printf(" %s %s %s (%s -> %s)\n", tran.Units, tran.SecID.UniqueIDType, tran.SecID.UniqueID, tran.SubAcctFrom, tran.SubAcctTo)
-				// TODO print ticker instead of CUSIP
+				fmt.Printf(" %s %s (%s -> %s)\n", tran.Units, security(tran.SecID), tran.SubAcctFrom, tran.SubAcctTo)
 			case ofxgo.MarginInterest:
 				printInvTran(&tran.InvTran)
 				currency := stmt.CurDef
@@ -130,38 +156,34 @@ func invTransactions() {
 				if ok, _ := tran.Currency.Valid(); ok {
 					currency = tran.Currency.CurSym
 				}
-				fmt.Printf(" %s (%s %s)@%s %s (Total: %s)\n", tran.Units, tran.SecID.UniqueIDType, tran.SecID.UniqueID, tran.UnitPrice, currency, tran.Total)
-				// TODO print ticker instead of CUSIP
+				fmt.Printf(" %s (%s)@%s %s (Total: %s)\n", tran.Units, security(tran.SecID), tran.UnitPrice, currency, tran.Total)
 			case ofxgo.RetOfCap:
 				printInvTran(&tran.InvTran)
 				currency := stmt.CurDef
 				if ok, _ := tran.Currency.Valid(); ok {
 					currency = tran.Currency.CurSym
 				}
-				fmt.Printf(" %s %s (%s %s)\n", tran.Total, currency, tran.SecID.UniqueIDType, tran.SecID.UniqueID)
-				// TODO print ticker instead of CUSIP
+				fmt.Printf(" %s %s (%s)\n", tran.Total, currency, security(tran.SecID))
 			case ofxgo.SellDebt:
-				printInvSell(stmt.CurDef, &tran.InvSell)
+				printInvSell(stmt.CurDef, &tran.InvSell, security)
 			case ofxgo.SellMF:
-				printInvSell(stmt.CurDef, &tran.InvSell)
+				printInvSell(stmt.CurDef, &tran.InvSell, security)
 			case ofxgo.SellOpt:
-				printInvSell(stmt.CurDef, &tran.InvSell)
+				printInvSell(stmt.CurDef, &tran.InvSell, security)
 			case ofxgo.SellOther:
-				printInvSell(stmt.CurDef, &tran.InvSell)
+				printInvSell(stmt.CurDef, &tran.InvSell, security)
 			case ofxgo.SellStock:
-				printInvSell(stmt.CurDef, &tran.InvSell)
+				printInvSell(stmt.CurDef, &tran.InvSell, security)
 			case ofxgo.Split:
 				printInvTran(&tran.InvTran)
 				currency := stmt.CurDef
 				if ok, _ := tran.Currency.Valid(); ok {
 					currency = tran.Currency.CurSym
 				}
-				fmt.Printf(" %d/%d %s -> %s shares of %s %s (%s %s for fractional shares)\n", tran.Numerator, tran.Denominator, tran.OldUnits, tran.NewUnits, tran.SecID.UniqueIDType, tran.SecID.UniqueID, tran.FracCash, currency)
-				// TODO print ticker instead of CUSIP
+				fmt.Printf(" %d/%d %s -> %s shares of %s (%s %s for fractional shares)\n", tran.Numerator, tran.Denominator, tran.OldUnits, tran.NewUnits, security(tran.SecID), tran.FracCash, currency)
 			case ofxgo.Transfer:
 				printInvTran(&tran.InvTran)
-				fmt.Printf(" %s (%s %s) %s\n", tran.Units, tran.SecID.UniqueIDType, tran.SecID.UniqueID, tran.TferAction)
-				// TODO print ticker instead of CUSIP
+				fmt.Printf(" %s (%s) %s\n", tran.Units, security(tran.SecID), tran.TferAction)
 			}
 		}
 	}
@@ -171,24 +193,22 @@ func printInvTran(it *ofxgo.InvTran) {
 	fmt.Printf("%s", it.DtTrade)
 }
 
-func printInvBuy(defCurrency ofxgo.CurrSymbol, ib *ofxgo.InvBuy) {
+func printInvBuy(defCurrency ofxgo.CurrSymbol, ib *ofxgo.InvBuy, security func(ofxgo.SecurityID) string) {
 	printInvTran(&ib.InvTran)
 	currency := defCurrency
 	if ok, _ := ib.Currency.Valid(); ok {
 		currency = ib.Currency.CurSym
 	}
 
-	fmt.Printf("%s (%s %s)@%s %s (Total: %s)\n", ib.Units, ib.SecID.UniqueIDType, ib.SecID.UniqueID, ib.UnitPrice, currency, ib.Total)
-	// TODO print ticker instead of CUSIP
+	fmt.Printf("%s (%s)@%s %s (Total: %s)\n", ib.Units, security(ib.SecID), ib.UnitPrice, currency, ib.Total)
 }
 
-func printInvSell(defCurrency ofxgo.CurrSymbol, is *ofxgo.InvSell) {
+func printInvSell(defCurrency ofxgo.CurrSymbol, is *ofxgo.InvSell, security func(ofxgo.SecurityID) string) {
 	printInvTran(&is.InvTran)
 	currency := defCurrency
 	if ok, _ := is.Currency.Valid(); ok {
 		currency = is.Currency.CurSym
 	}
 
-	fmt.Printf(" %s (%s %s)@%s %s (Total: %s)\n", is.Units, is.SecID.UniqueIDType, is.SecID.UniqueID, is.UnitPrice, currency.String(), is.Total)
-	// TODO print ticker instead of CUSIP
+	fmt.Printf(" %s (%s)@%s %s (Total: %s)\n", is.Units, security(is.SecID), is.UnitPrice, currency.String(), is.Total)
 }
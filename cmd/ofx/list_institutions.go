@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/aclindsa/ofxgo/fidir"
+)
+
+var listInstitutionsCommand = command{
+	Name:        "list-institutions",
+	Description: "List financial institutions usable with -institution",
+	Flags:       flag.NewFlagSet("list-institutions", flag.ExitOnError),
+	CheckFlags:  func() bool { return true },
+	Do:          listInstitutions,
+}
+
+var search string
+
+func init() {
+	listInstitutionsCommand.Flags.StringVar(&search, "search", "", "Only list institutions whose name contains this string")
+}
+
+func listInstitutions() {
+	for _, inst := range fidir.Default().Search(search) {
+		fmt.Printf("%s:\n", inst.Name)
+		fmt.Printf("\tURL: %s\n", inst.URL)
+		fmt.Printf("\tORG: %s, FID: %s\n", inst.Org, inst.Fid)
+		if len(inst.Quirks) > 0 {
+			fmt.Printf("\tQuirks: %v\n", inst.Quirks)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
@@ -16,6 +17,9 @@ var commands = []command{
 	ccTransactionsCommand,
 	invTransactionsCommand,
 	detectSettingsCommand,
+	listInstitutionsCommand,
+	convertCommand,
+	csvToOFXCommand,
 }
 
 func usage() {
@@ -47,6 +51,9 @@ func runCmd(c *command) {
 		c.usage()
 		os.Exit(1)
 	}
+	c.Flags.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
 
 	if !c.CheckFlags() {
 		fmt.Println()
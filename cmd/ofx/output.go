@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/export"
+	"os"
+	"strings"
+)
+
+// flags controlling how the transaction commands print statements
+var output string
+var csvColumns string
+var accountNames string
+var journalOptions export.JournalOptions // Set from accountNames by checkOutputFlags
+
+func defineOutputFlags(f *flag.FlagSet) {
+	f.StringVar(&output, "output", "text", "Output format: text, csv, json, ledger, or beancount")
+	f.StringVar(&csvColumns, "columns", "", "Comma-separated list of columns to print with -output=csv (see the export package's CSVOptions for the choices)")
+	f.StringVar(&accountNames, "accounts", "", "Comma-separated list of ACCTID=Account pairs naming the journal accounts for -output=ledger or beancount")
+}
+
+func checkOutputFlags() bool {
+	switch output {
+	case "text", "csv", "json", "ledger", "beancount":
+	default:
+		fmt.Printf("Error: Invalid output format %q (must be one of text, csv, json, ledger, or beancount)\n", output)
+		return false
+	}
+	if len(accountNames) == 0 {
+		return true
+	}
+	journalOptions.Accounts = make(map[string]string)
+	for _, pair := range strings.Split(accountNames, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			fmt.Printf("Error: Invalid -accounts entry %q (must be ACCTID=Account)\n", pair)
+			return false
+		}
+		journalOptions.Accounts[parts[0]] = parts[1]
+	}
+	return true
+}
+
+// checkTransactionFlags checks the flags common to the transaction commands
+func checkTransactionFlags() bool {
+	ret := checkOutputFlags()
+	return checkServerFlags() && ret
+}
+
+// printStatements prints the statements in response in the format selected
+// with -output, returning false if it is text, which is left to the caller
+func printStatements(response *ofxgo.Response) bool {
+	var err error
+	switch output {
+	case "text":
+		return false
+	case "ledger":
+		err = export.WriteLedger(os.Stdout, response, &journalOptions)
+	case "beancount":
+		err = export.WriteBeancount(os.Stdout, response, &journalOptions)
+	default:
+		err = printCSVOrJSON(response)
+	}
+	if err != nil {
+		fmt.Println("Error printing statements:", err)
+		os.Exit(1)
+	}
+	return true
+}
+
+func printCSVOrJSON(response *ofxgo.Response) error {
+	statements, err := export.NewStatements(response)
+	if err != nil {
+		return err
+	}
+	if output == "json" {
+		return export.WriteJSON(os.Stdout, statements)
+	}
+	var options export.CSVOptions
+	if len(csvColumns) > 0 {
+		options.Columns = strings.Split(csvColumns, ",")
+	}
+	return export.WriteCSV(os.Stdout, statements, &options)
+}
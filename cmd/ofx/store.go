@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/store"
+	"os"
+)
+
+// flag for the transaction commands which can save what they download
+var storePath string
+
+func defineStoreFlags(f *flag.FlagSet) {
+	f.StringVar(&storePath, "store", "", "File to save downloaded transactions, positions, and securities to. Only transactions since the last download saved there are requested.")
+}
+
+// openStore opens the store named by -store (returning nil if it wasn't set),
+// and returns the DTSTART to request so only transactions not yet stored for
+// the account identified by key are downloaded
+func openStore(key string) (*store.Store, *ofxgo.Date) {
+	if len(storePath) == 0 {
+		return nil, nil
+	}
+	s, err := store.OpenFile(storePath)
+	if err != nil {
+		fmt.Println("Error opening store:", err)
+		os.Exit(1)
+	}
+	return s, s.DtStart(key)
+}
+
+// saveResponse adds response to s, if it's non-nil
+func saveResponse(s *store.Store, response *ofxgo.Response) {
+	if s == nil {
+		return
+	}
+	added, err := s.AddResponse(response)
+	if err != nil {
+		fmt.Println("Error saving to store:", err)
+		os.Exit(1)
+	}
+	// Printed to stderr so it doesn't end up in -output=csv or json
+	fmt.Fprintf(os.Stderr, "Saved %d new transactions to %s\n", added, storePath)
+}
@@ -350,6 +350,17 @@ type InvAcct struct {
 	AcctID   String   `xml:"ACCTID"`
 }
 
+// Valid returns whether the InvAcct is valid according to the OFX spec
+func (i InvAcct) Valid() (bool, error) {
+	if len(i.BrokerID) == 0 {
+		return false, errors.New("InvAcct.BrokerID empty")
+	}
+	if len(i.AcctID) == 0 {
+		return false, errors.New("InvAcct.AcctID empty")
+	}
+	return true, nil
+}
+
 // Currency represents one ISO-4217 currency. CURRENCY elements signify that
 // the transaction containing this Currency struct is in this currency instead
 // of being converted to the statement's default. ORIGCURRENCY elements signify
@@ -361,6 +372,17 @@ type Currency struct {
 	CurSym  CurrSymbol `xml:"CURSYM"`  // ISO-4217 3-character currency identifier
 }
 
+// MarshalXML handles marshalling a Currency to an SGML/XML string. Unset
+// Currencies (with neither CurRate nor CurSym) are omitted entirely, since
+// omitempty has no effect on structs.
+func (c Currency) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if c.CurRate.Sign() == 0 && c.CurSym.String() == "XXX" {
+		return nil
+	}
+	type rawCurrency Currency
+	return e.EncodeElement(rawCurrency(c), start)
+}
+
 // Valid returns whether the Currency is valid according to the OFX spec
 func (c Currency) Valid() (bool, error) {
 	if c.CurRate.IsInt() && c.CurRate.Num().Int64() == 0 {
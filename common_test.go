@@ -1,7 +1,10 @@
 package ofxgo
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/aclindsa/xml"
 )
 
 func TestStatusValid(t *testing.T) {
@@ -63,3 +66,32 @@ func TestStatusCodeConditions(t *testing.T) {
 		t.Fatalf("Status.CodeConditions unexpectedly succeeded with invalid Code\n")
 	}
 }
+
+func TestMarshalCurrency(t *testing.T) {
+	income := Income{
+		InvTran:    InvTran{FiTID: "1", DtTrade: *NewDateGMT(2017, 2, 3, 0, 0, 0, 0)},
+		SecID:      SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"},
+		IncomeType: IncomeTypeDiv,
+	}
+	b, err := xml.Marshal(&income)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling Income: %s\n", err)
+	}
+	if strings.Contains(string(b), "CURRENCY") {
+		t.Errorf("Expected unset Currency and OrigCurrency to be omitted, got %s\n", b)
+	}
+
+	cursym, err := NewCurrSymbol("EUR")
+	if err != nil {
+		t.Fatalf("Unexpected error creating CurrSymbol: %s\n", err)
+	}
+	income.Currency.CurRate.SetFrac64(5, 4)
+	income.Currency.CurSym = *cursym
+	b, err = xml.Marshal(&income)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling Income: %s\n", err)
+	}
+	if !strings.Contains(string(b), "<CURRENCY><CURRATE>1.25</CURRATE><CURSYM>EUR</CURSYM></CURRENCY>") || strings.Contains(string(b), "ORIGCURRENCY") {
+		t.Errorf("Expected only CURRENCY to be marshalled, got %s\n", b)
+	}
+}
@@ -0,0 +1,147 @@
+package ofxgo
+
+import (
+	"errors"
+)
+
+// Convert returns a copy of the Response converted to targetVersion, so that
+// it may be marshalled as SGML (for 1.x versions) or XML (for 2.x versions).
+// Fields which aren't supported by targetVersion are dropped from the copy,
+// and a Diagnostic describing each is returned. The original Response is not
+// modified.
+//
+// The converted Response is validated against targetVersion, and is returned
+// along with any validation error so it may be inspected.
+func (or *Response) Convert(targetVersion ofxVersion) (*Response, []Diagnostic, error) {
+	if !targetVersion.Valid() {
+		return nil, nil, errors.New("Invalid OFX version: " + targetVersion.String())
+	}
+
+	converted := *or
+	converted.Version = targetVersion
+	var diagnostics []Diagnostic
+
+	if targetVersion < OfxVersion220 {
+		converted.Bank = convertMessages(or.Bank, func(m Message) Message {
+			stmt, ok := m.(*StatementResponse)
+			if !ok {
+				return m
+			}
+			c := *stmt
+			path := "OFX/" + BankRs.String() + "/" + c.Name() + "/STMTRS"
+			if c.BankTranListP != nil {
+				diagnostics = append(diagnostics, Diagnostic{
+					Path:    path + "/BANKTRANLISTP",
+					Offset:  -1,
+					Message: "Dropped pending transactions, which require OFX 2.2",
+				})
+				c.BankTranListP = nil
+			}
+			c.BankTranList = dropTransactionImages(c.BankTranList, path+"/BANKTRANLIST", &diagnostics)
+			return &c
+		})
+		converted.CreditCard = convertMessages(or.CreditCard, func(m Message) Message {
+			stmt, ok := m.(*CCStatementResponse)
+			if !ok {
+				return m
+			}
+			c := *stmt
+			path := "OFX/" + CreditCardRs.String() + "/" + c.Name() + "/CCSTMTRS/BANKTRANLIST"
+			c.BankTranList = dropTransactionImages(c.BankTranList, path, &diagnostics)
+			return &c
+		})
+		converted.InvStmt = convertMessages(or.InvStmt, func(m Message) Message {
+			stmt, ok := m.(*InvStatementResponse)
+			if !ok || stmt.InvTranList == nil {
+				return m
+			}
+			c := *stmt
+			list := *c.InvTranList
+			if list.BankTransactions != nil {
+				list.BankTransactions = make([]InvBankTransaction, len(list.BankTransactions))
+			}
+			for i, tran := range c.InvTranList.BankTransactions {
+				path := "OFX/" + InvStmtRs.String() + "/" + c.Name() + "/INVSTMTRS/INVTRANLIST/INVBANKTRAN"
+				tran.Transactions = dropImages(tran.Transactions, path, &diagnostics)
+				list.BankTransactions[i] = tran
+			}
+			c.InvTranList = &list
+			return &c
+		})
+	}
+
+	if targetVersion < OfxVersion151 {
+		converted.InvStmt = convertMessages(converted.InvStmt, func(m Message) Message {
+			stmt, ok := m.(*InvStatementResponse)
+			if !ok || (stmt.Inv401K == nil && stmt.Inv401KBal == nil) {
+				return m
+			}
+			c := *stmt
+			path := "OFX/" + InvStmtRs.String() + "/" + c.Name() + "/INVSTMTRS"
+			if c.Inv401K != nil {
+				diagnostics = append(diagnostics, Diagnostic{
+					Path:    path + "/INV401K",
+					Offset:  -1,
+					Message: "Dropped 401(k) account information, which requires OFX 1.5.1",
+				})
+				c.Inv401K = nil
+			}
+			if c.Inv401KBal != nil {
+				diagnostics = append(diagnostics, Diagnostic{
+					Path:    path + "/INV401KBAL",
+					Offset:  -1,
+					Message: "Dropped 401(k) balances, which require OFX 1.5.1",
+				})
+				c.Inv401KBal = nil
+			}
+			return &c
+		})
+	}
+
+	_, err := converted.Valid()
+	return &converted, diagnostics, err
+}
+
+// convertMessages returns a new slice containing the result of calling
+// convert on each of messages
+func convertMessages(messages []Message, convert func(Message) Message) []Message {
+	if messages == nil {
+		return nil
+	}
+	converted := make([]Message, len(messages))
+	for i, m := range messages {
+		converted[i] = convert(m)
+	}
+	return converted
+}
+
+// dropTransactionImages returns a copy of list with ImageData (which requires
+// OFX 2.2) removed from all its transactions
+func dropTransactionImages(list *TransactionList, path string, diagnostics *[]Diagnostic) *TransactionList {
+	if list == nil {
+		return nil
+	}
+	c := *list
+	c.Transactions = dropImages(list.Transactions, path, diagnostics)
+	return &c
+}
+
+// dropImages returns a copy of transactions with ImageData removed
+func dropImages(transactions []Transaction, path string, diagnostics *[]Diagnostic) []Transaction {
+	if transactions == nil {
+		return nil
+	}
+	c := make([]Transaction, len(transactions))
+	for i, tran := range transactions {
+		if len(tran.ImageData) > 0 {
+			*diagnostics = append(*diagnostics, Diagnostic{
+				Path:    path + "/STMTTRN/IMAGEDATA",
+				Offset:  -1,
+				Message: "Dropped images for transaction " + tran.FiTID.String() + ", which require OFX 2.2",
+			})
+			tran.ImageData = nil
+		}
+		c[i] = tran
+	}
+	return c
+}
@@ -0,0 +1,181 @@
+package ofxgo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// has401K returns true if any of response's investment statements contain
+// 401(k) aggregates, which are dropped when converting to OFX < 1.5.1
+func has401K(response *Response) bool {
+	for _, m := range response.InvStmt {
+		if stmt, ok := m.(*InvStatementResponse); ok && (stmt.Inv401K != nil || stmt.Inv401KBal != nil) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestConvertSamples(t *testing.T) {
+	fn := func(path string, info os.FileInfo, err error) error {
+		if info.IsDir() {
+			return nil
+		} else if ext := filepath.Ext(path); ext != ".ofx" && ext != ".qfx" {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Unexpected error opening %s: %s\n", path, err)
+		}
+		defer file.Close()
+		response, err := ParseResponse(file)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %s: %s\n", path, err)
+		}
+
+		for _, version := range []ofxVersion{OfxVersion102, OfxVersion160, OfxVersion203, OfxVersion220} {
+			converted, diagnostics, err := response.Convert(version)
+			if err != nil {
+				t.Fatalf("Unexpected error converting %s to %s: %s\n", path, version, err)
+			}
+			if len(diagnostics) > 0 && !(version < OfxVersion151 && has401K(response)) {
+				t.Errorf("Unexpected diagnostics converting %s to %s: %v\n", path, version, diagnostics)
+			}
+			if converted.Version != version {
+				t.Errorf("Expected converted version %s, got %s\n", version, converted.Version)
+			}
+
+			b, err := converted.Marshal()
+			if err != nil {
+				t.Fatalf("Unexpected error marshalling %s as %s: %s\n", path, version, err)
+			}
+			reparsed, err := ParseResponse(b)
+			if err != nil {
+				t.Fatalf("Unexpected error re-parsing %s as %s: %s\n", path, version, err)
+			}
+			checkResponsesEqual(t, converted, reparsed)
+		}
+		return nil
+	}
+	filepath.Walk("samples/valid_responses", fn)
+	filepath.Walk("samples/busted_responses", fn)
+}
+
+func TestConvertDropsUnsupported(t *testing.T) {
+	file, err := os.Open("samples/valid_responses/moneymrkt1_v203.ofx")
+	if err != nil {
+		t.Fatalf("Unexpected error opening sample: %s\n", err)
+	}
+	defer file.Close()
+	response, err := ParseResponse(file)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing sample: %s\n", err)
+	}
+
+	// Add pending transactions and images, which require OFX 2.2
+	response, _, err = response.Convert(OfxVersion220)
+	if err != nil {
+		t.Fatalf("Unexpected error converting to 220: %s\n", err)
+	}
+	stmt := response.Bank[0].(*StatementResponse)
+	var amount Amount
+	amount.SetFrac64(-20, 1)
+	stmt.BankTranListP = &PendingTransactionList{
+		DtAsOf: *NewDateGMT(2017, 4, 7, 0, 0, 0, 0),
+		Transactions: []PendingTransaction{{
+			TrnType: TrnTypeHold,
+			DtTran:  *NewDateGMT(2017, 4, 6, 0, 0, 0, 0),
+			TrnAmt:  amount,
+			Name:    "Pending purchase",
+		}},
+	}
+	stmt.BankTranList.Transactions[0].ImageData = []ImageData{{
+		ImageType:    ImageTypeTransaction,
+		ImageRef:     "https://example.com/image",
+		ImageRefType: ImageRefTypeURL,
+	}}
+	if ok, err := response.Valid(); !ok {
+		t.Fatalf("Unexpectedly invalid 220 response: %s\n", err)
+	}
+	if _, err := response.Marshal(); err != nil {
+		t.Fatalf("Unexpected error marshalling 220 response: %s\n", err)
+	}
+
+	converted, diagnostics, err := response.Convert(OfxVersion102)
+	if err != nil {
+		t.Fatalf("Unexpected error converting to 102: %s\n", err)
+	}
+	expectedPaths := []string{
+		"OFX/BANKMSGSRSV1/STMTTRNRS/STMTRS/BANKTRANLISTP",
+		"OFX/BANKMSGSRSV1/STMTTRNRS/STMTRS/BANKTRANLIST/STMTTRN/IMAGEDATA",
+	}
+	var paths []string
+	for _, d := range diagnostics {
+		paths = append(paths, d.Path)
+	}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("Expected diagnostics for %v, got %v\n", expectedPaths, diagnostics)
+	}
+
+	convertedStmt := converted.Bank[0].(*StatementResponse)
+	if convertedStmt.BankTranListP != nil {
+		t.Errorf("BankTranListP not dropped\n")
+	}
+	if len(convertedStmt.BankTranList.Transactions[0].ImageData) != 0 {
+		t.Errorf("ImageData not dropped\n")
+	}
+	if _, err := converted.Marshal(); err != nil {
+		t.Errorf("Unexpected error marshalling converted response: %s\n", err)
+	}
+
+	// The original must not have been modified
+	if stmt.BankTranListP == nil || len(stmt.BankTranList.Transactions[0].ImageData) != 1 {
+		t.Errorf("Convert modified the original Response\n")
+	}
+	if response.Version != OfxVersion220 {
+		t.Errorf("Convert modified the original Response's version\n")
+	}
+}
+
+func TestConvertDrops401K(t *testing.T) {
+	file, err := os.Open("samples/valid_responses/401k_v203.ofx")
+	if err != nil {
+		t.Fatalf("Unexpected error opening sample: %s\n", err)
+	}
+	defer file.Close()
+	response, err := ParseResponse(file)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing sample: %s\n", err)
+	}
+
+	converted, diagnostics, err := response.Convert(OfxVersion102)
+	if err != nil {
+		t.Fatalf("Unexpected error converting to 102: %s\n", err)
+	}
+	expectedPaths := []string{
+		"OFX/INVSTMTMSGSRSV1/INVSTMTTRNRS/INVSTMTRS/INV401K",
+		"OFX/INVSTMTMSGSRSV1/INVSTMTTRNRS/INVSTMTRS/INV401KBAL",
+	}
+	var paths []string
+	for _, d := range diagnostics {
+		paths = append(paths, d.Path)
+	}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("Expected diagnostics for %v, got %v\n", expectedPaths, diagnostics)
+	}
+	if has401K(converted) {
+		t.Errorf("401(k) aggregates not dropped\n")
+	}
+	if !has401K(response) {
+		t.Errorf("Convert modified the original Response\n")
+	}
+}
+
+func TestConvertInvalidVersion(t *testing.T) {
+	var response Response
+	if _, _, err := response.Convert(ofxVersion(0)); err == nil {
+		t.Errorf("Expected error converting to invalid version\n")
+	}
+}
@@ -0,0 +1,399 @@
+// Package csvimport builds OFX bank and credit card statements from the CSV
+// files some financial institutions offer instead of OFX downloads, so they
+// can be processed the same way as statements downloaded with OFX.
+//
+// Which columns hold which fields, how dates are formatted, and which sign
+// amounts have are configured with Options. CSV files rarely include an
+// equivalent of FITID, so unless one is mapped, a FITID is synthesized for
+// each transaction from its contents; reading the same CSV twice yields the
+// same FITIDs. The ledger balance is taken from a balance column if there is
+// one, and otherwise computed from Options.OpeningBalance and the
+// transactions.
+package csvimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/internal/fitid"
+)
+
+// DefaultDateFormats are the formats (in the form expected by time.Parse)
+// dates are parsed with if Options doesn't specify any
+var DefaultDateFormats = []string{"2006-01-02", "01/02/2006", "1/2/2006", "01/02/06", "1/2/06"}
+
+// Columns names the CSV columns holding each transaction field. Columns are
+// named by their header (compared case-insensitively), or by their number
+// (starting at 1) if Options.NoHeader is set. Empty names are unmapped.
+type Columns struct {
+	Date string // Required
+	// Either Amount, or one or both of Debit and Credit, are required.
+	// Amounts in Debit are made negative and those in Credit positive
+	// regardless of their sign in the CSV, and if a row has values in both
+	// they are added together.
+	Amount string
+	Debit  string
+	Credit string
+	// Indicator holds a value saying whether the transaction is a debit or
+	// credit, for CSVs with unsigned amounts. Amounts in rows where it is
+	// one of Options.DebitIndicators are made negative, and others positive.
+	Indicator string
+	Name      string
+	Memo      string
+	CheckNum  string
+	RefNum    string
+	FiTID     string
+	Balance   string // The account balance after the transaction
+}
+
+// Options control how CSV is read
+type Options struct {
+	Columns     Columns
+	DateFormats []string // Tried in order; defaults to DefaultDateFormats
+	// InvertSign negates amounts, for CSVs where debits are positive (as in
+	// many credit card downloads)
+	InvertSign bool
+	// DebitIndicators are the values of the Indicator column marking debits
+	// (compared case-insensitively). Defaults to "debit", "dr", and "d".
+	DebitIndicators []string
+	// DecimalComma indicates amounts use ',' as the decimal separator and
+	// '.' to separate thousands (i.e. 1.234,56)
+	DecimalComma bool
+	Comma        rune // The field delimiter; defaults to ','
+	NoHeader     bool // The first row is a transaction rather than column names
+	SkipRows     int  // The number of rows to skip before the header or first transaction
+	Currency     string
+	// OpeningBalance is the balance before the first transaction, used to
+	// compute the ledger balance if there is no Balance column
+	OpeningBalance ofxgo.Amount
+}
+
+// Statement holds the transactions read from a CSV file, ready to be placed
+// in a StatementResponse or CCStatementResponse
+type Statement struct {
+	Transactions ofxgo.TransactionList
+	Balance      ofxgo.Amount
+	DtAsOf       ofxgo.Date // The date of the last transaction
+}
+
+var defaultDebitIndicators = []string{"debit", "dr", "d"}
+
+// reader holds the state of a Read call
+type reader struct {
+	options Options
+	columns map[string]int // Maps the names in Columns to column indexes
+	fitids  fitid.Generator
+	account string
+}
+
+// Read reads the transactions in the CSV in r. account identifies the account
+// the CSV is for when synthesizing FITIDs, so identical transactions in
+// different accounts have different FITIDs.
+func Read(r io.Reader, account string, options *Options) (*Statement, error) {
+	rd := reader{
+		columns: make(map[string]int),
+		account: account,
+	}
+	if options != nil {
+		rd.options = *options
+	}
+	if len(rd.options.DateFormats) == 0 {
+		rd.options.DateFormats = DefaultDateFormats
+	}
+	if len(rd.options.DebitIndicators) == 0 {
+		rd.options.DebitIndicators = defaultDebitIndicators
+	}
+	c := &rd.options.Columns
+	if len(c.Date) == 0 {
+		return nil, errors.New("No date column specified")
+	}
+	if len(c.Amount) == 0 && len(c.Debit) == 0 && len(c.Credit) == 0 {
+		return nil, errors.New("No amount, debit, or credit column specified")
+	}
+
+	cr := csv.NewReader(r)
+	if rd.options.Comma != 0 {
+		cr.Comma = rd.options.Comma
+	}
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+	}
+	if rd.options.SkipRows > len(rows) {
+		rows = nil
+	} else {
+		rows = rows[rd.options.SkipRows:]
+	}
+	line := rd.options.SkipRows + 1
+
+	var header []string
+	if !rd.options.NoHeader {
+		if len(rows) == 0 {
+			return nil, errors.New("CSV has no header row")
+		}
+		header = rows[0]
+		rows = rows[1:]
+		line++
+	}
+	for _, name := range []string{c.Date, c.Amount, c.Debit, c.Credit, c.Indicator, c.Name, c.Memo, c.CheckNum, c.RefNum, c.FiTID, c.Balance} {
+		if len(name) == 0 {
+			continue
+		}
+		index, err := columnIndex(name, header, rd.options.NoHeader)
+		if err != nil {
+			return nil, err
+		}
+		rd.columns[name] = index
+	}
+
+	s := Statement{}
+	balance := new(big.Rat).Set(&rd.options.OpeningBalance.Rat)
+	var balanceRow int // The index of the row the balance was taken from, plus one
+	var first, last time.Time
+	descending := rd.descending(rows)
+	for i, row := range rows {
+		if isBlank(row) {
+			continue
+		}
+		t, rowBalance, err := rd.transaction(row)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line+i, err)
+		}
+		s.Transactions.Transactions = append(s.Transactions.Transactions, *t)
+		balance.Add(balance, &t.TrnAmt.Rat)
+
+		date := t.DtPosted.Time
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+		// Files may be sorted either way, so the balance is taken from the
+		// last row of the latest date in whichever order the file is in
+		if rowBalance != nil && (balanceRow == 0 || date.After(last) || date.Equal(last) && !descending) {
+			s.Balance = *rowBalance
+			balanceRow = i + 1
+		}
+		if date.After(last) {
+			last = date
+		}
+	}
+	if len(c.Balance) == 0 || balanceRow == 0 {
+		s.Balance.Set(balance)
+	}
+	if last.IsZero() {
+		last = time.Now().UTC()
+		first = last
+	}
+	s.Transactions.DtStart = ofxgo.Date{Time: first}
+	s.Transactions.DtEnd = ofxgo.Date{Time: last}
+	s.DtAsOf = ofxgo.Date{Time: last}
+	return &s, nil
+}
+
+// descending returns true if the dates in rows are in descending order, as
+// judged by the first and last rows
+func (rd *reader) descending(rows [][]string) bool {
+	if len(rows) == 0 {
+		return false
+	}
+	first, err := rd.date(rd.field(rows[0], rd.options.Columns.Date))
+	if err != nil {
+		return false
+	}
+	last, err := rd.date(rd.field(rows[len(rows)-1], rd.options.Columns.Date))
+	if err != nil {
+		return false
+	}
+	return first.After(last)
+}
+
+func columnIndex(name string, header []string, noHeader bool) (int, error) {
+	if noHeader {
+		n, err := strconv.Atoi(name)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("Invalid column number %q", name)
+		}
+		return n - 1, nil
+	}
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), name) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("Column %q not found in header", name)
+}
+
+func isBlank(row []string) bool {
+	for _, field := range row {
+		if len(strings.TrimSpace(field)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// field returns the value in row of the column named name, or "" if name is
+// unmapped or the row is too short
+func (rd *reader) field(row []string, name string) string {
+	if len(name) == 0 {
+		return ""
+	}
+	index := rd.columns[name]
+	if index >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[index])
+}
+
+func (rd *reader) date(s string) (time.Time, error) {
+	for _, format := range rd.options.DateFormats {
+		if t, err := time.Parse(format, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid date: %q", s)
+}
+
+// amount parses a CSV amount, which may include currency symbols, thousands
+// separators, and parentheses or a trailing '-' denoting negative amounts
+func (rd *reader) amount(s string) (*big.Rat, error) {
+	value := strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = value[1 : len(value)-1]
+	}
+	if strings.HasSuffix(value, "-") {
+		negative = !negative
+		value = value[:len(value)-1]
+	}
+	value = strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '.' || r == ',' || r == '-' || r == '+' {
+			return r
+		}
+		return -1
+	}, value)
+	if rd.options.DecimalComma {
+		value = strings.Replace(strings.Replace(value, ".", "", -1), ",", ".", 1)
+	} else {
+		value = strings.Replace(value, ",", "", -1)
+	}
+	a, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, fmt.Errorf("Invalid amount: %q", s)
+	}
+	if negative {
+		a.Neg(a)
+	}
+	return a, nil
+}
+
+// transactionAmount returns the signed amount of the transaction in row
+func (rd *reader) transactionAmount(row []string) (*big.Rat, error) {
+	c := &rd.options.Columns
+	var amount *big.Rat
+	var err error
+	if value := rd.field(row, c.Amount); len(value) > 0 {
+		if amount, err = rd.amount(value); err != nil {
+			return nil, err
+		}
+	} else {
+		// Some banks fill the unused one of Debit and Credit with zero rather
+		// than leaving it empty, so both are added together
+		debit, credit := rd.field(row, c.Debit), rd.field(row, c.Credit)
+		if len(debit) == 0 && len(credit) == 0 {
+			return nil, errors.New("Transaction has no amount")
+		}
+		amount = new(big.Rat)
+		if len(debit) > 0 {
+			value, err := rd.amount(debit)
+			if err != nil {
+				return nil, err
+			}
+			amount.Sub(amount, value.Abs(value))
+		}
+		if len(credit) > 0 {
+			value, err := rd.amount(credit)
+			if err != nil {
+				return nil, err
+			}
+			amount.Add(amount, value.Abs(value))
+		}
+	}
+
+	if len(c.Indicator) > 0 {
+		amount.Abs(amount)
+		indicator := rd.field(row, c.Indicator)
+		for _, debit := range rd.options.DebitIndicators {
+			if strings.EqualFold(indicator, debit) {
+				amount.Neg(amount)
+				break
+			}
+		}
+	}
+	if rd.options.InvertSign {
+		amount.Neg(amount)
+	}
+	return amount, nil
+}
+
+// transaction converts row to a Transaction, also returning the balance from
+// the row's balance column, if any
+func (rd *reader) transaction(row []string) (*ofxgo.Transaction, *ofxgo.Amount, error) {
+	c := &rd.options.Columns
+	date, err := rd.date(rd.field(row, c.Date))
+	if err != nil {
+		return nil, nil, err
+	}
+	amount, err := rd.transactionAmount(row)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := ofxgo.Transaction{
+		TrnType:  ofxgo.TrnTypeCredit,
+		DtPosted: ofxgo.Date{Time: date},
+		Name:     ofxgo.String(rd.field(row, c.Name)),
+		Memo:     ofxgo.String(rd.field(row, c.Memo)),
+		CheckNum: ofxgo.String(rd.field(row, c.CheckNum)),
+		RefNum:   ofxgo.String(rd.field(row, c.RefNum)),
+		FiTID:    ofxgo.String(rd.field(row, c.FiTID)),
+	}
+	t.TrnAmt.Set(amount)
+	if len(t.CheckNum) > 0 {
+		t.TrnType = ofxgo.TrnTypeCheck
+	} else if amount.Sign() < 0 {
+		t.TrnType = ofxgo.TrnTypeDebit
+	}
+	if len(t.FiTID) == 0 {
+		t.FiTID = rd.fitid(&t)
+	}
+
+	var balance *ofxgo.Amount
+	if value := rd.field(row, c.Balance); len(value) > 0 {
+		b, err := rd.amount(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		balance = &ofxgo.Amount{}
+		balance.Set(b)
+	}
+	return &t, balance, nil
+}
+
+// fitid synthesizes a FITID for t from the account and its fields
+func (rd *reader) fitid(t *ofxgo.Transaction) ofxgo.String {
+	return rd.fitids.FiTID(rd.account, t.DtPosted.Format("20060102"), t.TrnAmt.String(),
+		string(t.Name), string(t.Memo), string(t.CheckNum), string(t.RefNum))
+}
@@ -0,0 +1,202 @@
+package csvimport
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/internal/ofxtest"
+)
+
+const bankCSV = "\ufeffDate,Description,Check,Withdrawal,Deposit,Running Balance\n" +
+	"03/05/2018,Coffee,,\"1,012.50\",,\"1,087.50\"\n" +
+	"03/05/2018,Coffee,,\"1,012.50\",,\"2,100.00\"\n" +
+	"03/02/2018,Rent,1001,$900.00,,\"3,112.50\"\n" +
+	"\n" +
+	"03/01/2018,Paycheck,,,\"4,000.00\",\"4,012.50\"\n"
+
+var bankOptions = Options{
+	Columns: Columns{
+		Date:     "date",
+		Name:     "description",
+		CheckNum: "check",
+		Debit:    "withdrawal",
+		Credit:   "deposit",
+		Balance:  "running balance",
+	},
+}
+
+func TestRead(t *testing.T) {
+	s, err := Read(strings.NewReader(bankCSV), "1234", &bankOptions)
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSV: %s\n", err)
+	}
+	transactions := s.Transactions.Transactions
+	if len(transactions) != 4 {
+		t.Fatalf("Expected 4 transactions, got %d\n", len(transactions))
+	}
+	// The file is newest-first, so the balance comes from its first row
+	if !ofxtest.AmountEqual(s.Balance, "1087.5") || !s.DtAsOf.Time.Equal(time.Date(2018, 3, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected balance %s as of %s\n", s.Balance, s.DtAsOf)
+	}
+	if !s.Transactions.DtStart.Time.Equal(time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected DtStart %s\n", s.Transactions.DtStart)
+	}
+
+	coffee := transactions[0]
+	if coffee.TrnType != ofxgo.TrnTypeDebit || !ofxtest.AmountEqual(coffee.TrnAmt, "-1012.5") || coffee.Name != "Coffee" {
+		t.Errorf("Unexpected transaction %v\n", coffee)
+	}
+	if len(coffee.FiTID) == 0 || coffee.FiTID == transactions[1].FiTID || transactions[1].FiTID != coffee.FiTID+"-2" {
+		t.Errorf("Expected distinct FITIDs for identical transactions, got %s and %s\n", coffee.FiTID, transactions[1].FiTID)
+	}
+	rent := transactions[2]
+	if rent.TrnType != ofxgo.TrnTypeCheck || rent.CheckNum != "1001" || !ofxtest.AmountEqual(rent.TrnAmt, "-900") {
+		t.Errorf("Unexpected check %v\n", rent)
+	}
+	pay := transactions[3]
+	if pay.TrnType != ofxgo.TrnTypeCredit || !ofxtest.AmountEqual(pay.TrnAmt, "4000") {
+		t.Errorf("Unexpected credit %v\n", pay)
+	}
+
+	again, err := Read(strings.NewReader(bankCSV), "1234", &bankOptions)
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSV: %s\n", err)
+	}
+	if again.Transactions.Transactions[2].FiTID != rent.FiTID {
+		t.Errorf("Expected FITIDs to be stable\n")
+	}
+	other, err := Read(strings.NewReader(bankCSV), "5678", &bankOptions)
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSV: %s\n", err)
+	}
+	if other.Transactions.Transactions[2].FiTID == rent.FiTID {
+		t.Errorf("Expected FITIDs to differ between accounts\n")
+	}
+}
+
+func TestReadSignConventions(t *testing.T) {
+	// No header, day-first dates, debits positive, and a closing balance
+	// computed from the opening balance
+	csv := "skipped preamble\n" +
+		"01.03.2018;GROCERIES;45,10\n" +
+		"02.03.2018;REFUND;(1.005,00)\n"
+	options := Options{
+		Columns:      Columns{Date: "1", Memo: "2", Amount: "3"},
+		DateFormats:  []string{"02.01.2006"},
+		InvertSign:   true,
+		DecimalComma: true,
+		Comma:        ';',
+		NoHeader:     true,
+		SkipRows:     1,
+	}
+	options.OpeningBalance.SetInt64(-100)
+	s, err := Read(strings.NewReader(csv), "cc", &options)
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSV: %s\n", err)
+	}
+	transactions := s.Transactions.Transactions
+	if len(transactions) != 2 || !ofxtest.AmountEqual(transactions[0].TrnAmt, "-45.1") || !ofxtest.AmountEqual(transactions[1].TrnAmt, "1005") {
+		t.Fatalf("Unexpected transactions %v\n", transactions)
+	}
+	if transactions[0].Memo != "GROCERIES" || !transactions[1].DtPosted.Time.Equal(time.Date(2018, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected transactions %v\n", transactions)
+	}
+	if !ofxtest.AmountEqual(s.Balance, "859.9") {
+		t.Errorf("Expected computed balance 859.9, got %s\n", s.Balance)
+	}
+
+	// Unsigned amounts with a debit/credit indicator column
+	csv = "Date,Amount,Type\n2018-03-01,12.00,DR\n2018-03-02,-5.00,CR\n"
+	s, err = Read(strings.NewReader(csv), "cc", &Options{Columns: Columns{Date: "Date", Amount: "Amount", Indicator: "Type"}})
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSV: %s\n", err)
+	}
+	transactions = s.Transactions.Transactions
+	if !ofxtest.AmountEqual(transactions[0].TrnAmt, "-12") || !ofxtest.AmountEqual(transactions[1].TrnAmt, "5") || !ofxtest.AmountEqual(s.Balance, "-7") {
+		t.Errorf("Unexpected transactions %v\n", transactions)
+	}
+	// Separate debit and credit columns, with the unused one zero rather than
+	// empty
+	csv = "Date,Debit,Credit\n2018-03-01,12.00,0.00\n2018-03-02,0.00,50.00\n"
+	s, err = Read(strings.NewReader(csv), "1234", &Options{Columns: Columns{Date: "Date", Debit: "Debit", Credit: "Credit"}})
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSV: %s\n", err)
+	}
+	transactions = s.Transactions.Transactions
+	if len(transactions) != 2 || !ofxtest.AmountEqual(transactions[0].TrnAmt, "-12") || !ofxtest.AmountEqual(transactions[1].TrnAmt, "50") {
+		t.Fatalf("Unexpected transactions %v\n", transactions)
+	}
+	if transactions[0].TrnType != ofxgo.TrnTypeDebit || transactions[1].TrnType != ofxgo.TrnTypeCredit {
+		t.Errorf("Unexpected transaction types %s, %s\n", transactions[0].TrnType, transactions[1].TrnType)
+	}
+	if !ofxtest.AmountEqual(s.Balance, "38") {
+		t.Errorf("Expected computed balance 38, got %s\n", s.Balance)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		csv     string
+		columns Columns
+	}{
+		{"Date,Amount\n2018-03-01,1\n", Columns{Amount: "Amount"}},
+		{"Date,Amount\n2018-03-01,1\n", Columns{Date: "Date"}},
+		{"Date,Amount\n2018-03-01,1\n", Columns{Date: "Date", Amount: "Total"}},
+		{"Date,Amount\n03/01/2018x,1\n", Columns{Date: "Date", Amount: "Amount"}},
+		{"Date,Amount\n2018-03-01,one\n", Columns{Date: "Date", Amount: "Amount"}},
+		{"Date,Amount\n2018-03-01,\n", Columns{Date: "Date", Amount: "Amount"}},
+	}
+	for _, test := range tests {
+		if _, err := Read(strings.NewReader(test.csv), "", &Options{Columns: test.columns}); err == nil {
+			t.Errorf("Expected error reading %q with columns %v\n", test.csv, test.columns)
+		}
+	}
+}
+
+func TestNewResponseMarshal(t *testing.T) {
+	account := ofxgo.BankAcct{BankID: "318398732", AcctID: "78346129", AcctType: ofxgo.AcctTypeChecking}
+	stmt, err := NewStatementResponse(strings.NewReader(bankCSV), account, &bankOptions)
+	if err != nil {
+		t.Fatalf("Unexpected error building statement: %s\n", err)
+	}
+	ccCSV := "Posted,Payee,Amount\n2018-03-03,Books,25.99\n"
+	ccStmt, err := NewCCStatementResponse(strings.NewReader(ccCSV), ofxgo.CCAcct{AcctID: "4111111111111111"},
+		&Options{Columns: Columns{Date: "posted", Name: "payee", Amount: "amount"}, InvertSign: true, Currency: "CAD"})
+	if err != nil {
+		t.Fatalf("Unexpected error building credit card statement: %s\n", err)
+	}
+
+	response, err := NewResponse("Example Bank", "1234", stmt, ccStmt)
+	if err != nil {
+		t.Fatalf("Unexpected error building response: %s\n", err)
+	}
+	b, err := response.Marshal()
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling response: %s\n", err)
+	}
+	parsed, err := ofxgo.ParseResponse(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing marshaled response: %s\n%s\n", err, b.String())
+	}
+	if len(parsed.Bank) != 1 || len(parsed.CreditCard) != 1 || parsed.Signon.Org != "Example Bank" {
+		t.Fatalf("Unexpected parsed response %v\n", parsed)
+	}
+	bank, ok := parsed.Bank[0].(*ofxgo.StatementResponse)
+	if !ok || len(bank.BankTranList.Transactions) != 4 || !ofxtest.AmountEqual(bank.BalAmt, "1087.5") || bank.BankAcctFrom.AcctID != "78346129" {
+		t.Errorf("Unexpected bank statement %v\n", parsed.Bank[0])
+	}
+	if bank.BankTranList.Transactions[2].FiTID != stmt.BankTranList.Transactions[2].FiTID {
+		t.Errorf("Expected FITIDs to survive marshaling\n")
+	}
+	cc, ok := parsed.CreditCard[0].(*ofxgo.CCStatementResponse)
+	if !ok || cc.CurDef.String() != "CAD" || !ofxtest.AmountEqual(cc.BalAmt, "-25.99") || cc.BankTranList.Transactions[0].TrnType != ofxgo.TrnTypeDebit {
+		t.Errorf("Unexpected credit card statement %v\n", parsed.CreditCard[0])
+	}
+
+	if _, err := NewResponse("", "", &ofxgo.SecurityList{}); err == nil {
+		t.Errorf("Expected error wrapping a security list\n")
+	}
+}
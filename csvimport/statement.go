@@ -0,0 +1,95 @@
+package csvimport
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/aclindsa/ofxgo"
+)
+
+// DefaultCurrency is the currency statements are in if Options doesn't
+// specify one
+const DefaultCurrency = "USD"
+
+// currency returns the CURDEF for statements read with options
+func currency(options *Options) (*ofxgo.CurrSymbol, error) {
+	if options != nil && len(options.Currency) > 0 {
+		return ofxgo.NewCurrSymbol(options.Currency)
+	}
+	return ofxgo.NewCurrSymbol(DefaultCurrency)
+}
+
+// NewStatementResponse reads the CSV in r into a bank statement for account
+func NewStatementResponse(r io.Reader, account ofxgo.BankAcct, options *Options) (*ofxgo.StatementResponse, error) {
+	curdef, err := currency(options)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Read(r, string(account.BankID)+"/"+string(account.AcctID), options)
+	if err != nil {
+		return nil, err
+	}
+	return &ofxgo.StatementResponse{
+		TrnUID:       "0",
+		Status:       ofxgo.Status{Code: 0, Severity: "INFO"},
+		CurDef:       *curdef,
+		BankAcctFrom: account,
+		BankTranList: &s.Transactions,
+		BalAmt:       s.Balance,
+		DtAsOf:       s.DtAsOf,
+	}, nil
+}
+
+// NewCCStatementResponse reads the CSV in r into a credit card statement for
+// account
+func NewCCStatementResponse(r io.Reader, account ofxgo.CCAcct, options *Options) (*ofxgo.CCStatementResponse, error) {
+	curdef, err := currency(options)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Read(r, string(account.AcctID), options)
+	if err != nil {
+		return nil, err
+	}
+	return &ofxgo.CCStatementResponse{
+		TrnUID:       "0",
+		Status:       ofxgo.Status{Code: 0, Severity: "INFO"},
+		CurDef:       *curdef,
+		CCAcctFrom:   account,
+		BankTranList: &s.Transactions,
+		BalAmt:       s.Balance,
+		DtAsOf:       s.DtAsOf,
+	}, nil
+}
+
+// NewResponse wraps statements, which must each be a *StatementResponse or
+// *CCStatementResponse, in an OFX 2.0.3 Response from the FI identified by
+// org and fid (either of which may be empty), ready to be marshaled with
+// Response.Marshal
+func NewResponse(org, fid string, statements ...ofxgo.Message) (*ofxgo.Response, error) {
+	response := ofxgo.Response{
+		Version: ofxgo.OfxVersion203,
+		Signon: ofxgo.SignonResponse{
+			Status:   ofxgo.Status{Code: 0, Severity: "INFO"},
+			DtServer: ofxgo.Date{Time: time.Now().UTC()},
+			Language: "ENG",
+			Org:      ofxgo.String(org),
+			Fid:      ofxgo.String(fid),
+		},
+	}
+	for _, s := range statements {
+		if ok, err := s.Valid(response.Version); !ok {
+			return nil, err
+		}
+		switch s.(type) {
+		case *ofxgo.StatementResponse:
+			response.Bank = append(response.Bank, s)
+		case *ofxgo.CCStatementResponse:
+			response.CreditCard = append(response.CreditCard, s)
+		default:
+			return nil, errors.New("Message is not a bank or credit card statement")
+		}
+	}
+	return &response, nil
+}
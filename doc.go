@@ -108,10 +108,11 @@ account and print the balance:
     os.Exit(1)
   }
 
+  // Statuses with SEVERITY ERROR are returned as a *StatusError above, but
+  // INFO and WARN statuses (i.e. a password about to expire) are not
   if response.Signon.Status.Code != 0 {
     meaning, _ := response.Signon.Status.CodeMeaning()
     fmt.Printf("Nonzero signon status (%d: %s) with message: %s\n", response.Signon.Status.Code, meaning, response.Signon.Status.Message)
-    os.Exit(1)
   }
 
   if len(response.Bank) < 1 {
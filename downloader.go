@@ -0,0 +1,227 @@
+package ofxgo
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DownloadJob describes downloading statements for all of a user's accounts
+// at one FI
+type DownloadJob struct {
+	Name     string            // Identifies this job in the DownloadReport
+	Client   Client            // Client used to make all requests for this job
+	Request  *Request          // Supplies URL and SignonRequest for all requests, as in NewBatch
+	Options  BatchOptions      // Passed to NewBatch
+	Accounts *AcctInfoResponse // Accounts to download. If nil, an AcctInfoRequest is sent to get them.
+	Profile  *ProfileResponse  // If non-nil, requests are routed according to this profile
+}
+
+// DownloadResult contains the results of one DownloadJob. If Err is non-nil,
+// Results may be incomplete or empty.
+type DownloadResult struct {
+	Job     *DownloadJob
+	Results []BatchResult
+	Err     error
+}
+
+// DownloadReport contains the results of all the DownloadJobs passed to
+// Downloader.Run, in the same order
+type DownloadReport struct {
+	Jobs []DownloadResult
+}
+
+// Errors returns the errors encountered by any jobs, and for any accounts
+// whose statements weren't returned or whose transactions failed
+func (dr *DownloadReport) Errors() []error {
+	var errs []error
+	for _, job := range dr.Jobs {
+		if job.Err != nil {
+			errs = append(errs, errors.New(job.Job.Name+": "+job.Err.Error()))
+			continue
+		}
+		for _, result := range job.Results {
+			if result.Response == nil {
+				errs = append(errs, errors.New(job.Job.Name+": no statement returned for account "+accountDescription(result.Account)))
+			} else if status, ok := transactionStatus(result.Response); ok {
+				if err := status.Err(result.Response.Name(), result.Account.TrnUID); err != nil {
+					errs = append(errs, errors.New(job.Job.Name+": account "+accountDescription(result.Account)+": "+err.Error()))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func accountDescription(a *BatchAccount) string {
+	switch {
+	case a.AcctInfo.BankAcctInfo != nil:
+		return a.AcctInfo.BankAcctInfo.BankAcctFrom.AcctID.String()
+	case a.AcctInfo.CCAcctInfo != nil:
+		return a.AcctInfo.CCAcctInfo.CCAcctFrom.AcctID.String()
+	case a.AcctInfo.InvAcctInfo != nil:
+		return a.AcctInfo.InvAcctInfo.InvAcctFrom.AcctID.String()
+	}
+	return string(a.TrnUID)
+}
+
+// Downloader runs many DownloadJobs concurrently, while limiting how hard any
+// one OFX server is hit. Many FIs will lock accounts or reject requests when
+// they receive too many in quick succession, so by default only one request at
+// a time is made to each host.
+type Downloader struct {
+	MaxConcurrent int           // Maximum number of jobs to run at once (0 means no limit)
+	MaxPerHost    int           // Maximum number of simultaneous requests to any one host (defaults to 1)
+	Delay         time.Duration // Minimum delay between the end of one request to a host and the start of the next
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+// hostLimiter limits concurrency and request rate for one host
+type hostLimiter struct {
+	sem  chan struct{}
+	mu   sync.Mutex
+	last time.Time
+}
+
+func (d *Downloader) limiter(URL string) *hostLimiter {
+	host := URL
+	if u, err := url.Parse(URL); err == nil && len(u.Host) > 0 {
+		host = u.Host
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.hosts == nil {
+		d.hosts = make(map[string]*hostLimiter)
+	}
+	l, ok := d.hosts[host]
+	if !ok {
+		perHost := d.MaxPerHost
+		if perHost <= 0 {
+			perHost = 1
+		}
+		l = &hostLimiter{sem: make(chan struct{}, perHost)}
+		d.hosts[host] = l
+	}
+	return l
+}
+
+func (l *hostLimiter) acquire(delay time.Duration) {
+	l.sem <- struct{}{}
+	l.mu.Lock()
+	var wait time.Duration
+	if !l.last.IsZero() {
+		wait = delay - time.Since(l.last)
+	}
+	l.mu.Unlock()
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+func (l *hostLimiter) release() {
+	l.mu.Lock()
+	l.last = time.Now()
+	l.mu.Unlock()
+	<-l.sem
+}
+
+// limitedClient wraps a Client, making every request wait on the limiter for
+// the host it is sent to
+type limitedClient struct {
+	Client
+	d *Downloader
+}
+
+func (c limitedClient) RequestNoParse(r *Request) (*http.Response, error) {
+	l := c.d.limiter(r.URL)
+	l.acquire(c.d.Delay)
+	defer l.release()
+	return c.Client.RequestNoParse(r)
+}
+
+func (c limitedClient) Request(r *Request) (*Response, error) {
+	l := c.d.limiter(r.URL)
+	l.acquire(c.d.Delay)
+	defer l.release()
+	return c.Client.Request(r)
+}
+
+// runJob downloads the statements for one job
+func (d *Downloader) runJob(job *DownloadJob) ([]BatchResult, error) {
+	client := limitedClient{Client: job.Client, d: d}
+
+	accounts := job.Accounts
+	if accounts == nil {
+		uid, err := RandomUID()
+		if err != nil {
+			return nil, err
+		}
+		request := Request{
+			URL:     job.Request.URL,
+			Version: job.Request.Version,
+			Signon:  job.Request.Signon,
+			Signup: []Message{&AcctInfoRequest{
+				TrnUID:   *uid,
+				DtAcctUp: Date{Time: time.Unix(0, 0)},
+			}},
+		}
+		response, err := client.Request(&request)
+		if err != nil {
+			return nil, err
+		}
+		if len(response.Signup) < 1 {
+			return nil, errors.New("No ACCTINFOTRNRS returned for AcctInfoRequest")
+		}
+		var ok bool
+		if accounts, ok = response.Signup[0].(*AcctInfoResponse); !ok {
+			return nil, errors.New("Unexpected message type returned for AcctInfoRequest")
+		}
+	}
+
+	batch, err := NewBatch(job.Request, accounts, job.Options)
+	if err != nil {
+		return nil, err
+	}
+	if job.Profile != nil {
+		if err := batch.Route(NewProfiledClient(client, job.Profile, job.Request.Signon.ClientUID)); err != nil {
+			return nil, err
+		}
+	}
+	results, err := batch.Send(client)
+	if statusErr, ok := err.(*StatusError); ok && !statusErr.Signon() {
+		// Failed transactions are reported per-account in the results
+		err = nil
+	}
+	return results, err
+}
+
+// Run runs all the jobs, returning once they have all completed
+func (d *Downloader) Run(jobs []DownloadJob) *DownloadReport {
+	report := DownloadReport{Jobs: make([]DownloadResult, len(jobs))}
+
+	var sem chan struct{}
+	if d.MaxConcurrent > 0 {
+		sem = make(chan struct{}, d.MaxConcurrent)
+	}
+
+	var wg sync.WaitGroup
+	for i := range jobs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if sem != nil {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+			}
+			report.Jobs[i].Job = &jobs[i]
+			report.Jobs[i].Results, report.Jobs[i].Err = d.runJob(&jobs[i])
+		}(i)
+	}
+	wg.Wait()
+	return &report
+}
@@ -0,0 +1,131 @@
+package ofxgo
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStatementClient answers statement requests without making network
+// requests, recording how many requests are outstanding to each URL at once
+type fakeStatementClient struct {
+	BasicClient
+	mu          sync.Mutex
+	outstanding map[string]int
+	maxSeen     map[string]int
+	starts      []time.Time
+	fail        bool
+}
+
+func (c *fakeStatementClient) Request(r *Request) (*Response, error) {
+	c.mu.Lock()
+	if c.outstanding == nil {
+		c.outstanding = make(map[string]int)
+		c.maxSeen = make(map[string]int)
+	}
+	c.outstanding[r.URL]++
+	if c.outstanding[r.URL] > c.maxSeen[r.URL] {
+		c.maxSeen[r.URL] = c.outstanding[r.URL]
+	}
+	c.starts = append(c.starts, time.Now())
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.mu.Lock()
+	c.outstanding[r.URL]--
+	c.mu.Unlock()
+
+	if c.fail {
+		return nil, errors.New("Fake failure")
+	}
+	var response Response
+	for range r.Signup {
+		response.Signup = append(response.Signup, testAcctInfoResponse())
+	}
+	for _, m := range r.Bank {
+		response.Bank = append(response.Bank, &StatementResponse{TrnUID: m.(*StatementRequest).TrnUID})
+	}
+	for _, m := range r.CreditCard {
+		response.CreditCard = append(response.CreditCard, &CCStatementResponse{TrnUID: m.(*CCStatementRequest).TrnUID})
+	}
+	for _, m := range r.InvStmt {
+		response.InvStmt = append(response.InvStmt, &InvStatementResponse{TrnUID: m.(*InvStatementRequest).TrnUID})
+	}
+	return &response, nil
+}
+
+func TestDownloader(t *testing.T) {
+	client := &fakeStatementClient{}
+	failing := &fakeStatementClient{fail: true}
+	jobs := []DownloadJob{
+		{Name: "one", Client: client, Request: &Request{URL: "https://ofx.example.com/one"}},
+		{Name: "two", Client: client, Request: &Request{URL: "https://ofx.example.com/two"}},
+		{Name: "three", Client: client, Request: &Request{URL: "https://ofx.example.net/"}, Accounts: testAcctInfoResponse()},
+		{Name: "failing", Client: failing, Request: &Request{URL: "https://ofx.example.org/"}},
+	}
+
+	d := Downloader{Delay: 20 * time.Millisecond}
+	report := d.Run(jobs)
+	if len(report.Jobs) != 4 {
+		t.Fatalf("Expected 4 job results, got %d\n", len(report.Jobs))
+	}
+	for i, job := range report.Jobs[:3] {
+		if job.Job != &jobs[i] {
+			t.Errorf("Job results out of order\n")
+		}
+		if job.Err != nil {
+			t.Errorf("Unexpected error in job %s: %s\n", job.Job.Name, job.Err)
+		}
+		if len(job.Results) != 3 {
+			t.Errorf("Expected 3 results for job %s, got %d\n", job.Job.Name, len(job.Results))
+		}
+	}
+	if report.Jobs[3].Err == nil {
+		t.Errorf("Expected error for failing job\n")
+	}
+	if errs := report.Errors(); len(errs) != 1 {
+		t.Errorf("Expected 1 error in report, got %v\n", errs)
+	}
+
+	// Jobs one and two share a host, so their requests must not have overlapped
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	for URL, max := range client.maxSeen {
+		if max > 1 {
+			t.Errorf("Expected at most one simultaneous request to %s, saw %d\n", URL, max)
+		}
+	}
+	if len(client.starts) != 5 {
+		t.Errorf("Expected 5 requests (2 account info, 3 statement), got %d\n", len(client.starts))
+	}
+}
+
+func TestDownloaderHostLimit(t *testing.T) {
+	client := &fakeStatementClient{}
+	var jobs []DownloadJob
+	for i := 0; i < 4; i++ {
+		jobs = append(jobs, DownloadJob{
+			Name:     "job",
+			Client:   client,
+			Request:  &Request{URL: "https://ofx.example.com/"},
+			Accounts: testAcctInfoResponse(),
+		})
+	}
+
+	start := time.Now()
+	d := Downloader{MaxPerHost: 1, Delay: 15 * time.Millisecond}
+	report := d.Run(jobs)
+	elapsed := time.Since(start)
+	if errs := report.Errors(); len(errs) != 0 {
+		t.Fatalf("Unexpected errors: %v\n", errs)
+	}
+	if client.maxSeen["https://ofx.example.com/"] != 1 {
+		t.Errorf("Expected requests to one host to be serialized, saw %d at once\n", client.maxSeen["https://ofx.example.com/"])
+	}
+	// 4 requests of 10ms each, with 3 delays of 15ms between them
+	if elapsed < 4*10*time.Millisecond+3*15*time.Millisecond {
+		t.Errorf("Expected delay between requests to be respected, took only %s\n", elapsed)
+	}
+}
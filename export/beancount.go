@@ -0,0 +1,111 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/aclindsa/ofxgo"
+)
+
+// beancountCommodity converts symbol to a valid Beancount commodity: up to 24
+// capital letters, digits, and the characters '._- starting with a letter and
+// ending with a letter or digit
+func beancountCommodity(symbol string) string {
+	c := strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || strings.ContainsRune("'._-", r) {
+			return r
+		}
+		return '-'
+	}, strings.ToUpper(symbol))
+	if len(c) == 0 || c[0] < 'A' || c[0] > 'Z' {
+		c = "X" + c
+	}
+	if len(c) > 24 {
+		c = c[:24]
+	}
+	return strings.TrimRight(c, "'._-")
+}
+
+func beancountAmount(a *journalAmount) string {
+	return formatNumber(a.number) + " " + beancountCommodity(a.commodity)
+}
+
+// beancountString quotes s as a Beancount string
+func beancountString(s string) string {
+	s = strings.Replace(s, "\\", "\\\\", -1)
+	return "\"" + strings.Replace(s, "\"", "\\\"", -1) + "\""
+}
+
+// WriteBeancount writes the bank, credit card, and investment statements in
+// response in Beancount syntax. Accounts are opened and securities declared
+// as commodities (with their names and UNIQUEIDs as metadata) as of the first
+// transaction, followed by a transaction for each bank and investment
+// transaction with its FITID as "fitid" metadata, so duplicates can be found
+// when the same transactions are downloaded again. Securities bought are held
+// at their total cost, and those sold reduce existing lots (as chosen by the
+// account's booking method) with the difference booked to
+// JournalOptions.GainsAccount. Balance assertions for each account's
+// LEDGERBAL (or AVAILCASH for investment accounts) are dated the day after
+// DTASOF, since Beancount checks balances at the beginning of the day.
+// options may be nil to use the defaults.
+func WriteBeancount(w io.Writer, response *ofxgo.Response, options *JournalOptions) error {
+	j, err := newJournal(response, options)
+	if err != nil {
+		return err
+	}
+
+	b := bufio.NewWriter(w)
+	first := j.firstDate().Format(journalDateFormat)
+	for _, account := range j.accounts {
+		b.WriteString(first + " open " + account + "\n")
+	}
+	if len(j.accounts) > 0 {
+		b.WriteString("\n")
+	}
+	for _, c := range j.commodities {
+		b.WriteString(first + " commodity " + beancountCommodity(c.symbol) + "\n")
+		if len(c.security.Name) > 0 {
+			b.WriteString("  name: " + beancountString(c.security.Name) + "\n")
+		}
+		b.WriteString("  uniqueid: " + beancountString(c.security.UniqueID) + "\n")
+		b.WriteString("  uniqueidtype: " + beancountString(c.security.UniqueIDType) + "\n\n")
+	}
+
+	for _, e := range j.entries {
+		date := e.date.Format(journalDateFormat)
+		if len(e.postings) == 0 {
+			b.WriteString("; " + date + " " + ledgerText(e.narration) + " (fitid " + e.fitid + ")\n\n")
+			continue
+		}
+		b.WriteString(date + " *")
+		if len(e.payee) > 0 {
+			b.WriteString(" " + beancountString(e.payee))
+		}
+		b.WriteString(" " + beancountString(e.narration) + "\n")
+		b.WriteString("  fitid: " + beancountString(e.fitid) + "\n")
+		for _, p := range e.postings {
+			b.WriteString("  " + p.account)
+			if p.amount != nil {
+				b.WriteString("  " + beancountAmount(p.amount))
+				switch p.lot {
+				case lotOpen:
+					b.WriteString(" {{" + beancountAmount(p.total) + "}}")
+				case lotClose:
+					b.WriteString(" {}")
+					if p.price != nil {
+						b.WriteString(" @ " + beancountAmount(p.price))
+					}
+				}
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	for _, balance := range j.balances {
+		date := balance.date.AddDate(0, 0, 1).Format(journalDateFormat)
+		b.WriteString(date + " balance " + balance.account + "  " + beancountAmount(&balance.amount) + "\n")
+	}
+	return b.Flush()
+}
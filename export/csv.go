@@ -0,0 +1,167 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// DefaultCSVColumns are the columns written by WriteCSV for bank and credit
+// card statements if none are specified
+var DefaultCSVColumns = []string{"acctid", "date", "type", "amount", "currency", "name", "memo", "checknum", "fitid"}
+
+// DefaultInvCSVColumns are the columns written by WriteCSV if none are
+// specified and any of the statements are investment statements
+var DefaultInvCSVColumns = []string{"acctid", "date", "type", "action", "ticker", "uniqueid", "units", "unitprice", "commission", "fees", "amount", "currency", "memo", "fitid"}
+
+// csvColumns maps the name of each column WriteCSV can write to a function
+// returning its value for a row. Each row describes either a Transaction or
+// an InvTransaction from a Statement.
+var csvColumns = map[string]func(r *csvRow) string{
+	"statementtype": func(r *csvRow) string { return r.s.Type },
+	"bankid":        func(r *csvRow) string { return r.s.Account.BankID },
+	"brokerid":      func(r *csvRow) string { return r.s.Account.BrokerID },
+	"acctid":        func(r *csvRow) string { return r.s.Account.AcctID },
+	"accttype":      func(r *csvRow) string { return r.s.Account.AcctType },
+	"fitid":         column(func(t *Transaction) string { return t.FiTID }, func(t *InvTransaction) string { return t.FiTID }),
+	"type":          column(func(t *Transaction) string { return t.Type }, func(t *InvTransaction) string { return t.Type }),
+	"date":          column(func(t *Transaction) string { return t.DtPosted }, func(t *InvTransaction) string { return t.DtTrade }),
+	"dtuser":        column(func(t *Transaction) string { return t.DtUser }, nil),
+	"dtavail":       column(func(t *Transaction) string { return t.DtAvail }, nil),
+	"dtsettle":      column(nil, func(t *InvTransaction) string { return t.DtSettle }),
+	"amount":        column(func(t *Transaction) string { return t.Amount }, func(t *InvTransaction) string { return t.Total }),
+	"name":          column(func(t *Transaction) string { return t.Name }, nil),
+	"memo":          column(func(t *Transaction) string { return t.Memo }, func(t *InvTransaction) string { return t.Memo }),
+	"checknum":      column(func(t *Transaction) string { return t.CheckNum }, nil),
+	"refnum":        column(func(t *Transaction) string { return t.RefNum }, nil),
+	"correctfitid":  column(func(t *Transaction) string { return t.CorrectFiTID }, nil),
+	"correctaction": column(func(t *Transaction) string { return t.CorrectAction }, nil),
+	"reversalfitid": column(nil, func(t *InvTransaction) string { return t.ReversalFiTID }),
+	"action":        column(nil, func(t *InvTransaction) string { return t.Action }),
+	"incometype":    column(nil, func(t *InvTransaction) string { return t.IncomeType }),
+	"uniqueid":      column(nil, func(t *InvTransaction) string { return security(t).UniqueID }),
+	"uniqueidtype":  column(nil, func(t *InvTransaction) string { return security(t).UniqueIDType }),
+	"ticker":        column(nil, func(t *InvTransaction) string { return security(t).Ticker }),
+	"secname":       column(nil, func(t *InvTransaction) string { return security(t).Name }),
+	"units":         column(nil, func(t *InvTransaction) string { return t.Units }),
+	"unitprice":     column(nil, func(t *InvTransaction) string { return t.UnitPrice }),
+	"commission":    column(nil, func(t *InvTransaction) string { return t.Commission }),
+	"fees":          column(nil, func(t *InvTransaction) string { return t.Fees }),
+	"taxes":         column(nil, func(t *InvTransaction) string { return t.Taxes }),
+	"total":         column(nil, func(t *InvTransaction) string { return t.Total }),
+	"gain":          column(nil, func(t *InvTransaction) string { return t.Gain }),
+	"subacctsec":    column(nil, func(t *InvTransaction) string { return t.SubAcctSec }),
+	"subacctfund":   column(func(t *Transaction) string { return t.SubAcctFund }, func(t *InvTransaction) string { return t.SubAcctFund }),
+	"currency": func(row *csvRow) string {
+		currency := row.s.Currency
+		if row.t != nil && len(row.t.Currency) > 0 {
+			currency = row.t.Currency
+		} else if row.i != nil && len(row.i.Currency) > 0 {
+			currency = row.i.Currency
+		}
+		return currency
+	},
+}
+
+// csvRow is one row of CSV output
+type csvRow struct {
+	s *Statement
+	t *Transaction // Exactly one of t and i is non-nil
+	i *InvTransaction
+}
+
+// column returns a column function calling bank for rows describing Transactions
+// and inv for rows describing InvTransactions (either may be nil if the column
+// doesn't apply to those rows)
+func column(bank func(t *Transaction) string, inv func(t *InvTransaction) string) func(*csvRow) string {
+	return func(row *csvRow) string {
+		if row.t != nil && bank != nil {
+			return bank(row.t)
+		} else if row.i != nil && inv != nil {
+			return inv(row.i)
+		}
+		return ""
+	}
+}
+
+func security(t *InvTransaction) *Security {
+	if t.Security == nil {
+		return &Security{}
+	}
+	return t.Security
+}
+
+// CSVOptions control how WriteCSV writes statements
+type CSVOptions struct {
+	// Columns lists the columns to write, in order. Each is one of
+	// statementtype, bankid, brokerid, acctid, accttype, fitid, type, date,
+	// dtuser, dtavail, dtsettle, amount, name, memo, checknum, refnum,
+	// correctfitid, correctaction, reversalfitid, action, incometype,
+	// uniqueid, uniqueidtype, ticker, secname, units, unitprice, commission,
+	// fees, taxes, total, gain, subacctsec, subacctfund, or currency. Column
+	// values are formatted as in the JSON schema; columns which don't apply to
+	// a transaction are left empty. "date" is DTPOSTED for bank transactions
+	// and DTTRADE for investment transactions, and "amount" is TRNAMT for bank
+	// transactions and TOTAL for investment transactions. If Columns is
+	// empty, DefaultCSVColumns or DefaultInvCSVColumns is used.
+	Columns []string
+	// NoHeader omits the header row naming the columns
+	NoHeader bool
+}
+
+// WriteCSV writes one CSV row for each transaction and investment transaction
+// in statements. options may be nil to use the defaults.
+func WriteCSV(w io.Writer, statements []Statement, options *CSVOptions) error {
+	var columns []string
+	var noHeader bool
+	if options != nil {
+		columns = options.Columns
+		noHeader = options.NoHeader
+	}
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+		for _, s := range statements {
+			if s.Type == StatementTypeInvestment {
+				columns = DefaultInvCSVColumns
+			}
+		}
+	}
+
+	funcs := make([]func(*csvRow) string, len(columns))
+	for i, name := range columns {
+		f, ok := csvColumns[name]
+		if !ok {
+			return fmt.Errorf("Invalid CSV column: %s", name)
+		}
+		funcs[i] = f
+	}
+
+	writer := csv.NewWriter(w)
+	if !noHeader {
+		if err := writer.Write(columns); err != nil {
+			return err
+		}
+	}
+	record := make([]string, len(columns))
+	write := func(row *csvRow) error {
+		for i, f := range funcs {
+			record[i] = f(row)
+		}
+		return writer.Write(record)
+	}
+	for i := range statements {
+		s := &statements[i]
+		for j := range s.Transactions {
+			if err := write(&csvRow{s: s, t: &s.Transactions[j]}); err != nil {
+				return err
+			}
+		}
+		for j := range s.InvTransactions {
+			if err := write(&csvRow{s: s, i: &s.InvTransactions[j]}); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,513 @@
+// Package export converts parsed bank, credit card, and investment statements
+// into flat records which can be written out as CSV or JSON.
+//
+// The JSON schema is stable: amounts are decimal strings (to avoid losing
+// precision to floating point), dates are RFC 3339 strings, and enumerated
+// values are the strings the OFX specification uses for them (i.e. "DEBIT" or
+// "BUYSTOCK"). Fields which weren't present in the OFX are omitted.
+package export
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aclindsa/ofxgo"
+)
+
+// Statement types
+const (
+	StatementTypeBank       = "bank"
+	StatementTypeCreditCard = "creditcard"
+	StatementTypeInvestment = "investment"
+)
+
+// Statement is the exported form of a StatementResponse, CCStatementResponse,
+// or InvStatementResponse
+type Statement struct {
+	Type             string           `json:"type"` // One of StatementTypeBank, StatementTypeCreditCard, StatementTypeInvestment
+	Account          Account          `json:"account"`
+	Currency         string           `json:"currency"`
+	DtStart          string           `json:"dtstart,omitempty"`
+	DtEnd            string           `json:"dtend,omitempty"`
+	Balance          *Balance         `json:"balance,omitempty"`          // LEDGERBAL, or AVAILCASH for investment statements
+	AvailableBalance *Balance         `json:"availablebalance,omitempty"` // AVAILBAL
+	Transactions     []Transaction    `json:"transactions"`               // Bank transactions, including those in investment accounts
+	InvTransactions  []InvTransaction `json:"invtransactions,omitempty"`
+	Positions        []Position       `json:"positions,omitempty"`
+}
+
+// Account identifies the account a Statement is for
+type Account struct {
+	BankID   string `json:"bankid,omitempty"`
+	BrokerID string `json:"brokerid,omitempty"`
+	AcctID   string `json:"acctid"`
+	AcctType string `json:"accttype,omitempty"`
+}
+
+// Balance is an account balance as of a point in time
+type Balance struct {
+	Amount string `json:"amount"`
+	DtAsOf string `json:"dtasof,omitempty"`
+}
+
+// Transaction is the exported form of a bank or credit card Transaction
+type Transaction struct {
+	FiTID         string `json:"fitid"`
+	Type          string `json:"type"`
+	DtPosted      string `json:"dtposted"`
+	DtUser        string `json:"dtuser,omitempty"`
+	DtAvail       string `json:"dtavail,omitempty"`
+	Amount        string `json:"amount"`
+	Currency      string `json:"currency,omitempty"` // Only present if different from the Statement's
+	Name          string `json:"name,omitempty"`     // NAME, or the name from PAYEE
+	Memo          string `json:"memo,omitempty"`
+	CheckNum      string `json:"checknum,omitempty"`
+	RefNum        string `json:"refnum,omitempty"`
+	CorrectFiTID  string `json:"correctfitid,omitempty"`
+	CorrectAction string `json:"correctaction,omitempty"`
+	SubAcctFund   string `json:"subacctfund,omitempty"` // Only for bank transactions in investment accounts
+}
+
+// Security identifies the security an InvTransaction or Position refers to
+type Security struct {
+	UniqueID     string `json:"uniqueid"`
+	UniqueIDType string `json:"uniqueidtype"`
+	Ticker       string `json:"ticker,omitempty"` // Only present if the response included a SECLIST describing the security
+	Name         string `json:"name,omitempty"`
+}
+
+// InvTransaction is the exported form of an investment transaction. Which of
+// the optional fields are present depends on Type.
+type InvTransaction struct {
+	FiTID         string    `json:"fitid"`
+	Type          string    `json:"type"` // i.e. BUYSTOCK
+	DtTrade       string    `json:"dttrade"`
+	DtSettle      string    `json:"dtsettle,omitempty"`
+	Memo          string    `json:"memo,omitempty"`
+	ReversalFiTID string    `json:"reversalfitid,omitempty"`
+	Security      *Security `json:"security,omitempty"`
+	Action        string    `json:"action,omitempty"`     // BUYTYPE, SELLTYPE, OPTBUYTYPE, OPTSELLTYPE, OPTACTION, or TFERACTION
+	IncomeType    string    `json:"incometype,omitempty"` // For INCOME and REINVEST
+	Units         string    `json:"units,omitempty"`
+	UnitPrice     string    `json:"unitprice,omitempty"`
+	Commission    string    `json:"commission,omitempty"`
+	Fees          string    `json:"fees,omitempty"`
+	Taxes         string    `json:"taxes,omitempty"`
+	Total         string    `json:"total,omitempty"`
+	Gain          string    `json:"gain,omitempty"`
+	Currency      string    `json:"currency,omitempty"` // Only present if different from the Statement's
+	SubAcctSec    string    `json:"subacctsec,omitempty"`
+	SubAcctFund   string    `json:"subacctfund,omitempty"`
+}
+
+// Position is the exported form of a Position
+type Position struct {
+	Type         string   `json:"type"` // i.e. POSSTOCK
+	Security     Security `json:"security"`
+	HeldInAcct   string   `json:"heldinacct,omitempty"`
+	PosType      string   `json:"postype,omitempty"`
+	Units        string   `json:"units"`
+	UnitPrice    string   `json:"unitprice"`
+	MktVal       string   `json:"mktval"`
+	AvgCostBasis string   `json:"avgcostbasis,omitempty"`
+	DtPriceAsOf  string   `json:"dtpriceasof,omitempty"`
+	Memo         string   `json:"memo,omitempty"`
+}
+
+// enum is implemented by all the OFX enumerated types
+type enum interface {
+	Valid() bool
+	String() string
+}
+
+func enumString(e enum) string {
+	if e.Valid() {
+		return e.String()
+	}
+	return ""
+}
+
+func dateString(d ofxgo.Date) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(time.RFC3339)
+}
+
+func datePtrString(d *ofxgo.Date) string {
+	if d == nil {
+		return ""
+	}
+	return dateString(*d)
+}
+
+func amountString(a ofxgo.Amount) string {
+	return a.String()
+}
+
+// optionalAmountString returns "" for a zero Amount, so that optional
+// elements which weren't present are omitted
+func optionalAmountString(a ofxgo.Amount) string {
+	if a.Sign() == 0 {
+		return ""
+	}
+	return a.String()
+}
+
+func currSymbolString(c ofxgo.CurrSymbol) string {
+	if ok, _ := c.Valid(); !ok {
+		return ""
+	}
+	return c.String()
+}
+
+func currencyString(c *ofxgo.Currency) string {
+	if c == nil {
+		return ""
+	}
+	if ok, _ := c.Valid(); !ok {
+		return ""
+	}
+	return currSymbolString(c.CurSym)
+}
+
+// NewStatements converts all the bank, credit card, and investment statements
+// in response, using its security list (if any) to fill in security names and
+// tickers
+func NewStatements(response *ofxgo.Response) ([]Statement, error) {
+	securities := ofxgo.NewSecurityMaster(response)
+	var statements []Statement
+	var messages []ofxgo.Message
+	messages = append(messages, response.Bank...)
+	messages = append(messages, response.CreditCard...)
+	messages = append(messages, response.InvStmt...)
+	for _, m := range messages {
+		switch m.(type) {
+		case *ofxgo.StatementResponse, *ofxgo.CCStatementResponse, *ofxgo.InvStatementResponse:
+			s, err := NewStatement(m, securities)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, *s)
+		}
+	}
+	return statements, nil
+}
+
+// NewStatement converts m, which must be a *StatementResponse,
+// *CCStatementResponse, or *InvStatementResponse. securities is used to look
+// up security names and tickers, and may be nil.
+func NewStatement(m ofxgo.Message, securities *ofxgo.SecurityMaster) (*Statement, error) {
+	var s Statement
+	switch stmt := m.(type) {
+	case *ofxgo.StatementResponse:
+		s.Type = StatementTypeBank
+		s.Account = Account{
+			BankID:   string(stmt.BankAcctFrom.BankID),
+			AcctID:   string(stmt.BankAcctFrom.AcctID),
+			AcctType: enumString(stmt.BankAcctFrom.AcctType),
+		}
+		s.Currency = currSymbolString(stmt.CurDef)
+		s.addTransactionList(stmt.BankTranList)
+		s.Balance = &Balance{Amount: amountString(stmt.BalAmt), DtAsOf: dateString(stmt.DtAsOf)}
+		if stmt.AvailBalAmt != nil {
+			s.AvailableBalance = &Balance{Amount: amountString(*stmt.AvailBalAmt), DtAsOf: datePtrString(stmt.AvailDtAsOf)}
+		}
+	case *ofxgo.CCStatementResponse:
+		s.Type = StatementTypeCreditCard
+		s.Account = Account{AcctID: string(stmt.CCAcctFrom.AcctID)}
+		s.Currency = currSymbolString(stmt.CurDef)
+		s.addTransactionList(stmt.BankTranList)
+		s.Balance = &Balance{Amount: amountString(stmt.BalAmt), DtAsOf: dateString(stmt.DtAsOf)}
+		if stmt.AvailBalAmt != nil {
+			s.AvailableBalance = &Balance{Amount: amountString(*stmt.AvailBalAmt), DtAsOf: datePtrString(stmt.AvailDtAsOf)}
+		}
+	case *ofxgo.InvStatementResponse:
+		s.Type = StatementTypeInvestment
+		s.Account = Account{
+			BrokerID: string(stmt.InvAcctFrom.BrokerID),
+			AcctID:   string(stmt.InvAcctFrom.AcctID),
+		}
+		s.Currency = currSymbolString(stmt.CurDef)
+		if stmt.InvTranList != nil {
+			s.DtStart = dateString(stmt.InvTranList.DtStart)
+			s.DtEnd = dateString(stmt.InvTranList.DtEnd)
+			for _, t := range stmt.InvTranList.InvTransactions {
+				s.InvTransactions = append(s.InvTransactions, newInvTransaction(t, securities))
+			}
+			for _, b := range stmt.InvTranList.BankTransactions {
+				for i := range b.Transactions {
+					t := newTransaction(&b.Transactions[i])
+					t.SubAcctFund = enumString(b.SubAcctFund)
+					s.Transactions = append(s.Transactions, t)
+				}
+			}
+		}
+		for _, p := range stmt.InvPosList {
+			s.Positions = append(s.Positions, newPosition(p, securities))
+		}
+		if stmt.InvBal != nil {
+			s.Balance = &Balance{Amount: amountString(stmt.InvBal.AvailCash), DtAsOf: dateString(stmt.DtAsOf)}
+		}
+	default:
+		return nil, errors.New("Message is not a bank, credit card, or investment statement")
+	}
+	if s.Transactions == nil {
+		s.Transactions = []Transaction{}
+	}
+	return &s, nil
+}
+
+func (s *Statement) addTransactionList(l *ofxgo.TransactionList) {
+	if l == nil {
+		return
+	}
+	s.DtStart = dateString(l.DtStart)
+	s.DtEnd = dateString(l.DtEnd)
+	for i := range l.Transactions {
+		s.Transactions = append(s.Transactions, newTransaction(&l.Transactions[i]))
+	}
+}
+
+func newTransaction(t *ofxgo.Transaction) Transaction {
+	name := string(t.Name)
+	if len(name) == 0 && t.Payee != nil {
+		name = string(t.Payee.Name)
+	}
+	e := Transaction{
+		FiTID:        string(t.FiTID),
+		Type:         enumString(t.TrnType),
+		DtPosted:     dateString(t.DtPosted),
+		DtUser:       datePtrString(t.DtUser),
+		DtAvail:      datePtrString(t.DtAvail),
+		Amount:       amountString(t.TrnAmt),
+		Currency:     currencyString(t.Currency),
+		Name:         name,
+		Memo:         string(t.Memo),
+		CheckNum:     string(t.CheckNum),
+		RefNum:       string(t.RefNum),
+		CorrectFiTID: string(t.CorrectFiTID),
+	}
+	if len(t.CorrectFiTID) > 0 {
+		e.CorrectAction = enumString(t.CorrectAction)
+	}
+	return e
+}
+
+func newSecurity(id ofxgo.SecurityID, securities *ofxgo.SecurityMaster) Security {
+	s := Security{
+		UniqueID:     string(id.UniqueID),
+		UniqueIDType: string(id.UniqueIDType),
+	}
+	if securities != nil {
+		if security, ok := securities.Lookup(id); ok {
+			info := security.SecurityInfo()
+			s.Ticker = string(info.Ticker)
+			s.Name = string(info.SecName)
+		}
+	}
+	return s
+}
+
+func newPosition(p ofxgo.Position, securities *ofxgo.SecurityMaster) Position {
+	pos := p.InvPosition()
+	return Position{
+		Type:         p.PositionType(),
+		Security:     newSecurity(pos.SecID, securities),
+		HeldInAcct:   enumString(pos.HeldInAcct),
+		PosType:      enumString(pos.PosType),
+		Units:        amountString(pos.Units),
+		UnitPrice:    amountString(pos.UnitPrice),
+		MktVal:       amountString(pos.MktVal),
+		AvgCostBasis: optionalAmountString(pos.AvgCostBasis),
+		DtPriceAsOf:  dateString(pos.DtPriceAsOf),
+		Memo:         string(pos.Memo),
+	}
+}
+
+// invDetails holds the parts of an investment transaction which depend on its
+// type. Amounts the transaction type doesn't have, and optional ones which
+// weren't present, are nil.
+type invDetails struct {
+	secID       *ofxgo.SecurityID
+	action      string // BUYTYPE, SELLTYPE, OPTBUYTYPE, OPTSELLTYPE, OPTACTION, or TFERACTION
+	incomeType  string
+	units       *ofxgo.Amount
+	unitPrice   *ofxgo.Amount
+	commission  *ofxgo.Amount
+	fees        *ofxgo.Amount
+	taxes       *ofxgo.Amount
+	total       *ofxgo.Amount
+	gain        *ofxgo.Amount
+	currency    string
+	subAcctSec  string
+	subAcctFund string
+}
+
+// optionalAmount returns nil for a zero Amount, so that optional elements
+// which weren't present are omitted
+func optionalAmount(a *ofxgo.Amount) *ofxgo.Amount {
+	if a.Sign() == 0 {
+		return nil
+	}
+	return a
+}
+
+func amountPtrString(a *ofxgo.Amount) string {
+	if a == nil {
+		return ""
+	}
+	return a.String()
+}
+
+func (d *invDetails) setBuy(b *ofxgo.InvBuy) {
+	d.secID = &b.SecID
+	d.units = &b.Units
+	d.unitPrice = &b.UnitPrice
+	d.commission = optionalAmount(&b.Commission)
+	d.fees = optionalAmount(&b.Fees)
+	d.taxes = optionalAmount(&b.Taxes)
+	d.total = &b.Total
+	d.currency = currencyString(&b.Currency)
+	d.subAcctSec = enumString(b.SubAcctSec)
+	d.subAcctFund = enumString(b.SubAcctFund)
+}
+
+func (d *invDetails) setSell(s *ofxgo.InvSell) {
+	d.secID = &s.SecID
+	d.units = &s.Units
+	d.unitPrice = &s.UnitPrice
+	d.commission = optionalAmount(&s.Commission)
+	d.fees = optionalAmount(&s.Fees)
+	d.taxes = optionalAmount(&s.Taxes)
+	d.total = &s.Total
+	d.gain = optionalAmount(&s.Gain)
+	d.currency = currencyString(&s.Currency)
+	d.subAcctSec = enumString(s.SubAcctSec)
+	d.subAcctFund = enumString(s.SubAcctFund)
+}
+
+func newInvDetails(t ofxgo.InvTransaction) invDetails {
+	var d invDetails
+	switch t := t.(type) {
+	case ofxgo.BuyDebt:
+		d.setBuy(&t.InvBuy)
+	case ofxgo.BuyMF:
+		d.setBuy(&t.InvBuy)
+		d.action = enumString(t.BuyType)
+	case ofxgo.BuyOpt:
+		d.setBuy(&t.InvBuy)
+		d.action = enumString(t.OptBuyType)
+	case ofxgo.BuyOther:
+		d.setBuy(&t.InvBuy)
+	case ofxgo.BuyStock:
+		d.setBuy(&t.InvBuy)
+		d.action = enumString(t.BuyType)
+	case ofxgo.SellDebt:
+		d.setSell(&t.InvSell)
+	case ofxgo.SellMF:
+		d.setSell(&t.InvSell)
+		d.action = enumString(t.SellType)
+	case ofxgo.SellOpt:
+		d.setSell(&t.InvSell)
+		d.action = enumString(t.OptSellType)
+	case ofxgo.SellOther:
+		d.setSell(&t.InvSell)
+	case ofxgo.SellStock:
+		d.setSell(&t.InvSell)
+		d.action = enumString(t.SellType)
+	case ofxgo.ClosureOpt:
+		d.secID = &t.SecID
+		d.action = enumString(t.OptAction)
+		d.units = &t.Units
+		d.gain = optionalAmount(&t.Gain)
+		d.subAcctSec = enumString(t.SubAcctSec)
+	case ofxgo.Income:
+		d.secID = &t.SecID
+		d.incomeType = enumString(t.IncomeType)
+		d.total = &t.Total
+		d.currency = currencyString(&t.Currency)
+		d.subAcctSec = enumString(t.SubAcctSec)
+		d.subAcctFund = enumString(t.SubAcctFund)
+	case ofxgo.InvExpense:
+		d.secID = &t.SecID
+		d.total = &t.Total
+		d.currency = currencyString(&t.Currency)
+		d.subAcctSec = enumString(t.SubAcctSec)
+		d.subAcctFund = enumString(t.SubAcctFund)
+	case ofxgo.JrnlFund:
+		d.total = &t.Total
+		d.subAcctFund = enumString(t.SubAcctFrom)
+		d.subAcctSec = enumString(t.SubAcctTo)
+	case ofxgo.JrnlSec:
+		d.secID = &t.SecID
+		d.units = &t.Units
+		d.subAcctFund = enumString(t.SubAcctFrom)
+		d.subAcctSec = enumString(t.SubAcctTo)
+	case ofxgo.MarginInterest:
+		d.total = &t.Total
+		d.currency = currencyString(&t.Currency)
+		d.subAcctFund = enumString(t.SubAcctFund)
+	case ofxgo.Reinvest:
+		d.secID = &t.SecID
+		d.incomeType = enumString(t.IncomeType)
+		d.units = &t.Units
+		d.unitPrice = &t.UnitPrice
+		d.commission = optionalAmount(&t.Commission)
+		d.fees = optionalAmount(&t.Fees)
+		d.taxes = optionalAmount(&t.Taxes)
+		d.total = &t.Total
+		d.currency = currencyString(&t.Currency)
+		d.subAcctSec = enumString(t.SubAcctSec)
+	case ofxgo.RetOfCap:
+		d.secID = &t.SecID
+		d.total = &t.Total
+		d.currency = currencyString(&t.Currency)
+		d.subAcctSec = enumString(t.SubAcctSec)
+		d.subAcctFund = enumString(t.SubAcctFund)
+	case ofxgo.Split:
+		d.secID = &t.SecID
+		d.units = &t.NewUnits
+		d.total = optionalAmount(&t.FracCash)
+		d.currency = currencyString(&t.Currency)
+		d.subAcctSec = enumString(t.SubAcctSec)
+		d.subAcctFund = enumString(t.SubAcctFund)
+	case ofxgo.Transfer:
+		d.secID = &t.SecID
+		d.action = enumString(t.TferAction)
+		d.units = &t.Units
+		d.unitPrice = optionalAmount(&t.UnitPrice)
+		d.subAcctSec = enumString(t.SubAcctSec)
+	}
+	return d
+}
+
+func newInvTransaction(t ofxgo.InvTransaction, securities *ofxgo.SecurityMaster) InvTransaction {
+	tran := t.InvTransaction()
+	d := newInvDetails(t)
+	e := InvTransaction{
+		FiTID:         string(tran.FiTID),
+		Type:          t.TransactionType(),
+		DtTrade:       dateString(tran.DtTrade),
+		DtSettle:      datePtrString(tran.DtSettle),
+		Memo:          string(tran.Memo),
+		ReversalFiTID: string(tran.ReversalFiTID),
+		Action:        d.action,
+		IncomeType:    d.incomeType,
+		Units:         amountPtrString(d.units),
+		UnitPrice:     amountPtrString(d.unitPrice),
+		Commission:    amountPtrString(d.commission),
+		Fees:          amountPtrString(d.fees),
+		Taxes:         amountPtrString(d.taxes),
+		Total:         amountPtrString(d.total),
+		Gain:          amountPtrString(d.gain),
+		Currency:      d.currency,
+		SubAcctSec:    d.subAcctSec,
+		SubAcctFund:   d.subAcctFund,
+	}
+	if d.secID != nil {
+		security := newSecurity(*d.secID, securities)
+		e.Security = &security
+	}
+	return e
+}
@@ -0,0 +1,200 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/internal/ofxtest"
+)
+
+func TestWriteJSONSample(t *testing.T) {
+	response := ofxtest.ParseSample(t, "../samples/valid_responses/moneymrkt1_v203.ofx")
+	statements, err := NewStatements(response)
+	if err != nil {
+		t.Fatalf("Unexpected error converting statements: %s\n", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, statements); err != nil {
+		t.Fatalf("Unexpected error writing JSON: %s\n", err)
+	}
+	var document Document
+	if err := json.Unmarshal(buf.Bytes(), &document); err != nil {
+		t.Fatalf("Unexpected error decoding JSON: %s\n", err)
+	}
+
+	if len(document.Statements) != 1 {
+		t.Fatalf("Expected 1 statement, got %d\n", len(document.Statements))
+	}
+	s := document.Statements[0]
+	expectedAccount := Account{BankID: "188545178", AcctID: "83483499583", AcctType: "MONEYMRKT"}
+	if s.Type != StatementTypeBank || s.Account != expectedAccount || s.Currency != "USD" {
+		t.Errorf("Unexpected statement: %v %v %s\n", s.Type, s.Account, s.Currency)
+	}
+	if s.DtStart != "2017-01-07T01:15:05Z" || s.DtEnd != "2017-04-07T00:15:05Z" {
+		t.Errorf("Unexpected statement dates %s to %s\n", s.DtStart, s.DtEnd)
+	}
+	if s.Balance == nil || *s.Balance != (Balance{Amount: "3317.738651126686", DtAsOf: "2017-04-07T00:15:05Z"}) {
+		t.Errorf("Unexpected balance %v\n", s.Balance)
+	}
+	if s.AvailableBalance == nil || s.AvailableBalance.Amount != "658.9377225082694" {
+		t.Errorf("Unexpected available balance %v\n", s.AvailableBalance)
+	}
+	if len(s.Transactions) != 3 {
+		t.Fatalf("Expected 3 transactions, got %d\n", len(s.Transactions))
+	}
+	expected := Transaction{
+		FiTID:    "a43dfadf-350b-4bb1-85d7-8be57ad0ef82",
+		Type:     "CREDIT",
+		DtPosted: "2017-03-15T12:00:00Z",
+		Amount:   "-850.1196618322863",
+		Name:     "Dividend Earned",
+	}
+	if s.Transactions[2] != expected {
+		t.Errorf("Expected transaction %v, got %v\n", expected, s.Transactions[2])
+	}
+
+	// Absent optional fields are omitted rather than written empty
+	if strings.Contains(buf.String(), `"memo"`) || strings.Contains(buf.String(), `"invtransactions"`) {
+		t.Errorf("Expected empty fields to be omitted:\n%s\n", buf.String())
+	}
+}
+
+func TestNewStatementInvestment(t *testing.T) {
+	secID := ofxgo.SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}
+	securities := ofxgo.NewSecurityMaster()
+	securities.Add(ofxgo.StockInfo{SecInfo: ofxgo.SecInfo{SecID: secID, SecName: "S&P 500 ETF", Ticker: "SPY"}})
+
+	stmt := &ofxgo.InvStatementResponse{
+		DtAsOf:      *ofxgo.NewDateGMT(2017, 4, 1, 0, 0, 0, 0),
+		InvAcctFrom: ofxgo.InvAcct{BrokerID: "example.com", AcctID: "1234"},
+		InvTranList: &ofxgo.InvTranList{
+			DtStart: *ofxgo.NewDateGMT(2017, 1, 1, 0, 0, 0, 0),
+			DtEnd:   *ofxgo.NewDateGMT(2017, 4, 1, 0, 0, 0, 0),
+			InvTransactions: []ofxgo.InvTransaction{
+				ofxgo.BuyStock{
+					InvBuy: ofxgo.InvBuy{
+						InvTran:     ofxgo.InvTran{FiTID: "1", DtTrade: *ofxgo.NewDateGMT(2017, 2, 1, 0, 0, 0, 0)},
+						SecID:       secID,
+						Units:       ofxtest.Amount("10"),
+						UnitPrice:   ofxtest.Amount("225.5"),
+						Total:       ofxtest.Amount("-2255"),
+						SubAcctSec:  ofxgo.SubAcctTypeCash,
+						SubAcctFund: ofxgo.SubAcctTypeCash,
+					},
+					BuyType: ofxgo.BuyTypeBuy,
+				},
+				ofxgo.Income{
+					InvTran:    ofxgo.InvTran{FiTID: "2", DtTrade: *ofxgo.NewDateGMT(2017, 3, 1, 0, 0, 0, 0)},
+					SecID:      secID,
+					IncomeType: ofxgo.IncomeTypeDiv,
+					Total:      ofxtest.Amount("12.34"),
+				},
+			},
+		},
+		InvBal: &ofxgo.InvBalance{AvailCash: ofxtest.Amount("100")},
+	}
+	s, err := NewStatement(stmt, securities)
+	if err != nil {
+		t.Fatalf("Unexpected error converting statement: %s\n", err)
+	}
+	if s.Type != StatementTypeInvestment || s.Account.BrokerID != "example.com" || s.Balance.Amount != "100" {
+		t.Errorf("Unexpected statement %v\n", s)
+	}
+	if s.Transactions == nil {
+		t.Errorf("Expected transactions to be empty rather than nil, so they're written as []\n")
+	}
+	if len(s.InvTransactions) != 2 {
+		t.Fatalf("Expected 2 investment transactions, got %d\n", len(s.InvTransactions))
+	}
+
+	expectedSecurity := Security{UniqueID: "78462F103", UniqueIDType: "CUSIP", Ticker: "SPY", Name: "S&P 500 ETF"}
+	buy := s.InvTransactions[0]
+	if buy.Type != "BUYSTOCK" || buy.Action != "BUY" || buy.Units != "10" || buy.UnitPrice != "225.5" || buy.Total != "-2255" || buy.SubAcctSec != "CASH" {
+		t.Errorf("Unexpected buy %v\n", buy)
+	}
+	if buy.Security == nil || *buy.Security != expectedSecurity {
+		t.Errorf("Expected security %v, got %v\n", expectedSecurity, buy.Security)
+	}
+	if len(buy.Commission) > 0 {
+		t.Errorf("Expected absent commission to be omitted, got %s\n", buy.Commission)
+	}
+	income := s.InvTransactions[1]
+	if income.Type != "INCOME" || income.IncomeType != "DIV" || income.Total != "12.34" || len(income.Units) > 0 {
+		t.Errorf("Unexpected income %v\n", income)
+	}
+
+	if _, err := NewStatement(&ofxgo.SecurityList{}, nil); err == nil {
+		t.Errorf("Expected error converting a security list\n")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	statements := []Statement{
+		{
+			Type:     StatementTypeBank,
+			Account:  Account{BankID: "318398732", AcctID: "78346129", AcctType: "CHECKING"},
+			Currency: "USD",
+			Transactions: []Transaction{
+				{FiTID: "1", Type: "DEBIT", DtPosted: "2017-03-01T00:00:00Z", Amount: "-12.5", Name: "Coffee, \"large\""},
+				{FiTID: "2", Type: "CREDIT", DtPosted: "2017-03-02T00:00:00Z", Amount: "100", Currency: "EUR", Memo: "Refund"},
+			},
+		},
+		{
+			Type:     StatementTypeInvestment,
+			Account:  Account{BrokerID: "example.com", AcctID: "1234"},
+			Currency: "USD",
+			InvTransactions: []InvTransaction{
+				{FiTID: "3", Type: "BUYSTOCK", DtTrade: "2017-03-03T00:00:00Z", Action: "BUY", Security: &Security{UniqueID: "78462F103", UniqueIDType: "CUSIP", Ticker: "SPY"}, Units: "10", Total: "-2255"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, statements, &CSVOptions{Columns: []string{"acctid", "date", "amount", "currency", "name", "ticker", "units"}})
+	if err != nil {
+		t.Fatalf("Unexpected error writing CSV: %s\n", err)
+	}
+	expected := `acctid,date,amount,currency,name,ticker,units
+78346129,2017-03-01T00:00:00Z,-12.5,USD,"Coffee, ""large""",,
+78346129,2017-03-02T00:00:00Z,100,EUR,,,
+1234,2017-03-03T00:00:00Z,-2255,USD,,SPY,10
+`
+	if buf.String() != expected {
+		t.Errorf("Expected CSV:\n%s\nGot:\n%s\n", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteCSV(&buf, statements[:1], &CSVOptions{NoHeader: true}); err != nil {
+		t.Fatalf("Unexpected error writing CSV: %s\n", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], "78346129,2017-03-01T00:00:00Z,DEBIT,-12.5,USD,") {
+		t.Errorf("Unexpected CSV with default columns:\n%s\n", buf.String())
+	}
+
+	if err := WriteCSV(&buf, statements, &CSVOptions{Columns: []string{"acctid", "bogus"}}); err == nil {
+		t.Errorf("Expected error for an invalid column\n")
+	}
+}
+
+func TestWriteCSVSample(t *testing.T) {
+	statements, err := NewStatements(ofxtest.ParseSample(t, "../samples/valid_responses/inv_v202.ofx"))
+	if err != nil {
+		t.Fatalf("Unexpected error converting statements: %s\n", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, statements, nil); err != nil {
+		t.Fatalf("Unexpected error writing CSV: %s\n", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != strings.Join(DefaultInvCSVColumns, ",") {
+		t.Errorf("Expected default investment columns, got %s\n", lines[0])
+	}
+	rows := len(statements[0].Transactions) + len(statements[0].InvTransactions)
+	if len(lines) != rows+1 {
+		t.Errorf("Expected %d rows, got %d\n", rows, len(lines)-1)
+	}
+}
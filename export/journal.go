@@ -0,0 +1,479 @@
+package export
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aclindsa/ofxgo"
+)
+
+// Account names used by WriteLedger and WriteBeancount for fields of
+// JournalOptions which are left empty
+const (
+	DefaultBankAccountPrefix       = "Assets:Bank"
+	DefaultCreditCardAccountPrefix = "Liabilities:CreditCard"
+	DefaultInvAccountPrefix        = "Assets:Investments"
+	DefaultExpenseAccount          = "Expenses:Unknown"
+	DefaultIncomeAccount           = "Income:Unknown"
+	DefaultInvIncomeAccount        = "Income:Investments"
+	DefaultFeesAccount             = "Expenses:Investments:Fees"
+	DefaultGainsAccount            = "Income:Investments:Gains"
+	DefaultTransferAccount         = "Equity:Transfers"
+)
+
+// JournalOptions control how WriteLedger and WriteBeancount name accounts and
+// commodities. The zero value uses the defaults for everything.
+type JournalOptions struct {
+	// Accounts maps OFX accounts to journal account names. Keys are either
+	// "BANKID/ACCTID" (or "BROKERID/ACCTID" for investment accounts), which
+	// take precedence, or just the ACCTID. Accounts not listed are named by
+	// appending the ACCTID to DefaultBankAccountPrefix,
+	// DefaultCreditCardAccountPrefix, or DefaultInvAccountPrefix. Cash in
+	// investment accounts is posted to a "Cash" subaccount of the account.
+	Accounts map[string]string
+	// Commodities maps security UNIQUEIDs to commodity symbols. Securities
+	// not listed use their ticker, or their UNIQUEID if the response didn't
+	// include a ticker for them.
+	Commodities map[string]string
+
+	ExpenseAccount   string // The other side of bank and credit card debits
+	IncomeAccount    string // The other side of bank and credit card credits
+	InvIncomeAccount string // Investment income, in a subaccount per INCOMETYPE
+	FeesAccount      string // Commissions, fees, taxes, expenses, and margin interest
+	GainsAccount     string // Realized gains (Beancount only; Ledger doesn't track lots from OFX)
+	TransferAccount  string // The other side of securities transferred in or out
+
+	// AvailableBalance asserts AVAILBAL instead of LEDGERBAL for bank and
+	// credit card statements which include it
+	AvailableBalance bool
+}
+
+func (o *JournalOptions) setDefaults() {
+	defaults := []struct {
+		field *string
+		value string
+	}{
+		{&o.ExpenseAccount, DefaultExpenseAccount},
+		{&o.IncomeAccount, DefaultIncomeAccount},
+		{&o.InvIncomeAccount, DefaultInvIncomeAccount},
+		{&o.FeesAccount, DefaultFeesAccount},
+		{&o.GainsAccount, DefaultGainsAccount},
+		{&o.TransferAccount, DefaultTransferAccount},
+	}
+	for _, d := range defaults {
+		if len(*d.field) == 0 {
+			*d.field = d.value
+		}
+	}
+}
+
+// journalAmount is a quantity of a currency or commodity
+type journalAmount struct {
+	number    *big.Rat
+	commodity string
+}
+
+// lot kinds, which determine how the cost of commodity postings is written
+const (
+	lotNone  = iota
+	lotOpen  // Opens a new lot costing total
+	lotClose // Reduces existing lots, selling for total (price per unit)
+)
+
+// journalPosting is one posting of a journalEntry
+type journalPosting struct {
+	account string
+	amount  *journalAmount // nil if the amount should be inferred
+	lot     int
+	total   *journalAmount // Total cost or proceeds of a lotOpen or lotClose posting
+	price   *journalAmount // Price per unit of a lotClose posting, if known
+	gains   bool           // Only written by formats that book realized gains
+}
+
+// journalEntry is one transaction. Entries with no postings describe
+// transactions which couldn't be converted, and are written as comments.
+type journalEntry struct {
+	date      time.Time
+	payee     string
+	narration string
+	fitid     string
+	postings  []journalPosting
+}
+
+// journalBalance is a balance assertion
+type journalBalance struct {
+	date    time.Time
+	account string
+	amount  journalAmount
+}
+
+// journalCommodity describes a security to declare as a commodity
+type journalCommodity struct {
+	symbol   string
+	security Security
+}
+
+// journal is the format-independent form of the statements written by
+// WriteLedger and WriteBeancount
+type journal struct {
+	options     JournalOptions
+	accounts    []string
+	commodities []journalCommodity
+	entries     []journalEntry
+	balances    []journalBalance
+
+	accountsSeen    map[string]bool
+	commoditiesSeen map[string]bool
+}
+
+func newJournal(response *ofxgo.Response, options *JournalOptions) (*journal, error) {
+	j := &journal{
+		accountsSeen:    make(map[string]bool),
+		commoditiesSeen: make(map[string]bool),
+	}
+	if options != nil {
+		j.options = *options
+	}
+	j.options.setDefaults()
+
+	securities := ofxgo.NewSecurityMaster(response)
+	for _, security := range securities.Securities() {
+		info := security.SecurityInfo()
+		j.commodity(Security{
+			UniqueID:     string(info.SecID.UniqueID),
+			UniqueIDType: string(info.SecID.UniqueIDType),
+			Ticker:       string(info.Ticker),
+			Name:         string(info.SecName),
+		})
+	}
+	var messages []ofxgo.Message
+	messages = append(messages, response.Bank...)
+	messages = append(messages, response.CreditCard...)
+	messages = append(messages, response.InvStmt...)
+	for _, m := range messages {
+		switch stmt := m.(type) {
+		case *ofxgo.StatementResponse:
+			j.addBankStatement(stmt)
+		case *ofxgo.CCStatementResponse:
+			j.addCCStatement(stmt)
+		case *ofxgo.InvStatementResponse:
+			j.addInvStatement(stmt, securities)
+		}
+	}
+	sort.SliceStable(j.entries, func(a, b int) bool {
+		return j.entries[a].date.Before(j.entries[b].date)
+	})
+	sort.SliceStable(j.balances, func(a, b int) bool {
+		return j.balances[a].date.Before(j.balances[b].date)
+	})
+	return j, nil
+}
+
+// firstDate returns the earliest date in the journal, which accounts and
+// commodities are declared as of
+func (j *journal) firstDate() time.Time {
+	var first time.Time
+	for _, e := range j.entries {
+		if first.IsZero() || e.date.Before(first) {
+			first = e.date
+		}
+	}
+	for _, b := range j.balances {
+		if first.IsZero() || b.date.Before(first) {
+			first = b.date
+		}
+	}
+	if first.IsZero() {
+		first = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return first
+}
+
+// account records that name is used, returning it
+func (j *journal) account(name string) string {
+	if !j.accountsSeen[name] {
+		j.accountsSeen[name] = true
+		j.accounts = append(j.accounts, name)
+	}
+	return name
+}
+
+// commodity returns the symbol for security, declaring it if it hasn't been
+func (j *journal) commodity(security Security) string {
+	symbol, ok := j.options.Commodities[security.UniqueID]
+	if !ok {
+		symbol = security.Ticker
+		if len(symbol) == 0 {
+			symbol = security.UniqueID
+		}
+	}
+	if !j.commoditiesSeen[symbol] {
+		j.commoditiesSeen[symbol] = true
+		j.commodities = append(j.commodities, journalCommodity{symbol: symbol, security: security})
+	}
+	return symbol
+}
+
+// accountName returns the journal account for the account identified by
+// institution (the BANKID or BROKERID, if any) and acctID. prefix is used to
+// name accounts not listed in JournalOptions.Accounts.
+func (j *journal) accountName(prefix, institution, acctID string) string {
+	if name, ok := j.options.Accounts[institution+"/"+acctID]; ok {
+		return name
+	}
+	if name, ok := j.options.Accounts[acctID]; ok {
+		return name
+	}
+	return prefix + ":" + accountComponent(acctID)
+}
+
+// accountComponent converts s to something usable as one component of an
+// account name in all the journal formats: it must start with a capital
+// letter or digit, and contain only letters, digits, and dashes
+func accountComponent(s string) string {
+	component := []rune(strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, s))
+	if len(component) == 0 {
+		return "X"
+	}
+	if component[0] >= 'a' && component[0] <= 'z' {
+		component[0] += 'A' - 'a'
+	} else if component[0] == '-' {
+		component = append([]rune{'X'}, component...)
+	}
+	return string(component)
+}
+
+// absAmount returns the absolute value of a, or zero if a is nil
+func absAmount(a *ofxgo.Amount) *big.Rat {
+	r := new(big.Rat)
+	if a != nil {
+		r.Abs(&a.Rat)
+	}
+	return r
+}
+
+func newAmount(number *big.Rat, commodity string) *journalAmount {
+	return &journalAmount{number: number, commodity: commodity}
+}
+
+// addBalance asserts that account's balance was amount as of dtAsOf, if the
+// FI provided it
+func (j *journal) addBalance(account string, amount *ofxgo.Amount, dtAsOf *ofxgo.Date, currency string) {
+	if amount == nil || dtAsOf == nil || dtAsOf.IsZero() {
+		return
+	}
+	j.balances = append(j.balances, journalBalance{
+		date:    dtAsOf.Time,
+		account: j.account(account),
+		amount:  journalAmount{number: new(big.Rat).Set(&amount.Rat), commodity: currency},
+	})
+}
+
+func (j *journal) addTransactionList(l *ofxgo.TransactionList, account, currency string) {
+	if l == nil {
+		return
+	}
+	for i := range l.Transactions {
+		j.addTransaction(&l.Transactions[i], account, currency)
+	}
+}
+
+func (j *journal) addBankStatement(stmt *ofxgo.StatementResponse) {
+	account := j.account(j.accountName(DefaultBankAccountPrefix, string(stmt.BankAcctFrom.BankID), string(stmt.BankAcctFrom.AcctID)))
+	currency := currSymbolString(stmt.CurDef)
+	j.addTransactionList(stmt.BankTranList, account, currency)
+	if j.options.AvailableBalance && stmt.AvailBalAmt != nil {
+		j.addBalance(account, stmt.AvailBalAmt, stmt.AvailDtAsOf, currency)
+	} else {
+		j.addBalance(account, &stmt.BalAmt, &stmt.DtAsOf, currency)
+	}
+}
+
+func (j *journal) addCCStatement(stmt *ofxgo.CCStatementResponse) {
+	account := j.account(j.accountName(DefaultCreditCardAccountPrefix, "", string(stmt.CCAcctFrom.AcctID)))
+	currency := currSymbolString(stmt.CurDef)
+	j.addTransactionList(stmt.BankTranList, account, currency)
+	if j.options.AvailableBalance && stmt.AvailBalAmt != nil {
+		j.addBalance(account, stmt.AvailBalAmt, stmt.AvailDtAsOf, currency)
+	} else {
+		j.addBalance(account, &stmt.BalAmt, &stmt.DtAsOf, currency)
+	}
+}
+
+// addInvStatement adds stmt's transactions, with cash posted to a "Cash"
+// subaccount of the investment account
+func (j *journal) addInvStatement(stmt *ofxgo.InvStatementResponse, securities *ofxgo.SecurityMaster) {
+	account := j.account(j.accountName(DefaultInvAccountPrefix, string(stmt.InvAcctFrom.BrokerID), string(stmt.InvAcctFrom.AcctID)))
+	cashAccount := account + ":Cash"
+	currency := currSymbolString(stmt.CurDef)
+	if stmt.InvTranList != nil {
+		for _, b := range stmt.InvTranList.BankTransactions {
+			for i := range b.Transactions {
+				j.addTransaction(&b.Transactions[i], cashAccount, currency)
+			}
+		}
+		for _, t := range stmt.InvTranList.InvTransactions {
+			j.addInvTransaction(t, securities, account, cashAccount, currency)
+		}
+	}
+	if stmt.InvBal != nil {
+		j.addBalance(cashAccount, &stmt.InvBal.AvailCash, &stmt.DtAsOf, currency)
+	}
+}
+
+func (j *journal) addTransaction(t *ofxgo.Transaction, account, currency string) {
+	e := journalEntry{
+		date:      t.DtPosted.Time,
+		payee:     string(t.Name),
+		narration: string(t.Memo),
+		fitid:     string(t.FiTID),
+	}
+	if len(e.payee) == 0 && t.Payee != nil {
+		e.payee = string(t.Payee.Name)
+	}
+	if len(t.CorrectFiTID) > 0 {
+		e.narration = "Correction (" + enumString(t.CorrectAction) + ") of " + string(t.CorrectFiTID)
+		j.entries = append(j.entries, e)
+		return
+	}
+	if c := currencyString(t.Currency); len(c) > 0 {
+		currency = c
+	}
+	amount := new(big.Rat).Set(&t.TrnAmt.Rat)
+	counter := j.options.ExpenseAccount
+	if amount.Sign() > 0 {
+		counter = j.options.IncomeAccount
+	}
+	e.postings = []journalPosting{
+		{account: j.account(account), amount: newAmount(amount, currency)},
+		{account: j.account(counter)},
+	}
+	j.entries = append(j.entries, e)
+}
+
+func (j *journal) addInvTransaction(t ofxgo.InvTransaction, securities *ofxgo.SecurityMaster, account, cashAccount, currency string) {
+	tran := t.InvTransaction()
+	d := newInvDetails(t)
+	tranType := t.TransactionType()
+	e := journalEntry{
+		date:      tran.DtTrade.Time,
+		narration: string(tran.Memo),
+		fitid:     string(tran.FiTID),
+	}
+	if len(e.narration) == 0 {
+		e.narration = tranType
+	}
+	var symbol string
+	if d.secID != nil {
+		security := newSecurity(*d.secID, securities)
+		symbol = j.commodity(security)
+		e.payee = security.Name
+		if len(e.payee) == 0 {
+			e.payee = symbol
+		}
+	}
+	if len(d.currency) > 0 {
+		currency = d.currency
+	}
+	units := absAmount(d.units)
+	total := absAmount(d.total)
+	fees := absAmount(d.commission)
+	fees.Add(fees, absAmount(d.fees)).Add(fees, absAmount(d.taxes))
+
+	cash := func(sign int) journalPosting {
+		amount := new(big.Rat).Set(total)
+		if sign < 0 {
+			amount.Neg(amount)
+		}
+		return journalPosting{account: j.account(cashAccount), amount: newAmount(amount, currency)}
+	}
+	feePostings := func() []journalPosting {
+		if fees.Sign() == 0 {
+			return nil
+		}
+		return []journalPosting{{account: j.account(j.options.FeesAccount), amount: newAmount(fees, currency)}}
+	}
+	security := func(sign, lot int, value *big.Rat) journalPosting {
+		amount := new(big.Rat).Set(units)
+		if sign < 0 {
+			amount.Neg(amount)
+		}
+		p := journalPosting{account: j.account(account), amount: newAmount(amount, symbol), lot: lot}
+		if value != nil {
+			p.total = newAmount(value, currency)
+		}
+		if lot == lotClose && d.unitPrice != nil {
+			p.price = newAmount(absAmount(d.unitPrice), currency)
+		}
+		return p
+	}
+	gains := func() journalPosting {
+		return journalPosting{account: j.account(j.options.GainsAccount), gains: true}
+	}
+
+	switch {
+	case strings.HasPrefix(tranType, "BUY"):
+		// Buying to cover a short or close an option reduces existing lots
+		cost := new(big.Rat).Sub(total, fees)
+		if d.action == "BUYTOCOVER" || d.action == "BUYTOCLOSE" {
+			e.postings = append(e.postings, security(1, lotClose, cost), gains())
+		} else {
+			e.postings = append(e.postings, security(1, lotOpen, cost))
+		}
+		e.postings = append(e.postings, cash(-1))
+		e.postings = append(e.postings, feePostings()...)
+	case strings.HasPrefix(tranType, "SELL"):
+		proceeds := new(big.Rat).Add(total, fees)
+		if d.action == "SELLSHORT" || d.action == "SELLTOOPEN" {
+			e.postings = append(e.postings, security(-1, lotOpen, proceeds))
+		} else {
+			e.postings = append(e.postings, security(-1, lotClose, proceeds), gains())
+		}
+		e.postings = append(e.postings, cash(1))
+		e.postings = append(e.postings, feePostings()...)
+	case tranType == "INCOME":
+		e.postings = []journalPosting{
+			cash(1),
+			{account: j.account(j.options.InvIncomeAccount + ":" + accountComponent(d.incomeType))},
+		}
+	case tranType == "REINVEST":
+		e.postings = []journalPosting{
+			security(1, lotOpen, total),
+			{account: j.account(j.options.InvIncomeAccount + ":" + accountComponent(d.incomeType))},
+		}
+	case tranType == "RETOFCAP":
+		e.postings = []journalPosting{
+			cash(1),
+			{account: j.account(j.options.InvIncomeAccount + ":" + accountComponent(tranType))},
+		}
+	case tranType == "INVEXPENSE" || tranType == "MARGININTEREST":
+		e.postings = []journalPosting{
+			cash(-1),
+			{account: j.account(j.options.FeesAccount)},
+		}
+	case tranType == "TRANSFER":
+		if d.action == "OUT" {
+			e.postings = append(e.postings, security(-1, lotClose, nil))
+		} else if d.unitPrice != nil {
+			e.postings = append(e.postings, security(1, lotOpen, new(big.Rat).Mul(units, absAmount(d.unitPrice))))
+		} else {
+			e.postings = append(e.postings, security(1, lotNone, nil))
+		}
+		e.postings = append(e.postings, journalPosting{account: j.account(j.options.TransferAccount)})
+	default:
+		// Splits, journal transactions between subaccounts, and option
+		// closures don't map onto postings without knowing the lots held
+		e.narration = tranType + " not converted"
+		if len(tran.Memo) > 0 {
+			e.narration += ": " + string(tran.Memo)
+		}
+	}
+	j.entries = append(j.entries, e)
+}
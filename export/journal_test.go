@@ -0,0 +1,226 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/internal/ofxtest"
+)
+
+func journalResponse(t *testing.T) *ofxgo.Response {
+	usd, err := ofxgo.NewCurrSymbol("USD")
+	if err != nil {
+		t.Fatalf("Unexpected error creating currency: %s\n", err)
+	}
+	spy := ofxgo.SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}
+	bond := ofxgo.SecurityID{UniqueID: "921937835", UniqueIDType: "CUSIP"}
+	availBal := ofxtest.Amount("80")
+
+	return &ofxgo.Response{
+		Bank: []ofxgo.Message{&ofxgo.StatementResponse{
+			CurDef:       *usd,
+			BankAcctFrom: ofxgo.BankAcct{BankID: "318398732", AcctID: "78346129", AcctType: ofxgo.AcctTypeChecking},
+			BankTranList: &ofxgo.TransactionList{
+				DtStart: *ofxgo.NewDateGMT(2017, 3, 1, 0, 0, 0, 0),
+				DtEnd:   *ofxgo.NewDateGMT(2017, 3, 31, 0, 0, 0, 0),
+				Transactions: []ofxgo.Transaction{
+					{TrnType: ofxgo.TrnTypeCredit, DtPosted: *ofxgo.NewDateGMT(2017, 3, 2, 0, 0, 0, 0), TrnAmt: ofxtest.Amount("100"), FiTID: "2", Name: "Employer", Memo: "Refund"},
+					{TrnType: ofxgo.TrnTypeDebit, DtPosted: *ofxgo.NewDateGMT(2017, 3, 1, 0, 0, 0, 0), TrnAmt: ofxtest.Amount("-12.5"), FiTID: "1", Name: "Coffee \"large\""},
+				},
+			},
+			BalAmt:      ofxtest.Amount("87.5"),
+			DtAsOf:      *ofxgo.NewDateGMT(2017, 3, 31, 0, 0, 0, 0),
+			AvailBalAmt: &availBal,
+			AvailDtAsOf: ofxgo.NewDateGMT(2017, 3, 31, 0, 0, 0, 0),
+		}},
+		InvStmt: []ofxgo.Message{&ofxgo.InvStatementResponse{
+			DtAsOf:      *ofxgo.NewDateGMT(2017, 4, 1, 0, 0, 0, 0),
+			CurDef:      *usd,
+			InvAcctFrom: ofxgo.InvAcct{BrokerID: "example.com", AcctID: "1234"},
+			InvTranList: &ofxgo.InvTranList{
+				DtStart: *ofxgo.NewDateGMT(2017, 3, 1, 0, 0, 0, 0),
+				DtEnd:   *ofxgo.NewDateGMT(2017, 4, 1, 0, 0, 0, 0),
+				InvTransactions: []ofxgo.InvTransaction{
+					ofxgo.BuyStock{
+						InvBuy: ofxgo.InvBuy{
+							InvTran:    ofxgo.InvTran{FiTID: "3", DtTrade: *ofxgo.NewDateGMT(2017, 3, 3, 0, 0, 0, 0)},
+							SecID:      spy,
+							Units:      ofxtest.Amount("10"),
+							UnitPrice:  ofxtest.Amount("225.5"),
+							Commission: ofxtest.Amount("4.95"),
+							Total:      ofxtest.Amount("-2259.95"),
+						},
+						BuyType: ofxgo.BuyTypeBuy,
+					},
+					ofxgo.SellStock{
+						InvSell: ofxgo.InvSell{
+							InvTran:    ofxgo.InvTran{FiTID: "4", DtTrade: *ofxgo.NewDateGMT(2017, 3, 20, 0, 0, 0, 0), Memo: "Partial sale"},
+							SecID:      spy,
+							Units:      ofxtest.Amount("-4"),
+							UnitPrice:  ofxtest.Amount("250"),
+							Commission: ofxtest.Amount("4.95"),
+							Total:      ofxtest.Amount("995.05"),
+						},
+						SellType: ofxgo.SellTypeSell,
+					},
+					ofxgo.Income{
+						InvTran:    ofxgo.InvTran{FiTID: "5", DtTrade: *ofxgo.NewDateGMT(2017, 3, 25, 0, 0, 0, 0)},
+						SecID:      bond,
+						IncomeType: ofxgo.IncomeTypeInterest,
+						Total:      ofxtest.Amount("12.34"),
+					},
+					ofxgo.Split{
+						InvTran:     ofxgo.InvTran{FiTID: "6", DtTrade: *ofxgo.NewDateGMT(2017, 3, 28, 0, 0, 0, 0)},
+						SecID:       spy,
+						OldUnits:    ofxtest.Amount("6"),
+						NewUnits:    ofxtest.Amount("12"),
+						Numerator:   2,
+						Denominator: 1,
+					},
+				},
+			},
+			InvBal: &ofxgo.InvBalance{AvailCash: ofxtest.Amount("-1252.56")},
+		}},
+		SecList: []ofxgo.Message{&ofxgo.SecurityList{
+			Securities: []ofxgo.Security{
+				ofxgo.StockInfo{SecInfo: ofxgo.SecInfo{SecID: spy, SecName: "S&P 500 ETF", Ticker: "SPY"}},
+			},
+		}},
+	}
+}
+
+func TestWriteBeancount(t *testing.T) {
+	var buf bytes.Buffer
+	options := JournalOptions{Accounts: map[string]string{"318398732/78346129": "Assets:Checking"}}
+	if err := WriteBeancount(&buf, journalResponse(t), &options); err != nil {
+		t.Fatalf("Unexpected error writing Beancount: %s\n", err)
+	}
+	expected := `2017-03-01 open Assets:Checking
+2017-03-01 open Income:Unknown
+2017-03-01 open Expenses:Unknown
+2017-03-01 open Assets:Investments:1234
+2017-03-01 open Assets:Investments:1234:Cash
+2017-03-01 open Expenses:Investments:Fees
+2017-03-01 open Income:Investments:Gains
+2017-03-01 open Income:Investments:INTEREST
+
+2017-03-01 commodity SPY
+  name: "S&P 500 ETF"
+  uniqueid: "78462F103"
+  uniqueidtype: "CUSIP"
+
+2017-03-01 commodity X921937835
+  uniqueid: "921937835"
+  uniqueidtype: "CUSIP"
+
+2017-03-01 * "Coffee \"large\"" ""
+  fitid: "1"
+  Assets:Checking  -12.5 USD
+  Expenses:Unknown
+
+2017-03-02 * "Employer" "Refund"
+  fitid: "2"
+  Assets:Checking  100 USD
+  Income:Unknown
+
+2017-03-03 * "S&P 500 ETF" "BUYSTOCK"
+  fitid: "3"
+  Assets:Investments:1234  10 SPY {{2255 USD}}
+  Assets:Investments:1234:Cash  -2259.95 USD
+  Expenses:Investments:Fees  4.95 USD
+
+2017-03-20 * "S&P 500 ETF" "Partial sale"
+  fitid: "4"
+  Assets:Investments:1234  -4 SPY {} @ 250 USD
+  Income:Investments:Gains
+  Assets:Investments:1234:Cash  995.05 USD
+  Expenses:Investments:Fees  4.95 USD
+
+2017-03-25 * "921937835" "INCOME"
+  fitid: "5"
+  Assets:Investments:1234:Cash  12.34 USD
+  Income:Investments:INTEREST
+
+; 2017-03-28 SPLIT not converted (fitid 6)
+
+2017-04-01 balance Assets:Checking  87.5 USD
+2017-04-02 balance Assets:Investments:1234:Cash  -1252.56 USD
+`
+	if buf.String() != expected {
+		t.Errorf("Expected Beancount:\n%s\nGot:\n%s\n", expected, buf.String())
+	}
+
+	buf.Reset()
+	options.AvailableBalance = true
+	options.Commodities = map[string]string{"921937835": "BND"}
+	if err := WriteBeancount(&buf, journalResponse(t), &options); err != nil {
+		t.Fatalf("Unexpected error writing Beancount: %s\n", err)
+	}
+	if !strings.Contains(buf.String(), "2017-04-01 balance Assets:Checking  80 USD\n") {
+		t.Errorf("Expected AVAILBAL to be asserted:\n%s\n", buf.String())
+	}
+	if !strings.Contains(buf.String(), "commodity BND\n") {
+		t.Errorf("Expected mapped commodity:\n%s\n", buf.String())
+	}
+}
+
+func TestWriteLedger(t *testing.T) {
+	// Posted after the bank statement's DTASOF, so it must follow the
+	// balance assertion
+	response := journalResponse(t)
+	tranList := response.Bank[0].(*ofxgo.StatementResponse).BankTranList
+	tranList.Transactions = append(tranList.Transactions, ofxgo.Transaction{TrnType: ofxgo.TrnTypeDebit, DtPosted: *ofxgo.NewDateGMT(2017, 4, 3, 0, 0, 0, 0), TrnAmt: ofxtest.Amount("-5"), FiTID: "7", Name: "Late"})
+
+	var buf bytes.Buffer
+	if err := WriteLedger(&buf, response, nil); err != nil {
+		t.Fatalf("Unexpected error writing Ledger: %s\n", err)
+	}
+	expected := []string{
+		"account Assets:Bank:78346129\n",
+		"; S&P 500 ETF\ncommodity SPY\n",
+		"commodity \"921937835\"\n",
+		"2017-03-02 * Employer\n    ; fitid: 2\n    ; Refund\n    Assets:Bank:78346129  100 USD\n    Income:Unknown\n",
+		"    Assets:Investments:1234  10 SPY @@ 2255 USD\n    Assets:Investments:1234:Cash  -2259.95 USD\n    Expenses:Investments:Fees  4.95 USD\n",
+		"    Assets:Investments:1234  -4 SPY @@ 1000 USD\n    Assets:Investments:1234:Cash  995.05 USD\n",
+		"2017-03-25 * 921937835\n",
+		"; 2017-03-28 SPLIT not converted (fitid 6)\n",
+		"2017-03-31 * Balance assertion\n    Assets:Bank:78346129  0 USD = 87.5 USD\n",
+		"2017-04-01 * Balance assertion\n    Assets:Investments:1234:Cash  0 USD = -1252.56 USD\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(buf.String(), e) {
+			t.Errorf("Expected Ledger output to contain:\n%s\nGot:\n%s\n", e, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "Gains") {
+		t.Errorf("Expected no gains account in Ledger output:\n%s\n", buf.String())
+	}
+	assertion := strings.Index(buf.String(), "2017-03-31 * Balance assertion\n")
+	split := strings.Index(buf.String(), "; 2017-03-28 SPLIT")
+	late := strings.Index(buf.String(), "2017-04-03 * Late\n")
+	if assertion < split || late < assertion {
+		t.Errorf("Expected balance assertions to be in date order with transactions:\n%s\n", buf.String())
+	}
+}
+
+func TestAccountComponent(t *testing.T) {
+	tests := map[string]string{
+		"1234":      "1234",
+		"abc 12/34": "Abc-12-34",
+		"-12":       "X-12",
+		"":          "X",
+	}
+	for in, expected := range tests {
+		if out := accountComponent(in); out != expected {
+			t.Errorf("accountComponent(%q): expected %q, got %q\n", in, expected, out)
+		}
+	}
+	if c := beancountCommodity("brk.b"); c != "BRK.B" {
+		t.Errorf("Unexpected Beancount commodity %s\n", c)
+	}
+	if c := beancountCommodity("78462F103"); c != "X78462F103" {
+		t.Errorf("Unexpected Beancount commodity %s\n", c)
+	}
+}
@@ -0,0 +1,21 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Document is the top-level object written by WriteJSON
+type Document struct {
+	Statements []Statement `json:"statements"`
+}
+
+// WriteJSON writes statements as an indented JSON Document
+func WriteJSON(w io.Writer, statements []Statement) error {
+	if statements == nil {
+		statements = []Statement{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(&Document{Statements: statements})
+}
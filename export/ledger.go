@@ -0,0 +1,137 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/aclindsa/ofxgo"
+)
+
+// journalDateFormat is the format of dates in Ledger and Beancount files
+const journalDateFormat = "2006-01-02"
+
+func formatNumber(r *big.Rat) string {
+	var a ofxgo.Amount
+	a.Set(r)
+	return a.String()
+}
+
+// ledgerCommodity quotes symbol if it contains anything other than letters,
+// as Ledger and hledger require
+func ledgerCommodity(symbol string) string {
+	for _, r := range symbol {
+		if !((r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z')) {
+			return "\"" + strings.Replace(symbol, "\"", "", -1) + "\""
+		}
+	}
+	return symbol
+}
+
+func ledgerAmount(a *journalAmount) string {
+	return formatNumber(a.number) + " " + ledgerCommodity(a.commodity)
+}
+
+// ledgerText removes line breaks from s so it can be written on one line
+func ledgerText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// WriteLedger writes the bank, credit card, and investment statements in
+// response as a Ledger journal, which hledger can also read. Accounts and
+// securities are declared first, followed by a transaction for each bank and
+// investment transaction (with its FITID as "fitid" metadata, so duplicates
+// can be found when the same transactions are downloaded again), in date
+// order. Balance assertions for each account's LEDGERBAL (or AVAILCASH for
+// investment accounts) follow the last transaction on or before their DTASOF.
+// Securities bought are annotated with their total cost
+// and those sold with their total proceeds. options may be nil to use the
+// defaults.
+func WriteLedger(w io.Writer, response *ofxgo.Response, options *JournalOptions) error {
+	j, err := newJournal(response, options)
+	if err != nil {
+		return err
+	}
+
+	// Gains postings aren't written, so their account may not be used
+	used := make(map[string]bool)
+	for _, e := range j.entries {
+		for _, p := range e.postings {
+			used[p.account] = used[p.account] || !p.gains
+		}
+	}
+	for _, balance := range j.balances {
+		used[balance.account] = true
+	}
+
+	b := bufio.NewWriter(w)
+	for _, account := range j.accounts {
+		if used[account] {
+			b.WriteString("account " + account + "\n")
+		}
+	}
+	if len(j.accounts) > 0 {
+		b.WriteString("\n")
+	}
+	for _, c := range j.commodities {
+		if len(c.security.Name) > 0 {
+			b.WriteString("; " + ledgerText(c.security.Name) + "\n")
+		}
+		b.WriteString("commodity " + ledgerCommodity(c.symbol) + "\n")
+	}
+	if len(j.commodities) > 0 {
+		b.WriteString("\n")
+	}
+
+	// Balance assertions are written after the last entry on or before their
+	// DTASOF, since Ledger checks them in the order they appear
+	balances := j.balances
+	writeBalances := func(before string) {
+		for len(balances) > 0 {
+			balance := balances[0]
+			date := balance.date.Format(journalDateFormat)
+			if len(before) > 0 && date >= before {
+				return
+			}
+			zero := journalAmount{number: new(big.Rat), commodity: balance.amount.commodity}
+			b.WriteString(date + " * Balance assertion\n")
+			b.WriteString("    " + balance.account + "  " + ledgerAmount(&zero) + " = " + ledgerAmount(&balance.amount) + "\n\n")
+			balances = balances[1:]
+		}
+	}
+
+	for _, e := range j.entries {
+		date := e.date.Format(journalDateFormat)
+		writeBalances(date)
+		if len(e.postings) == 0 {
+			b.WriteString("; " + date + " " + ledgerText(e.narration) + " (fitid " + e.fitid + ")\n\n")
+			continue
+		}
+		payee, narration := ledgerText(e.payee), ledgerText(e.narration)
+		if len(payee) == 0 {
+			payee, narration = narration, ""
+		}
+		b.WriteString(date + " * " + payee + "\n")
+		b.WriteString("    ; fitid: " + ledgerText(e.fitid) + "\n")
+		if len(narration) > 0 {
+			b.WriteString("    ; " + narration + "\n")
+		}
+		for _, p := range e.postings {
+			if p.gains {
+				continue
+			}
+			b.WriteString("    " + p.account)
+			if p.amount != nil {
+				b.WriteString("  " + ledgerAmount(p.amount))
+				if p.total != nil {
+					b.WriteString(" @@ " + ledgerAmount(p.total))
+				}
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	writeBalances("")
+	return b.Flush()
+}
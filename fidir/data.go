@@ -0,0 +1,83 @@
+package fidir
+
+// defaultInstitutions is the dataset bundled with this package, in the same
+// JSON format accepted by Load. FIs change their servers from time to time, so
+// entries here may go stale; use Directory.Merge to override them at runtime,
+// and please send updates upstream.
+const defaultInstitutions = `[
+	{
+		"name": "American Express Card",
+		"url": "https://online.americanexpress.com/myca/ofxdl/desktop/desktopDownload.do?request_type=nl_ofxdownload",
+		"org": "AMEX",
+		"fid": "3101",
+		"appid": "QWIN",
+		"appver": "2500"
+	},
+	{
+		"name": "Chase",
+		"url": "https://ofx.chase.com",
+		"org": "B1",
+		"fid": "10898",
+		"appid": "QWIN",
+		"appver": "2800",
+		"ofxversion": "220",
+		"quirks": ["clientuid"]
+	},
+	{
+		"name": "Charles Schwab",
+		"url": "https://ofx.schwab.com/cgi_dev/ofx_server",
+		"org": "ISC",
+		"fid": "5104",
+		"brokerid": "SCHWAB.COM"
+	},
+	{
+		"name": "Citi Cards",
+		"url": "https://mobilesoa.citi.com/CitiOfxInterface",
+		"org": "Citigroup",
+		"fid": "24909",
+		"appid": "QWIN",
+		"appver": "2500",
+		"ofxversion": "102",
+		"quirks": ["noindent", "carriagereturn"]
+	},
+	{
+		"name": "Discover Card",
+		"url": "https://ofx.discovercard.com",
+		"org": "Discover Financial Services",
+		"fid": "7101",
+		"appid": "QWIN",
+		"appver": "2500",
+		"ofxversion": "102",
+		"quirks": ["noindent"]
+	},
+	{
+		"name": "Fidelity Investments",
+		"url": "https://ofx.fidelity.com/ftgw/OFX/clients/download",
+		"org": "fidelity.com",
+		"fid": "7776",
+		"brokerid": "fidelity.com",
+		"ofxversion": "102"
+	},
+	{
+		"name": "USAA",
+		"url": "https://service2.usaa.com/ofx/OFXServlet",
+		"org": "USAA",
+		"fid": "24591",
+		"bankid": "314074269"
+	},
+	{
+		"name": "Vanguard",
+		"url": "https://vesnc.vanguard.com/us/OfxDirectConnectServlet",
+		"org": "Vanguard",
+		"fid": "15103",
+		"brokerid": "vanguard.com",
+		"ofxversion": "102"
+	},
+	{
+		"name": "Wells Fargo",
+		"url": "https://ofxdc.wellsfargo.com/ofx/process.ofx",
+		"org": "WF",
+		"fid": "3000",
+		"ofxversion": "102"
+	}
+]`
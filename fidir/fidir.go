@@ -0,0 +1,235 @@
+// Package fidir provides a directory of financial institutions supporting OFX
+// Direct Connect, along with the settings needed to talk to each of them (in
+// the spirit of ofxhome.com). A default dataset is bundled with the package,
+// and may be extended or updated at runtime by loading JSON in the same
+// format.
+package fidir
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aclindsa/ofxgo"
+)
+
+// Quirks which may be listed in Institution.Quirks
+const (
+	QuirkNoIndent       = "noindent"       // Server requires requests without newlines or indentation
+	QuirkCarriageReturn = "carriagereturn" // Server requires CRLF line endings in requests
+	QuirkClientUID      = "clientuid"      // Server requires SONRQ>CLIENTUID to be set
+)
+
+// Institution describes one financial institution and how to connect to its
+// OFX server
+type Institution struct {
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	Org        string   `json:"org"`
+	Fid        string   `json:"fid"`
+	BankID     string   `json:"bankid,omitempty"`     // Routing number, for banks with a single one
+	BrokerID   string   `json:"brokerid,omitempty"`   // BROKERID, for investment accounts
+	AppID      string   `json:"appid,omitempty"`      // APPID the server is known to require, if any
+	AppVer     string   `json:"appver,omitempty"`     // APPVER the server is known to require, if any
+	OfxVersion string   `json:"ofxversion,omitempty"` // OFX version the server is known to require, if any
+	UserAgent  string   `json:"useragent,omitempty"`  // User-Agent header the server is known to require, if any
+	Quirks     []string `json:"quirks,omitempty"`     // Any of the Quirk* constants
+}
+
+// HasQuirk returns true if the Institution lists the given quirk
+func (i *Institution) HasQuirk(quirk string) bool {
+	for _, q := range i.Quirks {
+		if strings.EqualFold(q, quirk) {
+			return true
+		}
+	}
+	return false
+}
+
+// BasicClient returns a new BasicClient configured with this Institution's
+// known requirements. Fields left empty use the BasicClient defaults.
+func (i *Institution) BasicClient() (*ofxgo.BasicClient, error) {
+	bc := &ofxgo.BasicClient{
+		AppID:          i.AppID,
+		AppVer:         i.AppVer,
+		UserAgent:      i.UserAgent,
+		NoIndent:       i.HasQuirk(QuirkNoIndent),
+		CarriageReturn: i.HasQuirk(QuirkCarriageReturn),
+	}
+	if len(i.OfxVersion) > 0 {
+		ver, err := ofxgo.NewOfxVersion(i.OfxVersion)
+		if err != nil {
+			return nil, err
+		}
+		bc.SpecVersion = ver
+	}
+	return bc, nil
+}
+
+// Client returns a Client for this Institution. It is configured from a copy
+// of bc, with any settings the Institution has which are unset in bc filled
+// in, so that institutions needing specialized clients get them. bc itself
+// is not modified, so it may be reused for other Institutions.
+func (i *Institution) Client(bc *ofxgo.BasicClient) (ofxgo.Client, error) {
+	defaults, err := i.BasicClient()
+	if err != nil {
+		return nil, err
+	}
+	if bc == nil {
+		bc = defaults
+	} else {
+		configured := *bc
+		bc = &configured
+		if len(bc.AppID) == 0 {
+			bc.AppID = defaults.AppID
+		}
+		if len(bc.AppVer) == 0 {
+			bc.AppVer = defaults.AppVer
+		}
+		if !bc.SpecVersion.Valid() {
+			bc.SpecVersion = defaults.SpecVersion
+		}
+		if len(bc.UserAgent) == 0 {
+			bc.UserAgent = defaults.UserAgent
+		}
+		bc.NoIndent = bc.NoIndent || defaults.NoIndent
+		bc.CarriageReturn = bc.CarriageReturn || defaults.CarriageReturn
+	}
+	return ofxgo.GetClient(i.URL, bc), nil
+}
+
+// Request returns a new Request with the URL and signon FI information for
+// this Institution filled in
+func (i *Institution) Request() *ofxgo.Request {
+	var r ofxgo.Request
+	r.URL = i.URL
+	r.Signon.Org = ofxgo.String(i.Org)
+	r.Signon.Fid = ofxgo.String(i.Fid)
+	return &r
+}
+
+// Directory is a searchable collection of Institutions. Directories are never
+// modified once created, so they are safe for concurrent use.
+type Directory struct {
+	institutions []Institution
+}
+
+// New returns a Directory containing the supplied Institutions
+func New(institutions []Institution) *Directory {
+	return (&Directory{}).Merge(&Directory{institutions})
+}
+
+// Load reads a JSON array of Institutions from r and returns them as a
+// Directory
+func Load(r io.Reader) (*Directory, error) {
+	var institutions []Institution
+	if err := json.NewDecoder(r).Decode(&institutions); err != nil {
+		return nil, err
+	}
+	for _, i := range institutions {
+		if len(i.Name) == 0 || len(i.URL) == 0 {
+			return nil, errors.New("Institution name and URL must be supplied")
+		}
+	}
+	return New(institutions), nil
+}
+
+var defaultDirectory *Directory
+var defaultOnce sync.Once
+
+// Default returns a Directory containing the dataset bundled with this
+// package. The returned Directory is shared; use Merge to create an updated
+// copy of it.
+func Default() *Directory {
+	defaultOnce.Do(func() {
+		d, err := Load(strings.NewReader(defaultInstitutions))
+		if err != nil {
+			panic("fidir: invalid bundled dataset: " + err.Error())
+		}
+		defaultDirectory = d
+	})
+	return defaultDirectory
+}
+
+// Merge returns a new Directory containing the Institutions in both d and
+// other. Institutions in other with the same name (compared case-insensitively)
+// as one in d replace it, so this may be used to update stale entries. Neither
+// d nor other is modified.
+func (d *Directory) Merge(other *Directory) *Directory {
+	merged := &Directory{append([]Institution(nil), d.institutions...)}
+	for _, inst := range other.institutions {
+		replaced := false
+		for i := range merged.institutions {
+			if strings.EqualFold(merged.institutions[i].Name, inst.Name) {
+				merged.institutions[i] = inst
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged.institutions = append(merged.institutions, inst)
+		}
+	}
+	sort.SliceStable(merged.institutions, func(i, j int) bool {
+		return strings.ToLower(merged.institutions[i].Name) < strings.ToLower(merged.institutions[j].Name)
+	})
+	return merged
+}
+
+// Institutions returns all the Institutions in the Directory, sorted by name
+func (d *Directory) Institutions() []Institution {
+	return append([]Institution(nil), d.institutions...)
+}
+
+// Search returns all Institutions whose name contains query (compared
+// case-insensitively)
+func (d *Directory) Search(query string) []Institution {
+	var matches []Institution
+	query = strings.ToLower(strings.TrimSpace(query))
+	for _, inst := range d.institutions {
+		if strings.Contains(strings.ToLower(inst.Name), query) {
+			matches = append(matches, inst)
+		}
+	}
+	return matches
+}
+
+// ByName returns the Institution with the given name. If no Institution's name
+// matches exactly (ignoring case), a name containing it is accepted as long as
+// there is only one such Institution.
+func (d *Directory) ByName(name string) (*Institution, error) {
+	trimmed := strings.TrimSpace(name)
+	for i := range d.institutions {
+		if strings.EqualFold(d.institutions[i].Name, trimmed) {
+			inst := d.institutions[i]
+			return &inst, nil
+		}
+	}
+	matches := d.Search(trimmed)
+	switch len(matches) {
+	case 0:
+		return nil, errors.New("No institution found matching \"" + name + "\"")
+	case 1:
+		return &matches[0], nil
+	}
+	var names []string
+	for _, inst := range matches {
+		names = append(names, inst.Name)
+	}
+	return nil, errors.New("Multiple institutions match \"" + name + "\": " + strings.Join(names, ", "))
+}
+
+// ByFID returns all Institutions with the given FID. Several may share one FID
+// if an FI operates multiple OFX servers.
+func (d *Directory) ByFID(fid string) []Institution {
+	var matches []Institution
+	for _, inst := range d.institutions {
+		if inst.Fid == strings.TrimSpace(fid) {
+			matches = append(matches, inst)
+		}
+	}
+	return matches
+}
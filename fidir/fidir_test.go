@@ -0,0 +1,121 @@
+package fidir
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aclindsa/ofxgo"
+)
+
+func TestDefaultDirectory(t *testing.T) {
+	institutions := Default().Institutions()
+	if len(institutions) == 0 {
+		t.Fatalf("Expected bundled dataset to contain institutions\n")
+	}
+	for _, inst := range institutions {
+		if len(inst.URL) == 0 || len(inst.Org) == 0 || len(inst.Fid) == 0 {
+			t.Errorf("Institution %q is missing URL, ORG, or FID\n", inst.Name)
+		}
+		if _, err := inst.BasicClient(); err != nil {
+			t.Errorf("Institution %q has invalid settings: %s\n", inst.Name, err)
+		}
+	}
+}
+
+func TestByName(t *testing.T) {
+	d := Default()
+	inst, err := d.ByName("vanguard")
+	if err != nil {
+		t.Fatalf("Unexpected error looking up Vanguard: %s\n", err)
+	}
+	if inst.Fid != "15103" {
+		t.Errorf("Expected Vanguard FID 15103, got %s\n", inst.Fid)
+	}
+
+	inst, err = d.ByName("Discover")
+	if err != nil {
+		t.Fatalf("Unexpected error looking up unique substring: %s\n", err)
+	}
+	if inst.Name != "Discover Card" {
+		t.Errorf("Expected Discover Card, got %s\n", inst.Name)
+	}
+
+	if _, err = d.ByName("No Such Bank"); err == nil {
+		t.Errorf("Expected error looking up nonexistent institution\n")
+	}
+	if _, err = d.ByName("c"); err == nil {
+		t.Errorf("Expected error looking up ambiguous institution name\n")
+	}
+}
+
+func TestByFID(t *testing.T) {
+	matches := Default().ByFID("7101")
+	if len(matches) != 1 || matches[0].Name != "Discover Card" {
+		t.Errorf("Expected FID 7101 to match Discover Card, got %v\n", matches)
+	}
+	if matches = Default().ByFID("99999999"); len(matches) != 0 {
+		t.Errorf("Expected no matches for unknown FID, got %v\n", matches)
+	}
+}
+
+func TestLoadMerge(t *testing.T) {
+	update, err := Load(strings.NewReader(`[
+		{"name": "vanguard", "url": "https://example.com/vanguard", "org": "Vanguard", "fid": "15103"},
+		{"name": "Example Bank", "url": "https://example.com/ofx", "org": "EXB", "fid": "1234", "quirks": ["noindent"]}
+	]`))
+	if err != nil {
+		t.Fatalf("Unexpected error loading directory: %s\n", err)
+	}
+
+	d := Default().Merge(update)
+	if len(d.Institutions()) != len(Default().Institutions())+1 {
+		t.Fatalf("Expected merge to add one institution and replace one\n")
+	}
+	inst, err := d.ByName("Vanguard")
+	if err != nil {
+		t.Fatalf("Unexpected error looking up Vanguard: %s\n", err)
+	}
+	if inst.URL != "https://example.com/vanguard" {
+		t.Errorf("Expected merged Vanguard entry to replace bundled one, got URL %s\n", inst.URL)
+	}
+	if orig, _ := Default().ByName("Vanguard"); orig.URL == inst.URL {
+		t.Errorf("Merging modified the default directory\n")
+	}
+
+	if _, err = Load(strings.NewReader(`[{"name": "No URL"}]`)); err == nil {
+		t.Errorf("Expected error loading institution without URL\n")
+	}
+}
+
+func TestClient(t *testing.T) {
+	inst, err := Default().ByName("Discover Card")
+	if err != nil {
+		t.Fatalf("Unexpected error looking up Discover Card: %s\n", err)
+	}
+	bc := &ofxgo.BasicClient{AppVer: "2600"}
+	client, err := inst.Client(bc)
+	if err != nil {
+		t.Fatalf("Unexpected error creating client: %s\n", err)
+	}
+	if !reflect.DeepEqual(*bc, ofxgo.BasicClient{AppVer: "2600"}) {
+		t.Errorf("Expected the BasicClient passed to Client to be left unchanged, got %+v\n", *bc)
+	}
+	if _, ok := client.(*ofxgo.DiscoverCardClient); !ok {
+		t.Errorf("Expected GetClient to return a DiscoverCardClient, got %T\n", client)
+	}
+	if client.ID() != "QWIN" || client.Version() != "2600" {
+		t.Errorf("Expected APPID QWIN and explicitly-set APPVER 2600, got %s and %s\n", client.ID(), client.Version())
+	}
+	if client.IndentRequests() {
+		t.Errorf("Expected noindent quirk to be applied\n")
+	}
+	if client.OfxVersion() != ofxgo.OfxVersion102 {
+		t.Errorf("Expected OFX version 102, got %s\n", client.OfxVersion())
+	}
+
+	r := inst.Request()
+	if r.URL != inst.URL || r.Signon.Org != "Discover Financial Services" || r.Signon.Fid != "7101" {
+		t.Errorf("Request not populated with institution settings: %+v\n", r.Signon)
+	}
+}
@@ -0,0 +1,150 @@
+package ofxgo
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// Hooks is implemented by types wishing to observe what a Client sends and
+// receives, for example to log requests or record tracing spans while
+// diagnosing problems with a particular FI. Set BasicClient.Hooks to have them
+// called from Client.Request() and Client.RequestNoParse().
+//
+// Any requests or request and response bodies passed to Hooks have already had
+// credentials removed (see RedactCredentials), so they are safe to log. Embed
+// NopHooks to only implement the methods you are interested in.
+type Hooks interface {
+	// RequestMarshaled is called after a Request has been marshaled into
+	// SGML/XML, but before it is sent. r is a copy of the Request with
+	// SONRQ>USERPASS and SONRQ>USERKEY blanked.
+	RequestMarshaled(r *Request, body []byte)
+	// RequestSent is called after the HTTP request to URL has completed (or
+	// failed), along with how long it took
+	RequestSent(URL string, response *http.Response, elapsed time.Duration, err error)
+	// ResponseReceived is called with the raw body of the HTTP response, before
+	// it is parsed. It is only called by Client.Request(), since
+	// RequestNoParse() leaves reading the body to the caller.
+	ResponseReceived(URL string, body []byte)
+	// ResponseParsed is called after the response has been decoded into a
+	// Response object, or failed to be
+	ResponseParsed(response *Response, err error)
+	// ValidationFailed is called if a decoded Response fails validation
+	ValidationFailed(response *Response, err error)
+}
+
+// hookedClient is implemented by Clients which support Hooks
+type hookedClient interface {
+	hooks() Hooks
+}
+
+// clientHooks returns the Hooks set for a Client, or nil if it has none
+func clientHooks(c Client) Hooks {
+	if hc, ok := c.(hookedClient); ok {
+		return hc.hooks()
+	}
+	return nil
+}
+
+// NopHooks implements Hooks, doing nothing for each event. It is intended to
+// be embedded in other Hooks implementations.
+type NopHooks struct{}
+
+// RequestMarshaled does nothing
+func (NopHooks) RequestMarshaled(r *Request, body []byte) {}
+
+// RequestSent does nothing
+func (NopHooks) RequestSent(URL string, response *http.Response, elapsed time.Duration, err error) {
+}
+
+// ResponseReceived does nothing
+func (NopHooks) ResponseReceived(URL string, body []byte) {}
+
+// ResponseParsed does nothing
+func (NopHooks) ResponseParsed(response *Response, err error) {}
+
+// ValidationFailed does nothing
+func (NopHooks) ValidationFailed(response *Response, err error) {}
+
+// LogHooks implements Hooks by writing a line for each event to a log.Logger.
+// Adapters for other logging or tracing libraries can be written the same way.
+type LogHooks struct {
+	Logger *log.Logger // Defaults to the standard logger if nil
+	Bodies bool        // Also log (redacted) request and response bodies
+}
+
+func (h LogHooks) printf(format string, v ...interface{}) {
+	if h.Logger != nil {
+		h.Logger.Printf(format, v...)
+	} else {
+		log.Printf(format, v...)
+	}
+}
+
+// RequestMarshaled logs the size (and optionally the contents) of the request
+func (h LogHooks) RequestMarshaled(r *Request, body []byte) {
+	h.printf("ofxgo: marshaled %d-byte OFX %s request for %s", len(body), r.Version, r.URL)
+	if h.Bodies {
+		h.printf("ofxgo: request body:\n%s", body)
+	}
+}
+
+// RequestSent logs the HTTP status and duration of the request
+func (h LogHooks) RequestSent(URL string, response *http.Response, elapsed time.Duration, err error) {
+	status := "no response"
+	if response != nil {
+		status = response.Status
+	}
+	if err != nil {
+		h.printf("ofxgo: request to %s failed after %s (%s): %s", URL, elapsed, status, err)
+	} else {
+		h.printf("ofxgo: request to %s returned %s after %s", URL, status, elapsed)
+	}
+}
+
+// ResponseReceived logs the size (and optionally the contents) of the
+// response
+func (h LogHooks) ResponseReceived(URL string, body []byte) {
+	h.printf("ofxgo: received %d-byte response from %s", len(body), URL)
+	if h.Bodies {
+		h.printf("ofxgo: response body:\n%s", body)
+	}
+}
+
+// ResponseParsed logs whether the response was successfully parsed
+func (h LogHooks) ResponseParsed(response *Response, err error) {
+	if err != nil {
+		h.printf("ofxgo: failed to parse response: %s", err)
+	} else {
+		h.printf("ofxgo: parsed OFX %s response with signon status %d", response.Version, response.Signon.Status.Code)
+	}
+}
+
+// ValidationFailed logs the validation error
+func (h LogHooks) ValidationFailed(response *Response, err error) {
+	h.printf("ofxgo: response failed validation: %s", err)
+}
+
+// redactedRequest returns a shallow copy of r with the credentials in its
+// SignonRequest blanked, suitable for passing to Hooks
+func redactedRequest(r *Request) *Request {
+	redacted := *r
+	if len(redacted.Signon.UserPass) > 0 {
+		redacted.Signon.UserPass = "***"
+	}
+	if len(redacted.Signon.UserKey) > 0 {
+		redacted.Signon.UserKey = "***"
+	}
+	return &redacted
+}
+
+// Elements whose contents are credentials and should never be logged
+var credentialsExp = regexp.MustCompile(`(?i)(<(USERPASS|NEWUSERPASS|USERKEY|USERCRED1|USERCRED2|AUTHTOKEN|ACCESSTOKEN|ACCESSKEY|SESSCOOKIE|PHRASEA)>\s*)[^<\r\n]*`)
+
+// RedactCredentials returns a copy of the SGML/XML OFX request or response in
+// b, with the contents of elements containing credentials (USERPASS, USERKEY,
+// AUTHTOKEN, etc.) replaced by asterisks
+func RedactCredentials(b []byte) []byte {
+	return credentialsExp.ReplaceAll(b, []byte("${1}***"))
+}
@@ -0,0 +1,159 @@
+package ofxgo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingHooks struct {
+	NopHooks
+	events      []string
+	request     *Request
+	requestBody []byte
+	response    *Response
+	invalid     *Response
+	invalidErr  error
+}
+
+func (h *recordingHooks) RequestMarshaled(r *Request, body []byte) {
+	h.events = append(h.events, "marshaled")
+	h.request = r
+	h.requestBody = body
+}
+
+func (h *recordingHooks) RequestSent(URL string, response *http.Response, elapsed time.Duration, err error) {
+	h.events = append(h.events, "sent")
+}
+
+func (h *recordingHooks) ResponseReceived(URL string, body []byte) {
+	h.events = append(h.events, "received")
+}
+
+func (h *recordingHooks) ResponseParsed(response *Response, err error) {
+	h.events = append(h.events, "parsed")
+	h.response = response
+}
+
+func (h *recordingHooks) ValidationFailed(response *Response, err error) {
+	h.events = append(h.events, "invalid")
+	h.invalid = response
+	h.invalidErr = err
+}
+
+func TestBasicClientHooks(t *testing.T) {
+	responseBody, err := ioutil.ReadFile("samples/valid_responses/moneymrkt1_v203.ofx")
+	if err != nil {
+		t.Fatalf("Unable to read sample response: %s\n", err)
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(responseBody)
+	}))
+	defer server.Close()
+
+	hooks := &recordingHooks{}
+	c := &BasicClient{
+		HTTPClient: server.Client(),
+		Hooks:      hooks,
+	}
+	request := &Request{
+		URL: server.URL,
+		Signon: SignonRequest{
+			UserID:   "myusername",
+			UserPass: "Pa$$word",
+			Org:      "BNK",
+			Fid:      "1987",
+		},
+	}
+	response, err := c.Request(request)
+	if err != nil {
+		t.Fatalf("Unexpected error making request: %s\n", err)
+	}
+
+	expected := []string{"marshaled", "sent", "received", "parsed"}
+	if strings.Join(hooks.events, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected hook events %v, got %v\n", expected, hooks.events)
+	}
+	if hooks.response != response {
+		t.Fatalf("Expected ResponseParsed to be passed the returned Response\n")
+	}
+	if bytes.Contains(hooks.requestBody, []byte("Pa$$word")) {
+		t.Fatalf("Password was not redacted from request passed to hooks:\n%s\n", hooks.requestBody)
+	}
+	if hooks.request.Signon.UserPass == "Pa$$word" {
+		t.Fatalf("Password was not redacted from Request passed to hooks\n")
+	}
+	if request.Signon.UserPass != "Pa$$word" {
+		t.Fatalf("Redacting the Request passed to hooks modified the caller's Request\n")
+	}
+	if hooks.request.Signon.UserID != "myusername" {
+		t.Fatalf("Request passed to hooks was unexpectedly missing USERID\n")
+	}
+	if !bytes.Contains(hooks.requestBody, []byte("myusername")) {
+		t.Fatalf("Request passed to hooks was unexpectedly missing USERID:\n%s\n", hooks.requestBody)
+	}
+}
+
+func TestBasicClientHooksValidationFailed(t *testing.T) {
+	responseBody, err := ioutil.ReadFile("samples/valid_responses/moneymrkt1_v203.ofx")
+	if err != nil {
+		t.Fatalf("Unable to read sample response: %s\n", err)
+	}
+	// An empty TRNUID parses, but fails validation
+	responseBody = bytes.Replace(responseBody, []byte("262e39f1-e698-48cb-b2a2-b2f8ac2478fa"), nil, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(responseBody)
+	}))
+	defer server.Close()
+
+	hooks := &recordingHooks{}
+	c := &BasicClient{
+		HTTPClient: server.Client(),
+		Hooks:      hooks,
+	}
+	response, err := c.Request(&Request{
+		URL: server.URL,
+		Signon: SignonRequest{
+			UserID:   "myusername",
+			UserPass: "Pa$$word",
+			Org:      "BNK",
+			Fid:      "1987",
+		},
+	})
+	if err == nil || response != nil {
+		t.Fatalf("Expected validation error from invalid response\n")
+	}
+
+	expected := []string{"marshaled", "sent", "received", "parsed", "invalid"}
+	if strings.Join(hooks.events, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected hook events %v, got %v\n", expected, hooks.events)
+	}
+	if hooks.invalid == nil || hooks.invalid != hooks.response {
+		t.Errorf("Expected ValidationFailed to be passed the parsed Response\n")
+	}
+	if hooks.invalidErr == nil || hooks.invalidErr.Error() != err.Error() {
+		t.Errorf("Expected ValidationFailed to be passed the returned error %q, got %v\n", err, hooks.invalidErr)
+	}
+}
+
+func TestRedactCredentials(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"<USERPASS>hunter2</USERPASS>", "<USERPASS>***</USERPASS>"},
+		{"<USERPASS>hunter2\r\n<LANGUAGE>ENG", "<USERPASS>***\r\n<LANGUAGE>ENG"},
+		{"<USERID>me<USERKEY>abcdef<APPID>QWIN", "<USERID>me<USERKEY>***<APPID>QWIN"},
+		{"<SESSCOOKIE>abc-123<INTU.BID>1000", "<SESSCOOKIE>***<INTU.BID>1000"},
+		{"<USERID>me</USERID>", "<USERID>me</USERID>"},
+	}
+	for _, test := range tests {
+		actual := string(RedactCredentials([]byte(test.input)))
+		if actual != test.expected {
+			t.Errorf("RedactCredentials(%q): expected %q, got %q\n", test.input, test.expected, actual)
+		}
+	}
+}
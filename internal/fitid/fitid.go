@@ -0,0 +1,34 @@
+// Package fitid synthesizes FITIDs for transactions read from formats which
+// don't have them, such as QIF and CSV
+package fitid
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/aclindsa/ofxgo"
+)
+
+// Generator synthesizes FITIDs by hashing the fields of each transaction, so
+// the same transaction gets the same FITID when it's imported again.
+// Identical transactions are numbered so their FITIDs differ. The zero value
+// is ready to use.
+type Generator struct {
+	counts map[string]int // The number of times each hash has been generated
+}
+
+// FiTID returns the FITID for a transaction identified by fields
+func (g *Generator) FiTID(fields ...string) ofxgo.String {
+	if g.counts == nil {
+		g.counts = make(map[string]int)
+	}
+	sum := sha1.Sum([]byte(strings.Join(fields, "\x00")))
+	fitid := hex.EncodeToString(sum[:])
+	g.counts[fitid]++
+	if n := g.counts[fitid]; n > 1 {
+		return ofxgo.String(fmt.Sprintf("%s-%d", fitid, n))
+	}
+	return ofxgo.String(fitid)
+}
@@ -0,0 +1,28 @@
+package fitid
+
+import (
+	"testing"
+)
+
+func TestGenerator(t *testing.T) {
+	var g Generator
+	first := g.FiTID("checking", "20170101", "-12.5", "Coffee")
+	second := g.FiTID("checking", "20170101", "-12.5", "Coffee")
+	other := g.FiTID("checking", "20170101", "-12.5", "Tea")
+	if len(first) != 40 || second != first+"-2" {
+		t.Errorf("Expected identical transactions to be numbered, got %s and %s\n", first, second)
+	}
+	if other == first {
+		t.Errorf("Expected different transactions to have different FITIDs\n")
+	}
+
+	// FITIDs only depend on the transactions seen so far
+	var again Generator
+	if fitid := again.FiTID("checking", "20170101", "-12.5", "Coffee"); fitid != first {
+		t.Errorf("Expected FITID %s to be reproducible, got %s\n", first, fitid)
+	}
+	// Fields are separated, so they can't run together
+	if again.FiTID("ab", "c") == again.FiTID("a", "bc") {
+		t.Errorf("Expected FITIDs to depend on field boundaries\n")
+	}
+}
@@ -0,0 +1,39 @@
+// Package ofxtest provides helpers shared by the tests of ofxgo's
+// subpackages
+package ofxtest
+
+import (
+	"github.com/aclindsa/ofxgo"
+	"os"
+	"testing"
+)
+
+// Amount returns the Amount represented by s (i.e. "-12.50" or "1/3"),
+// panicking if s isn't a valid number
+func Amount(s string) ofxgo.Amount {
+	var a ofxgo.Amount
+	if _, ok := a.SetString(s); !ok {
+		panic("ofxtest: invalid amount " + s)
+	}
+	return a
+}
+
+// AmountEqual returns true if a is equal to the number represented by s
+func AmountEqual(a ofxgo.Amount, s string) bool {
+	return a.Equal(Amount(s))
+}
+
+// ParseSample parses the OFX response in the file at path, failing the test if
+// it can't be opened or parsed
+func ParseSample(t *testing.T, path string) *ofxgo.Response {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening %s: %s\n", path, err)
+	}
+	defer file.Close()
+	response, err := ofxgo.ParseResponse(file)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %s: %s\n", path, err)
+	}
+	return response
+}
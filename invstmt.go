@@ -37,8 +37,22 @@ func (r *InvStatementRequest) Name() string {
 func (r *InvStatementRequest) Valid(version ofxVersion) (bool, error) {
 	if ok, err := r.TrnUID.Valid(); !ok {
 		return false, err
+	} else if ok, err := r.InvAcctFrom.Valid(); !ok {
+		return false, err
+	}
+	if r.DtStart != nil && r.DtEnd != nil && r.DtStart.After(r.DtEnd.Time) {
+		return false, errors.New("InvStatementRequest.DtStart after DtEnd")
+	} else if (r.DtStart != nil || r.DtEnd != nil) && !r.Include {
+		return false, errors.New("InvStatementRequest.DtStart/DtEnd supplied without Include")
+	} else if r.PosDtAsOf != nil && !r.IncludePos {
+		return false, errors.New("InvStatementRequest.PosDtAsOf supplied without IncludePos")
+	}
+	if (r.Include401K || r.Include401KBal) && version < OfxVersion151 {
+		return false, errors.New("InvStatementRequest.Include401K* invalid for OFX < 1.5.1")
+	}
+	if r.IncludeTranImage && version < OfxVersion210 {
+		return false, errors.New("InvStatementRequest.IncludeTranImage invalid for OFX < 2.1")
 	}
-	// TODO implement
 	return true, nil
 }
 
@@ -61,6 +75,41 @@ type InvTran struct {
 	Memo          String   `xml:"MEMO,omitempty"`
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t InvTran) Valid() (bool, error) {
+	var emptyDate Date
+	if len(t.FiTID) == 0 {
+		return false, errors.New("InvTran.FiTID empty")
+	} else if t.DtTrade.Equal(emptyDate) {
+		return false, errors.New("InvTran.DtTrade not filled")
+	}
+	return true, nil
+}
+
+// validInvCurrency returns (true, nil) unless both currency and origCurrency
+// are Valid, since only one of them may be supplied for a transaction
+func validInvCurrency(currency, origCurrency Currency) (bool, error) {
+	ok1, _ := currency.Valid()
+	ok2, _ := origCurrency.Valid()
+	if ok1 && ok2 {
+		return false, errors.New("Currency and OrigCurrency both supplied for investment transaction, only one allowed")
+	}
+	return true, nil
+}
+
+// validInv401kSource returns (true, nil) if source is either unset or a valid
+// 401(k) source supported by version
+func validInv401kSource(source inv401kSource, version ofxVersion) (bool, error) {
+	if source == 0 {
+		return true, nil
+	} else if !source.Valid() {
+		return false, errors.New("Inv401kSource invalid")
+	} else if version < OfxVersion151 {
+		return false, errors.New("Inv401kSource invalid for OFX < 1.5.1")
+	}
+	return true, nil
+}
+
 // InvBuy represents generic investment purchase transaction. It is included
 // in many of the more specific transaction Buy* aggregates below.
 type InvBuy struct {
@@ -90,6 +139,28 @@ type InvBuy struct {
 	PriorYearContrib Boolean       `xml:"PRIORYEARCONTRIB,omitempty"` // For 401(k) accounts, indicates that this Buy was made with a prior year contribution
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (b InvBuy) Valid(version ofxVersion) (bool, error) {
+	if ok, err := b.InvTran.Valid(); !ok {
+		return false, err
+	} else if ok, err := b.SecID.Valid(); !ok {
+		return false, err
+	} else if !b.SubAcctSec.Valid() {
+		return false, errors.New("InvBuy.SubAcctSec invalid")
+	} else if !b.SubAcctFund.Valid() {
+		return false, errors.New("InvBuy.SubAcctFund invalid")
+	} else if ok, err := validInvCurrency(b.Currency, b.OrigCurrency); !ok {
+		return false, err
+	}
+	if len(b.LoanID) == 0 && (b.LoanPrincipal.Sign() != 0 || b.LoanInterest.Sign() != 0) {
+		return false, errors.New("InvBuy.LoanPrincipal and LoanInterest require LoanID")
+	}
+	if (len(b.LoanID) > 0 || b.DtPayroll != nil || b.PriorYearContrib) && version < OfxVersion151 {
+		return false, errors.New("InvBuy 401(k) elements invalid for OFX < 1.5.1")
+	}
+	return validInv401kSource(b.Inv401kSource, version)
+}
+
 // InvSell represents generic investment sale transaction. It is included in
 // many of the more specific transaction Sell* aggregates below.
 type InvSell struct {
@@ -119,6 +190,25 @@ type InvSell struct {
 	Inv401kSource inv401kSource `xml:"INV401KSOURCE,omitempty"` // Source of money for this transaction. One of PRETAX, AFTERTAX, MATCH, PROFITSHARING, ROLLOVER, OTHERVEST, OTHERNONVEST for 401(k) accounts. Default if not present is OTHERNONVEST. The following cash source types are subject to vesting: MATCH, PROFITSHARING, and OTHERVEST
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (s InvSell) Valid(version ofxVersion) (bool, error) {
+	if ok, err := s.InvTran.Valid(); !ok {
+		return false, err
+	} else if ok, err := s.SecID.Valid(); !ok {
+		return false, err
+	} else if !s.SubAcctSec.Valid() {
+		return false, errors.New("InvSell.SubAcctSec invalid")
+	} else if !s.SubAcctFund.Valid() {
+		return false, errors.New("InvSell.SubAcctFund invalid")
+	} else if ok, err := validInvCurrency(s.Currency, s.OrigCurrency); !ok {
+		return false, err
+	}
+	if len(s.LoanID) > 0 && version < OfxVersion151 {
+		return false, errors.New("InvSell.LoanID invalid for OFX < 1.5.1")
+	}
+	return validInv401kSource(s.Inv401kSource, version)
+}
+
 // BuyDebt represents a transaction purchasing a debt security
 type BuyDebt struct {
 	XMLName  xml.Name `xml:"BUYDEBT"`
@@ -135,6 +225,14 @@ func (t BuyDebt) InvTransaction() InvTran {
 	return t.InvBuy.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t BuyDebt) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvBuy.Valid(version); !ok {
+		return false, err
+	}
+	return true, nil
+}
+
 // BuyMF represents a transaction purchasing a mutual fund
 type BuyMF struct {
 	XMLName  xml.Name `xml:"BUYMF"`
@@ -152,6 +250,16 @@ func (t BuyMF) InvTransaction() InvTran {
 	return t.InvBuy.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t BuyMF) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvBuy.Valid(version); !ok {
+		return false, err
+	} else if !t.BuyType.Valid() {
+		return false, errors.New("BuyMF.BuyType invalid")
+	}
+	return true, nil
+}
+
 // BuyOpt represents a transaction purchasing an option
 type BuyOpt struct {
 	XMLName    xml.Name   `xml:"BUYOPT"`
@@ -169,6 +277,18 @@ func (t BuyOpt) InvTransaction() InvTran {
 	return t.InvBuy.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t BuyOpt) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvBuy.Valid(version); !ok {
+		return false, err
+	} else if !t.OptBuyType.Valid() {
+		return false, errors.New("BuyOpt.OptBuyType invalid")
+	} else if t.ShPerCtrct <= 0 {
+		return false, errors.New("BuyOpt.ShPerCtrct must be positive")
+	}
+	return true, nil
+}
+
 // BuyOther represents a transaction purchasing a type of security not covered
 // by the other Buy* structs
 type BuyOther struct {
@@ -185,6 +305,14 @@ func (t BuyOther) InvTransaction() InvTran {
 	return t.InvBuy.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t BuyOther) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvBuy.Valid(version); !ok {
+		return false, err
+	}
+	return true, nil
+}
+
 // BuyStock represents a transaction purchasing stock
 type BuyStock struct {
 	XMLName xml.Name `xml:"BUYSTOCK"`
@@ -201,6 +329,16 @@ func (t BuyStock) InvTransaction() InvTran {
 	return t.InvBuy.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t BuyStock) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvBuy.Valid(version); !ok {
+		return false, err
+	} else if !t.BuyType.Valid() {
+		return false, errors.New("BuyStock.BuyType invalid")
+	}
+	return true, nil
+}
+
 // ClosureOpt represents a transaction closing a position for an option
 type ClosureOpt struct {
 	XMLName    xml.Name    `xml:"CLOSUREOPT"`
@@ -223,6 +361,22 @@ func (t ClosureOpt) InvTransaction() InvTran {
 	return t.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t ClosureOpt) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvTran.Valid(); !ok {
+		return false, err
+	} else if ok, err := t.SecID.Valid(); !ok {
+		return false, err
+	} else if !t.OptAction.Valid() {
+		return false, errors.New("ClosureOpt.OptAction invalid")
+	} else if t.ShPerCtrct <= 0 {
+		return false, errors.New("ClosureOpt.ShPerCtrct must be positive")
+	} else if !t.SubAcctSec.Valid() {
+		return false, errors.New("ClosureOpt.SubAcctSec invalid")
+	}
+	return true, nil
+}
+
 // Income represents a transaction where investment income is being realized as
 // cash into the investment account
 type Income struct {
@@ -249,6 +403,24 @@ func (t Income) InvTransaction() InvTran {
 	return t.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t Income) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvTran.Valid(); !ok {
+		return false, err
+	} else if ok, err := t.SecID.Valid(); !ok {
+		return false, err
+	} else if !t.IncomeType.Valid() {
+		return false, errors.New("Income.IncomeType invalid")
+	} else if !t.SubAcctSec.Valid() {
+		return false, errors.New("Income.SubAcctSec invalid")
+	} else if !t.SubAcctFund.Valid() {
+		return false, errors.New("Income.SubAcctFund invalid")
+	} else if ok, err := validInvCurrency(t.Currency, t.OrigCurrency); !ok {
+		return false, err
+	}
+	return validInv401kSource(t.Inv401kSource, version)
+}
+
 // InvExpense represents a transaction realizing an expense associated with an
 // investment
 type InvExpense struct {
@@ -272,14 +444,30 @@ func (t InvExpense) InvTransaction() InvTran {
 	return t.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t InvExpense) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvTran.Valid(); !ok {
+		return false, err
+	} else if ok, err := t.SecID.Valid(); !ok {
+		return false, err
+	} else if !t.SubAcctSec.Valid() {
+		return false, errors.New("InvExpense.SubAcctSec invalid")
+	} else if !t.SubAcctFund.Valid() {
+		return false, errors.New("InvExpense.SubAcctFund invalid")
+	} else if ok, err := validInvCurrency(t.Currency, t.OrigCurrency); !ok {
+		return false, err
+	}
+	return validInv401kSource(t.Inv401kSource, version)
+}
+
 // JrnlFund represents a transaction journaling cash holdings between
 // sub-accounts within the same investment account
 type JrnlFund struct {
 	XMLName     xml.Name    `xml:"JRNLFUND"`
 	InvTran     InvTran     `xml:"INVTRAN"`
-	Total       Amount      `xml:"TOTAL"`
-	SubAcctFrom subAcctType `xml:"SUBACCTFROM"` // Sub-account cash is being transferred from: CASH, MARGIN, SHORT, OTHER
 	SubAcctTo   subAcctType `xml:"SUBACCTTO"`   // Sub-account cash is being transferred to: CASH, MARGIN, SHORT, OTHER
+	SubAcctFrom subAcctType `xml:"SUBACCTFROM"` // Sub-account cash is being transferred from: CASH, MARGIN, SHORT, OTHER
+	Total       Amount      `xml:"TOTAL"`
 }
 
 // TransactionType returns a string representation of this transaction's type
@@ -291,14 +479,26 @@ func (t JrnlFund) InvTransaction() InvTran {
 	return t.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t JrnlFund) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvTran.Valid(); !ok {
+		return false, err
+	} else if !t.SubAcctFrom.Valid() {
+		return false, errors.New("JrnlFund.SubAcctFrom invalid")
+	} else if !t.SubAcctTo.Valid() {
+		return false, errors.New("JrnlFund.SubAcctTo invalid")
+	}
+	return true, nil
+}
+
 // JrnlSec represents a transaction journaling security holdings between
 // sub-accounts within the same investment account
 type JrnlSec struct {
 	XMLName     xml.Name    `xml:"JRNLSEC"`
 	InvTran     InvTran     `xml:"INVTRAN"`
 	SecID       SecurityID  `xml:"SECID"`
-	SubAcctFrom subAcctType `xml:"SUBACCTFROM"` // Sub-account cash is being transferred from: CASH, MARGIN, SHORT, OTHER
 	SubAcctTo   subAcctType `xml:"SUBACCTTO"`   // Sub-account cash is being transferred to: CASH, MARGIN, SHORT, OTHER
+	SubAcctFrom subAcctType `xml:"SUBACCTFROM"` // Sub-account cash is being transferred from: CASH, MARGIN, SHORT, OTHER
 	Units       Amount      `xml:"UNITS"`       // For stocks, MFs, other, number of shares held. Bonds = face value. Options = number of contracts
 }
 
@@ -311,6 +511,20 @@ func (t JrnlSec) InvTransaction() InvTran {
 	return t.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t JrnlSec) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvTran.Valid(); !ok {
+		return false, err
+	} else if ok, err := t.SecID.Valid(); !ok {
+		return false, err
+	} else if !t.SubAcctFrom.Valid() {
+		return false, errors.New("JrnlSec.SubAcctFrom invalid")
+	} else if !t.SubAcctTo.Valid() {
+		return false, errors.New("JrnlSec.SubAcctTo invalid")
+	}
+	return true, nil
+}
+
 // MarginInterest represents a transaction realizing a margin interest expense
 type MarginInterest struct {
 	XMLName      xml.Name    `xml:"MARGININTEREST"`
@@ -330,6 +544,16 @@ func (t MarginInterest) InvTransaction() InvTran {
 	return t.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t MarginInterest) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvTran.Valid(); !ok {
+		return false, err
+	} else if !t.SubAcctFund.Valid() {
+		return false, errors.New("MarginInterest.SubAcctFund invalid")
+	}
+	return validInvCurrency(t.Currency, t.OrigCurrency)
+}
+
 // Reinvest is a single transaction that contains both income and an investment
 // transaction. If servers can’t track this as a single transaction they should
 // return an Income transaction and an InvTran.
@@ -361,6 +585,22 @@ func (t Reinvest) InvTransaction() InvTran {
 	return t.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t Reinvest) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvTran.Valid(); !ok {
+		return false, err
+	} else if ok, err := t.SecID.Valid(); !ok {
+		return false, err
+	} else if !t.IncomeType.Valid() {
+		return false, errors.New("Reinvest.IncomeType invalid")
+	} else if !t.SubAcctSec.Valid() {
+		return false, errors.New("Reinvest.SubAcctSec invalid")
+	} else if ok, err := validInvCurrency(t.Currency, t.OrigCurrency); !ok {
+		return false, err
+	}
+	return validInv401kSource(t.Inv401kSource, version)
+}
+
 // RetOfCap represents a transaction where capital is being returned to the
 // account holder
 type RetOfCap struct {
@@ -384,6 +624,22 @@ func (t RetOfCap) InvTransaction() InvTran {
 	return t.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t RetOfCap) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvTran.Valid(); !ok {
+		return false, err
+	} else if ok, err := t.SecID.Valid(); !ok {
+		return false, err
+	} else if !t.SubAcctSec.Valid() {
+		return false, errors.New("RetOfCap.SubAcctSec invalid")
+	} else if !t.SubAcctFund.Valid() {
+		return false, errors.New("RetOfCap.SubAcctFund invalid")
+	} else if ok, err := validInvCurrency(t.Currency, t.OrigCurrency); !ok {
+		return false, err
+	}
+	return validInv401kSource(t.Inv401kSource, version)
+}
+
 // SellDebt represents the sale of a debt security. Used when debt is sold,
 // called, or reaches maturity.
 type SellDebt struct {
@@ -402,6 +658,16 @@ func (t SellDebt) InvTransaction() InvTran {
 	return t.InvSell.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t SellDebt) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvSell.Valid(version); !ok {
+		return false, err
+	} else if !t.SellReason.Valid() {
+		return false, errors.New("SellDebt.SellReason invalid")
+	}
+	return true, nil
+}
+
 // SellMF represents a transaction selling a mutual fund
 type SellMF struct {
 	XMLName      xml.Name `xml:"SELLMF"`
@@ -420,6 +686,16 @@ func (t SellMF) InvTransaction() InvTran {
 	return t.InvSell.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t SellMF) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvSell.Valid(version); !ok {
+		return false, err
+	} else if !t.SellType.Valid() {
+		return false, errors.New("SellMF.SellType invalid")
+	}
+	return true, nil
+}
+
 // SellOpt represents a transaction selling an option. Depending on the value
 // of OptSellType, can be used to sell a previously bought option or write a
 // new option.
@@ -442,6 +718,22 @@ func (t SellOpt) InvTransaction() InvTran {
 	return t.InvSell.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t SellOpt) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvSell.Valid(version); !ok {
+		return false, err
+	} else if !t.OptSellType.Valid() {
+		return false, errors.New("SellOpt.OptSellType invalid")
+	} else if t.ShPerCtrct <= 0 {
+		return false, errors.New("SellOpt.ShPerCtrct must be positive")
+	} else if t.RelType != 0 && !t.RelType.Valid() {
+		return false, errors.New("SellOpt.RelType invalid")
+	} else if t.Secured != 0 && !t.Secured.Valid() {
+		return false, errors.New("SellOpt.Secured invalid")
+	}
+	return true, nil
+}
+
 // SellOther represents a transaction selling a security type not covered by
 // the other Sell* structs
 type SellOther struct {
@@ -458,6 +750,14 @@ func (t SellOther) InvTransaction() InvTran {
 	return t.InvSell.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t SellOther) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvSell.Valid(version); !ok {
+		return false, err
+	}
+	return true, nil
+}
+
 // SellStock represents a transaction selling stock
 type SellStock struct {
 	XMLName  xml.Name `xml:"SELLSTOCK"`
@@ -474,6 +774,16 @@ func (t SellStock) InvTransaction() InvTran {
 	return t.InvSell.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t SellStock) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvSell.Valid(version); !ok {
+		return false, err
+	} else if !t.SellType.Valid() {
+		return false, errors.New("SellStock.SellType invalid")
+	}
+	return true, nil
+}
+
 // Split represents a stock or mutual fund split
 type Split struct {
 	XMLName       xml.Name      `xml:"SPLIT"`
@@ -500,6 +810,24 @@ func (t Split) InvTransaction() InvTran {
 	return t.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t Split) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvTran.Valid(); !ok {
+		return false, err
+	} else if ok, err := t.SecID.Valid(); !ok {
+		return false, err
+	} else if !t.SubAcctSec.Valid() {
+		return false, errors.New("Split.SubAcctSec invalid")
+	} else if t.Numerator <= 0 || t.Denominator <= 0 {
+		return false, errors.New("Split.Numerator and Denominator must be positive")
+	} else if t.SubAcctFund != 0 && !t.SubAcctFund.Valid() {
+		return false, errors.New("Split.SubAcctFund invalid")
+	} else if ok, err := validInvCurrency(t.Currency, t.OrigCurrency); !ok {
+		return false, err
+	}
+	return validInv401kSource(t.Inv401kSource, version)
+}
+
 // Transfer represents the transfer of securities into or out of an account
 type Transfer struct {
 	XMLName       xml.Name      `xml:"TRANSFER"`
@@ -525,6 +853,27 @@ func (t Transfer) InvTransaction() InvTran {
 	return t.InvTran
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t Transfer) Valid(version ofxVersion) (bool, error) {
+	if ok, err := t.InvTran.Valid(); !ok {
+		return false, err
+	} else if ok, err := t.SecID.Valid(); !ok {
+		return false, err
+	} else if !t.SubAcctSec.Valid() {
+		return false, errors.New("Transfer.SubAcctSec invalid")
+	} else if !t.TferAction.Valid() {
+		return false, errors.New("Transfer.TferAction invalid")
+	} else if !t.PosType.Valid() {
+		return false, errors.New("Transfer.PosType invalid")
+	}
+	if len(t.InvAcctFrom.BrokerID) > 0 || len(t.InvAcctFrom.AcctID) > 0 {
+		if ok, err := t.InvAcctFrom.Valid(); !ok {
+			return false, err
+		}
+	}
+	return validInv401kSource(t.Inv401kSource, version)
+}
+
 // InvTransaction is a generic interface met by all investment transactions
 // (Buy*, Sell*, & co.)
 type InvTransaction interface {
@@ -541,6 +890,148 @@ type InvBankTransaction struct {
 	SubAcctFund  subAcctType   `xml:"SUBACCTFUND"` // Where did the money for the transaction come from or go to? CASH, MARGIN, SHORT, OTHER
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (t InvBankTransaction) Valid(version ofxVersion) (bool, error) {
+	if !t.SubAcctFund.Valid() {
+		return false, errors.New("InvBankTransaction.SubAcctFund invalid")
+	}
+	for _, tran := range t.Transactions {
+		if ok, err := tran.Valid(version); !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// decodeInvTransaction decodes the InvTransaction starting with start, which
+// may be any INVTRANLIST child element other than INVBANKTRAN
+func decodeInvTransaction(d *xml.Decoder, start xml.StartElement) (InvTransaction, error) {
+	switch start.Name.Local {
+	case "BUYDEBT":
+		var tran BuyDebt
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "BUYMF":
+		var tran BuyMF
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "BUYOPT":
+		var tran BuyOpt
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "BUYOTHER":
+		var tran BuyOther
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "BUYSTOCK":
+		var tran BuyStock
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "CLOSUREOPT":
+		var tran ClosureOpt
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "INCOME":
+		var tran Income
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "INVEXPENSE":
+		var tran InvExpense
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "JRNLFUND":
+		var tran JrnlFund
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "JRNLSEC":
+		var tran JrnlSec
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "MARGININTEREST":
+		var tran MarginInterest
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "REINVEST":
+		var tran Reinvest
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "RETOFCAP":
+		var tran RetOfCap
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "SELLDEBT":
+		var tran SellDebt
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "SELLMF":
+		var tran SellMF
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "SELLOPT":
+		var tran SellOpt
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "SELLOTHER":
+		var tran SellOther
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "SELLSTOCK":
+		var tran SellStock
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "SPLIT":
+		var tran Split
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	case "TRANSFER":
+		var tran Transfer
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return nil, err
+		}
+		return InvTransaction(tran), nil
+	default:
+		return nil, errors.New("Invalid INVTRANLIST child tag: " + start.Name.Local)
+	}
+}
+
 // InvTranList represents a list of investment account transactions. It
 // includes the date range its transactions cover, as well as the bank- and
 // security-related transactions themselves. It must be unmarshalled manually
@@ -553,6 +1044,32 @@ type InvTranList struct {
 	BankTransactions []InvBankTransaction
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (l InvTranList) Valid(version ofxVersion) (bool, error) {
+	var emptyDate Date
+	if l.DtStart.Equal(emptyDate) {
+		return false, errors.New("InvTranList.DtStart not filled")
+	} else if l.DtEnd.Equal(emptyDate) {
+		return false, errors.New("InvTranList.DtEnd not filled")
+	}
+	for _, t := range l.InvTransactions {
+		// InvTransactions defined outside this package can't be validated
+		if v, ok := t.(interface {
+			Valid(ofxVersion) (bool, error)
+		}); ok {
+			if ok, err := v.Valid(version); !ok {
+				return false, err
+			}
+		}
+	}
+	for _, t := range l.BankTransactions {
+		if ok, err := t.Valid(version); !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 // UnmarshalXML handles unmarshalling an InvTranList element from an SGML/XML
 // string
 func (l *InvTranList) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
@@ -577,126 +1094,6 @@ func (l *InvTranList) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 					return err
 				}
 				l.DtEnd = dtend
-			case "BUYDEBT":
-				var tran BuyDebt
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "BUYMF":
-				var tran BuyMF
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "BUYOPT":
-				var tran BuyOpt
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "BUYOTHER":
-				var tran BuyOther
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "BUYSTOCK":
-				var tran BuyStock
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "CLOSUREOPT":
-				var tran ClosureOpt
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "INCOME":
-				var tran Income
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "INVEXPENSE":
-				var tran InvExpense
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "JRNLFUND":
-				var tran JrnlFund
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "JRNLSEC":
-				var tran JrnlSec
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "MARGININTEREST":
-				var tran MarginInterest
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "REINVEST":
-				var tran Reinvest
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "RETOFCAP":
-				var tran RetOfCap
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "SELLDEBT":
-				var tran SellDebt
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "SELLMF":
-				var tran SellMF
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "SELLOPT":
-				var tran SellOpt
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "SELLOTHER":
-				var tran SellOther
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "SELLSTOCK":
-				var tran SellStock
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "SPLIT":
-				var tran Split
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
-			case "TRANSFER":
-				var tran Transfer
-				if err := d.DecodeElement(&tran, &startElement); err != nil {
-					return err
-				}
-				l.InvTransactions = append(l.InvTransactions, InvTransaction(tran))
 			case "INVBANKTRAN":
 				var tran InvBankTransaction
 				if err := d.DecodeElement(&tran, &startElement); err != nil {
@@ -704,7 +1101,11 @@ func (l *InvTranList) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 				}
 				l.BankTransactions = append(l.BankTransactions, tran)
 			default:
-				return errors.New("Invalid INVTRANLIST child tag: " + startElement.Name.Local)
+				tran, err := decodeInvTransaction(d, startElement)
+				if err != nil {
+					return err
+				}
+				l.InvTransactions = append(l.InvTransactions, tran)
 			}
 		} else {
 			return errors.New("Didn't find an opening element")
@@ -842,6 +1243,26 @@ type InvPosition struct {
 	Inv401kSource inv401kSource `xml:"INV401KSOURCE,omitempty"` // One of PRETAX, AFTERTAX, MATCH, PROFITSHARING, ROLLOVER, OTHERVEST, OTHERNONVEST for 401(k) accounts. Default if not present is OTHERNONVEST. The following cash source types are subject to vesting: MATCH, PROFITSHARING, and OTHERVEST
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (p InvPosition) Valid(version ofxVersion) (bool, error) {
+	var emptyDate Date
+	if ok, err := p.SecID.Valid(); !ok {
+		return false, err
+	} else if !p.HeldInAcct.Valid() {
+		return false, errors.New("InvPosition.HeldInAcct invalid")
+	} else if !p.PosType.Valid() {
+		return false, errors.New("InvPosition.PosType invalid")
+	} else if p.DtPriceAsOf.Equal(emptyDate) {
+		return false, errors.New("InvPosition.DtPriceAsOf not filled")
+	}
+	if p.Currency != nil {
+		if ok, err := p.Currency.Valid(); !ok {
+			return false, err
+		}
+	}
+	return validInv401kSource(p.Inv401kSource, version)
+}
+
 // Position is an interface satisfied by all the other *Position types
 type Position interface {
 	PositionType() string
@@ -864,6 +1285,11 @@ func (p DebtPosition) InvPosition() InvPosition {
 	return p.InvPos
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (p DebtPosition) Valid(version ofxVersion) (bool, error) {
+	return p.InvPos.Valid(version)
+}
+
 // MFPosition represents a position held in a mutual fund
 type MFPosition struct {
 	XMLName     xml.Name    `xml:"POSMF"`
@@ -884,6 +1310,11 @@ func (p MFPosition) InvPosition() InvPosition {
 	return p.InvPos
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (p MFPosition) Valid(version ofxVersion) (bool, error) {
+	return p.InvPos.Valid(version)
+}
+
 // OptPosition represents a position held in an option
 type OptPosition struct {
 	XMLName xml.Name    `xml:"POSOPT"`
@@ -901,6 +1332,16 @@ func (p OptPosition) InvPosition() InvPosition {
 	return p.InvPos
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (p OptPosition) Valid(version ofxVersion) (bool, error) {
+	if ok, err := p.InvPos.Valid(version); !ok {
+		return false, err
+	} else if p.Secured != 0 && !p.Secured.Valid() {
+		return false, errors.New("OptPosition.Secured invalid")
+	}
+	return true, nil
+}
+
 // OtherPosition represents a position held in a security type not covered by
 // the other *Position elements
 type OtherPosition struct {
@@ -918,6 +1359,11 @@ func (p OtherPosition) InvPosition() InvPosition {
 	return p.InvPos
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (p OtherPosition) Valid(version ofxVersion) (bool, error) {
+	return p.InvPos.Valid(version)
+}
+
 // StockPosition represents a position held in a stock
 type StockPosition struct {
 	XMLName     xml.Name    `xml:"POSSTOCK"`
@@ -937,6 +1383,49 @@ func (p StockPosition) InvPosition() InvPosition {
 	return p.InvPos
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (p StockPosition) Valid(version ofxVersion) (bool, error) {
+	return p.InvPos.Valid(version)
+}
+
+// decodePosition decodes the Position starting with start
+func decodePosition(d *xml.Decoder, start xml.StartElement) (Position, error) {
+	switch start.Name.Local {
+	case "POSDEBT":
+		var position DebtPosition
+		if err := d.DecodeElement(&position, &start); err != nil {
+			return nil, err
+		}
+		return Position(position), nil
+	case "POSMF":
+		var position MFPosition
+		if err := d.DecodeElement(&position, &start); err != nil {
+			return nil, err
+		}
+		return Position(position), nil
+	case "POSOPT":
+		var position OptPosition
+		if err := d.DecodeElement(&position, &start); err != nil {
+			return nil, err
+		}
+		return Position(position), nil
+	case "POSOTHER":
+		var position OtherPosition
+		if err := d.DecodeElement(&position, &start); err != nil {
+			return nil, err
+		}
+		return Position(position), nil
+	case "POSSTOCK":
+		var position StockPosition
+		if err := d.DecodeElement(&position, &start); err != nil {
+			return nil, err
+		}
+		return Position(position), nil
+	default:
+		return nil, errors.New("Invalid INVPOSLIST child tag: " + start.Name.Local)
+	}
+}
+
 // PositionList represents a list of positions held in securities in an
 // investment account
 type PositionList []Position
@@ -951,40 +1440,11 @@ func (p *PositionList) UnmarshalXML(d *xml.Decoder, start xml.StartElement) erro
 			// If we found the end of our starting element, we're done parsing
 			return nil
 		} else if startElement, ok := tok.(xml.StartElement); ok {
-			switch startElement.Name.Local {
-			case "POSDEBT":
-				var position DebtPosition
-				if err := d.DecodeElement(&position, &startElement); err != nil {
-					return err
-				}
-				*p = append(*p, Position(position))
-			case "POSMF":
-				var position MFPosition
-				if err := d.DecodeElement(&position, &startElement); err != nil {
-					return err
-				}
-				*p = append(*p, Position(position))
-			case "POSOPT":
-				var position OptPosition
-				if err := d.DecodeElement(&position, &startElement); err != nil {
-					return err
-				}
-				*p = append(*p, Position(position))
-			case "POSOTHER":
-				var position OtherPosition
-				if err := d.DecodeElement(&position, &startElement); err != nil {
-					return err
-				}
-				*p = append(*p, Position(position))
-			case "POSSTOCK":
-				var position StockPosition
-				if err := d.DecodeElement(&position, &startElement); err != nil {
-					return err
-				}
-				*p = append(*p, Position(position))
-			default:
-				return errors.New("Invalid INVPOSLIST child tag: " + startElement.Name.Local)
+			position, err := decodePosition(d, startElement)
+			if err != nil {
+				return err
 			}
+			*p = append(*p, position)
 		} else {
 			return errors.New("Didn't find an opening element")
 		}
@@ -1042,6 +1502,16 @@ type InvBalance struct {
 	BalList       []Balance `xml:"BALLIST>BAL,omitempty"`
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (b InvBalance) Valid() (bool, error) {
+	for _, bal := range b.BalList {
+		if ok, err := bal.Valid(); !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 // OO represents a generic open investment order. It is included in the other
 // OO* elements.
 type OO struct {
@@ -1390,7 +1860,7 @@ type VestInfo struct {
 
 // LoanInfo represents a loan outstanding against this 401(k) account
 type LoanInfo struct {
-	XMLName               xml.Name    `xml:"VESTINFO"`
+	XMLName               xml.Name    `xml:"LOANINFO"`
 	LoanID                String      `xml:"LOANID"`                          // Identifier of this loan
 	LoanDesc              String      `xml:"LOANDESC,omitempty"`              // Loan description
 	InitialLoanBal        Amount      `xml:"INITIALLOANBAL,omitempty"`        // Initial loan balance
@@ -1430,7 +1900,7 @@ type Inv401KSummaryPeriod struct {
 	DtStart       Date                     `xml:"DTSTART"`
 	DtEnd         Date                     `xml:"DTEND"`
 	Contributions *Inv401KSummaryAggregate `xml:"CONTRIBUTIONS,omitempty"` // 401(k) contribution aggregate. Note: this includes loan payments.
-	Withdrawls    *Inv401KSummaryAggregate `xml:"WITHDRAWLS,omitempty"`    // 401(k) withdrawals aggregate. Note: this includes loan withdrawals.
+	Withdrawls    *Inv401KSummaryAggregate `xml:"WITHDRAWALS,omitempty"`   // 401(k) withdrawals aggregate. Note: this includes loan withdrawals.
 	Earnings      *Inv401KSummaryAggregate `xml:"EARNINGS,omitempty"`      // 401(k) earnings aggregate. This is the market value change. It includes dividends/interest, and capital gains - realized and unrealized.
 }
 
@@ -1453,7 +1923,7 @@ type Inv401K struct {
 	StartOfYear         *Date                 `xml:"MATCHINFO>STARTOFYEAR,omitempty"`       // Specifies when the employer contribution max is reset. Some plans have a maximum based on the company fiscal year rather than calendar year. Assume calendar year if omitted. Only the month and day (MMDD) are used; year (YYYY) and time are ignored
 	BaseMatchAmt        Amount                `xml:"MATCHINFO>BASEMATCHAMT"`                // Specifies a fixed dollar amount contributed by the employer if the employee participates in the plan at all. This may be present in addition to the <MATCHPCT>. $0 if omitted
 	BaseMatchPct        Amount                `xml:"MATCHINFO>BASEMATCHPCT"`                // Specifies a fixed percent of employee salary matched if the employee participates in the plan at all. This may be present in addition to the MATCHPCT>. 0% if omitted. Base match in a year is BASEMATCHPCT up to the BASEMATCHAMT,if provided
-	ContribInfo         []ContribSecurity     `xml:"CONTRIBINTO>CONTRIBSECURITY"`           // Aggregate to describe how new contributions are distributed among the available securities.
+	ContribInfo         []ContribSecurity     `xml:"CONTRIBINFO>CONTRIBSECURITY"`           // Aggregate to describe how new contributions are distributed among the available securities.
 	CurrentVestPct      Amount                `xml:"CURRENTVESTPCT,omitempty"`              // Estimated percentage of employer contributions vested as of the current date. If omitted, assume 100%
 	VestInfo            []VestInfo            `xml:"VESTINFO,omitempty"`                    // Vest change dates. Provides the vesting percentage as of any particular past, current, or future date. 0 or more.
 	LoanInfo            []LoanInfo            `xml:"LOANINFO,omitempty"`                    // List of any loans outstanding against this account
@@ -1478,6 +1948,16 @@ type Inv401KBal struct {
 	BalList       []Balance `xml:"BALLIST>BAL,omitempty"`
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (b Inv401KBal) Valid() (bool, error) {
+	for _, bal := range b.BalList {
+		if ok, err := bal.Valid(); !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 // InvStatementResponse includes requested transaction, position, open order,
 // and balance information for an investment account. It is in response to an
 // InvStatementRequest or sometimes provided as part of an OFX file downloaded
@@ -1507,10 +1987,51 @@ func (sr *InvStatementResponse) Name() string {
 
 // Valid returns (true, nil) if this struct was valid OFX when unmarshalled
 func (sr *InvStatementResponse) Valid(version ofxVersion) (bool, error) {
+	var emptyDate Date
 	if ok, err := sr.TrnUID.Valid(); !ok {
 		return false, err
+	} else if ok, err := sr.Status.Valid(); !ok {
+		return false, err
+	}
+	// Servers omit INVSTMTRS when they fail to process the request
+	if sr.Status.Code != 0 && sr.DtAsOf.Equal(emptyDate) && len(sr.InvAcctFrom.AcctID) == 0 {
+		return true, nil
+	}
+	if ok, err := sr.CurDef.Valid(); !ok {
+		return false, err
+	} else if ok, err := sr.InvAcctFrom.Valid(); !ok {
+		return false, err
+	} else if sr.DtAsOf.Equal(emptyDate) {
+		return false, errors.New("InvStatementResponse.DtAsOf not filled")
+	}
+	if sr.InvTranList != nil {
+		if ok, err := sr.InvTranList.Valid(version); !ok {
+			return false, err
+		}
+	}
+	for _, position := range sr.InvPosList {
+		// Positions defined outside this package can't be validated
+		if v, ok := position.(interface {
+			Valid(ofxVersion) (bool, error)
+		}); ok {
+			if ok, err := v.Valid(version); !ok {
+				return false, err
+			}
+		}
+	}
+	if sr.InvBal != nil {
+		if ok, err := sr.InvBal.Valid(); !ok {
+			return false, err
+		}
+	}
+	if (sr.Inv401K != nil || sr.Inv401KBal != nil) && version < OfxVersion151 {
+		return false, errors.New("InvStatementResponse.Inv401K* invalid for OFX < 1.5.1")
+	}
+	if sr.Inv401KBal != nil {
+		if ok, err := sr.Inv401KBal.Valid(); !ok {
+			return false, err
+		}
 	}
-	//TODO implement
 	return true, nil
 }
 
@@ -1948,3 +1948,392 @@ func TestInvTransaction(t *testing.T) {
 		})
 	}
 }
+
+// validatedInvTransaction is implemented by all of this package's
+// InvTransactions, which can be validated for a particular OFX version
+type validatedInvTransaction interface {
+	InvTransaction
+	Valid(version ofxVersion) (bool, error)
+}
+
+func TestInvTransactionValid(t *testing.T) {
+	invTran := InvTran{
+		FiTID:   "1234",
+		DtTrade: *NewDateGMT(2017, 1, 5, 0, 0, 0, 0),
+	}
+	secID := SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}
+	invBuy := InvBuy{
+		InvTran:     invTran,
+		SecID:       secID,
+		SubAcctSec:  SubAcctTypeCash,
+		SubAcctFund: SubAcctTypeCash,
+	}
+	invSell := InvSell{
+		InvTran:     invTran,
+		SecID:       secID,
+		SubAcctSec:  SubAcctTypeCash,
+		SubAcctFund: SubAcctTypeCash,
+	}
+	valid := []validatedInvTransaction{
+		BuyDebt{InvBuy: invBuy},
+		BuyMF{InvBuy: invBuy, BuyType: BuyTypeBuy},
+		BuyOpt{InvBuy: invBuy, OptBuyType: OptBuyTypeBuyToOpen, ShPerCtrct: 100},
+		BuyOther{InvBuy: invBuy},
+		BuyStock{InvBuy: invBuy, BuyType: BuyTypeBuy},
+		ClosureOpt{InvTran: invTran, SecID: secID, OptAction: OptActionExercise, ShPerCtrct: 100, SubAcctSec: SubAcctTypeCash},
+		Income{InvTran: invTran, SecID: secID, IncomeType: IncomeTypeDiv, SubAcctSec: SubAcctTypeCash, SubAcctFund: SubAcctTypeCash},
+		InvExpense{InvTran: invTran, SecID: secID, SubAcctSec: SubAcctTypeCash, SubAcctFund: SubAcctTypeCash},
+		JrnlFund{InvTran: invTran, SubAcctFrom: SubAcctTypeCash, SubAcctTo: SubAcctTypeMargin},
+		JrnlSec{InvTran: invTran, SecID: secID, SubAcctFrom: SubAcctTypeCash, SubAcctTo: SubAcctTypeMargin},
+		MarginInterest{InvTran: invTran, SubAcctFund: SubAcctTypeMargin},
+		Reinvest{InvTran: invTran, SecID: secID, IncomeType: IncomeTypeDiv, SubAcctSec: SubAcctTypeCash},
+		RetOfCap{InvTran: invTran, SecID: secID, SubAcctSec: SubAcctTypeCash, SubAcctFund: SubAcctTypeCash},
+		SellDebt{InvSell: invSell, SellReason: SellReasonMaturity},
+		SellMF{InvSell: invSell, SellType: SellTypeSell},
+		SellOpt{InvSell: invSell, OptSellType: OptSellTypeSellToClose, ShPerCtrct: 100},
+		SellOther{InvSell: invSell},
+		SellStock{InvSell: invSell, SellType: SellTypeSell},
+		Split{InvTran: invTran, SecID: secID, SubAcctSec: SubAcctTypeCash, Numerator: 2, Denominator: 1},
+		Transfer{InvTran: invTran, SecID: secID, SubAcctSec: SubAcctTypeCash, TferAction: TferActionIn, PosType: PosTypeLong},
+	}
+	for _, tran := range valid {
+		if ok, err := tran.Valid(OfxVersion102); !ok {
+			t.Errorf("Unexpectedly invalid %s: %s\n", tran.TransactionType(), err)
+		}
+	}
+
+	badBuy := invBuy
+	badBuy.SubAcctFund = 0
+	badSell := invSell
+	badSell.SecID.UniqueID = ""
+	noFiTID := invTran
+	noFiTID.FiTID = ""
+	usd, err := NewCurrSymbol("USD")
+	if err != nil {
+		t.Fatalf("Unexpected error creating CurrSymbol for USD\n")
+	}
+	var currency Currency
+	currency.CurRate.SetFrac64(1, 1)
+	currency.CurSym = *usd
+	var principal Amount
+	principal.SetFrac64(100, 1)
+	loanBuy := invBuy
+	loanBuy.LoanPrincipal = principal
+	sourceBuy := invBuy
+	sourceBuy.Inv401kSource = Inv401kSourcePreTax
+
+	invalid := []struct {
+		version ofxVersion
+		tran    validatedInvTransaction
+	}{
+		{OfxVersion203, BuyDebt{InvBuy: badBuy}},
+		{OfxVersion203, BuyMF{InvBuy: invBuy}},
+		{OfxVersion203, BuyOpt{InvBuy: invBuy, OptBuyType: OptBuyTypeBuyToOpen}},
+		{OfxVersion203, BuyStock{InvBuy: loanBuy, BuyType: BuyTypeBuy}},
+		{OfxVersion102, BuyStock{InvBuy: sourceBuy, BuyType: BuyTypeBuy}},
+		{OfxVersion203, ClosureOpt{InvTran: invTran, SecID: secID, ShPerCtrct: 100, SubAcctSec: SubAcctTypeCash}},
+		{OfxVersion203, Income{InvTran: noFiTID, SecID: secID, IncomeType: IncomeTypeDiv, SubAcctSec: SubAcctTypeCash, SubAcctFund: SubAcctTypeCash}},
+		{OfxVersion203, Income{InvTran: invTran, SecID: secID, IncomeType: IncomeTypeDiv, SubAcctSec: SubAcctTypeCash, SubAcctFund: SubAcctTypeCash, Currency: currency, OrigCurrency: currency}},
+		{OfxVersion203, InvExpense{InvTran: invTran, SecID: secID, SubAcctSec: SubAcctTypeCash}},
+		{OfxVersion203, JrnlFund{InvTran: invTran, SubAcctFrom: SubAcctTypeCash}},
+		{OfxVersion203, JrnlSec{InvTran: invTran, SubAcctFrom: SubAcctTypeCash, SubAcctTo: SubAcctTypeMargin}},
+		{OfxVersion203, MarginInterest{InvTran: invTran}},
+		{OfxVersion203, Reinvest{InvTran: invTran, SecID: secID, SubAcctSec: SubAcctTypeCash}},
+		{OfxVersion203, RetOfCap{InvTran: InvTran{FiTID: "1234"}, SecID: secID, SubAcctSec: SubAcctTypeCash, SubAcctFund: SubAcctTypeCash}},
+		{OfxVersion203, SellDebt{InvSell: invSell}},
+		{OfxVersion203, SellMF{InvSell: badSell, SellType: SellTypeSell}},
+		{OfxVersion203, SellOpt{InvSell: invSell, OptSellType: OptSellTypeSellToClose, ShPerCtrct: 100, RelType: relType(42)}},
+		{OfxVersion203, SellStock{InvSell: invSell}},
+		{OfxVersion203, Split{InvTran: invTran, SecID: secID, SubAcctSec: SubAcctTypeCash, Numerator: 2}},
+		{OfxVersion203, Transfer{InvTran: invTran, SecID: secID, SubAcctSec: SubAcctTypeCash, TferAction: TferActionIn}},
+		{OfxVersion203, Transfer{InvTran: invTran, SecID: secID, SubAcctSec: SubAcctTypeCash, TferAction: TferActionIn, PosType: PosTypeLong, InvAcctFrom: InvAcct{AcctID: "1234"}}},
+	}
+	for i, tc := range invalid {
+		if ok, err := tc.tran.Valid(tc.version); ok || err == nil {
+			t.Errorf("Expected error from calling Valid on %s (case %d)\n", tc.tran.TransactionType(), i)
+		}
+	}
+
+	// InvTransactions implemented outside this package have no Valid method
+	// and are skipped when validating an InvTranList
+	list := InvTranList{
+		DtStart:         *NewDateGMT(2017, 1, 1, 0, 0, 0, 0),
+		DtEnd:           *NewDateGMT(2017, 2, 1, 0, 0, 0, 0),
+		InvTransactions: []InvTransaction{externalInvTransaction{}},
+	}
+	if ok, err := list.Valid(OfxVersion203); !ok {
+		t.Errorf("Unexpected error validating InvTranList with external InvTransaction: %s\n", err)
+	}
+}
+
+type externalInvTransaction struct{}
+
+func (externalInvTransaction) TransactionType() string { return "EXTERNAL" }
+func (externalInvTransaction) InvTransaction() InvTran { return InvTran{} }
+
+// validatedPosition is implemented by all of this package's Positions, which
+// can be validated for a particular OFX version
+type validatedPosition interface {
+	Position
+	Valid(version ofxVersion) (bool, error)
+}
+
+func TestPositionValid(t *testing.T) {
+	invPos := InvPosition{
+		SecID:       SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"},
+		HeldInAcct:  SubAcctTypeCash,
+		PosType:     PosTypeLong,
+		DtPriceAsOf: *NewDateGMT(2017, 4, 1, 0, 0, 0, 0),
+	}
+	valid := []validatedPosition{
+		DebtPosition{InvPos: invPos},
+		MFPosition{InvPos: invPos},
+		OptPosition{InvPos: invPos, Secured: SecuredCovered},
+		OtherPosition{InvPos: invPos},
+		StockPosition{InvPos: invPos},
+	}
+	for _, pos := range valid {
+		if ok, err := pos.Valid(OfxVersion203); !ok {
+			t.Errorf("Unexpectedly invalid %s: %s\n", pos.PositionType(), err)
+		}
+	}
+
+	noHeldInAcct := invPos
+	noHeldInAcct.HeldInAcct = 0
+	noDate := invPos
+	noDate.DtPriceAsOf = Date{}
+	source := invPos
+	source.Inv401kSource = Inv401kSourceMatch
+	invalid := []struct {
+		version ofxVersion
+		pos     validatedPosition
+	}{
+		{OfxVersion203, StockPosition{InvPos: noHeldInAcct}},
+		{OfxVersion203, MFPosition{InvPos: noDate}},
+		{OfxVersion203, OptPosition{InvPos: invPos, Secured: secured(42)}},
+		{OfxVersion102, OtherPosition{InvPos: source}},
+	}
+	for i, tc := range invalid {
+		if ok, err := tc.pos.Valid(tc.version); ok || err == nil {
+			t.Errorf("Expected error from calling Valid on %s (case %d)\n", tc.pos.PositionType(), i)
+		}
+	}
+}
+
+func TestInvStatementRequestValid(t *testing.T) {
+	r := InvStatementRequest{
+		TrnUID:      "382827d6-e2d0-4396-bf3b-665979285420",
+		InvAcctFrom: InvAcct{BrokerID: "fi.example.com", AcctID: "82736664"},
+		DtStart:     NewDateGMT(2016, 1, 1, 0, 0, 0, 0),
+		DtEnd:       NewDateGMT(2016, 12, 31, 0, 0, 0, 0),
+		Include:     true,
+		IncludePos:  true,
+	}
+	if ok, err := r.Valid(OfxVersion102); !ok {
+		t.Fatalf("Unexpected error from calling Valid: %s\n", err)
+	}
+
+	bad := r
+	bad.InvAcctFrom.BrokerID = ""
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with empty BrokerID\n")
+	}
+
+	bad = r
+	bad.DtStart, bad.DtEnd = r.DtEnd, r.DtStart
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with DtStart after DtEnd\n")
+	}
+
+	bad = r
+	bad.Include = false
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with DtStart but not Include\n")
+	}
+
+	bad = r
+	bad.Include401K = true
+	if ok, err := bad.Valid(OfxVersion102); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with Include401K for OFX 1.0.2\n")
+	}
+	if ok, err := bad.Valid(OfxVersion160); !ok {
+		t.Errorf("Unexpected error from calling Valid with Include401K for OFX 1.6: %s\n", err)
+	}
+
+	bad = r
+	bad.IncludeTranImage = true
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with IncludeTranImage for OFX 2.0.3\n")
+	}
+}
+
+func TestInvStatementResponseValid(t *testing.T) {
+	usd, err := NewCurrSymbol("USD")
+	if err != nil {
+		t.Fatalf("Unexpected error creating CurrSymbol for USD\n")
+	}
+	sr := InvStatementResponse{
+		TrnUID:      "1001",
+		Status:      Status{Code: 0, Severity: "INFO"},
+		DtAsOf:      *NewDateGMT(2017, 4, 1, 0, 0, 0, 0),
+		CurDef:      *usd,
+		InvAcctFrom: InvAcct{BrokerID: "example.com", AcctID: "12345"},
+		InvTranList: &InvTranList{
+			DtStart: *NewDateGMT(2017, 1, 1, 0, 0, 0, 0),
+			DtEnd:   *NewDateGMT(2017, 4, 1, 0, 0, 0, 0),
+		},
+	}
+	if ok, err := sr.Valid(OfxVersion102); !ok {
+		t.Fatalf("Unexpected error from calling Valid: %s\n", err)
+	}
+
+	// INVSTMTRS may be omitted when the request failed
+	failed := InvStatementResponse{
+		TrnUID: "1001",
+		Status: Status{Code: 2000, Severity: "ERROR"},
+	}
+	if ok, err := failed.Valid(OfxVersion102); !ok {
+		t.Errorf("Unexpected error from calling Valid on failed response: %s\n", err)
+	}
+
+	bad := sr
+	bad.DtAsOf = Date{}
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with empty DtAsOf\n")
+	}
+
+	bad = sr
+	bad.InvTranList = &InvTranList{
+		DtStart:         sr.InvTranList.DtStart,
+		DtEnd:           sr.InvTranList.DtEnd,
+		InvTransactions: []InvTransaction{BuyStock{}},
+	}
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with invalid transaction\n")
+	}
+
+	bad = sr
+	bad.InvPosList = PositionList{StockPosition{}}
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with invalid position\n")
+	}
+
+	bad = sr
+	bad.Inv401KBal = &Inv401KBal{}
+	if ok, err := bad.Valid(OfxVersion102); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with Inv401KBal for OFX 1.0.2\n")
+	}
+}
+
+func TestSecListRequestValid(t *testing.T) {
+	r := SecListRequest{
+		TrnUID: "1234",
+		Securities: []SecurityRequest{
+			{Ticker: "SPY"},
+			{SecID: &SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}},
+		},
+	}
+	if ok, err := r.Valid(OfxVersion203); !ok {
+		t.Fatalf("Unexpected error from calling Valid: %s\n", err)
+	}
+
+	bad := r
+	bad.Securities = nil
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with no securities\n")
+	}
+
+	bad = r
+	bad.Securities = []SecurityRequest{{Ticker: "SPY", FiID: "1"}}
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with both Ticker and FiID\n")
+	}
+
+	bad = r
+	bad.Securities = []SecurityRequest{{SecID: &SecurityID{UniqueID: "78462F103"}}}
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with empty UniqueIDType\n")
+	}
+}
+
+func TestMarshalJrnlTransactions(t *testing.T) {
+	invTran := InvTran{FiTID: "129837-1112", DtTrade: *NewDateGMT(2017, 2, 3, 0, 0, 0, 0)}
+	jrnlFund := JrnlFund{InvTran: invTran, SubAcctTo: SubAcctTypeMargin, SubAcctFrom: SubAcctTypeCash}
+	jrnlFund.Total.SetInt64(2300)
+	b, err := xml.Marshal(&jrnlFund)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling JrnlFund: %s\n", err)
+	}
+	if !strings.Contains(string(b), "</INVTRAN><SUBACCTTO>MARGIN</SUBACCTTO><SUBACCTFROM>CASH</SUBACCTFROM><TOTAL>2300</TOTAL></JRNLFUND>") {
+		t.Errorf("Unexpected JRNLFUND element order: %s\n", b)
+	}
+
+	jrnlSec := JrnlSec{InvTran: invTran, SecID: SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}, SubAcctTo: SubAcctTypeMargin, SubAcctFrom: SubAcctTypeCash}
+	jrnlSec.Units.SetInt64(100)
+	b, err = xml.Marshal(&jrnlSec)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling JrnlSec: %s\n", err)
+	}
+	if !strings.Contains(string(b), "</SECID><SUBACCTTO>MARGIN</SUBACCTTO><SUBACCTFROM>CASH</SUBACCTFROM><UNITS>100</UNITS></JRNLSEC>") {
+		t.Errorf("Unexpected JRNLSEC element order: %s\n", b)
+	}
+}
+
+func TestUnmarshalInv401K(t *testing.T) {
+	input := `<INV401K>
+	<EMPLOYERNAME>Example Corp</EMPLOYERNAME>
+	<MATCHINFO>
+		<BASEMATCHAMT>0</BASEMATCHAMT>
+		<BASEMATCHPCT>0</BASEMATCHPCT>
+	</MATCHINFO>
+	<CONTRIBINFO>
+		<CONTRIBSECURITY>
+			<SECID>
+				<UNIQUEID>922908363</UNIQUEID>
+				<UNIQUEIDTYPE>CUSIP</UNIQUEIDTYPE>
+			</SECID>
+			<PRETAXCONTRIBPCT>100</PRETAXCONTRIBPCT>
+		</CONTRIBSECURITY>
+	</CONTRIBINFO>
+	<LOANINFO>
+		<LOANID>1</LOANID>
+		<CURRENTLOANBAL>1500</CURRENTLOANBAL>
+		<DTASOF>20170331</DTASOF>
+	</LOANINFO>
+	<INV401KSUMMARY>
+		<YEARTODATE>
+			<DTSTART>20170101</DTSTART>
+			<DTEND>20170331</DTEND>
+			<WITHDRAWALS>
+				<PRETAX>250</PRETAX>
+				<TOTAL>250</TOTAL>
+			</WITHDRAWALS>
+		</YEARTODATE>
+	</INV401KSUMMARY>
+</INV401K>`
+
+	var inv401k Inv401K
+	if err := xml.Unmarshal([]byte(input), &inv401k); err != nil {
+		t.Fatalf("Unexpected error unmarshalling INV401K: %s\n", err)
+	}
+	if len(inv401k.ContribInfo) != 1 || inv401k.ContribInfo[0].SecID.UniqueID != "922908363" {
+		t.Errorf("Expected CONTRIBINFO to be unmarshalled, got %v\n", inv401k.ContribInfo)
+	}
+	if len(inv401k.LoanInfo) != 1 || inv401k.LoanInfo[0].LoanID != "1" {
+		t.Errorf("Expected LOANINFO to be unmarshalled, got %v\n", inv401k.LoanInfo)
+	}
+	withdrawals := inv401k.YearToDateSummary.Withdrawls
+	if withdrawals == nil || withdrawals.Total.String() != "250" {
+		t.Errorf("Expected WITHDRAWALS to be unmarshalled, got %v\n", withdrawals)
+	}
+
+	b, err := xml.Marshal(&inv401k)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling INV401K: %s\n", err)
+	}
+	for _, element := range []string{"<CONTRIBINFO><CONTRIBSECURITY>", "<LOANINFO><LOANID>1</LOANID>", "<WITHDRAWALS><PRETAX>250</PRETAX>"} {
+		if !strings.Contains(string(b), element) {
+			t.Errorf("Expected marshalled INV401K to contain %s, got %s\n", element, b)
+		}
+	}
+}
@@ -0,0 +1,518 @@
+package ofxgo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aclindsa/xml"
+)
+
+// ParseOptions control how ParseResponseWithOptions parses a response
+type ParseOptions struct {
+	// Lenient enables repairing common defects in responses from FIs which
+	// don't follow the spec closely, rather than failing to parse them. Each
+	// repair made is reported as a Diagnostic. Defects repaired include
+	// malformed SGML headers, aggregates which are never closed, closing tags
+	// which don't match any open element, stray text between elements,
+	// malformed dates, transactions placed in the wrong message set, and data
+	// following the closing OFX element.
+	Lenient bool
+	// SecurityIDCheck, if non-nil, is called with each distinct SecurityID
+	// (once normalized, see SecurityID.Normalize) in the response's security
+	// lists, investment transactions, and positions. Errors it returns are
+	// reported as validation failures. Set it to CheckSecurityID to reject
+	// CUSIPs, ISINs, and SEDOLs with incorrect check digits; they aren't
+	// checked by default because many FIs put other identifiers (i.e. option
+	// symbols or their own fund codes) in UNIQUEIDs with a UNIQUEIDTYPE of
+	// CUSIP.
+	SecurityIDCheck func(id SecurityID) error
+}
+
+// Diagnostic describes one problem found in a response by
+// ParseResponseWithOptions, and how it was repaired if it was
+type Diagnostic struct {
+	Path    string // Slash-separated path of the element the problem was found in, i.e. OFX/BANKMSGSRSV1/STMTTRNRS
+	Offset  int64  // Byte offset into the response where the problem was found, or -1 if it isn't known
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	s := d.Message
+	if len(d.Path) > 0 {
+		s = d.Path + ": " + s
+	}
+	if d.Offset >= 0 {
+		s = fmt.Sprintf("offset %d: %s", d.Offset, s)
+	}
+	return s
+}
+
+// ParseResponseWithOptions parses and validates an OFX response in SGML or XML
+// from the given io.Reader, like ParseResponse, but returns each problem found
+// as a separate Diagnostic rather than combining them into one error.
+//
+// If options.Lenient is false, the returned error is the same as would be
+// returned by ParseResponse, and the Diagnostics contain the validation
+// failures individually. If options.Lenient is true, defects in the response
+// are repaired where possible (see ParseOptions), and a non-nil error is only
+// returned if the response couldn't be parsed at all. Validation failures are
+// then only reported as Diagnostics.
+func ParseResponseWithOptions(reader io.Reader, options ParseOptions) (*Response, []Diagnostic, error) {
+	var or Response
+
+	rd, err := newResponseDecoder(reader, options.Lenient)
+	if err != nil {
+		return nil, nil, err
+	}
+	or.Version = rd.version
+	diagnostics := rd.diagnostics
+
+	if options.Lenient {
+		repairer := newRepairingTokenReader(rd)
+		err = decodeResponseBody(xml.NewDecoder(repairer), &or)
+		if err == nil {
+			// Read the remainder so anything following the closing OFX
+			// element is diagnosed
+			_, err = io.Copy(ioutil.Discard, repairer)
+		}
+		diagnostics = append(diagnostics, repairer.diagnostics...)
+	} else {
+		err = decodeResponseBody(rd.Decoder, &or)
+	}
+	if err != nil {
+		return nil, diagnostics, rd.position.wrap(err)
+	}
+
+	validation := or.validationDiagnostics()
+	diagnostics = append(diagnostics, validation...)
+	if len(validation) > 0 && !options.Lenient {
+		_, err = or.Valid()
+	}
+	if options.SecurityIDCheck != nil {
+		idDiagnostics := or.securityIDDiagnostics(options.SecurityIDCheck)
+		diagnostics = append(diagnostics, idDiagnostics...)
+		if len(idDiagnostics) > 0 && !options.Lenient && err == nil {
+			err = diagnosticsErr(idDiagnostics)
+		}
+	}
+	return &or, diagnostics, err
+}
+
+// validationDiagnostics validates the signon and each message in or, returning
+// a Diagnostic for each validation failure
+func (or *Response) validationDiagnostics() []Diagnostic {
+	var diagnostics []Diagnostic
+	var add func(path string, err error)
+	add = func(path string, err error) {
+		if errs, ok := err.(errInvalid); ok {
+			for _, err := range errs {
+				add(path, err)
+			}
+		} else if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{Path: path, Offset: -1, Message: err.Error()})
+		}
+	}
+
+	if ok, err := or.Signon.Valid(or.Version); !ok {
+		add("OFX/"+SignonRs.String()+"/"+or.Signon.Name(), err)
+	}
+	for _, set := range responseMessageSets(or) {
+		for _, message := range *set {
+			if ok, err := message.Valid(or.Version); !ok {
+				add("OFX/"+message.Type().String()+"/"+message.Name(), err)
+			}
+		}
+	}
+	return diagnostics
+}
+
+// diagnosticsErr returns an error listing diagnostics
+func diagnosticsErr(diagnostics []Diagnostic) error {
+	var errs errInvalid
+	for _, d := range diagnostics {
+		errs.AddErr(errors.New(d.String()))
+	}
+	return errs
+}
+
+// securityIDErr calls check with each distinct SecurityID referenced by or,
+// returning an error listing those it rejected, or nil if there were none
+func (or *Response) securityIDErr(check func(id SecurityID) error) error {
+	if diagnostics := or.securityIDDiagnostics(check); len(diagnostics) > 0 {
+		return diagnosticsErr(diagnostics)
+	}
+	return nil
+}
+
+// securityIDDiagnostics calls check with each distinct SecurityID referenced
+// by the security lists, investment transactions, and positions in or,
+// returning a Diagnostic for each error it returns
+func (or *Response) securityIDDiagnostics(check func(id SecurityID) error) []Diagnostic {
+	var diagnostics []Diagnostic
+	checked := make(map[SecurityID]bool)
+	checkID := func(path string, id SecurityID) {
+		id = id.Normalize()
+		id.XMLName = xml.Name{}
+		if checked[id] {
+			return
+		}
+		checked[id] = true
+		if err := check(id); err != nil {
+			diagnostics = append(diagnostics, Diagnostic{Path: path, Offset: -1, Message: err.Error()})
+		}
+	}
+
+	for _, set := range responseMessageSets(or) {
+		for _, message := range *set {
+			path := "OFX/" + message.Type().String() + "/" + message.Name()
+			switch m := message.(type) {
+			case *SecurityList:
+				for _, security := range m.Securities {
+					checkID(path, security.SecurityInfo().SecID)
+				}
+			case *InvStatementResponse:
+				if m.InvTranList != nil {
+					for _, tran := range m.InvTranList.InvTransactions {
+						if id, ok := transactionSecurityID(tran); ok {
+							checkID(path, id)
+						}
+					}
+				}
+				for _, position := range m.InvPosList {
+					checkID(path, position.InvPosition().SecID)
+				}
+			}
+		}
+	}
+	return diagnostics
+}
+
+var ofxLeafElementSet = func() map[string]bool {
+	set := make(map[string]bool, len(ofxLeafElements))
+	for _, name := range ofxLeafElements {
+		set[name] = true
+	}
+	return set
+}()
+
+// repairEntry is one open element tracked by repairingTokenReader
+type repairEntry struct {
+	name    string
+	leaf    bool   // Listed in ofxLeafElements, or found to contain character data
+	parent  bool   // Has had child elements
+	skip    bool   // Neither this element nor its contents are passed on
+	emitted bool   // Whether the StartElement has been passed on (dates are held until they're checked)
+	reopen  string // For message sets opened to relocate a transaction, the message set to reopen after it
+}
+
+// repairingTokenReader reads raw tokens from an OFX response's body and
+// repairs them into a well-formed token stream (with all leaf elements
+// closed), recording a Diagnostic for each repair made. The repaired tokens
+// are read back as XML, since xml.NewTokenDecoder doesn't support decoding
+// into types implementing xml.Unmarshaler.
+type repairingTokenReader struct {
+	d           *xml.Decoder
+	buf         bytes.Buffer
+	encoder     *xml.Encoder
+	sgml        bool
+	headerLen   int64
+	offset      int64 // Offset of the token currently being repaired
+	stack       []repairEntry
+	queue       []xml.Token
+	done        bool // The closing OFX element has been seen
+	eof         bool
+	diagnostics []Diagnostic
+}
+
+func newRepairingTokenReader(rd *responseDecoder) *repairingTokenReader {
+	r := &repairingTokenReader{
+		d:         rd.Decoder,
+		sgml:      rd.sgml,
+		headerLen: rd.headerLen,
+	}
+	r.encoder = xml.NewEncoder(&r.buf)
+	return r
+}
+
+// Read implements io.Reader, returning the repaired tokens encoded as XML
+func (r *repairingTokenReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		tok, err := r.Token()
+		if err != nil {
+			return 0, err
+		}
+		if err := r.encoder.EncodeToken(tok); err != nil {
+			return 0, err
+		}
+		if err := r.encoder.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	return r.buf.Read(p)
+}
+
+// Token returns the next repaired token
+func (r *repairingTokenReader) Token() (xml.Token, error) {
+	for len(r.queue) == 0 {
+		if err := r.next(); err != nil {
+			return nil, err
+		}
+	}
+	tok := r.queue[0]
+	r.queue = r.queue[1:]
+	return tok, nil
+}
+
+func (r *repairingTokenReader) diagnose(message string) {
+	names := make([]string, len(r.stack))
+	for i := range r.stack {
+		names[i] = r.stack[i].name
+	}
+	r.diagnostics = append(r.diagnostics, Diagnostic{
+		Path:    strings.Join(names, "/"),
+		Offset:  r.offset,
+		Message: message,
+	})
+}
+
+func (r *repairingTokenReader) emit(tok xml.Token) {
+	r.queue = append(r.queue, tok)
+}
+
+func (r *repairingTokenReader) top() *repairEntry {
+	if len(r.stack) == 0 {
+		return nil
+	}
+	return &r.stack[len(r.stack)-1]
+}
+
+func (r *repairingTokenReader) skipping() bool {
+	top := r.top()
+	return top != nil && top.skip
+}
+
+// push opens a new element, passing it on unless it is being skipped or is a
+// date which must be checked first
+func (r *repairingTokenReader) push(name string, skip bool) {
+	entry := repairEntry{
+		name: name,
+		leaf: ofxLeafElementSet[name],
+		skip: skip || r.skipping(),
+	}
+	if top := r.top(); top != nil {
+		top.parent = true
+	}
+	if !entry.skip && !(entry.leaf && strings.HasPrefix(name, "DT")) {
+		entry.emitted = true
+		r.emit(xml.StartElement{Name: xml.Name{Local: name}})
+	}
+	r.stack = append(r.stack, entry)
+}
+
+// pop closes the innermost open element
+func (r *repairingTokenReader) pop() {
+	e := r.stack[len(r.stack)-1]
+	if e.leaf && !e.emitted && !e.skip {
+		r.diagnose("Dropped date element with no value")
+	}
+	r.stack = r.stack[:len(r.stack)-1]
+	if e.emitted {
+		r.emit(xml.EndElement{Name: xml.Name{Local: e.name}})
+	}
+	if len(r.stack) == 0 && e.name == "OFX" {
+		r.done = true
+	}
+
+	// If this was a transaction relocated to another message set, close that
+	// set and reopen the one it was originally found in
+	if top := r.top(); top != nil && len(top.reopen) > 0 {
+		wrapper := *top
+		r.stack = r.stack[:len(r.stack)-1]
+		r.emit(xml.EndElement{Name: xml.Name{Local: wrapper.name}})
+		r.push(wrapper.reopen, false)
+	}
+}
+
+func (r *repairingTokenReader) next() error {
+	if r.eof {
+		return io.EOF
+	}
+
+	r.offset = r.headerLen + r.d.InputOffset()
+	tok, err := r.d.RawToken()
+	if err == io.EOF {
+		r.eof = true
+		if len(r.stack) > 0 {
+			r.diagnose(fmt.Sprintf("Unexpected end of input with %d elements open, closing them", len(r.stack)))
+			for len(r.stack) > 0 {
+				r.pop()
+			}
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	tok = xml.CopyToken(tok)
+
+	if r.done {
+		if chars, ok := tok.(xml.CharData); ok && len(bytes.TrimSpace(chars)) == 0 {
+			return nil
+		}
+		r.diagnose("Ignored data following closing OFX element")
+		r.eof = true
+		return nil
+	}
+
+	switch t := tok.(type) {
+	case xml.StartElement:
+		r.start(t.Name.Local)
+	case xml.EndElement:
+		r.end(t.Name.Local)
+	case xml.CharData:
+		r.charData(t)
+	}
+	// Comments, processing instructions, and directives are dropped
+	return nil
+}
+
+func (r *repairingTokenReader) start(name string) {
+	// Leaf elements are not closed in SGML, so any element beginning closes
+	// the open leaf element
+	if top := r.top(); top != nil && top.leaf {
+		if !r.sgml && !top.skip {
+			r.diagnose("Closed unclosed element " + top.name)
+		}
+		r.pop()
+	}
+
+	parent := r.top()
+	if parent == nil || parent.skip {
+		r.push(name, false)
+		return
+	}
+
+	if parent.name == "OFX" && name != SignonRs.String() {
+		if _, ok := responseTypes[name]; !ok {
+			r.diagnose("Skipped unknown message set " + name)
+			r.push(name, true)
+			return
+		}
+	}
+
+	if setTypes, ok := responseTypes[parent.name]; ok {
+		if _, ok := setTypes[name]; !ok {
+			for setName, types := range responseTypes {
+				if _, ok := types[name]; ok {
+					r.diagnose("Moved " + name + " to " + setName)
+					parentName := parent.name
+					r.pop()
+					r.stack = append(r.stack, repairEntry{name: setName, emitted: true, reopen: parentName})
+					r.emit(xml.StartElement{Name: xml.Name{Local: setName}})
+					r.push(name, false)
+					return
+				}
+			}
+			r.diagnose("Skipped unsupported element " + name)
+			r.push(name, true)
+			return
+		}
+	}
+
+	r.push(name, false)
+}
+
+func (r *repairingTokenReader) end(name string) {
+	i := len(r.stack) - 1
+	for ; i >= 0; i-- {
+		if r.stack[i].name == name {
+			break
+		}
+	}
+	if i < 0 {
+		if !r.skipping() {
+			r.diagnose("Dropped closing tag for element which isn't open: " + name)
+		}
+		return
+	}
+
+	for len(r.stack) > i+1 {
+		top := r.top()
+		if !top.skip && (!top.leaf || !r.sgml) {
+			r.diagnose("Closed unclosed element " + top.name)
+		}
+		r.pop()
+	}
+	r.pop()
+}
+
+func (r *repairingTokenReader) charData(t xml.CharData) {
+	top := r.top()
+	if top == nil || top.skip {
+		return
+	}
+
+	trimmed := bytes.TrimSpace(t)
+	if !top.leaf && !top.parent && len(trimmed) > 0 {
+		// Not all leaf elements added since OFX 1.0.3 are listed in
+		// ofxLeafElements
+		top.leaf = true
+	}
+
+	if top.leaf {
+		if !top.emitted {
+			// Dates are held back until they can be checked
+			value := strings.TrimSpace(string(t))
+			if _, err := parseDate(value); err != nil {
+				if repaired, ok := repairDate(value); ok {
+					r.diagnose(fmt.Sprintf("Repaired malformed date %q as %s", value, repaired))
+					t = xml.CharData(repaired)
+				} else {
+					r.diagnose(fmt.Sprintf("Dropped element with unparseable date %q", value))
+					top.skip = true
+					return
+				}
+			}
+			top.emitted = true
+			r.emit(xml.StartElement{Name: xml.Name{Local: top.name}})
+		}
+		r.emit(t)
+		return
+	}
+
+	if len(trimmed) > 0 {
+		r.diagnose(fmt.Sprintf("Dropped stray text %q", trimmed))
+		return
+	}
+	r.emit(t)
+}
+
+var (
+	separatedDateRegex = regexp.MustCompile(`^([0-9]{4})-([0-9]{2})-([0-9]{2})(?:[T ]([0-9]{2}):([0-9]{2})(?::([0-9]{2}))?)?(\[.*\])?$`)
+	slashedDateRegex   = regexp.MustCompile(`^([0-9]{1,2})/([0-9]{1,2})/([0-9]{4})$`)
+)
+
+// repairDate attempts to convert a date in one of the formats commonly (but
+// incorrectly) used by FIs, such as 2006-01-02T15:04:05 or 01/02/2006, into
+// the format required by the OFX spec
+func repairDate(value string) (string, bool) {
+	var repaired string
+	if m := separatedDateRegex.FindStringSubmatch(value); m != nil {
+		repaired = strings.Join(m[1:], "")
+	} else if m := slashedDateRegex.FindStringSubmatch(value); m != nil {
+		month, _ := strconv.Atoi(m[1])
+		day, _ := strconv.Atoi(m[2])
+		repaired = fmt.Sprintf("%s%02d%02d", m[3], month, day)
+	} else {
+		return "", false
+	}
+	if _, err := parseDate(repaired); err != nil {
+		return "", false
+	}
+	return repaired, true
+}
@@ -0,0 +1,310 @@
+package ofxgo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const lenientTestHeader = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+SECURITY:NONE
+ENCODING:USASCII
+CHARSET:1252
+COMPRESSION:NONE
+OLDFILEUID:NONE
+NEWFILEUID:NONE
+
+`
+
+const lenientTestSignon = `<OFX>
+<SIGNONMSGSRSV1>
+<SONRS>
+<STATUS>
+<CODE>0
+<SEVERITY>INFO
+</STATUS>
+<DTSERVER>20170407001840
+<LANGUAGE>ENG
+</SONRS>
+</SIGNONMSGSRSV1>
+`
+
+// lenientTestStatement returns a bank statement response containing one
+// transaction posted on dtPosted, with extra inserted just before BANKTRANLIST
+func lenientTestStatement(dtPosted, extra string) string {
+	return `<STMTTRNRS>
+<TRNUID>1001
+<STATUS>
+<CODE>0
+<SEVERITY>INFO
+</STATUS>
+<STMTRS>
+<CURDEF>USD
+<BANKACCTFROM>
+<BANKID>318398732
+<ACCTID>78346129
+<ACCTTYPE>CHECKING
+</BANKACCTFROM>` + extra + `
+<BANKTRANLIST>
+<DTSTART>20170101
+<DTEND>20170201
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>` + dtPosted + `
+<TRNAMT>-50.00
+<FITID>2017011701
+<NAME>Groceries
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL>
+<BALAMT>100.00
+<DTASOF>20170201
+</LEDGERBAL>
+</STMTRS>
+</STMTTRNRS>
+`
+}
+
+func lenientTestResponse(set, dtPosted, extra string) string {
+	return lenientTestHeader + lenientTestSignon + "<" + set + ">\n" +
+		lenientTestStatement(dtPosted, extra) + "</" + set + ">\n</OFX>\n"
+}
+
+// findDiagnostic returns the first diagnostic whose message contains message
+func findDiagnostic(diagnostics []Diagnostic, message string) *Diagnostic {
+	for i := range diagnostics {
+		if strings.Contains(diagnostics[i].Message, message) {
+			return &diagnostics[i]
+		}
+	}
+	return nil
+}
+
+func TestParseResponseWithOptionsSamples(t *testing.T) {
+	fn := func(path string, info os.FileInfo, err error) error {
+		if info.IsDir() {
+			return nil
+		} else if ext := filepath.Ext(path); ext != ".ofx" && ext != ".qfx" {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Unexpected error opening %s: %s\n", path, err)
+		}
+		defer file.Close()
+		expected, err := DecodeResponse(file)
+		if err != nil {
+			t.Fatalf("Unexpected error decoding %s: %s\n", path, err)
+		}
+
+		for _, lenient := range []bool{false, true} {
+			file.Seek(0, 0)
+			response, diagnostics, err := ParseResponseWithOptions(file, ParseOptions{Lenient: lenient})
+			if err != nil {
+				t.Fatalf("Unexpected error parsing %s (lenient=%t): %s\n", path, lenient, err)
+			}
+			for _, d := range diagnostics {
+				t.Errorf("Unexpected diagnostic parsing %s (lenient=%t): %s\n", path, lenient, d)
+			}
+			checkResponsesEqual(t, expected, response)
+		}
+		return nil
+	}
+	filepath.Walk("samples/valid_responses", fn)
+	filepath.Walk("samples/busted_responses", fn)
+}
+
+func TestParseResponseWithOptionsRepairs(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		message    string // Expected diagnostic message
+		path       string // Expected diagnostic path
+		strictFail bool   // Whether parsing in strict mode fails
+	}{
+		{
+			name:       "unclosed aggregate",
+			input:      strings.Replace(lenientTestResponse("BANKMSGSRSV1", "20170117", ""), "</BANKTRANLIST>\n", "", 1),
+			message:    "Closed unclosed element BANKTRANLIST",
+			path:       "OFX/BANKMSGSRSV1/STMTTRNRS/STMTRS/BANKTRANLIST",
+			strictFail: true,
+		},
+		{
+			name:       "stray text",
+			input:      lenientTestResponse("BANKMSGSRSV1", "20170117", "\nbalance follows"),
+			message:    `Dropped stray text "balance follows"`,
+			path:       "OFX/BANKMSGSRSV1/STMTTRNRS/STMTRS",
+			strictFail: false, // Silently ignored by the non-strict SGML decoder
+		},
+		{
+			name:       "separated date",
+			input:      lenientTestResponse("BANKMSGSRSV1", "2017-01-17T12:00:00", ""),
+			message:    `Repaired malformed date "2017-01-17T12:00:00" as 20170117120000`,
+			path:       "OFX/BANKMSGSRSV1/STMTTRNRS/STMTRS/BANKTRANLIST/STMTTRN/DTPOSTED",
+			strictFail: true,
+		},
+		{
+			name:       "slashed date",
+			input:      lenientTestResponse("BANKMSGSRSV1", "1/17/2017", ""),
+			message:    `Repaired malformed date "1/17/2017" as 20170117`,
+			path:       "OFX/BANKMSGSRSV1/STMTTRNRS/STMTRS/BANKTRANLIST/STMTTRN/DTPOSTED",
+			strictFail: true,
+		},
+		{
+			name:       "misplaced transaction",
+			input:      lenientTestResponse("CREDITCARDMSGSRSV1", "20170117", ""),
+			message:    "Moved STMTTRNRS to BANKMSGSRSV1",
+			path:       "OFX/CREDITCARDMSGSRSV1",
+			strictFail: true,
+		},
+		{
+			name:       "unmatched closing tag",
+			input:      lenientTestResponse("BANKMSGSRSV1", "20170117", "\n</BANKINFO>"),
+			message:    "Dropped closing tag for element which isn't open: BANKINFO",
+			path:       "OFX/BANKMSGSRSV1/STMTTRNRS/STMTRS",
+			strictFail: false, // Silently tolerated by the non-strict SGML decoder
+		},
+		{
+			name:       "trailing data",
+			input:      lenientTestResponse("BANKMSGSRSV1", "20170117", "") + "<OFX>\n",
+			message:    "Ignored data following closing OFX element",
+			path:       "",
+			strictFail: false,
+		},
+		{
+			name:       "truncated",
+			input:      strings.SplitAfter(lenientTestResponse("BANKMSGSRSV1", "20170117", ""), "</LEDGERBAL>\n")[0],
+			message:    "Unexpected end of input with 4 elements open, closing them",
+			path:       "OFX/BANKMSGSRSV1/STMTTRNRS/STMTRS",
+			strictFail: true,
+		},
+		{
+			name:       "malformed header",
+			input:      strings.Replace(lenientTestResponse("BANKMSGSRSV1", "20170117", ""), "COMPRESSION:NONE", "COMPRESSION:GZIP", 1),
+			message:    "OFX COMPRESSION header not NONE; assuming OFX version 102",
+			path:       "",
+			strictFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		_, _, err := ParseResponseWithOptions(strings.NewReader(test.input), ParseOptions{})
+		if test.strictFail && err == nil {
+			t.Errorf("%s: expected error parsing in strict mode\n", test.name)
+		}
+
+		response, diagnostics, err := ParseResponseWithOptions(strings.NewReader(test.input), ParseOptions{Lenient: true})
+		if err != nil {
+			t.Errorf("%s: unexpected error parsing leniently: %s\n", test.name, err)
+			continue
+		}
+		d := findDiagnostic(diagnostics, test.message)
+		if d == nil {
+			t.Errorf("%s: expected diagnostic %q, got %v\n", test.name, test.message, diagnostics)
+			continue
+		}
+		if d.Path != test.path {
+			t.Errorf("%s: expected diagnostic path %q, got %q\n", test.name, test.path, d.Path)
+		}
+		if d.Offset < 0 || d.Offset > int64(len(test.input)) {
+			t.Errorf("%s: diagnostic offset %d out of range\n", test.name, d.Offset)
+		}
+
+		if len(response.Bank) != 1 {
+			t.Errorf("%s: expected 1 bank message, got %d\n", test.name, len(response.Bank))
+			continue
+		}
+		stmt, ok := response.Bank[0].(*StatementResponse)
+		if !ok {
+			t.Errorf("%s: expected *StatementResponse, got %T\n", test.name, response.Bank[0])
+			continue
+		}
+		if stmt.BankTranList == nil || len(stmt.BankTranList.Transactions) != 1 {
+			t.Errorf("%s: expected 1 transaction\n", test.name)
+			continue
+		}
+		tran := stmt.BankTranList.Transactions[0]
+		if !tran.DtPosted.Equal(*NewDateGMT(2017, 1, 17, tran.DtPosted.Hour(), 0, 0, 0)) {
+			t.Errorf("%s: unexpected DTPOSTED %s\n", test.name, tran.DtPosted)
+		}
+	}
+}
+
+func TestParseResponseWithOptionsOffset(t *testing.T) {
+	input := lenientTestResponse("BANKMSGSRSV1", "20170117", "garbage")
+	_, diagnostics, err := ParseResponseWithOptions(strings.NewReader(input), ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	d := findDiagnostic(diagnostics, "Dropped stray text")
+	if d == nil {
+		t.Fatalf("Expected stray text diagnostic, got %v\n", diagnostics)
+	}
+	if expected := int64(strings.Index(input, "garbage")); d.Offset != expected {
+		t.Errorf("Expected offset %d, got %d\n", expected, d.Offset)
+	}
+}
+
+func TestParseResponseWithOptionsUnparseableDate(t *testing.T) {
+	input := lenientTestResponse("BANKMSGSRSV1", "yesterday", "")
+	response, diagnostics, err := ParseResponseWithOptions(strings.NewReader(input), ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if d := findDiagnostic(diagnostics, `Dropped element with unparseable date "yesterday"`); d == nil {
+		t.Fatalf("Expected unparseable date diagnostic, got %v\n", diagnostics)
+	}
+	tran := response.Bank[0].(*StatementResponse).BankTranList.Transactions[0]
+	if !tran.DtPosted.IsZero() {
+		t.Errorf("Expected DTPOSTED to be dropped, got %s\n", tran.DtPosted)
+	}
+	if tran.FiTID != "2017011701" {
+		t.Errorf("Expected remainder of transaction to be parsed, got FITID %s\n", tran.FiTID)
+	}
+}
+
+func TestParseResponseWithOptionsSkipsUnsupported(t *testing.T) {
+	unsupported := "<INTRARS>\n<SRVRTID>1234\n<XFERINFO>\n<TRNAMT>5.00\n</XFERINFO>\n</INTRARS>\n"
+	input := strings.Replace(lenientTestResponse("BANKMSGSRSV1", "20170117", ""), "<STMTTRNRS>", unsupported+"<STMTTRNRS>", 1)
+	response, diagnostics, err := ParseResponseWithOptions(strings.NewReader(input), ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	d := findDiagnostic(diagnostics, "Skipped unsupported element INTRARS")
+	if d == nil {
+		t.Fatalf("Expected unsupported element diagnostic, got %v\n", diagnostics)
+	}
+	if d.Path != "OFX/BANKMSGSRSV1" {
+		t.Errorf("Unexpected diagnostic path %q\n", d.Path)
+	}
+	if len(diagnostics) != 1 {
+		t.Errorf("Expected only one diagnostic, got %v\n", diagnostics)
+	}
+	if len(response.Bank) != 1 {
+		t.Errorf("Expected 1 bank message, got %d\n", len(response.Bank))
+	}
+}
+
+func TestParseResponseWithOptionsValidation(t *testing.T) {
+	input := strings.Replace(lenientTestResponse("BANKMSGSRSV1", "20170117", ""), "<TRNUID>1001", "<TRNUID>", 1)
+	for _, lenient := range []bool{false, true} {
+		response, diagnostics, err := ParseResponseWithOptions(strings.NewReader(input), ParseOptions{Lenient: lenient})
+		if lenient && err != nil {
+			t.Errorf("Unexpected error parsing leniently: %s\n", err)
+		} else if !lenient && err == nil {
+			t.Errorf("Expected validation error parsing strictly\n")
+		}
+		if response == nil {
+			t.Fatalf("Expected response despite validation failure\n")
+		}
+		if len(diagnostics) != 1 {
+			t.Fatalf("Expected 1 diagnostic, got %v\n", diagnostics)
+		}
+		if diagnostics[0].Path != "OFX/BANKMSGSRSV1/STMTTRNRS" || diagnostics[0].Offset != -1 {
+			t.Errorf("Unexpected validation diagnostic: %s\n", diagnostics[0])
+		}
+	}
+}
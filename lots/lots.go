@@ -0,0 +1,526 @@
+// Package lots tracks the cost basis of investment holdings as tax lots,
+// computing realized gains and losses from the transactions in one or more
+// InvTranList aggregates. Lots are matched to sales using FIFO, LIFO,
+// specific identification, or average cost, and realized gains are split into
+// short and long term according to how long each lot was held.
+//
+// Only long positions are tracked. Short sales (and the purchases covering
+// them) are reported as errors rather than being guessed at.
+package lots
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/aclindsa/ofxgo"
+)
+
+// Method determines which open lots are disposed of first when a security is
+// sold or transferred out
+type Method int
+
+// Methods of choosing the lots disposed of by a sale
+const (
+	FIFO        Method = iota // First in, first out: the oldest lots are sold first
+	LIFO                      // Last in, first out: the newest lots are sold first
+	SpecificID                // Lots are chosen by Tracker.Selections, falling back to FIFO
+	AverageCost               // Each unit's basis is the average of all open lots, with holding periods determined FIFO
+)
+
+var methods = [...]string{"FIFO", "LIFO", "SpecificID", "AverageCost"}
+
+func (m Method) String() string {
+	if m >= FIFO && m <= AverageCost {
+		return methods[m]
+	}
+	return fmt.Sprintf("invalid Method (%d)", int(m))
+}
+
+// Lot is a quantity of one security acquired at the same time and cost
+type Lot struct {
+	SecID      ofxgo.SecurityID
+	FiTID      string // FITID of the transaction which opened this lot
+	DtAcquired time.Time
+	Units      ofxgo.Amount
+	CostBasis  ofxgo.Amount // Total cost basis of Units, including commissions and fees
+}
+
+// Gain is the gain (or loss, if negative) realized by disposing of some or all
+// of a single lot
+type Gain struct {
+	SecID      ofxgo.SecurityID
+	FiTID      string // FITID of the transaction which realized the gain
+	LotFiTID   string // FITID of the transaction which opened the lot
+	DtAcquired time.Time
+	DtSold     time.Time
+	Units      ofxgo.Amount
+	Proceeds   ofxgo.Amount
+	CostBasis  ofxgo.Amount
+	Gain       ofxgo.Amount
+	LongTerm   bool // The lot was held for more than one year
+}
+
+// Selection specifies how many units of a lot (identified by the FITID of the
+// transaction which opened it) to sell when using the SpecificID method
+type Selection struct {
+	LotFiTID string
+	Units    ofxgo.Amount
+}
+
+// ErrShortPosition is returned for transactions which open or close short
+// positions, which are not tracked
+var ErrShortPosition = errors.New("lots: short positions are not supported")
+
+// lot is the internal representation of a Lot, using *big.Rat so that its
+// values can be updated in place without aliasing those of returned Lots
+type lot struct {
+	secID      ofxgo.SecurityID
+	fiTID      string
+	dtAcquired time.Time
+	units      *big.Rat
+	costBasis  *big.Rat
+}
+
+type securityKey struct {
+	uniqueIDType string
+	uniqueID     string
+}
+
+func newSecurityKey(id ofxgo.SecurityID) securityKey {
+	id = id.Normalize()
+	return securityKey{uniqueIDType: string(id.UniqueIDType), uniqueID: string(id.UniqueID)}
+}
+
+// Tracker maintains the open lots for each security in an account as
+// investment transactions are added to it, recording the gains realized along
+// the way. The zero value is a Tracker using FIFO, ready to use.
+type Tracker struct {
+	Method Method
+	// Selections maps the FITID of a sale to the lots it should dispose of
+	// when Method is SpecificID. Sales without an entry are matched FIFO.
+	Selections map[string][]Selection
+
+	lots  map[securityKey][]*lot // Open lots for each security, in the order they were acquired
+	gains []Gain
+}
+
+// NewTracker returns an empty Tracker using method to match sales to lots
+func NewTracker(method Method) *Tracker {
+	return &Tracker{Method: method}
+}
+
+// AddList adds all the transactions in list to the Tracker in chronological
+// order (by trade date, keeping the order the FI listed them in for
+// transactions on the same day). It stops at the first transaction which
+// can't be applied, returning its error.
+func (t *Tracker) AddList(list *ofxgo.InvTranList) error {
+	transactions := make([]ofxgo.InvTransaction, len(list.InvTransactions))
+	copy(transactions, list.InvTransactions)
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].InvTransaction().DtTrade.Before(transactions[j].InvTransaction().DtTrade.Time)
+	})
+	for _, tran := range transactions {
+		if err := t.Add(tran); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add applies one investment transaction to the Tracker. Transactions must be
+// added in chronological order. Transactions which don't affect the units or
+// cost basis of a security (i.e. Income or MarginInterest) are ignored.
+func (t *Tracker) Add(tran ofxgo.InvTransaction) error {
+	switch tr := tran.(type) {
+	case ofxgo.BuyDebt:
+		return t.buy(tr.InvBuy)
+	case ofxgo.BuyMF:
+		if tr.BuyType == ofxgo.BuyTypeBuyToCover {
+			return ErrShortPosition
+		}
+		return t.buy(tr.InvBuy)
+	case ofxgo.BuyOpt:
+		if tr.OptBuyType == ofxgo.OptBuyTypeBuyToClose {
+			return ErrShortPosition
+		}
+		return t.buy(tr.InvBuy)
+	case ofxgo.BuyOther:
+		return t.buy(tr.InvBuy)
+	case ofxgo.BuyStock:
+		if tr.BuyType == ofxgo.BuyTypeBuyToCover {
+			return ErrShortPosition
+		}
+		return t.buy(tr.InvBuy)
+	case ofxgo.SellDebt:
+		return t.sell(tr.InvSell)
+	case ofxgo.SellMF:
+		if tr.SellType == ofxgo.SellTypeSellShort {
+			return ErrShortPosition
+		}
+		return t.sell(tr.InvSell)
+	case ofxgo.SellOpt:
+		if tr.OptSellType == ofxgo.OptSellTypeSellToOpen {
+			return ErrShortPosition
+		}
+		return t.sell(tr.InvSell)
+	case ofxgo.SellOther:
+		return t.sell(tr.InvSell)
+	case ofxgo.SellStock:
+		if tr.SellType == ofxgo.SellTypeSellShort {
+			return ErrShortPosition
+		}
+		return t.sell(tr.InvSell)
+	case ofxgo.Reinvest:
+		t.open(tr.SecID, tr.InvTran, tr.InvTran.DtTrade.Time, abs(&tr.Units.Rat), abs(&tr.Total.Rat))
+		return nil
+	case ofxgo.Split:
+		return t.split(tr)
+	case ofxgo.Transfer:
+		return t.transfer(tr)
+	case ofxgo.RetOfCap:
+		return t.returnOfCapital(tr)
+	case ofxgo.ClosureOpt:
+		return t.closeOption(tr)
+	}
+	return nil
+}
+
+// OpenLots returns the lots still held, ordered by security and then by the
+// order in which they were acquired
+func (t *Tracker) OpenLots() []Lot {
+	keys := make([]securityKey, 0, len(t.lots))
+	for key := range t.lots {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].uniqueIDType != keys[j].uniqueIDType {
+			return keys[i].uniqueIDType < keys[j].uniqueIDType
+		}
+		return keys[i].uniqueID < keys[j].uniqueID
+	})
+
+	var lots []Lot
+	for _, key := range keys {
+		for _, l := range t.lots[key] {
+			lot := Lot{
+				SecID:      l.secID,
+				FiTID:      l.fiTID,
+				DtAcquired: l.dtAcquired,
+			}
+			lot.Units.Set(l.units)
+			lot.CostBasis.Set(l.costBasis)
+			lots = append(lots, lot)
+		}
+	}
+	return lots
+}
+
+// Gains returns the gains realized so far, in the order they were realized
+func (t *Tracker) Gains() []Gain {
+	gains := make([]Gain, len(t.gains))
+	copy(gains, t.gains)
+	return gains
+}
+
+// Units returns the total number of units of the security identified by id
+// which are held
+func (t *Tracker) Units(id ofxgo.SecurityID) ofxgo.Amount {
+	var units ofxgo.Amount
+	for _, l := range t.lots[newSecurityKey(id)] {
+		units.Add(&units.Rat, l.units)
+	}
+	return units
+}
+
+func abs(r *big.Rat) *big.Rat {
+	return new(big.Rat).Abs(r)
+}
+
+// purchaseBasis returns the cost basis of a purchase: the magnitude of its
+// TOTAL, or if the FI didn't provide one, what it should have been
+func purchaseBasis(b ofxgo.InvBuy) *big.Rat {
+	if b.Total.Sign() != 0 {
+		return abs(&b.Total.Rat)
+	}
+	basis := new(big.Rat).Mul(abs(&b.Units.Rat), &b.UnitPrice.Rat)
+	for _, cost := range []*ofxgo.Amount{&b.Commission, &b.Taxes, &b.Fees, &b.Load} {
+		basis.Add(basis, &cost.Rat)
+	}
+	return basis
+}
+
+// saleProceeds returns the proceeds of a sale: the magnitude of its TOTAL, or
+// if the FI didn't provide one, what it should have been
+func saleProceeds(s ofxgo.InvSell) *big.Rat {
+	if s.Total.Sign() != 0 {
+		return abs(&s.Total.Rat)
+	}
+	proceeds := new(big.Rat).Mul(abs(&s.Units.Rat), &s.UnitPrice.Rat)
+	for _, cost := range []*ofxgo.Amount{&s.Commission, &s.Taxes, &s.Fees, &s.Load, &s.Withholding, &s.StateWithholding, &s.Penalty} {
+		proceeds.Sub(proceeds, &cost.Rat)
+	}
+	return proceeds
+}
+
+func (t *Tracker) open(id ofxgo.SecurityID, tran ofxgo.InvTran, dtAcquired time.Time, units, costBasis *big.Rat) {
+	if t.lots == nil {
+		t.lots = make(map[securityKey][]*lot)
+	}
+	key := newSecurityKey(id)
+	t.lots[key] = append(t.lots[key], &lot{
+		secID:      id.Normalize(),
+		fiTID:      string(tran.FiTID),
+		dtAcquired: dtAcquired,
+		units:      units,
+		costBasis:  costBasis,
+	})
+}
+
+func (t *Tracker) buy(b ofxgo.InvBuy) error {
+	t.open(b.SecID, b.InvTran, b.InvTran.DtTrade.Time, abs(&b.Units.Rat), purchaseBasis(b))
+	return nil
+}
+
+func (t *Tracker) sell(s ofxgo.InvSell) error {
+	return t.dispose(s.SecID, s.InvTran, abs(&s.Units.Rat), saleProceeds(s), true)
+}
+
+// disposal is the portion of one lot removed by a sale or transfer
+type disposal struct {
+	lot       *lot
+	units     *big.Rat
+	costBasis *big.Rat
+}
+
+// dispose removes units of the security identified by id from its open lots,
+// choosing lots according to t.Method. If realize is true, a Gain is recorded
+// for each lot disposed of, with proceeds allocated between them by units.
+func (t *Tracker) dispose(id ofxgo.SecurityID, tran ofxgo.InvTran, units, proceeds *big.Rat, realize bool) error {
+	key := newSecurityKey(id)
+	held := t.Units(id)
+	if held.Cmp(units) < 0 {
+		return fmt.Errorf("lots: transaction %s disposes of %s units of %s %s, but only %s are held", tran.FiTID, units.FloatString(4), id.UniqueIDType, id.UniqueID, held.FloatString(4))
+	}
+
+	if t.Method == AverageCost {
+		t.averageBasis(key, &held.Rat)
+	}
+	disposals, err := t.chooseLots(key, tran, units)
+	if err != nil {
+		return err
+	}
+
+	var open []*lot
+	for _, l := range t.lots[key] {
+		if l.units.Sign() > 0 {
+			open = append(open, l)
+		}
+	}
+	t.lots[key] = open
+	if len(open) == 0 {
+		delete(t.lots, key)
+	}
+
+	if !realize {
+		return nil
+	}
+	dtSold := tran.DtTrade.Time
+	for _, d := range disposals {
+		share := new(big.Rat).Mul(proceeds, d.units)
+		share.Quo(share, units)
+		g := Gain{
+			SecID:      d.lot.secID,
+			FiTID:      string(tran.FiTID),
+			LotFiTID:   d.lot.fiTID,
+			DtAcquired: d.lot.dtAcquired,
+			DtSold:     dtSold,
+			LongTerm:   longTerm(d.lot.dtAcquired, dtSold),
+		}
+		g.Units.Set(d.units)
+		g.Proceeds.Set(share)
+		g.CostBasis.Set(d.costBasis)
+		g.Gain.Sub(share, d.costBasis)
+		t.gains = append(t.gains, g)
+	}
+	return nil
+}
+
+// chooseLots removes units from the open lots for key according to t.Method,
+// returning the portion of each lot removed. The caller must ensure enough
+// units are held.
+func (t *Tracker) chooseLots(key securityKey, tran ofxgo.InvTran, units *big.Rat) ([]disposal, error) {
+	lots := t.lots[key]
+	remaining := new(big.Rat).Set(units)
+	var disposals []disposal
+
+	take := func(l *lot, want *big.Rat) {
+		n := new(big.Rat).Set(want)
+		if n.Cmp(l.units) > 0 {
+			n.Set(l.units)
+		}
+		if n.Sign() <= 0 {
+			return
+		}
+		basis := new(big.Rat).Mul(l.costBasis, n)
+		basis.Quo(basis, l.units)
+		l.units.Sub(l.units, n)
+		l.costBasis.Sub(l.costBasis, basis)
+		remaining.Sub(remaining, n)
+		disposals = append(disposals, disposal{lot: l, units: n, costBasis: basis})
+	}
+
+	if selections, ok := t.Selections[string(tran.FiTID)]; ok && t.Method == SpecificID {
+		// Check all the selections before taking any units, so the open lots
+		// are left untouched if they can't be honored
+		selected := make([]*lot, len(selections))
+		wanted := make(map[*lot]*big.Rat)
+		total := new(big.Rat)
+		for i, s := range selections {
+			for _, l := range lots {
+				if l.fiTID == s.LotFiTID {
+					selected[i] = l
+					break
+				}
+			}
+			l := selected[i]
+			if l == nil {
+				return nil, fmt.Errorf("lots: transaction %s selects unknown lot %s", tran.FiTID, s.LotFiTID)
+			}
+			if wanted[l] == nil {
+				wanted[l] = new(big.Rat)
+			}
+			wanted[l].Add(wanted[l], &s.Units.Rat)
+			if l.units.Cmp(wanted[l]) < 0 {
+				return nil, fmt.Errorf("lots: transaction %s selects %s units from lot %s, which has only %s", tran.FiTID, wanted[l].FloatString(4), s.LotFiTID, l.units.FloatString(4))
+			}
+			total.Add(total, &s.Units.Rat)
+		}
+		if total.Cmp(units) != 0 {
+			return nil, fmt.Errorf("lots: selections for transaction %s don't add up to the units disposed of", tran.FiTID)
+		}
+		for i, s := range selections {
+			take(selected[i], &s.Units.Rat)
+		}
+		return disposals, nil
+	}
+
+	if t.Method == LIFO {
+		for i := len(lots) - 1; i >= 0 && remaining.Sign() > 0; i-- {
+			take(lots[i], remaining)
+		}
+	} else {
+		for i := 0; i < len(lots) && remaining.Sign() > 0; i++ {
+			take(lots[i], remaining)
+		}
+	}
+	return disposals, nil
+}
+
+// averageBasis sets the cost basis of each open lot for key to the average
+// across all of them, so that units sold from any lot carry the same basis
+func (t *Tracker) averageBasis(key securityKey, units *big.Rat) {
+	if units.Sign() == 0 {
+		return
+	}
+	total := new(big.Rat)
+	for _, l := range t.lots[key] {
+		total.Add(total, l.costBasis)
+	}
+	average := new(big.Rat).Quo(total, units)
+	for _, l := range t.lots[key] {
+		l.costBasis.Mul(average, l.units)
+	}
+}
+
+// longTerm returns true if a lot acquired on acquired and sold on sold was
+// held for more than one year
+func longTerm(acquired, sold time.Time) bool {
+	return sold.After(acquired.AddDate(1, 0, 0))
+}
+
+func (t *Tracker) split(s ofxgo.Split) error {
+	if s.Numerator <= 0 || s.Denominator <= 0 {
+		return fmt.Errorf("lots: split %s has an invalid ratio", s.InvTran.FiTID)
+	}
+	key := newSecurityKey(s.SecID)
+	ratio := big.NewRat(int64(s.Numerator), int64(s.Denominator))
+	for _, l := range t.lots[key] {
+		l.units.Mul(l.units, ratio)
+	}
+
+	// Units which would have been fractional after the split are paid out in
+	// cash, which is a sale of those units
+	if s.FracCash.Sign() != 0 && s.NewUnits.Sign() != 0 {
+		fractional := new(big.Rat).Mul(abs(&s.OldUnits.Rat), ratio)
+		fractional.Sub(fractional, abs(&s.NewUnits.Rat))
+		if fractional.Sign() > 0 {
+			return t.dispose(s.SecID, s.InvTran, fractional, abs(&s.FracCash.Rat), true)
+		}
+	}
+	return nil
+}
+
+func (t *Tracker) transfer(tr ofxgo.Transfer) error {
+	if tr.PosType == ofxgo.PosTypeShort {
+		return ErrShortPosition
+	}
+	units := abs(&tr.Units.Rat)
+	if tr.TferAction == ofxgo.TferActionOut {
+		return t.dispose(tr.SecID, tr.InvTran, units, new(big.Rat), false)
+	}
+	dtAcquired := tr.InvTran.DtTrade.Time
+	if tr.DtPurchase != nil {
+		dtAcquired = tr.DtPurchase.Time
+	}
+	basis := new(big.Rat).Mul(units, &tr.AvgCostBasis.Rat)
+	t.open(tr.SecID, tr.InvTran, dtAcquired, units, basis)
+	return nil
+}
+
+// returnOfCapital reduces the cost basis of the open lots of a security by
+// the amount returned, allocated between them by units. Any amount returned
+// beyond a lot's basis is realized as a gain.
+func (t *Tracker) returnOfCapital(r ofxgo.RetOfCap) error {
+	key := newSecurityKey(r.SecID)
+	held := t.Units(r.SecID)
+	if held.Sign() == 0 {
+		return fmt.Errorf("lots: return of capital %s for %s %s, which is not held", r.InvTran.FiTID, r.SecID.UniqueIDType, r.SecID.UniqueID)
+	}
+	total := abs(&r.Total.Rat)
+	for _, l := range t.lots[key] {
+		share := new(big.Rat).Mul(total, l.units)
+		share.Quo(share, &held.Rat)
+		if share.Cmp(l.costBasis) <= 0 {
+			l.costBasis.Sub(l.costBasis, share)
+			continue
+		}
+		g := Gain{
+			SecID:      l.secID,
+			FiTID:      string(r.InvTran.FiTID),
+			LotFiTID:   l.fiTID,
+			DtAcquired: l.dtAcquired,
+			DtSold:     r.InvTran.DtTrade.Time,
+			LongTerm:   longTerm(l.dtAcquired, r.InvTran.DtTrade.Time),
+		}
+		g.Gain.Sub(share, l.costBasis)
+		g.Proceeds.Set(&g.Gain.Rat)
+		t.gains = append(t.gains, g)
+		l.costBasis.SetInt64(0)
+	}
+	return nil
+}
+
+// closeOption removes the contracts closed by an option closure. Expired
+// options are realized as a loss of their entire basis; the basis of
+// exercised or assigned options is dropped without realizing a gain, since
+// FIs fold it into the TOTAL of the resulting trade of the underlying
+// security.
+func (t *Tracker) closeOption(c ofxgo.ClosureOpt) error {
+	if c.SubAcctSec == ofxgo.SubAcctTypeShort {
+		return ErrShortPosition
+	}
+	return t.dispose(c.SecID, c.InvTran, abs(&c.Units.Rat), new(big.Rat), c.OptAction == ofxgo.OptActionExpire)
+}
@@ -0,0 +1,287 @@
+package lots
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/internal/ofxtest"
+)
+
+var testSecID = ofxgo.SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}
+
+func invTran(fitid string, year int, month time.Month, day int) ofxgo.InvTran {
+	return ofxgo.InvTran{FiTID: ofxgo.String(fitid), DtTrade: *ofxgo.NewDateGMT(year, month, day, 0, 0, 0, 0)}
+}
+
+func buy(fitid string, year int, month time.Month, day int, units, total string) ofxgo.BuyStock {
+	return ofxgo.BuyStock{
+		InvBuy: ofxgo.InvBuy{
+			InvTran: invTran(fitid, year, month, day),
+			SecID:   testSecID,
+			Units:   ofxtest.Amount(units),
+			Total:   ofxtest.Amount(total),
+		},
+		BuyType: ofxgo.BuyTypeBuy,
+	}
+}
+
+func sell(fitid string, year int, month time.Month, day int, units, total string) ofxgo.SellStock {
+	return ofxgo.SellStock{
+		InvSell: ofxgo.InvSell{
+			InvTran: invTran(fitid, year, month, day),
+			SecID:   testSecID,
+			Units:   ofxtest.Amount(units),
+			Total:   ofxtest.Amount(total),
+		},
+		SellType: ofxgo.SellTypeSell,
+	}
+}
+
+type expectedGain struct {
+	lotFiTID  string
+	units     string
+	costBasis string
+	gain      string
+	longTerm  bool
+}
+
+type expectedLot struct {
+	fitid     string
+	units     string
+	costBasis string
+}
+
+func checkGains(t *testing.T, name string, tracker *Tracker, expected []expectedGain) {
+	gains := tracker.Gains()
+	if len(gains) != len(expected) {
+		t.Fatalf("%s: Expected %d gains, got %d\n", name, len(expected), len(gains))
+	}
+	for i, e := range expected {
+		g := gains[i]
+		if g.LotFiTID != e.lotFiTID || !ofxtest.AmountEqual(g.Units, e.units) || !ofxtest.AmountEqual(g.CostBasis, e.costBasis) || !ofxtest.AmountEqual(g.Gain, e.gain) || g.LongTerm != e.longTerm {
+			t.Errorf("%s: Expected gain %d to be %+v, got lot %s units %s basis %s gain %s long term %t\n", name, i, e, g.LotFiTID, g.Units.String(), g.CostBasis.String(), g.Gain.String(), g.LongTerm)
+		}
+	}
+}
+
+func checkLots(t *testing.T, name string, tracker *Tracker, expected []expectedLot) {
+	lots := tracker.OpenLots()
+	if len(lots) != len(expected) {
+		t.Fatalf("%s: Expected %d open lots, got %d\n", name, len(expected), len(lots))
+	}
+	for i, e := range expected {
+		l := lots[i]
+		if l.FiTID != e.fitid || !ofxtest.AmountEqual(l.Units, e.units) || !ofxtest.AmountEqual(l.CostBasis, e.costBasis) {
+			t.Errorf("%s: Expected lot %d to be %+v, got %s units %s basis %s\n", name, i, e, l.FiTID, l.Units.String(), l.CostBasis.String())
+		}
+	}
+}
+
+func TestMethods(t *testing.T) {
+	transactions := []ofxgo.InvTransaction{
+		buy("1", 2016, 1, 4, "10", "-1000"),
+		buy("2", 2017, 6, 1, "10", "-1500"),
+		sell("3", 2017, 7, 3, "-15", "3000"),
+	}
+	tests := []struct {
+		method     Method
+		selections map[string][]Selection
+		gains      []expectedGain
+		lots       []expectedLot
+	}{
+		{
+			method: FIFO,
+			gains: []expectedGain{
+				{"1", "10", "1000", "1000", true},
+				{"2", "5", "750", "250", false},
+			},
+			lots: []expectedLot{{"2", "5", "750"}},
+		},
+		{
+			method: LIFO,
+			gains: []expectedGain{
+				{"2", "10", "1500", "500", false},
+				{"1", "5", "500", "500", true},
+			},
+			lots: []expectedLot{{"1", "5", "500"}},
+		},
+		{
+			method: AverageCost,
+			gains: []expectedGain{
+				{"1", "10", "1250", "750", true},
+				{"2", "5", "625", "375", false},
+			},
+			lots: []expectedLot{{"2", "5", "625"}},
+		},
+		{
+			method: SpecificID,
+			selections: map[string][]Selection{
+				"3": {{LotFiTID: "2", Units: ofxtest.Amount("8")}, {LotFiTID: "1", Units: ofxtest.Amount("7")}},
+			},
+			gains: []expectedGain{
+				{"2", "8", "1200", "400", false},
+				{"1", "7", "700", "700", true},
+			},
+			lots: []expectedLot{{"1", "3", "300"}, {"2", "2", "300"}},
+		},
+		{
+			// Sales without selections fall back to FIFO
+			method: SpecificID,
+			gains: []expectedGain{
+				{"1", "10", "1000", "1000", true},
+				{"2", "5", "750", "250", false},
+			},
+			lots: []expectedLot{{"2", "5", "750"}},
+		},
+	}
+
+	for _, test := range tests {
+		tracker := NewTracker(test.method)
+		tracker.Selections = test.selections
+		// Out of order, to ensure AddList sorts them
+		list := ofxgo.InvTranList{InvTransactions: []ofxgo.InvTransaction{transactions[2], transactions[0], transactions[1]}}
+		if err := tracker.AddList(&list); err != nil {
+			t.Fatalf("%s: Unexpected error: %s\n", test.method, err)
+		}
+		checkGains(t, test.method.String(), tracker, test.gains)
+		checkLots(t, test.method.String(), tracker, test.lots)
+	}
+}
+
+func TestCorporateActions(t *testing.T) {
+	tracker := NewTracker(FIFO)
+	transactions := []ofxgo.InvTransaction{
+		buy("1", 2016, 1, 4, "10", "-1000"),
+		ofxgo.Reinvest{
+			InvTran:    invTran("2", 2016, 3, 1),
+			SecID:      testSecID,
+			IncomeType: ofxgo.IncomeTypeDiv,
+			Units:      ofxtest.Amount("1"),
+			Total:      ofxtest.Amount("-110"),
+		},
+		ofxgo.Split{
+			InvTran:     invTran("3", 2016, 6, 1),
+			SecID:       testSecID,
+			OldUnits:    ofxtest.Amount("11"),
+			NewUnits:    ofxtest.Amount("22"),
+			Numerator:   2,
+			Denominator: 1,
+		},
+		ofxgo.RetOfCap{
+			InvTran: invTran("4", 2016, 9, 1),
+			SecID:   testSecID,
+			Total:   ofxtest.Amount("220"),
+		},
+		ofxgo.Transfer{
+			InvTran:      invTran("5", 2016, 10, 1),
+			SecID:        testSecID,
+			Units:        ofxtest.Amount("5"),
+			TferAction:   ofxgo.TferActionIn,
+			PosType:      ofxgo.PosTypeLong,
+			AvgCostBasis: ofxtest.Amount("40"),
+			DtPurchase:   ofxgo.NewDateGMT(2015, 1, 1, 0, 0, 0, 0),
+		},
+		ofxgo.Transfer{
+			InvTran:    invTran("6", 2016, 11, 1),
+			SecID:      testSecID,
+			Units:      ofxtest.Amount("-2"),
+			TferAction: ofxgo.TferActionOut,
+			PosType:    ofxgo.PosTypeLong,
+		},
+	}
+	for _, tran := range transactions {
+		if err := tracker.Add(tran); err != nil {
+			t.Fatalf("Unexpected error adding %s: %s\n", tran.TransactionType(), err)
+		}
+	}
+	checkGains(t, "corporate actions", tracker, nil)
+	checkLots(t, "corporate actions", tracker, []expectedLot{
+		{"1", "18", "720"},
+		{"2", "2", "90"},
+		{"5", "5", "200"},
+	})
+	units := tracker.Units(ofxgo.SecurityID{UniqueID: " 78462f103", UniqueIDType: "cusip"})
+	if !ofxtest.AmountEqual(units, "25") {
+		t.Errorf("Expected 25 units held, got %s\n", units.String())
+	}
+}
+
+func TestReturnOfCapitalExceedingBasis(t *testing.T) {
+	tracker := NewTracker(FIFO)
+	tracker.Add(buy("1", 2016, 1, 4, "10", "-100"))
+	err := tracker.Add(ofxgo.RetOfCap{
+		InvTran: invTran("2", 2017, 3, 1),
+		SecID:   testSecID,
+		Total:   ofxtest.Amount("150"),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	checkGains(t, "return of capital", tracker, []expectedGain{{"1", "0", "0", "50", true}})
+	checkLots(t, "return of capital", tracker, []expectedLot{{"1", "10", "0"}})
+}
+
+func TestExpiredOption(t *testing.T) {
+	optID := ofxgo.SecurityID{UniqueID: "SPY161216C00226000", UniqueIDType: "CUSIP"}
+	tracker := NewTracker(FIFO)
+	tracker.Add(ofxgo.BuyOpt{
+		InvBuy: ofxgo.InvBuy{
+			InvTran: invTran("1", 2016, 11, 1),
+			SecID:   optID,
+			Units:   ofxtest.Amount("2"),
+			Total:   ofxtest.Amount("-300"),
+		},
+		OptBuyType: ofxgo.OptBuyTypeBuyToOpen,
+		ShPerCtrct: 100,
+	})
+	err := tracker.Add(ofxgo.ClosureOpt{
+		InvTran:    invTran("2", 2016, 12, 16),
+		SecID:      optID,
+		OptAction:  ofxgo.OptActionExpire,
+		Units:      ofxtest.Amount("2"),
+		ShPerCtrct: 100,
+		SubAcctSec: ofxgo.SubAcctTypeCash,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	checkGains(t, "expired option", tracker, []expectedGain{{"1", "2", "300", "-300", false}})
+	checkLots(t, "expired option", tracker, nil)
+}
+
+func TestErrors(t *testing.T) {
+	tracker := NewTracker(FIFO)
+	tracker.Add(buy("1", 2016, 1, 4, "10", "-1000"))
+
+	if err := tracker.Add(sell("2", 2016, 2, 1, "-11", "1100")); err == nil {
+		t.Errorf("Expected error selling more units than held\n")
+	}
+	short := sell("3", 2016, 2, 1, "-5", "500")
+	short.SellType = ofxgo.SellTypeSellShort
+	if err := tracker.Add(short); err != ErrShortPosition {
+		t.Errorf("Expected ErrShortPosition for short sale, got %v\n", err)
+	}
+
+	tracker.Method = SpecificID
+	tracker.Selections = map[string][]Selection{
+		"4": {{LotFiTID: "99", Units: ofxtest.Amount("5")}},
+		"5": {{LotFiTID: "1", Units: ofxtest.Amount("4")}},
+		"6": {{LotFiTID: "1", Units: ofxtest.Amount("5")}, {LotFiTID: "99", Units: ofxtest.Amount("1")}},
+		"7": {{LotFiTID: "1", Units: ofxtest.Amount("6")}, {LotFiTID: "1", Units: ofxtest.Amount("5")}},
+	}
+	if err := tracker.Add(sell("4", 2016, 2, 1, "-5", "500")); err == nil {
+		t.Errorf("Expected error selecting unknown lot\n")
+	}
+	if err := tracker.Add(sell("5", 2016, 2, 1, "-5", "500")); err == nil {
+		t.Errorf("Expected error when selections don't match units sold\n")
+	}
+	if err := tracker.Add(sell("6", 2016, 2, 1, "-6", "600")); err == nil {
+		t.Errorf("Expected error selecting unknown lot after a valid selection\n")
+	}
+	if err := tracker.Add(sell("7", 2016, 2, 1, "-10", "1000")); err == nil {
+		t.Errorf("Expected error selecting more units from a lot than it has\n")
+	}
+	// Failed sales must leave the open lots untouched
+	checkLots(t, "failed selections", tracker, []expectedLot{{"1", "10", "1000"}})
+}
@@ -0,0 +1,149 @@
+package ofxgo
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TransactionError is the error reported by Response.Match for a transaction
+// the server responded to with an ERROR-severity Status
+type TransactionError struct {
+	Request  Message // The *TRNRQ sent
+	Response Message // The *TRNRS received
+	Status   Status  // Status from Response
+}
+
+func (e *TransactionError) Error() string {
+	trnUID, _, _ := transactionIDs(e.Request)
+	meaning, err := e.Status.CodeMeaning()
+	if err != nil {
+		meaning = err.Error()
+	}
+	s := fmt.Sprintf("%s %s failed with status %d (%s)", e.Request.Name(), trnUID, e.Status.Code, meaning)
+	if len(e.Status.Message) > 0 {
+		s += ": " + e.Status.Message.String()
+	}
+	return s
+}
+
+// Unwrap returns the *StatusError for the failed transaction, so that
+// errors.As may be used to inspect it
+func (e *TransactionError) Unwrap() error {
+	trnUID, _, _ := transactionIDs(e.Response)
+	return NewStatusError(&e.Status, e.Response.Name(), trnUID)
+}
+
+// MissingResponseError is the error reported by Response.Match for a
+// transaction the server didn't respond to at all
+type MissingResponseError struct {
+	Request Message
+}
+
+func (e *MissingResponseError) Error() string {
+	trnUID, _, _ := transactionIDs(e.Request)
+	return fmt.Sprintf("No response received for %s %s", e.Request.Name(), trnUID)
+}
+
+// MatchedTransaction pairs a transaction request with the server's response to
+// it. Err is nil if the server responded successfully (with an INFO or WARN
+// Status), or is otherwise a *TransactionError or *MissingResponseError.
+type MatchedTransaction struct {
+	Request  Message
+	Response Message // nil if the server didn't respond to Request
+	Err      error
+}
+
+// MatchResult is returned by Response.Match
+type MatchResult struct {
+	Transactions []MatchedTransaction // One for each transaction in the Request, in the same order
+	Unmatched    []Message            // Response messages which didn't correspond to any in the Request
+}
+
+// Errors returns the errors for all transactions which failed or were not
+// responded to
+func (mr *MatchResult) Errors() []error {
+	var errs []error
+	for _, t := range mr.Transactions {
+		if t.Err != nil {
+			errs = append(errs, t.Err)
+		}
+	}
+	return errs
+}
+
+// transactionStatus returns the Status of a *TRNRS message, or ok=false if it
+// doesn't have one
+func transactionStatus(m Message) (status Status, ok bool) {
+	v := reflect.ValueOf(m)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return status, false
+	}
+	field := v.FieldByName("Status")
+	if !field.IsValid() || field.Type() != reflect.TypeOf(status) {
+		return status, false
+	}
+	return field.Interface().(Status), true
+}
+
+// Match pairs each transaction in req with the response to it in or, by
+// TRNUID, so callers don't have to search through each of the Response's
+// message sets themselves. A request and response are only paired if the
+// response is in the message set corresponding to the request's.
+func (or *Response) Match(req *Request) *MatchResult {
+	var result MatchResult
+
+	type responseMessage struct {
+		Message Message
+		matched bool
+	}
+	responses := make(map[UID][]*responseMessage)
+	var allResponses []*responseMessage
+	for _, set := range responseMessageSets(or) {
+		for _, message := range *set {
+			rm := &responseMessage{Message: message}
+			allResponses = append(allResponses, rm)
+			if trnUID, _, ok := transactionIDs(message); ok {
+				responses[trnUID] = append(responses[trnUID], rm)
+			}
+		}
+	}
+
+	// Request and response message types are in the same order, so the
+	// response type for a request type is offset by a constant amount
+	offset := SignonRs - SignonRq
+
+	for _, set := range requestMessageSets(req) {
+		for _, request := range *set.Messages {
+			transaction := MatchedTransaction{Request: request}
+			trnUID, _, _ := transactionIDs(request)
+			for _, rm := range responses[trnUID] {
+				if !rm.matched && rm.Message.Type() == request.Type()+offset {
+					rm.matched = true
+					transaction.Response = rm.Message
+					break
+				}
+			}
+
+			if transaction.Response == nil {
+				transaction.Err = &MissingResponseError{Request: request}
+			} else if status, ok := transactionStatus(transaction.Response); ok && status.Severity == "ERROR" {
+				transaction.Err = &TransactionError{
+					Request:  request,
+					Response: transaction.Response,
+					Status:   status,
+				}
+			}
+			result.Transactions = append(result.Transactions, transaction)
+		}
+	}
+
+	for _, rm := range allResponses {
+		if !rm.matched {
+			result.Unmatched = append(result.Unmatched, rm.Message)
+		}
+	}
+	return &result
+}
@@ -0,0 +1,73 @@
+package ofxgo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResponseMatch(t *testing.T) {
+	var request Request
+	bankRequest := &StatementRequest{TrnUID: "1001"}
+	ccRequest := &CCStatementRequest{TrnUID: "1002"}
+	invRequest := &InvStatementRequest{TrnUID: "1003"}
+	request.Bank = append(request.Bank, bankRequest)
+	request.CreditCard = append(request.CreditCard, ccRequest)
+	request.InvStmt = append(request.InvStmt, invRequest)
+
+	bankResponse := &StatementResponse{
+		TrnUID: "1001",
+		Status: Status{Code: 0, Severity: "INFO"},
+	}
+	ccResponse := &CCStatementResponse{
+		TrnUID: "1002",
+		Status: Status{Code: 2003, Severity: "ERROR", Message: "No such account"},
+	}
+	// Same TRNUID as the investment request, but in the wrong message set
+	wrongSetResponse := &StatementResponse{
+		TrnUID: "1003",
+		Status: Status{Code: 0, Severity: "INFO"},
+	}
+	var response Response
+	response.Bank = append(response.Bank, wrongSetResponse, bankResponse)
+	response.CreditCard = append(response.CreditCard, ccResponse)
+
+	result := response.Match(&request)
+	if len(result.Transactions) != 3 {
+		t.Fatalf("Expected 3 matched transactions, got %d\n", len(result.Transactions))
+	}
+
+	bank := result.Transactions[0]
+	if bank.Request != bankRequest || bank.Response != bankResponse || bank.Err != nil {
+		t.Errorf("Bank transaction not matched correctly: %+v\n", bank)
+	}
+
+	cc := result.Transactions[1]
+	if cc.Request != ccRequest || cc.Response != ccResponse {
+		t.Errorf("Credit card transaction not matched correctly: %+v\n", cc)
+	}
+	if txErr, ok := cc.Err.(*TransactionError); !ok {
+		t.Errorf("Expected *TransactionError for credit card transaction, got %T\n", cc.Err)
+	} else {
+		if txErr.Status.Code != 2003 {
+			t.Errorf("Expected status code 2003, got %d\n", txErr.Status.Code)
+		}
+		if !strings.Contains(txErr.Error(), "Account not found") || !strings.Contains(txErr.Error(), "No such account") {
+			t.Errorf("Expected error to include code meaning and message, got %q\n", txErr.Error())
+		}
+	}
+
+	inv := result.Transactions[2]
+	if inv.Response != nil {
+		t.Errorf("Expected no response for investment transaction, got %v\n", inv.Response)
+	}
+	if _, ok := inv.Err.(*MissingResponseError); !ok {
+		t.Errorf("Expected *MissingResponseError for investment transaction, got %T\n", inv.Err)
+	}
+
+	if len(result.Unmatched) != 1 || result.Unmatched[0] != wrongSetResponse {
+		t.Errorf("Expected response in wrong message set to be unmatched, got %v\n", result.Unmatched)
+	}
+	if errs := result.Errors(); len(errs) != 2 {
+		t.Errorf("Expected 2 errors, got %v\n", errs)
+	}
+}
@@ -0,0 +1,187 @@
+package ofxgo
+
+import (
+	"fmt"
+)
+
+type mergeAction uint
+
+// MergeAction* constants represent the changes MergeTransactions and
+// MergeInvTransactions make while combining downloads
+const (
+	MergeActionDuplicate mergeAction = 1 + iota // A transaction was downloaded more than once; the latest copy was kept
+	MergeActionReplaced                         // A transaction was replaced by one with CORRECTACTION REPLACE
+	MergeActionDeleted                          // A transaction was deleted by one with CORRECTACTION DELETE
+	MergeActionReversed                         // A transaction and the one with a REVERSALFITID reversing it were both removed
+	MergeActionUnmatched                        // A correction or reversal referred to a transaction which wasn't downloaded
+)
+
+var mergeActions = [...]string{"duplicate", "replaced", "deleted", "reversed", "unmatched"}
+
+func (a mergeAction) String() string {
+	if a >= MergeActionDuplicate && a <= MergeActionUnmatched {
+		return mergeActions[a-1]
+	}
+	return fmt.Sprintf("invalid mergeAction (%d)", a)
+}
+
+// MergeEvent records one change made while merging transactions
+type MergeEvent struct {
+	Action mergeAction
+	FiTID  String // FITID of the transaction affected
+	By     String // FITID of the transaction which caused the change (the same as FiTID for duplicates)
+}
+
+func (e MergeEvent) String() string {
+	if e.Action == MergeActionDuplicate {
+		return fmt.Sprintf("%s %s", e.FiTID, e.Action)
+	}
+	return fmt.Sprintf("%s %s by %s", e.FiTID, e.Action, e.By)
+}
+
+// MergeReport lists the changes made while merging transactions, in the order
+// they were made. Since transactions are processed in the order they're
+// passed in, merging the same downloads always produces the same report.
+type MergeReport struct {
+	Events []MergeEvent
+}
+
+// mergeEntry holds the fields of a transaction needed to merge it
+type mergeEntry struct {
+	fiTID  String
+	target String      // CORRECTFITID or REVERSALFITID
+	action mergeAction // MergeActionReplaced, MergeActionDeleted, or MergeActionReversed if this transaction corrects target, otherwise 0
+}
+
+// mergeEntries merges transactions described by entries, returning the
+// indices of those to keep in the order they should be kept
+func mergeEntries(entries []mergeEntry) ([]int, *MergeReport) {
+	var report MergeReport
+	var slots []int // Index of the entry in each slot, or -1 if it was removed
+	position := make(map[String]int)
+
+	// Deduplicate ordinary transactions first, so that corrections and
+	// reversals apply no matter which download the transactions they refer to
+	// appear in
+	var corrections []int
+	for i, e := range entries {
+		if e.action != 0 {
+			corrections = append(corrections, i)
+			continue
+		}
+		if slot, ok := position[e.fiTID]; ok && len(e.fiTID) > 0 {
+			slots[slot] = i
+			report.Events = append(report.Events, MergeEvent{Action: MergeActionDuplicate, FiTID: e.fiTID, By: e.fiTID})
+			continue
+		}
+		position[e.fiTID] = len(slots)
+		slots = append(slots, i)
+	}
+
+	applied := make(map[String]bool)
+	for _, i := range corrections {
+		e := entries[i]
+		if applied[e.fiTID] && len(e.fiTID) > 0 {
+			report.Events = append(report.Events, MergeEvent{Action: MergeActionDuplicate, FiTID: e.fiTID, By: e.fiTID})
+			continue
+		}
+		applied[e.fiTID] = true
+
+		slot, ok := position[e.target]
+		if !ok || len(e.target) == 0 {
+			report.Events = append(report.Events, MergeEvent{Action: MergeActionUnmatched, FiTID: e.target, By: e.fiTID})
+			// Keep replacements and reversals of transactions we never saw,
+			// since they still affect the account; there's nothing to apply
+			// a deletion to
+			if e.action != MergeActionDeleted {
+				position[e.fiTID] = len(slots)
+				slots = append(slots, i)
+			}
+			continue
+		}
+
+		delete(position, e.target)
+		if e.action == MergeActionReplaced {
+			slots[slot] = i
+			position[e.fiTID] = slot
+		} else {
+			slots[slot] = -1
+		}
+		report.Events = append(report.Events, MergeEvent{Action: e.action, FiTID: e.target, By: e.fiTID})
+	}
+
+	var keep []int
+	for _, i := range slots {
+		if i >= 0 {
+			keep = append(keep, i)
+		}
+	}
+	return keep, &report
+}
+
+// MergeTransactions combines the bank or credit card transactions from
+// several downloads for the same account (which may overlap) into one set.
+// Transactions with the same FITID are only included once, using the copy
+// from the last list containing it, in the position the transaction first
+// appeared. Transactions with a CORRECTACTION of REPLACE take the place of the
+// transaction with their CORRECTFITID, and those with a CORRECTACTION of
+// DELETE remove it (and are not themselves included). Replacements for
+// transactions which weren't downloaded are kept, after all the others. The
+// lists should be passed in the order they were downloaded.
+func MergeTransactions(lists ...[]Transaction) ([]Transaction, *MergeReport) {
+	var all []Transaction
+	var entries []mergeEntry
+	for _, list := range lists {
+		for _, t := range list {
+			e := mergeEntry{fiTID: t.FiTID}
+			if len(t.CorrectFiTID) > 0 {
+				e.target = t.CorrectFiTID
+				switch t.CorrectAction {
+				case CorrectActionDelete:
+					e.action = MergeActionDeleted
+				default:
+					e.action = MergeActionReplaced
+				}
+			}
+			all = append(all, t)
+			entries = append(entries, e)
+		}
+	}
+
+	keep, report := mergeEntries(entries)
+	merged := make([]Transaction, len(keep))
+	for i, j := range keep {
+		merged[i] = all[j]
+	}
+	return merged, report
+}
+
+// MergeInvTransactions combines the investment transactions from several
+// downloads for the same account (which may overlap) into one set, in the same
+// way as MergeTransactions. Instead of corrections, a transaction with a
+// REVERSALFITID is removed along with the transaction it reverses. Reversals
+// of transactions which weren't downloaded are kept, since they still affect
+// the account.
+func MergeInvTransactions(lists ...[]InvTransaction) ([]InvTransaction, *MergeReport) {
+	var all []InvTransaction
+	var entries []mergeEntry
+	for _, list := range lists {
+		for _, t := range list {
+			tran := t.InvTransaction()
+			e := mergeEntry{fiTID: tran.FiTID}
+			if len(tran.ReversalFiTID) > 0 {
+				e.target = tran.ReversalFiTID
+				e.action = MergeActionReversed
+			}
+			all = append(all, t)
+			entries = append(entries, e)
+		}
+	}
+
+	keep, report := mergeEntries(entries)
+	merged := make([]InvTransaction, len(keep))
+	for i, j := range keep {
+		merged[i] = all[j]
+	}
+	return merged, report
+}
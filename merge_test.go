@@ -0,0 +1,143 @@
+package ofxgo
+
+import (
+	"testing"
+)
+
+func mergeTransaction(fitid, memo string) Transaction {
+	return Transaction{
+		TrnType:  TrnTypeDebit,
+		DtPosted: *NewDateGMT(2017, 2, 1, 0, 0, 0, 0),
+		FiTID:    String(fitid),
+		Memo:     String(memo),
+	}
+}
+
+func mergeCorrection(fitid, correctFiTID string, action correctAction) Transaction {
+	t := mergeTransaction(fitid, "correction")
+	t.CorrectFiTID = String(correctFiTID)
+	t.CorrectAction = action
+	return t
+}
+
+func checkMergeEvents(t *testing.T, report *MergeReport, expected []MergeEvent) {
+	if len(report.Events) != len(expected) {
+		t.Fatalf("Expected %d merge events, got %d: %v\n", len(expected), len(report.Events), report.Events)
+	}
+	for i, e := range expected {
+		if report.Events[i] != e {
+			t.Errorf("Expected merge event %d to be %s, got %s\n", i, e, report.Events[i])
+		}
+	}
+}
+
+func TestMergeTransactions(t *testing.T) {
+	first := []Transaction{
+		mergeTransaction("1", "first"),
+		mergeTransaction("2", "first"),
+		mergeTransaction("3", "first"),
+	}
+	second := []Transaction{
+		mergeTransaction("2", "second"),
+		mergeCorrection("4", "3", CorrectActionReplace),
+		mergeCorrection("5", "1", CorrectActionDelete),
+		mergeCorrection("6", "99", CorrectActionReplace),
+		mergeCorrection("7", "98", CorrectActionDelete),
+	}
+	// Overlaps the second download, so it contains the deleted transaction
+	// again, along with the replacement
+	third := []Transaction{
+		mergeTransaction("1", "third"),
+		mergeCorrection("4", "3", CorrectActionReplace),
+		mergeTransaction("8", "third"),
+	}
+
+	merged, report := MergeTransactions(first, second, third)
+
+	expected := []struct {
+		fitid string
+		memo  string
+	}{
+		{"2", "second"},
+		{"4", "correction"},
+		{"8", "third"},
+		{"6", "correction"},
+	}
+	if len(merged) != len(expected) {
+		t.Fatalf("Expected %d merged transactions, got %d: %v\n", len(expected), len(merged), merged)
+	}
+	for i, e := range expected {
+		if string(merged[i].FiTID) != e.fitid || string(merged[i].Memo) != e.memo {
+			t.Errorf("Expected merged transaction %d to be %s (%s), got %s (%s)\n", i, e.fitid, e.memo, merged[i].FiTID, merged[i].Memo)
+		}
+	}
+
+	checkMergeEvents(t, report, []MergeEvent{
+		{Action: MergeActionDuplicate, FiTID: "2", By: "2"},
+		{Action: MergeActionDuplicate, FiTID: "1", By: "1"},
+		{Action: MergeActionReplaced, FiTID: "3", By: "4"},
+		{Action: MergeActionDeleted, FiTID: "1", By: "5"},
+		{Action: MergeActionUnmatched, FiTID: "99", By: "6"},
+		{Action: MergeActionUnmatched, FiTID: "98", By: "7"},
+		{Action: MergeActionDuplicate, FiTID: "4", By: "4"},
+	})
+
+	// Merging again produces the same result
+	again, _ := MergeTransactions(first, second, third)
+	for i := range again {
+		if again[i].FiTID != merged[i].FiTID {
+			t.Errorf("Expected merging to be stable\n")
+		}
+	}
+}
+
+func TestMergeChainedCorrections(t *testing.T) {
+	merged, report := MergeTransactions(
+		[]Transaction{mergeTransaction("1", "original")},
+		[]Transaction{mergeCorrection("2", "1", CorrectActionReplace)},
+		[]Transaction{mergeCorrection("3", "2", CorrectActionReplace)},
+	)
+	if len(merged) != 1 || merged[0].FiTID != "3" {
+		t.Errorf("Expected only the final correction to remain, got %v\n", merged)
+	}
+	checkMergeEvents(t, report, []MergeEvent{
+		{Action: MergeActionReplaced, FiTID: "1", By: "2"},
+		{Action: MergeActionReplaced, FiTID: "2", By: "3"},
+	})
+}
+
+func TestMergeInvTransactions(t *testing.T) {
+	secID := SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}
+	buy := func(fitid, reversal string) InvTransaction {
+		return BuyStock{
+			InvBuy: InvBuy{
+				InvTran: InvTran{
+					FiTID:         String(fitid),
+					DtTrade:       *NewDateGMT(2017, 2, 1, 0, 0, 0, 0),
+					ReversalFiTID: String(reversal),
+				},
+				SecID: secID,
+			},
+			BuyType: BuyTypeBuy,
+		}
+	}
+
+	merged, report := MergeInvTransactions(
+		[]InvTransaction{buy("1", ""), buy("2", "")},
+		[]InvTransaction{buy("2", ""), buy("3", "1"), buy("4", "99"), MarginInterest{InvTran: InvTran{FiTID: "5"}}},
+	)
+	expected := []String{"2", "5", "4"}
+	if len(merged) != len(expected) {
+		t.Fatalf("Expected %d merged transactions, got %d\n", len(expected), len(merged))
+	}
+	for i, fitid := range expected {
+		if merged[i].InvTransaction().FiTID != fitid {
+			t.Errorf("Expected merged transaction %d to be %s, got %s\n", i, fitid, merged[i].InvTransaction().FiTID)
+		}
+	}
+	checkMergeEvents(t, report, []MergeEvent{
+		{Action: MergeActionDuplicate, FiTID: "2", By: "2"},
+		{Action: MergeActionReversed, FiTID: "1", By: "3"},
+		{Action: MergeActionUnmatched, FiTID: "99", By: "4"},
+	})
+}
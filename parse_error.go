@@ -0,0 +1,182 @@
+package ofxgo
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// ParseError is returned when decoding a response fails, identifying where in
+// the response the failure occurred
+type ParseError struct {
+	Path []string // Names of the elements enclosing the failure, outermost first (i.e. OFX, INVSTMTMSGSRSV1, ...)
+	Line int      // Line on which the innermost element in Path began
+	Err  error    // The underlying error
+}
+
+func (e *ParseError) Error() string {
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("Line %d: %s", e.Line, e.Err)
+	}
+	return fmt.Sprintf("Line %d, %s: %s", e.Line, strings.Join(e.Path, ">"), e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// positionElement is one open element tracked by positionReader
+type positionElement struct {
+	name string
+	line int
+}
+
+// positionReader tracks the current line and the stack of open elements as
+// the xml.Decoder reads a response body through it. It implements
+// io.ByteReader so the Decoder doesn't read ahead of the element it is
+// decoding.
+type positionReader struct {
+	r      *bufio.Reader
+	line   int
+	stack  []positionElement
+	closed *positionElement // Element closed by the last tag, if only whitespace has followed it
+
+	// In SGML, the xml.Decoder reads the whole tag following a leaf
+	// element's value before closing it, so if the last tag closed a leaf
+	// element and nothing has been read since, the stack from before that tag
+	// is the one describing the element being decoded
+	autoClosed []positionElement
+	justTagged bool
+
+	inTag    bool
+	nameDone bool
+	tag      []byte // Name of the tag being read, including any leading '/', '?', or '!'
+	last     byte   // Last non-whitespace byte read in the current tag
+}
+
+func newPositionReader(r *bufio.Reader, line int) *positionReader {
+	return &positionReader{r: r, line: line}
+}
+
+func (p *positionReader) ReadByte() (byte, error) {
+	c, err := p.r.ReadByte()
+	if err == nil {
+		p.scan(c)
+	}
+	return c, err
+}
+
+func (p *positionReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	for _, c := range b[:n] {
+		p.scan(c)
+	}
+	return n, err
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func (p *positionReader) scan(c byte) {
+	p.justTagged = false
+	if c == '\n' {
+		p.line++
+	}
+	if !p.inTag {
+		if c == '<' {
+			p.inTag = true
+			p.nameDone = false
+			p.tag = p.tag[:0]
+			p.last = 0
+		} else if !isSpace(c) {
+			p.closed = nil
+		}
+		return
+	}
+
+	if c == '>' {
+		p.inTag = false
+		p.endTag(p.last == '/')
+		p.justTagged = true
+		return
+	}
+	if !p.nameDone {
+		if isSpace(c) || (c == '/' && len(p.tag) > 0) {
+			p.nameDone = true
+		} else {
+			p.tag = append(p.tag, c)
+		}
+	}
+	if !isSpace(c) {
+		p.last = c
+	}
+}
+
+// endTag updates the element stack once a complete tag has been read
+func (p *positionReader) endTag(selfClosing bool) {
+	if len(p.tag) == 0 || p.tag[0] == '?' || p.tag[0] == '!' {
+		return
+	}
+
+	p.autoClosed = nil
+	if len(p.stack) > 0 {
+		top := p.stack[len(p.stack)-1].name
+		if ofxLeafElementSet[top] && string(p.tag) != "/"+top {
+			p.autoClosed = append(p.autoClosed, p.stack...)
+		}
+	}
+
+	if p.tag[0] == '/' {
+		name := string(p.tag[1:])
+		for i := len(p.stack) - 1; i >= 0; i-- {
+			if p.stack[i].name == name {
+				closed := p.stack[i]
+				p.closed = &closed
+				p.stack = p.stack[:i]
+				break
+			}
+		}
+		return
+	}
+
+	// Leaf elements are closed by the next tag in SGML, as they are by the
+	// xml.Decoder's AutoCloseAfterCharData
+	if len(p.stack) > 0 && ofxLeafElementSet[p.stack[len(p.stack)-1].name] {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+	element := positionElement{name: string(p.tag), line: p.line}
+	p.closed = nil
+	if selfClosing {
+		p.closed = &element
+	} else {
+		p.stack = append(p.stack, element)
+	}
+}
+
+// wrap returns err wrapped in a *ParseError describing the current position.
+// If an element was just closed, it is assumed to be the one which failed to
+// decode.
+func (p *positionReader) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*ParseError); ok {
+		return err
+	}
+	parseErr := ParseError{Line: p.line, Err: err}
+	elements := p.stack
+	if p.justTagged && p.autoClosed != nil {
+		elements = p.autoClosed
+	} else if p.closed != nil {
+		elements = append(elements[:len(elements):len(elements)], *p.closed)
+	}
+	for _, e := range elements {
+		parseErr.Path = append(parseErr.Path, e.name)
+	}
+	if len(elements) > 0 {
+		parseErr.Line = elements[len(elements)-1].line
+	}
+	return &parseErr
+}
@@ -0,0 +1,150 @@
+package ofxgo
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const parseErrorSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+SECURITY:NONE
+ENCODING:USASCII
+CHARSET:1252
+COMPRESSION:NONE
+OLDFILEUID:NONE
+NEWFILEUID:NONE
+
+<OFX>
+<SIGNONMSGSRSV1>
+<SONRS>
+<STATUS>
+<CODE>0
+<SEVERITY>INFO
+</STATUS>
+<DTSERVER>20170407001840
+<LANGUAGE>ENG
+</SONRS>
+</SIGNONMSGSRSV1>
+<INVSTMTMSGSRSV1>
+<INVSTMTTRNRS>
+<TRNUID>1001
+<STATUS>
+<CODE>0
+<SEVERITY>INFO
+</STATUS>
+<INVSTMTRS>
+<DTASOF>20170401
+<CURDEF>USD
+<INVACCTFROM>
+<BROKERID>example.com
+<ACCTID>12345
+</INVACCTFROM>
+<INVTRANLIST>
+<DTSTART>20170101
+<DTEND>20170401
+<BUYSTOCK>
+<INVBUY>
+<INVTRAN>
+<FITID>1234
+<DTTRADE>20170105
+</INVTRAN>
+<SECID>
+<UNIQUEID>78462F103
+<UNIQUEIDTYPE>CUSIP
+</SECID>
+<UNITS>100
+<UNITPRICE>two hundred
+<TOTAL>-20000
+<SUBACCTSEC>CASH
+<SUBACCTFUND>CASH
+</INVBUY>
+<BUYTYPE>BUY
+</BUYSTOCK>
+</INVTRANLIST>
+</INVSTMTRS>
+</INVSTMTTRNRS>
+</INVSTMTMSGSRSV1>
+</OFX>
+`
+
+var parseErrorPath = []string{"OFX", "INVSTMTMSGSRSV1", "INVSTMTTRNRS", "INVSTMTRS", "INVTRANLIST", "BUYSTOCK", "INVBUY", "UNITPRICE"}
+
+// parseErrorXML returns parseErrorSGML converted to XML, with all leaf
+// elements closed
+func parseErrorXML() string {
+	var lines []string
+	lines = append(lines, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`)
+	lines = append(lines, `<?OFX OFXHEADER="200" VERSION="203" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`)
+	body := parseErrorSGML[strings.Index(parseErrorSGML, "<OFX>"):]
+	for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+		if i := strings.Index(line, ">"); i > 0 && i < len(line)-1 {
+			line += "</" + line[1:i] + ">"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func lineOf(input, s string) int {
+	return strings.Count(input[:strings.Index(input, s)], "\n") + 1
+}
+
+func checkParseError(t *testing.T, name string, err error, expectedLine int) {
+	if err == nil {
+		t.Fatalf("%s: expected error\n", name)
+	}
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("%s: expected *ParseError, got %T: %s\n", name, err, err)
+	}
+	if !reflect.DeepEqual(parseErr.Path, parseErrorPath) {
+		t.Errorf("%s: expected path %v, got %v\n", name, parseErrorPath, parseErr.Path)
+	}
+	if parseErr.Line != expectedLine {
+		t.Errorf("%s: expected line %d, got %d\n", name, expectedLine, parseErr.Line)
+	}
+	if parseErr.Unwrap() == nil || !strings.Contains(err.Error(), strings.Join(parseErrorPath, ">")) {
+		t.Errorf("%s: unexpected error message: %s\n", name, err)
+	}
+}
+
+func TestParseErrorLocation(t *testing.T) {
+	for _, input := range []string{parseErrorSGML, parseErrorXML()} {
+		line := lineOf(input, "<UNITPRICE>")
+
+		_, err := DecodeResponse(strings.NewReader(input))
+		checkParseError(t, "DecodeResponse", err, line)
+
+		_, err = ParseResponse(strings.NewReader(input))
+		checkParseError(t, "ParseResponse", err, line)
+
+		_, _, err = ParseResponseWithOptions(strings.NewReader(input), ParseOptions{})
+		checkParseError(t, "ParseResponseWithOptions", err, line)
+
+		_, err = StreamResponse(strings.NewReader(input), &StreamHandler{
+			InvTransaction: func(account *StreamAccount, tran InvTransaction) error {
+				return nil
+			},
+		})
+		checkParseError(t, "StreamResponse", err, line)
+	}
+}
+
+func TestParseErrorTruncated(t *testing.T) {
+	input := parseErrorSGML[:strings.Index(parseErrorSGML, "<CURDEF>")]
+	_, err := DecodeResponse(strings.NewReader(input))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected *ParseError, got %T: %v\n", err, err)
+	}
+	expected := []string{"OFX", "INVSTMTMSGSRSV1", "INVSTMTTRNRS", "INVSTMTRS", "DTASOF"}
+	if !reflect.DeepEqual(parseErr.Path, expected) {
+		t.Errorf("Expected path %v, got %v\n", expected, parseErr.Path)
+	}
+	if expectedLine := lineOf(input, "<DTASOF>"); parseErr.Line != expectedLine {
+		t.Errorf("Expected line %d, got %d\n", expectedLine, parseErr.Line)
+	}
+}
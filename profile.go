@@ -67,6 +67,20 @@ type SignonInfo struct {
 	AccessTokenReq    Boolean  `xml:"ACCESSTOKENREQ,omitempty"`    // Server requires ACCESSTOKEN to be sent with all requests except profile
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (si SignonInfo) Valid(version ofxVersion) (bool, error) {
+	if len(si.SignonRealm) == 0 {
+		return false, errors.New("SignonInfo.SignonRealm empty")
+	} else if si.Min < 0 || si.Max < si.Min {
+		return false, errors.New("SignonInfo.Min and Max invalid")
+	} else if !si.CharType.Valid() {
+		return false, errors.New("SignonInfo.CharType invalid")
+	} else if si.AccessTokenReq && version < OfxVersion220 {
+		return false, errors.New("SignonInfo.AccessTokenReq invalid for OFX < 2.2")
+	}
+	return true, nil
+}
+
 // MessageSet represents one message set supported by an FI and its
 // capabilities
 type MessageSet struct {
@@ -85,6 +99,26 @@ type MessageSet struct {
 	// TODO MessageSet-specific stuff?
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (ms MessageSet) Valid() (bool, error) {
+	if len(ms.Name) == 0 {
+		return false, errors.New("MessageSet.Name empty")
+	} else if ms.Ver <= 0 {
+		return false, errors.New("MessageSet.Ver invalid")
+	} else if len(ms.URL) == 0 {
+		return false, errors.New("MessageSet.URL empty")
+	} else if !ms.OfxSec.Valid() {
+		return false, errors.New("MessageSet.OfxSec invalid")
+	} else if len(ms.SignonRealm) == 0 {
+		return false, errors.New("MessageSet.SignonRealm empty")
+	} else if len(ms.Language) == 0 {
+		return false, errors.New("MessageSet.Language empty")
+	} else if !ms.SyncMode.Valid() {
+		return false, errors.New("MessageSet.SyncMode invalid")
+	}
+	return true, nil
+}
+
 // MessageSetList is a list of MessageSets (necessary because they must be
 // manually parsed)
 type MessageSetList []MessageSet
@@ -192,10 +226,42 @@ func (pr *ProfileResponse) Name() string {
 
 // Valid returns (true, nil) if this struct was valid OFX when unmarshalled
 func (pr *ProfileResponse) Valid(version ofxVersion) (bool, error) {
+	var emptyDate Date
 	if ok, err := pr.TrnUID.Valid(); !ok {
 		return false, err
+	} else if ok, err := pr.Status.Valid(); !ok {
+		return false, err
+	}
+	// PROFRS is omitted if the client's profile is up-to-date (CODE 1) or the
+	// request failed
+	if pr.Status.Code != 0 && pr.DtProfUp.Equal(emptyDate) {
+		return true, nil
+	}
+	if pr.DtProfUp.Equal(emptyDate) {
+		return false, errors.New("ProfileResponse.DtProfUp not filled")
+	} else if len(pr.FiName) == 0 {
+		return false, errors.New("ProfileResponse.FiName empty")
+	} else if len(pr.Addr1) == 0 || len(pr.City) == 0 || len(pr.State) == 0 || len(pr.PostalCode) == 0 || len(pr.Country) == 0 {
+		return false, errors.New("ProfileResponse address incomplete")
+	} else if len(pr.MessageSetList) == 0 {
+		return false, errors.New("ProfileResponse.MessageSetList empty")
+	} else if len(pr.SignonInfoList) == 0 {
+		return false, errors.New("ProfileResponse.SignonInfoList empty")
+	}
+	realms := make(map[String]bool)
+	for _, si := range pr.SignonInfoList {
+		if ok, err := si.Valid(version); !ok {
+			return false, err
+		}
+		realms[si.SignonRealm] = true
+	}
+	for _, ms := range pr.MessageSetList {
+		if ok, err := ms.Valid(); !ok {
+			return false, err
+		} else if !realms[ms.SignonRealm] {
+			return false, errors.New("MessageSet " + ms.Name + " refers to unknown SignonRealm " + ms.SignonRealm.String())
+		}
 	}
-	//TODO implement
 	return true, nil
 }
 
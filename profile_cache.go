@@ -0,0 +1,167 @@
+package ofxgo
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"github.com/aclindsa/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ProfileStore is implemented by types able to persist ProfileResponses
+// between runs for a ProfileCache
+type ProfileStore interface {
+	// LoadProfile returns the profile saved under key, or (nil, nil) if there
+	// is none
+	LoadProfile(key string) (*ProfileResponse, error)
+	// SaveProfile saves profile under key, replacing any existing profile
+	SaveProfile(key string, profile *ProfileResponse) error
+}
+
+// FileProfileStore implements ProfileStore by saving each profile as an XML
+// file in a directory
+type FileProfileStore struct {
+	Dir string // Created if it doesn't exist
+}
+
+func (s FileProfileStore) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(s.Dir, hex.EncodeToString(sum[:])+".xml")
+}
+
+// LoadProfile reads the profile saved under key, if any
+func (s FileProfileStore) LoadProfile(key string) (*ProfileResponse, error) {
+	b, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var profile ProfileResponse
+	decoder := xml.NewDecoder(bytes.NewReader(b))
+	if err := decoder.Decode(&profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// SaveProfile writes profile to a file in s.Dir, first writing it to a
+// temporary file so a partially-written profile is never read back
+func (s FileProfileStore) SaveProfile(key string, profile *ProfileResponse) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	b, err := xml.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(s.Dir, ".profile")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), s.path(key))
+}
+
+// ProfileCache avoids re-downloading an FI's profile every time it's needed,
+// only requesting a new one when the FI indicates its profile has changed
+// since the cached copy was downloaded. Profiles are cached per URL, ORG, and
+// FID.
+type ProfileCache struct {
+	Client Client
+	Store  ProfileStore
+}
+
+// NewProfileCache returns a ProfileCache which stores profiles in dir
+func NewProfileCache(c Client, dir string) *ProfileCache {
+	return &ProfileCache{
+		Client: c,
+		Store:  FileProfileStore{Dir: dir},
+	}
+}
+
+func profileKey(r *Request) string {
+	return r.URL + "\x00" + r.Signon.Org.String() + "\x00" + r.Signon.Fid.String()
+}
+
+// Profile returns the profile for the FI r is addressed to. r provides the
+// URL and signon information used if the profile must be requested, and any
+// message sets it contains are ignored.
+//
+// serverDtProfUp should be the DTPROFUP the FI returned in the SignonResponse
+// of the most recent response received from it, if any. If it is nil or no
+// newer than the cached profile's DTPROFUP, the cached profile is returned
+// without contacting the server. Otherwise the profile is requested with the
+// cached DTPROFUP, so the server may still respond that the cached profile is
+// up-to-date. In that case the cached profile is saved again with
+// serverDtProfUp as its DTPROFUP, so later calls passing the same DTPROFUP
+// don't contact the server again.
+func (pc *ProfileCache) Profile(r *Request, serverDtProfUp *Date) (*ProfileResponse, error) {
+	key := profileKey(r)
+	cached, err := pc.Store.LoadProfile(key)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && (serverDtProfUp == nil || !serverDtProfUp.After(cached.DtProfUp.Time)) {
+		return cached, nil
+	}
+
+	uid, err := RandomUID()
+	if err != nil {
+		return nil, err
+	}
+	profileRequest := ProfileRequest{
+		TrnUID:   *uid,
+		DtProfUp: Date{Time: time.Unix(0, 0)},
+	}
+	if cached != nil {
+		profileRequest.DtProfUp = cached.DtProfUp
+	}
+	request := Request{
+		URL:    r.URL,
+		Signon: r.Signon,
+		Prof:   []Message{&profileRequest},
+	}
+
+	response, err := pc.Client.Request(&request)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Prof) < 1 {
+		return nil, errors.New("No PROFTRNRS returned for ProfileRequest")
+	}
+	profile, ok := response.Prof[0].(*ProfileResponse)
+	if !ok {
+		return nil, errors.New("Unexpected message type returned for ProfileRequest")
+	}
+
+	// Status 1 means "Client is up-to-date"
+	if profile.Status.Code == 1 && cached != nil {
+		cached.DtProfUp = *serverDtProfUp
+		if err := pc.Store.SaveProfile(key, cached); err != nil {
+			return nil, err
+		}
+		return cached, nil
+	}
+	if profile.Status.Code != 0 {
+		meaning, _ := profile.Status.CodeMeaning()
+		return nil, errors.New("Error requesting profile: " + meaning)
+	}
+	if err := pc.Store.SaveProfile(key, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
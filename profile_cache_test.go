@@ -0,0 +1,116 @@
+package ofxgo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestFileProfileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ofxgo-profiles")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %s\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := FileProfileStore{Dir: dir}
+	profile, err := store.LoadProfile("missing")
+	if profile != nil || err != nil {
+		t.Fatalf("Expected (nil, nil) loading missing profile, got (%v, %v)\n", profile, err)
+	}
+
+	expected := testProfile()
+	expected.DtProfUp = *NewDateGMT(2017, 4, 3, 9, 34, 58, 0)
+	expected.FiName = "Example Bank"
+	if err := store.SaveProfile("key", expected); err != nil {
+		t.Fatalf("Unexpected error saving profile: %s\n", err)
+	}
+	profile, err = store.LoadProfile("key")
+	if err != nil {
+		t.Fatalf("Unexpected error loading profile: %s\n", err)
+	}
+	checkEqual(t, "ProfileResponse", reflect.ValueOf(expected), reflect.ValueOf(profile))
+}
+
+func TestProfileCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ofxgo-profiles")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %s\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var requests int
+	var upToDate bool
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		profile := validTestProfile()
+		profile.DtProfUp = *NewDateGMT(2017, 4, 3, 9, 34, 58, 0)
+		if upToDate {
+			profile = &ProfileResponse{
+				TrnUID: profile.TrnUID,
+				Status: Status{Code: 1, Severity: "INFO"},
+			}
+		}
+		var response Response
+		response.Version = OfxVersion203
+		response.Signon.Status = Status{Code: 0, Severity: "INFO"}
+		response.Signon.DtServer = *NewDateGMT(2017, 4, 3, 9, 34, 58, 0)
+		response.Signon.Language = "ENG"
+		response.Prof = append(response.Prof, profile)
+		b, err := response.Marshal()
+		if err != nil {
+			t.Errorf("Unexpected error marshalling response: %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(b.Bytes())
+	}))
+	defer server.Close()
+
+	cache := NewProfileCache(&BasicClient{HTTPClient: server.Client()}, dir)
+	var r Request
+	r.URL = server.URL
+	r.Signon.UserID = "anonymous00000000000000000000000"
+	r.Signon.UserPass = "anonymous00000000000000000000000"
+
+	profile, err := cache.Profile(&r, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error fetching profile: %s\n", err)
+	}
+	if requests != 1 || profile.MessageSet("BANKMSGSETV1") == nil {
+		t.Fatalf("Expected profile to be downloaded\n")
+	}
+
+	// Cached profile is used when the server's DTPROFUP isn't newer
+	profile, err = cache.Profile(&r, NewDateGMT(2017, 4, 3, 9, 34, 58, 0))
+	if err != nil {
+		t.Fatalf("Unexpected error fetching cached profile: %s\n", err)
+	}
+	if requests != 1 || profile.MessageSet("BANKMSGSETV1") == nil {
+		t.Fatalf("Expected cached profile to be returned without a request\n")
+	}
+
+	// Newer DTPROFUP causes a request, but the server may still say the
+	// cached profile is up-to-date
+	upToDate = true
+	profile, err = cache.Profile(&r, NewDateGMT(2018, 1, 1, 0, 0, 0, 0))
+	if err != nil {
+		t.Fatalf("Unexpected error refreshing profile: %s\n", err)
+	}
+	if requests != 2 || profile.MessageSet("BANKMSGSETV1") == nil {
+		t.Fatalf("Expected profile to be refreshed, and cached profile returned\n")
+	}
+
+	// The server already said the cached profile is up-to-date as of this
+	// DTPROFUP, so it isn't asked again
+	profile, err = cache.Profile(&r, NewDateGMT(2018, 1, 1, 0, 0, 0, 0))
+	if err != nil {
+		t.Fatalf("Unexpected error fetching cached profile: %s\n", err)
+	}
+	if requests != 2 || profile.MessageSet("BANKMSGSETV1") == nil {
+		t.Fatalf("Expected cached profile to be returned without a request\n")
+	}
+}
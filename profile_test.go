@@ -326,3 +326,63 @@ NEWFILEUID:NONE
 	checkResponsesEqual(t, &expected, response)
 	checkResponseRoundTrip(t, response)
 }
+
+// validTestProfile returns testProfile() with the remaining PROFRS elements
+// required for it to pass validation filled in
+func validTestProfile() *ProfileResponse {
+	pr := testProfile()
+	pr.Status = Status{Code: 0, Severity: "INFO"}
+	pr.DtProfUp = *NewDateGMT(2017, 4, 1, 0, 0, 0, 0)
+	pr.FiName = "Example"
+	pr.Addr1 = "1 Example Way"
+	pr.City = "Anytown"
+	pr.State = "NY"
+	pr.PostalCode = "10001"
+	pr.Country = "USA"
+	return pr
+}
+
+func TestProfileResponseValid(t *testing.T) {
+	pr := validTestProfile()
+	if ok, err := pr.Valid(OfxVersion203); !ok {
+		t.Fatalf("Unexpected error from calling Valid: %s\n", err)
+	}
+
+	// PROFRS is omitted when the client's profile is up-to-date
+	upToDate := ProfileResponse{
+		TrnUID: pr.TrnUID,
+		Status: Status{Code: 1, Severity: "INFO"},
+	}
+	if ok, err := upToDate.Valid(OfxVersion203); !ok {
+		t.Errorf("Unexpected error from calling Valid on up-to-date response: %s\n", err)
+	}
+
+	bad := *pr
+	bad.FiName = ""
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with empty FiName\n")
+	}
+
+	bad = *pr
+	bad.SignonInfoList = nil
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with empty SignonInfoList\n")
+	}
+
+	bad = *pr
+	bad.MessageSetList = MessageSetList{pr.MessageSetList[0]}
+	bad.MessageSetList[0].SignonRealm = "Unknown"
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with unknown SignonRealm\n")
+	}
+
+	bad = *pr
+	bad.SignonInfoList = []SignonInfo{pr.SignonInfoList[0]}
+	bad.SignonInfoList[0].AccessTokenReq = true
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with AccessTokenReq for OFX 2.0.3\n")
+	}
+	if ok, err := bad.Valid(OfxVersion220); !ok {
+		t.Errorf("Unexpected error from calling Valid with AccessTokenReq for OFX 2.2: %s\n", err)
+	}
+}
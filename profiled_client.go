@@ -0,0 +1,289 @@
+package ofxgo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// ProfiledClient wraps another Client, using the capabilities an FI advertised
+// in its ProfileResponse to configure requests before they are sent. Each
+// message set in a Request is routed to the URL the FI listed for it
+// (splitting the Request into several if necessary), CLIENTUID is set if the
+// FI requires it, and the password is checked against the FI's rules so that
+// obviously-bad passwords aren't sent (and counted against the user) at all.
+type ProfiledClient struct {
+	Client
+	Profile   *ProfileResponse
+	ClientUID UID // Sent as SONRQ>CLIENTUID if the FI's SignonInfo requires it
+}
+
+// NewProfiledClient returns a ProfiledClient which sends requests with c,
+// configured according to profile
+func NewProfiledClient(c Client, profile *ProfileResponse, clientUID UID) *ProfiledClient {
+	return &ProfiledClient{
+		Client:    c,
+		Profile:   profile,
+		ClientUID: clientUID,
+	}
+}
+
+// requestMessageSet pairs the messages in one of a Request's message sets with
+// the type of that message set
+type requestMessageSet struct {
+	Messages *[]Message
+	Type     messageType
+}
+
+// requestMessageSets returns pointers to each of the message sets (other than
+// signon) in a Request, in the order they are marshaled
+func requestMessageSets(r *Request) []requestMessageSet {
+	return []requestMessageSet{
+		{&r.Signup, SignupRq},
+		{&r.Bank, BankRq},
+		{&r.CreditCard, CreditCardRq},
+		{&r.Loan, LoanRq},
+		{&r.InvStmt, InvStmtRq},
+		{&r.InterXfer, InterXferRq},
+		{&r.WireXfer, WireXferRq},
+		{&r.Billpay, BillpayRq},
+		{&r.Email, EmailRq},
+		{&r.SecList, SecListRq},
+		{&r.PresDir, PresDirRq},
+		{&r.PresDlv, PresDlvRq},
+		{&r.Prof, ProfRq},
+		{&r.Image, ImageRq},
+	}
+}
+
+// responseMessageSets returns pointers to each of the message sets (other than
+// signon) in a Response, in the order they are marshaled
+func responseMessageSets(r *Response) []*[]Message {
+	return []*[]Message{
+		&r.Signup,
+		&r.Bank,
+		&r.CreditCard,
+		&r.Loan,
+		&r.InvStmt,
+		&r.InterXfer,
+		&r.WireXfer,
+		&r.Billpay,
+		&r.Email,
+		&r.SecList,
+		&r.PresDir,
+		&r.PresDlv,
+		&r.Prof,
+		&r.Image,
+	}
+}
+
+// profileMessageSetName returns the name a message set is listed under in a
+// profile's MSGSETLIST (i.e. BANKMSGSETV1 for BankRq)
+func profileMessageSetName(t messageType) string {
+	name := t.String()
+	name = strings.Replace(name, "MSGSRQV", "MSGSETV", 1)
+	return strings.Replace(name, "MSGSRSV", "MSGSETV", 1)
+}
+
+// MessageSet returns the MessageSet the profile lists for the given message
+// set name (i.e. "BANKMSGSETV1"), or nil if the FI doesn't support it
+func (pr *ProfileResponse) MessageSet(name string) *MessageSet {
+	for i := range pr.MessageSetList {
+		if pr.MessageSetList[i].Name == name {
+			return &pr.MessageSetList[i]
+		}
+	}
+	return nil
+}
+
+// SignonInfo returns the SignonInfo for the given signon realm, or nil if the
+// profile doesn't contain one
+func (pr *ProfileResponse) SignonInfo(realm String) *SignonInfo {
+	for i := range pr.SignonInfoList {
+		if pr.SignonInfoList[i].SignonRealm == realm {
+			return &pr.SignonInfoList[i]
+		}
+	}
+	return nil
+}
+
+// CheckPassword returns an error if password doesn't satisfy the FI's
+// requirements for passwords in this signon realm
+func (si *SignonInfo) CheckPassword(password String) error {
+	length := Int(utf8.RuneCountInString(string(password)))
+	if si.Min > 0 && length < si.Min {
+		return fmt.Errorf("Password shorter than the minimum of %d characters required by the FI", si.Min)
+	}
+	if si.Max > 0 && length > si.Max {
+		return fmt.Errorf("Password longer than the maximum of %d characters allowed by the FI", si.Max)
+	}
+
+	var alpha, numeric bool
+	for _, c := range password {
+		switch {
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			alpha = true
+		case c >= '0' && c <= '9':
+			numeric = true
+		case c == ' ':
+			if !si.Spaces {
+				return errors.New("Password contains spaces, which are not allowed by the FI")
+			}
+		default:
+			if !si.Special {
+				return errors.New("Password contains special characters, which are not allowed by the FI")
+			}
+		}
+	}
+
+	switch si.CharType {
+	case CharTypeAlphaOnly:
+		if numeric {
+			return errors.New("Password contains numbers, but the FI only allows letters")
+		}
+	case CharTypeNumericOnly:
+		if alpha {
+			return errors.New("Password contains letters, but the FI only allows numbers")
+		}
+	case CharTypeAlphaAndNumeric:
+		if !alpha || !numeric {
+			return errors.New("Password must contain both letters and numbers")
+		}
+	}
+	return nil
+}
+
+// Prepare splits r into one Request per URL its message sets are routed to by
+// the profile, setting CLIENTUID and checking the password for the signon
+// realm of each. Requests with no messages other than signon are sent to the
+// URL the signon message set is listed with. An error is returned if r
+// contains a message set the FI didn't list in its profile, or if the profile
+// requires something of the client it is unable to provide.
+func (pc *ProfiledClient) Prepare(r *Request) ([]*Request, error) {
+	var requests []*Request
+	byURL := make(map[string]*Request)
+
+	addMessageSet := func(t messageType, messages []Message) error {
+		name := profileMessageSetName(t)
+		msgset := pc.Profile.MessageSet(name)
+		if msgset == nil {
+			return errors.New("FI profile does not list support for " + name)
+		}
+		if msgset.OfxSec == OfxSecType1 {
+			return errors.New("FI requires unsupported 'TYPE 1' security for " + name)
+		}
+		if len(msgset.Language) > 0 {
+			language := r.Signon.Language
+			if len(language) == 0 {
+				language = "ENG"
+			}
+			supported := false
+			for _, l := range msgset.Language {
+				if l == language {
+					supported = true
+				}
+			}
+			if !supported {
+				return errors.New("FI does not support language " + language.String() + " for " + name)
+			}
+		}
+
+		URL := msgset.URL.String()
+		if len(URL) == 0 {
+			URL = r.URL
+		}
+		request, ok := byURL[URL]
+		if !ok {
+			request = &Request{
+				URL:     URL,
+				Version: r.Version,
+				Signon:  r.Signon,
+			}
+			byURL[URL] = request
+			requests = append(requests, request)
+		}
+
+		if si := pc.Profile.SignonInfo(msgset.SignonRealm); si != nil {
+			if len(request.Signon.UserPass) > 0 {
+				if err := si.CheckPassword(request.Signon.UserPass); err != nil {
+					return err
+				}
+			}
+			if si.ClientUIDReq {
+				if len(pc.ClientUID) == 0 {
+					return errors.New("FI requires CLIENTUID, but ProfiledClient.ClientUID is empty")
+				}
+				request.Signon.ClientUID = pc.ClientUID
+			}
+		}
+
+		for _, set := range requestMessageSets(request) {
+			if set.Type == t {
+				*set.Messages = append(*set.Messages, messages...)
+			}
+		}
+		return nil
+	}
+
+	for _, set := range requestMessageSets(r) {
+		if len(*set.Messages) > 0 {
+			if err := addMessageSet(set.Type, *set.Messages); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(requests) == 0 {
+		if err := addMessageSet(SignonRq, nil); err != nil {
+			return nil, err
+		}
+	}
+	return requests, nil
+}
+
+// RequestNoParse prepares the request according to the FI's profile and sends
+// it. Because only one HTTP response can be returned, an error is returned if
+// the profile routes the request's message sets to more than one URL.
+func (pc *ProfiledClient) RequestNoParse(r *Request) (*http.Response, error) {
+	requests, err := pc.Prepare(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(requests) != 1 {
+		return nil, errors.New("Request must be split across multiple URLs; use ProfiledClient.Request instead")
+	}
+	return pc.Client.RequestNoParse(requests[0])
+}
+
+// Request prepares the request according to the FI's profile, sends each of
+// the resulting requests, and merges their responses into one Response. The
+// Signon of the returned Response is that of the first response received. As
+// with Client.Request, the merged Response is returned along with a
+// *StatusError if the signon or any transaction failed.
+func (pc *ProfiledClient) Request(r *Request) (*Response, error) {
+	requests, err := pc.Prepare(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var merged *Response
+	var statusErr error
+	for _, request := range requests {
+		response, err := pc.Client.Request(request)
+		if response == nil {
+			return nil, err
+		} else if err != nil && statusErr == nil {
+			statusErr = err
+		}
+		if merged == nil {
+			merged = response
+			continue
+		}
+		mergedSets := responseMessageSets(merged)
+		for i, set := range responseMessageSets(response) {
+			*mergedSets[i] = append(*mergedSets[i], *set...)
+		}
+	}
+	return merged, statusErr
+}
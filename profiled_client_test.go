@@ -0,0 +1,153 @@
+package ofxgo
+
+import (
+	"testing"
+)
+
+func testProfile() *ProfileResponse {
+	msgset := func(name, URL string) MessageSet {
+		return MessageSet{
+			Name:        name,
+			Ver:         1,
+			URL:         String(URL),
+			OfxSec:      OfxSecNone,
+			TranspSec:   true,
+			SignonRealm: "Example",
+			Language:    []String{"ENG"},
+			SyncMode:    SyncModeLite,
+		}
+	}
+	return &ProfileResponse{
+		TrnUID: "0f94ce83-13b7-7568-e4fc-c02c7b47e7ab",
+		MessageSetList: MessageSetList{
+			msgset("SIGNONMSGSETV1", "https://ofx.example.com/signon"),
+			msgset("SIGNUPMSGSETV1", "https://ofx.example.com/signon"),
+			msgset("BANKMSGSETV1", "https://ofx.example.com/bank"),
+			msgset("INVSTMTMSGSETV1", "https://ofx.example.com/invest"),
+			msgset("SECLISTMSGSETV1", "https://ofx.example.com/invest"),
+		},
+		SignonInfoList: []SignonInfo{
+			{
+				SignonRealm:  "Example",
+				Min:          6,
+				Max:          12,
+				CharType:     CharTypeAlphaAndNumeric,
+				Special:      false,
+				Spaces:       false,
+				ClientUIDReq: true,
+			},
+		},
+	}
+}
+
+func TestProfiledClientPrepare(t *testing.T) {
+	pc := NewProfiledClient(&BasicClient{}, testProfile(), "00000000-0000-0000-0000-000000000000")
+
+	var r Request
+	r.URL = "https://ofx.example.com/"
+	r.Signon.UserID = "myusername"
+	r.Signon.UserPass = "Passw0rd"
+	r.Bank = append(r.Bank, &StatementRequest{TrnUID: "1"})
+	r.InvStmt = append(r.InvStmt, &InvStatementRequest{TrnUID: "2"})
+	r.SecList = append(r.SecList, &SecListRequest{TrnUID: "3"})
+
+	requests, err := pc.Prepare(&r)
+	if err != nil {
+		t.Fatalf("Unexpected error preparing request: %s\n", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Expected request to be split into 2, got %d\n", len(requests))
+	}
+	if requests[0].URL != "https://ofx.example.com/bank" || len(requests[0].Bank) != 1 || len(requests[0].InvStmt) != 0 {
+		t.Errorf("Bank message set not routed correctly: %+v\n", requests[0])
+	}
+	if requests[1].URL != "https://ofx.example.com/invest" || len(requests[1].InvStmt) != 1 || len(requests[1].SecList) != 1 || len(requests[1].Bank) != 0 {
+		t.Errorf("Investment message sets not routed correctly: %+v\n", requests[1])
+	}
+	for _, request := range requests {
+		if request.Signon.ClientUID != pc.ClientUID {
+			t.Errorf("Expected CLIENTUID to be set, got %q\n", request.Signon.ClientUID)
+		}
+		if request.Signon.UserID != "myusername" {
+			t.Errorf("Expected signon to be copied to each request\n")
+		}
+	}
+	if len(r.Bank) != 1 || len(r.InvStmt) != 1 || len(r.Signon.ClientUID) != 0 {
+		t.Errorf("Prepare modified the original request\n")
+	}
+
+	// Signon-only requests go to the signon message set's URL
+	r.Bank, r.InvStmt, r.SecList = nil, nil, nil
+	requests, err = pc.Prepare(&r)
+	if err != nil {
+		t.Fatalf("Unexpected error preparing signon-only request: %s\n", err)
+	}
+	if len(requests) != 1 || requests[0].URL != "https://ofx.example.com/signon" {
+		t.Errorf("Signon-only request not routed to signon URL: %+v\n", requests)
+	}
+
+	// Unsupported message sets are errors
+	r.CreditCard = append(r.CreditCard, &CCStatementRequest{TrnUID: "4"})
+	if _, err = pc.Prepare(&r); err == nil {
+		t.Errorf("Expected error preparing request for unsupported message set\n")
+	}
+	r.CreditCard = nil
+
+	// As are missing CLIENTUIDs, unsupported languages, and bad passwords
+	pc.ClientUID = ""
+	if _, err = pc.Prepare(&r); err == nil {
+		t.Errorf("Expected error preparing request without required CLIENTUID\n")
+	}
+	pc.ClientUID = "00000000-0000-0000-0000-000000000000"
+	r.Signon.Language = "SPA"
+	if _, err = pc.Prepare(&r); err == nil {
+		t.Errorf("Expected error preparing request with unsupported language\n")
+	}
+	r.Signon.Language = ""
+	r.Signon.UserPass = "password"
+	if _, err = pc.Prepare(&r); err == nil {
+		t.Errorf("Expected error preparing request with invalid password\n")
+	}
+}
+
+func TestSignonInfoCheckPassword(t *testing.T) {
+	tests := []struct {
+		charType charType
+		special  Boolean
+		spaces   Boolean
+		password String
+		valid    bool
+	}{
+		{CharTypeAlphaOrNumeric, false, false, "abc123", true},
+		{CharTypeAlphaOrNumeric, false, false, "abc", false},
+		{CharTypeAlphaOrNumeric, false, false, "abcdefghijklm", false},
+		{CharTypeAlphaOnly, false, false, "abcdef", true},
+		{CharTypeAlphaOnly, false, false, "abcde1", false},
+		{CharTypeNumericOnly, false, false, "123456", true},
+		{CharTypeNumericOnly, false, false, "12345a", false},
+		{CharTypeAlphaAndNumeric, false, false, "abcdef", false},
+		{CharTypeAlphaAndNumeric, false, false, "abcde1", true},
+		{CharTypeAlphaOrNumeric, false, false, "abc12$", false},
+		{CharTypeAlphaOrNumeric, true, false, "abc12$", true},
+		{CharTypeAlphaOrNumeric, false, false, "abc 12", false},
+		{CharTypeAlphaOrNumeric, false, true, "abc 12", true},
+		// Lengths are counted in characters, not bytes
+		{CharTypeAlphaOrNumeric, true, false, "äöüäöüäöüäöü", true},
+		{CharTypeAlphaOrNumeric, true, false, "äöü", false},
+	}
+	for _, test := range tests {
+		si := SignonInfo{
+			Min:      4,
+			Max:      12,
+			CharType: test.charType,
+			Special:  test.special,
+			Spaces:   test.spaces,
+		}
+		err := si.CheckPassword(test.password)
+		if test.valid && err != nil {
+			t.Errorf("Unexpected error checking %s password %q: %s\n", test.charType, test.password, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("Expected error checking %s password %q\n", test.charType, test.password)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Package qif converts between OFX and the Quicken Interchange Format (QIF),
+// which many older personal finance tools still import and export.
+//
+// Write and WriteStatement write QIF for the bank, credit card, and investment
+// statements in parsed OFX responses. Parse reads QIF into ofxgo Transaction
+// and InvTransaction values, which can be placed in a TransactionList or
+// InvTranList to convert the QIF to OFX. QIF has no equivalent of FITID, so
+// Parse synthesizes one for each transaction from its contents; parsing the
+// same QIF twice yields the same FITIDs.
+package qif
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aclindsa/ofxgo"
+)
+
+// Account types, as used in QIF "!Type:" headers
+const (
+	TypeBank       = "Bank"
+	TypeCash       = "Cash"
+	TypeCreditCard = "CCard"
+	TypeInvestment = "Invst"
+	TypeAsset      = "Oth A"
+	TypeLiability  = "Oth L"
+)
+
+// DefaultDateFormat is the format (in the form expected by time.Parse) dates
+// are written in if WriteOptions doesn't specify one
+const DefaultDateFormat = "01/02/2006"
+
+// Investment actions, as used in the "N" field of investment transactions
+const (
+	ActionBuy          = "Buy"
+	ActionBuyToCover   = "CvrShrt"
+	ActionSell         = "Sell"
+	ActionSellShort    = "ShtSell"
+	ActionDiv          = "Div"
+	ActionIntInc       = "IntInc"
+	ActionCGLong       = "CGLong"
+	ActionCGShort      = "CGShort"
+	ActionMiscInc      = "MiscInc"
+	ActionReinvDiv     = "ReinvDiv"
+	ActionReinvInt     = "ReinvInt"
+	ActionReinvLg      = "ReinvLg"
+	ActionReinvSh      = "ReinvSh"
+	ActionReinvMd      = "ReinvMd"
+	ActionShrsIn       = "ShrsIn"
+	ActionShrsOut      = "ShrsOut"
+	ActionStkSplit     = "StkSplit"
+	ActionMargInt      = "MargInt"
+	ActionMiscExp      = "MiscExp"
+	ActionRtrnCap      = "RtrnCap"
+	ActionExercise     = "Exercise"
+	ActionExpire       = "Expire"
+	ActionXIn          = "XIn"
+	ActionXOut         = "XOut"
+	ActionCash         = "Cash"
+	ActionContribution = "ContribX"
+	ActionWithdrawal   = "WithdrwX"
+)
+
+// Security types, as used in the "T" field of "!Type:Security" records
+const (
+	SecurityTypeStock      = "Stock"
+	SecurityTypeMutualFund = "Mutual Fund"
+	SecurityTypeBond       = "Bond"
+	SecurityTypeOption     = "Option"
+	SecurityTypeOther      = "Other"
+)
+
+// SecurityIDType is the UNIQUEIDTYPE of the SecurityIDs Parse creates for
+// securities, since QIF identifies them only by name and ticker symbol
+const SecurityIDType = "TICKER"
+
+// incomeActions maps the OFX income types (as strings) to the QIF actions for
+// income and reinvested income of that type
+var incomeActions = map[string][2]string{
+	"CGLONG":   {ActionCGLong, ActionReinvLg},
+	"CGSHORT":  {ActionCGShort, ActionReinvSh},
+	"DIV":      {ActionDiv, ActionReinvDiv},
+	"INTEREST": {ActionIntInc, ActionReinvInt},
+	"MISC":     {ActionMiscInc, ActionReinvDiv},
+}
+
+// parseAmount parses a QIF amount, which may contain thousands separators
+func parseAmount(s string) (ofxgo.Amount, error) {
+	var a ofxgo.Amount
+	s = strings.Replace(strings.TrimSpace(s), ",", "", -1)
+	if len(s) == 0 {
+		return a, nil
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return a, fmt.Errorf("Invalid amount: %q", s)
+	}
+	a.Set(r)
+	return a, nil
+}
+
+// formatAmount formats a for QIF
+func formatAmount(a *big.Rat) string {
+	var amount ofxgo.Amount
+	amount.Set(a)
+	return amount.String()
+}
+
+// parseDate parses a QIF date. QIF dates are written in a variety of ways:
+// month first (or day first if dayFirst is set) separated by '/', '-', or
+// '.', with two- or four-digit years. Quicken separates two-digit years in
+// 2000 and later with an apostrophe (i.e. 1/5'18), and pads with spaces.
+// Years written with four digits first (i.e. 2018-01-05) are also accepted.
+func parseDate(s string, dayFirst bool) (ofxgo.Date, error) {
+	value := strings.Replace(strings.TrimSpace(s), " ", "", -1)
+	apostrophe := strings.Contains(value, "'")
+	parts := strings.FieldsFunc(value, func(r rune) bool {
+		return r == '/' || r == '-' || r == '.' || r == '\''
+	})
+	if len(parts) != 3 {
+		return ofxgo.Date{}, fmt.Errorf("Invalid date: %q", s)
+	}
+	var numbers [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return ofxgo.Date{}, fmt.Errorf("Invalid date: %q", s)
+		}
+		numbers[i] = n
+	}
+
+	var year, month, day int
+	if len(parts[0]) == 4 {
+		year, month, day = numbers[0], numbers[1], numbers[2]
+	} else {
+		month, day, year = numbers[0], numbers[1], numbers[2]
+		if dayFirst {
+			month, day = day, month
+		}
+		if len(parts[2]) <= 2 {
+			if apostrophe || year < 70 {
+				year += 2000
+			} else {
+				year += 1900
+			}
+		}
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return ofxgo.Date{}, fmt.Errorf("Invalid date: %q", s)
+	}
+	return ofxgo.Date{Time: time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)}, nil
+}
+
+var errNoSecurity = errors.New("Investment transaction has no security (Y)")
@@ -0,0 +1,373 @@
+package qif
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/internal/ofxtest"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value    string
+		dayFirst bool
+		expected time.Time
+	}{
+		{"01/05/2018", false, time.Date(2018, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"1/ 5'18", false, time.Date(2018, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"12/31/99", false, time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"12/31/05", false, time.Date(2005, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"31.12.2017", true, time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"2017-12-31", false, time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		d, err := parseDate(test.value, test.dayFirst)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %q: %s\n", test.value, err)
+		} else if !d.Time.Equal(test.expected) {
+			t.Errorf("Expected %q to parse as %s, got %s\n", test.value, test.expected, d)
+		}
+	}
+	for _, value := range []string{"", "13/01/2018", "1/2", "Jan 5, 2018"} {
+		if _, err := parseDate(value, false); err == nil {
+			t.Errorf("Expected error parsing %q\n", value)
+		}
+	}
+}
+
+const bankQIF = `!Option:AutoSwitch
+!Account
+NChecking
+TBank
+^
+!Clear:AutoSwitch
+!Type:Bank
+D1/ 5'18
+T-1,234.56
+N1001
+PLandlord
+MJanuary rent
+LHousing:Rent
+^
+D01/06/2018
+T50.00
+NDEP
+PRefund
+^
+D01/06/2018
+T50.00
+NDEP
+PRefund
+^
+!Type:Cat
+NHousing
+D
+E
+^
+!Type:CCard
+D01/07/2018
+U-25.5
+PCoffee
+`
+
+func TestParseBank(t *testing.T) {
+	f, err := Parse(strings.NewReader(bankQIF), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing QIF: %s\n", err)
+	}
+	if len(f.Sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d\n", len(f.Sections))
+	}
+
+	bank := f.Sections[0]
+	if bank.Type != TypeBank || bank.Account != "Checking" || len(bank.Transactions) != 3 {
+		t.Fatalf("Unexpected bank section: %v\n", bank)
+	}
+	rent := bank.Transactions[0]
+	if rent.TrnType != ofxgo.TrnTypeCheck || rent.CheckNum != "1001" || !ofxtest.AmountEqual(rent.TrnAmt, "-1234.56") ||
+		rent.Name != "Landlord" || rent.Memo != "January rent" || !rent.DtPosted.Time.Equal(time.Date(2018, 1, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected rent transaction: %v\n", rent)
+	}
+	if bank.Transactions[1].TrnType != ofxgo.TrnTypeDep {
+		t.Errorf("Expected DEP transaction type, got %s\n", bank.Transactions[1].TrnType)
+	}
+	if bank.Transactions[1].FiTID == bank.Transactions[2].FiTID {
+		t.Errorf("Expected identical transactions to get different FITIDs\n")
+	}
+
+	cc := f.Sections[1]
+	if cc.Type != TypeCreditCard || len(cc.Transactions) != 1 || cc.Transactions[0].TrnType != ofxgo.TrnTypeDebit || !ofxtest.AmountEqual(cc.Transactions[0].TrnAmt, "-25.5") {
+		t.Errorf("Unexpected credit card section: %v\n", cc)
+	}
+
+	// FITIDs are stable across parses
+	again, err := Parse(strings.NewReader(bankQIF), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing QIF: %s\n", err)
+	}
+	for i, tran := range again.Sections[0].Transactions {
+		if tran.FiTID != bank.Transactions[i].FiTID {
+			t.Errorf("Expected FITID %s for transaction %d, got %s\n", bank.Transactions[i].FiTID, i, tran.FiTID)
+		}
+	}
+
+	l := bank.TransactionList()
+	if !l.DtStart.Equal(rent.DtPosted) || !l.DtEnd.Time.Equal(time.Date(2018, 1, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected transaction list dates %s to %s\n", l.DtStart, l.DtEnd)
+	}
+	for _, tran := range l.Transactions {
+		if ok, err := tran.Valid(ofxgo.OfxVersion203); !ok {
+			t.Errorf("Expected valid transaction, got %s\n", err)
+		}
+	}
+}
+
+const invstQIF = `!Type:Security
+NVanguard 500 Index
+SVFIAX
+TMutual Fund
+^
+!Type:Invst
+D01/10/2018
+NBuyX
+YVanguard 500 Index
+I250.00
+Q4
+T1,005.00
+O5.00
+^
+D02/10/2018
+NReinvDiv
+YVanguard 500 Index
+I260
+Q0.1
+T26
+^
+D03/10/2018
+NDiv
+YACME
+T12.34
+^
+D04/10/2018
+NStkSplit
+YACME
+Q20
+^
+D05/10/2018
+NXIn
+T1000
+PDeposit
+^
+D06/10/2018
+NSell
+YVFIAX
+I270
+Q2
+T540
+^
+`
+
+func TestParseInvestment(t *testing.T) {
+	f, err := Parse(strings.NewReader(invstQIF), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing QIF: %s\n", err)
+	}
+	if len(f.Sections) != 1 || f.Sections[0].Type != TypeInvestment {
+		t.Fatalf("Unexpected sections: %v\n", f.Sections)
+	}
+	s := f.Sections[0]
+	if len(s.InvTransactions) != 5 {
+		t.Fatalf("Expected 5 investment transactions, got %d\n", len(s.InvTransactions))
+	}
+	fund := ofxgo.SecurityID{UniqueID: "VFIAX", UniqueIDType: SecurityIDType}
+
+	buy, ok := s.InvTransactions[0].(ofxgo.BuyMF)
+	if !ok {
+		t.Fatalf("Expected a mutual fund purchase, got %s\n", s.InvTransactions[0].TransactionType())
+	}
+	if buy.InvBuy.SecID != fund || !ofxtest.AmountEqual(buy.InvBuy.Units, "4") || !ofxtest.AmountEqual(buy.InvBuy.UnitPrice, "250") || !ofxtest.AmountEqual(buy.InvBuy.Commission, "5") || !ofxtest.AmountEqual(buy.InvBuy.Total, "-1005") {
+		t.Errorf("Unexpected purchase: %v\n", buy)
+	}
+	if reinvest, ok := s.InvTransactions[1].(ofxgo.Reinvest); !ok || reinvest.IncomeType != ofxgo.IncomeTypeDiv || !ofxtest.AmountEqual(reinvest.Units, "0.1") {
+		t.Errorf("Unexpected reinvestment: %v\n", s.InvTransactions[1])
+	}
+	income, ok := s.InvTransactions[2].(ofxgo.Income)
+	if !ok || income.IncomeType != ofxgo.IncomeTypeDiv || !ofxtest.AmountEqual(income.Total, "12.34") || income.SecID.UniqueID != "ACME" {
+		t.Errorf("Unexpected income: %v\n", s.InvTransactions[2])
+	}
+	if split, ok := s.InvTransactions[3].(ofxgo.Split); !ok || split.Numerator != 2 || split.Denominator != 1 {
+		t.Errorf("Unexpected split: %v\n", s.InvTransactions[3])
+	}
+	// Securities may be referred to by symbol as well as name
+	if sell, ok := s.InvTransactions[4].(ofxgo.SellMF); !ok || sell.InvSell.SecID != fund || !ofxtest.AmountEqual(sell.InvSell.Units, "-2") || !ofxtest.AmountEqual(sell.InvSell.Total, "540") {
+		t.Errorf("Unexpected sale: %v\n", s.InvTransactions[4])
+	}
+	tranList := ofxgo.InvTranList{
+		DtStart:         *ofxgo.NewDateGMT(2018, 1, 1, 0, 0, 0, 0),
+		DtEnd:           *ofxgo.NewDateGMT(2018, 12, 31, 0, 0, 0, 0),
+		InvTransactions: s.InvTransactions,
+	}
+	if ok, err := tranList.Valid(ofxgo.OfxVersion203); !ok {
+		t.Errorf("Expected valid investment transactions, got %s\n", err)
+	}
+
+	if len(s.Transactions) != 1 || !ofxtest.AmountEqual(s.Transactions[0].TrnAmt, "1000") || s.Transactions[0].Name != "Deposit" {
+		t.Errorf("Expected cash transfer as a bank transaction, got %v\n", s.Transactions)
+	}
+	l := s.InvTranList()
+	if len(l.BankTransactions) != 1 || !l.DtEnd.Time.Equal(time.Date(2018, 6, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected investment transaction list %v\n", l)
+	}
+
+	if len(f.Securities) != 2 {
+		t.Fatalf("Expected 2 securities, got %d\n", len(f.Securities))
+	}
+	if mf, ok := f.Securities[0].(ofxgo.MFInfo); !ok || mf.SecInfo.SecID != fund || mf.SecInfo.SecName != "Vanguard 500 Index" {
+		t.Errorf("Unexpected security %v\n", f.Securities[0])
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"!Type:Bank\nT12\n^\n",
+		"!Type:Bank\nD01/01/2018\nTtwelve\n^\n",
+		"!Type:Invst\nD01/01/2018\nNBuy\nT12\n^\n",
+		"!Type:Invst\nD01/01/2018\nNGift\nYACME\n^\n",
+	}
+	for _, test := range tests {
+		if _, err := Parse(strings.NewReader(test), nil); err == nil {
+			t.Errorf("Expected error parsing %q\n", test)
+		}
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	secID := ofxgo.SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}
+	usd, _ := ofxgo.NewCurrSymbol("USD")
+	response := &ofxgo.Response{
+		Bank: []ofxgo.Message{&ofxgo.StatementResponse{
+			CurDef:       *usd,
+			BankAcctFrom: ofxgo.BankAcct{BankID: "318398732", AcctID: "78346129", AcctType: ofxgo.AcctTypeChecking},
+			BankTranList: &ofxgo.TransactionList{
+				Transactions: []ofxgo.Transaction{
+					{TrnType: ofxgo.TrnTypeCheck, DtPosted: *ofxgo.NewDateGMT(2018, 1, 5, 0, 0, 0, 0), TrnAmt: ofxtest.Amount("-100"), FiTID: "1", CheckNum: "1002", Name: "Plumber"},
+				},
+			},
+		}},
+		InvStmt: []ofxgo.Message{&ofxgo.InvStatementResponse{
+			CurDef:      *usd,
+			InvAcctFrom: ofxgo.InvAcct{BrokerID: "example.com", AcctID: "1234"},
+			InvTranList: &ofxgo.InvTranList{
+				InvTransactions: []ofxgo.InvTransaction{
+					ofxgo.BuyStock{
+						InvBuy: ofxgo.InvBuy{
+							InvTran:    ofxgo.InvTran{FiTID: "2", DtTrade: *ofxgo.NewDateGMT(2018, 2, 1, 0, 0, 0, 0), Memo: "Opening position"},
+							SecID:      secID,
+							Units:      ofxtest.Amount("10"),
+							UnitPrice:  ofxtest.Amount("270"),
+							Commission: ofxtest.Amount("4.95"),
+							Total:      ofxtest.Amount("-2704.95"),
+						},
+						BuyType: ofxgo.BuyTypeBuy,
+					},
+					ofxgo.Split{
+						InvTran:     ofxgo.InvTran{FiTID: "3", DtTrade: *ofxgo.NewDateGMT(2018, 3, 1, 0, 0, 0, 0)},
+						SecID:       secID,
+						Numerator:   3,
+						Denominator: 2,
+					},
+					ofxgo.JrnlFund{InvTran: ofxgo.InvTran{FiTID: "4", DtTrade: *ofxgo.NewDateGMT(2018, 3, 2, 0, 0, 0, 0)}},
+				},
+				BankTransactions: []ofxgo.InvBankTransaction{{
+					Transactions: []ofxgo.Transaction{
+						{TrnType: ofxgo.TrnTypeDebit, DtPosted: *ofxgo.NewDateGMT(2018, 4, 1, 0, 0, 0, 0), TrnAmt: ofxtest.Amount("-50"), FiTID: "5", Name: "Withdrawal"},
+					},
+				}},
+			},
+		}},
+		SecList: []ofxgo.Message{&ofxgo.SecurityList{
+			Securities: []ofxgo.Security{ofxgo.StockInfo{SecInfo: ofxgo.SecInfo{SecID: secID, SecName: "SPDR S&P 500 ETF", Ticker: "SPY"}}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, response, nil); err != nil {
+		t.Fatalf("Unexpected error writing QIF: %s\n", err)
+	}
+	expected := `!Type:Security
+NSPDR S&P 500 ETF
+SSPY
+TStock
+^
+!Account
+N78346129
+TBank
+^
+!Type:Bank
+D01/05/2018
+T-100
+N1002
+PPlumber
+^
+!Account
+N1234
+TInvst
+^
+!Type:Invst
+D02/01/2018
+NBuy
+YSPDR S&P 500 ETF
+I270
+Q10
+T2704.95
+O4.95
+MOpening position
+^
+D03/01/2018
+NStkSplit
+YSPDR S&P 500 ETF
+Q15
+^
+D04/01/2018
+NXOut
+T50
+PWithdrawal
+^
+`
+	if buf.String() != expected {
+		t.Fatalf("Expected QIF:\n%s\nGot:\n%s\n", expected, buf.String())
+	}
+
+	f, err := Parse(&buf, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing written QIF: %s\n", err)
+	}
+	if len(f.Sections) != 2 || f.Sections[0].Account != "78346129" || f.Sections[1].Account != "1234" {
+		t.Fatalf("Unexpected sections: %v\n", f.Sections)
+	}
+	if tran := f.Sections[0].Transactions[0]; tran.CheckNum != "1002" || !ofxtest.AmountEqual(tran.TrnAmt, "-100") {
+		t.Errorf("Unexpected bank transaction: %v\n", tran)
+	}
+	inv := f.Sections[1]
+	if buy, ok := inv.InvTransactions[0].(ofxgo.BuyStock); !ok || buy.InvBuy.SecID.UniqueID != "SPY" || !ofxtest.AmountEqual(buy.InvBuy.Total, "-2704.95") {
+		t.Errorf("Unexpected purchase: %v\n", inv.InvTransactions[0])
+	}
+	if split, ok := inv.InvTransactions[1].(ofxgo.Split); !ok || split.Numerator != 3 || split.Denominator != 2 {
+		t.Errorf("Unexpected split: %v\n", inv.InvTransactions[1])
+	}
+	if len(inv.Transactions) != 1 || !ofxtest.AmountEqual(inv.Transactions[0].TrnAmt, "-50") {
+		t.Errorf("Unexpected cash transactions: %v\n", inv.Transactions)
+	}
+
+	// A single statement is written without an !Account record
+	buf.Reset()
+	if err := WriteStatement(&buf, response.Bank[0], &WriteOptions{DateFormat: "2006-01-02"}); err != nil {
+		t.Fatalf("Unexpected error writing QIF: %s\n", err)
+	}
+	if !strings.HasPrefix(buf.String(), "!Type:Bank\nD2018-01-05\n") {
+		t.Errorf("Unexpected QIF for single statement:\n%s\n", buf.String())
+	}
+}
@@ -0,0 +1,665 @@
+package qif
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/internal/fitid"
+)
+
+// ParseOptions control how QIF is parsed
+type ParseOptions struct {
+	// DayFirst indicates dates are written with the day before the month
+	// (i.e. 31/12/2017), as some non-US programs do
+	DayFirst bool
+}
+
+// Section holds the transactions from one "!Type:" section of a QIF file
+type Section struct {
+	Type    string // One of TypeBank, TypeCash, TypeCreditCard, TypeInvestment, TypeAsset, TypeLiability
+	Account string // The name of the account from the preceding !Account record, if any
+	// For investment sections, Transactions holds the cash transferred in and
+	// out of the account
+	Transactions    []ofxgo.Transaction
+	InvTransactions []ofxgo.InvTransaction
+}
+
+// File is the result of parsing a QIF file
+type File struct {
+	Sections []Section
+	// Securities holds the securities described in "!Type:Security" lists, and
+	// any others investment transactions refer to. QIF doesn't include CUSIPs
+	// or other identifiers, so their SecurityIDs have a UniqueIDType of
+	// SecurityIDType, with the ticker symbol (or the name, if there is no
+	// symbol) as the UniqueID.
+	Securities []ofxgo.Security
+}
+
+// field is one line of a QIF record
+type field struct {
+	code  byte
+	value string
+}
+
+// record is a QIF record, made up of the fields preceding a "^" line
+type record struct {
+	line   int // The line the record started on
+	fields []field
+}
+
+func (r *record) get(code byte) string {
+	for _, f := range r.fields {
+		if f.code == code {
+			return f.value
+		}
+	}
+	return ""
+}
+
+// amount returns the value of the amount in field code, or ok=false if the
+// record doesn't have the field
+func (r *record) amount(code byte) (a ofxgo.Amount, ok bool, err error) {
+	value := r.get(code)
+	if len(value) == 0 {
+		return a, false, nil
+	}
+	a, err = parseAmount(value)
+	if err != nil {
+		return a, false, fmt.Errorf("line %d: %s", r.line, err)
+	}
+	return a, true, nil
+}
+
+// total returns the record's transaction amount ('T', or 'U' if that's
+// missing)
+func (r *record) total() (ofxgo.Amount, error) {
+	a, ok, err := r.amount('T')
+	if !ok && err == nil {
+		a, _, err = r.amount('U')
+	}
+	return a, err
+}
+
+type rawSection struct {
+	section Section
+	records []record
+}
+
+type security struct {
+	name, symbol, securityType string
+}
+
+// parser holds the state of a Parse call
+type parser struct {
+	options    ParseOptions
+	securities map[string]*security // Keyed by name and symbol
+	order      []*security
+	fitids     fitid.Generator
+}
+
+// Parse reads the bank, credit card, cash, asset, liability, and investment
+// transactions from the QIF in r, along with any securities. Lists of other
+// kinds (i.e. categories, classes, memorized transactions, or prices) are
+// skipped, as are the splits and categories of transactions.
+func Parse(r io.Reader, options *ParseOptions) (*File, error) {
+	p := parser{
+		securities: make(map[string]*security),
+	}
+	if options != nil {
+		p.options = *options
+	}
+
+	const (
+		modeSkip = iota
+		modeAccount
+		modeSecurity
+		modeTransactions
+	)
+	var sections []*rawSection
+	var account string
+	mode := modeSkip
+	var current record
+
+	endRecord := func() {
+		if len(current.fields) == 0 {
+			return
+		}
+		switch mode {
+		case modeAccount:
+			account = current.get('N')
+		case modeSecurity:
+			p.addSecurity(current.get('N'), current.get('S'), current.get('T'))
+		case modeTransactions:
+			s := sections[len(sections)-1]
+			s.records = append(s.records, current)
+		}
+		current = record{}
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if lineNumber == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		if line[0] == '!' {
+			endRecord()
+			header := strings.ToLower(strings.TrimSpace(line[1:]))
+			switch {
+			case header == "account":
+				mode = modeAccount
+			case header == "type:security":
+				mode = modeSecurity
+			case strings.HasPrefix(header, "option:") || strings.HasPrefix(header, "clear:"):
+				// Quicken's AutoSwitch options don't change how lists are read
+			case strings.HasPrefix(header, "type:"):
+				mode = modeSkip
+				for _, t := range []string{TypeBank, TypeCash, TypeCreditCard, TypeInvestment, TypeAsset, TypeLiability} {
+					if strings.TrimSpace(header[len("type:"):]) == strings.ToLower(t) {
+						mode = modeTransactions
+						sections = append(sections, &rawSection{section: Section{Type: t, Account: account}})
+					}
+				}
+			default:
+				mode = modeSkip
+			}
+			continue
+		}
+		if mode == modeSkip {
+			continue
+		}
+		if line[0] == '^' {
+			endRecord()
+			continue
+		}
+		if len(current.fields) == 0 {
+			current.line = lineNumber
+		}
+		current.fields = append(current.fields, field{code: line[0], value: line[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	endRecord()
+
+	var f File
+	for _, raw := range sections {
+		s := raw.section
+		for i := range raw.records {
+			var err error
+			if s.Type == TypeInvestment {
+				err = p.addInvRecord(&s, &raw.records[i])
+			} else {
+				err = p.addRecord(&s, &raw.records[i])
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		f.Sections = append(f.Sections, s)
+	}
+	for _, s := range p.order {
+		f.Securities = append(f.Securities, s.ofx())
+	}
+	return &f, nil
+}
+
+func (p *parser) addSecurity(name, symbol, securityType string) *security {
+	name, symbol = strings.TrimSpace(name), strings.TrimSpace(symbol)
+	if len(name) == 0 {
+		name = symbol
+	}
+	if s, ok := p.securities[name]; ok {
+		return s
+	}
+	s := &security{name: name, symbol: symbol, securityType: strings.TrimSpace(securityType)}
+	p.securities[name] = s
+	if len(symbol) > 0 {
+		if _, ok := p.securities[symbol]; !ok {
+			p.securities[symbol] = s
+		}
+	}
+	p.order = append(p.order, s)
+	return s
+}
+
+func (s *security) id() ofxgo.SecurityID {
+	id := s.symbol
+	if len(id) == 0 {
+		id = s.name
+	}
+	return ofxgo.SecurityID{UniqueID: ofxgo.String(id), UniqueIDType: SecurityIDType}
+}
+
+func (s *security) ofx() ofxgo.Security {
+	info := ofxgo.SecInfo{SecID: s.id(), SecName: ofxgo.String(s.name), Ticker: ofxgo.String(s.symbol)}
+	switch s.securityType {
+	case SecurityTypeMutualFund:
+		return ofxgo.MFInfo{SecInfo: info}
+	case SecurityTypeBond:
+		return ofxgo.DebtInfo{SecInfo: info}
+	case SecurityTypeStock, "":
+		return ofxgo.StockInfo{SecInfo: info}
+	default:
+		return ofxgo.OtherInfo{SecInfo: info}
+	}
+}
+
+// fitid synthesizes a FITID for a transaction from its section and fields
+func (p *parser) fitid(s *Section, r *record) ofxgo.String {
+	fields := []string{s.Type, s.Account}
+	for _, f := range r.fields {
+		fields = append(fields, string(f.code)+strings.TrimSpace(f.value))
+	}
+	return p.fitids.FiTID(fields...)
+}
+
+func (p *parser) date(r *record) (ofxgo.Date, error) {
+	value := r.get('D')
+	if len(value) == 0 {
+		return ofxgo.Date{}, fmt.Errorf("line %d: transaction has no date", r.line)
+	}
+	d, err := parseDate(value, p.options.DayFirst)
+	if err != nil {
+		return d, fmt.Errorf("line %d: %s", r.line, err)
+	}
+	return d, nil
+}
+
+// isCheckNumber returns true if s is a QIF check number, rather than one of
+// the other values (i.e. ATM, DEP, or EFT) the N field of bank transactions
+// may hold
+func isCheckNumber(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// newTransaction returns a bank transaction for r, with the given amount
+func (p *parser) newTransaction(s *Section, r *record, amount ofxgo.Amount) (ofxgo.Transaction, error) {
+	date, err := p.date(r)
+	if err != nil {
+		return ofxgo.Transaction{}, err
+	}
+	t := ofxgo.Transaction{
+		TrnType:  ofxgo.TrnTypeCredit,
+		DtPosted: date,
+		TrnAmt:   amount,
+		FiTID:    p.fitid(s, r),
+		Name:     ofxgo.String(strings.TrimSpace(r.get('P'))),
+		Memo:     ofxgo.String(strings.TrimSpace(r.get('M'))),
+	}
+	if amount.Sign() < 0 {
+		t.TrnType = ofxgo.TrnTypeDebit
+	}
+	number := strings.TrimSpace(r.get('N'))
+	if isCheckNumber(number) {
+		t.TrnType = ofxgo.TrnTypeCheck
+		t.CheckNum = ofxgo.String(number)
+	} else {
+		switch strings.ToUpper(number) {
+		case "ATM":
+			t.TrnType = ofxgo.TrnTypeATM
+		case "DEP":
+			t.TrnType = ofxgo.TrnTypeDep
+		case "XFER", "TXFR":
+			t.TrnType = ofxgo.TrnTypeXfer
+		default:
+			t.RefNum = ofxgo.String(number)
+		}
+	}
+	return t, nil
+}
+
+func (p *parser) addRecord(s *Section, r *record) error {
+	amount, err := r.total()
+	if err != nil {
+		return err
+	}
+	t, err := p.newTransaction(s, r, amount)
+	if err != nil {
+		return err
+	}
+	s.Transactions = append(s.Transactions, t)
+	return nil
+}
+
+// reinvestActions maps QIF actions for reinvested income to OFX income types
+var reinvestActions = map[string]string{
+	"reinvdiv": "DIV",
+	"reinvint": "INTEREST",
+	"reinvlg":  "CGLONG",
+	"reinvmd":  "CGLONG", // OFX has no mid-term capital gains
+	"reinvsh":  "CGSHORT",
+}
+
+// incomeTypes maps QIF actions for income to OFX income types
+var incomeTypes = map[string]string{
+	"div":     "DIV",
+	"intinc":  "INTEREST",
+	"cglong":  "CGLONG",
+	"cgmid":   "CGLONG",
+	"cgshort": "CGSHORT",
+	"miscinc": "MISC",
+}
+
+func negate(a ofxgo.Amount) ofxgo.Amount {
+	var n ofxgo.Amount
+	n.Neg(&a.Rat)
+	return n
+}
+
+func abs(a ofxgo.Amount) ofxgo.Amount {
+	var n ofxgo.Amount
+	n.Abs(&a.Rat)
+	return n
+}
+
+func (p *parser) addInvRecord(s *Section, r *record) error {
+	action := strings.ToLower(strings.TrimSpace(r.get('N')))
+	total, err := r.total()
+	if err != nil {
+		return err
+	}
+
+	// Cash moving in and out of the account
+	switch action {
+	case "xin", "contribx":
+		t, err := p.newTransaction(s, r, abs(total))
+		if err == nil {
+			s.Transactions = append(s.Transactions, t)
+		}
+		return err
+	case "xout", "withdrwx":
+		t, err := p.newTransaction(s, r, negate(abs(total)))
+		if err == nil {
+			s.Transactions = append(s.Transactions, t)
+		}
+		return err
+	case "cash":
+		t, err := p.newTransaction(s, r, total)
+		if err == nil {
+			s.Transactions = append(s.Transactions, t)
+		}
+		return err
+	}
+	// Actions ending in X transfer the cash involved to or from another
+	// account, which doesn't change the transaction in this one
+	if len(action) > 1 && strings.HasSuffix(action, "x") {
+		action = action[:len(action)-1]
+	}
+
+	date, err := p.date(r)
+	if err != nil {
+		return err
+	}
+	tran := ofxgo.InvTran{
+		FiTID:   p.fitid(s, r),
+		DtTrade: date,
+		Memo:    ofxgo.String(strings.TrimSpace(r.get('M'))),
+	}
+	var sec *security
+	if name := strings.TrimSpace(r.get('Y')); len(name) > 0 {
+		if sec = p.securities[name]; sec == nil {
+			sec = p.addSecurity(name, "", "")
+		}
+	}
+	units, _, err := r.amount('Q')
+	if err != nil {
+		return err
+	}
+	price, _, err := r.amount('I')
+	if err != nil {
+		return err
+	}
+	commission, _, err := r.amount('O')
+	if err != nil {
+		return err
+	}
+	units, price, commission, total = abs(units), abs(price), abs(commission), abs(total)
+
+	requireSecurity := func() error {
+		if sec == nil {
+			return fmt.Errorf("line %d: %s", r.line, errNoSecurity)
+		}
+		return nil
+	}
+
+	var t ofxgo.InvTransaction
+	switch action {
+	case "buy", "cvrshrt":
+		if err := requireSecurity(); err != nil {
+			return err
+		}
+		buy := ofxgo.InvBuy{
+			InvTran:     tran,
+			SecID:       sec.id(),
+			Units:       units,
+			UnitPrice:   price,
+			Commission:  commission,
+			Total:       negate(total),
+			SubAcctSec:  ofxgo.SubAcctTypeCash,
+			SubAcctFund: ofxgo.SubAcctTypeCash,
+		}
+		buyType := ofxgo.BuyTypeBuy
+		if action == "cvrshrt" {
+			buyType = ofxgo.BuyTypeBuyToCover
+		}
+		switch sec.securityType {
+		case SecurityTypeMutualFund:
+			t = ofxgo.BuyMF{InvBuy: buy, BuyType: buyType}
+		case SecurityTypeBond:
+			t = ofxgo.BuyDebt{InvBuy: buy}
+		case SecurityTypeStock, "":
+			t = ofxgo.BuyStock{InvBuy: buy, BuyType: buyType}
+		default:
+			t = ofxgo.BuyOther{InvBuy: buy}
+		}
+	case "sell", "shtsell":
+		if err := requireSecurity(); err != nil {
+			return err
+		}
+		sell := ofxgo.InvSell{
+			InvTran:     tran,
+			SecID:       sec.id(),
+			Units:       negate(units),
+			UnitPrice:   price,
+			Commission:  commission,
+			Total:       total,
+			SubAcctSec:  ofxgo.SubAcctTypeCash,
+			SubAcctFund: ofxgo.SubAcctTypeCash,
+		}
+		sellType := ofxgo.SellTypeSell
+		if action == "shtsell" {
+			sellType = ofxgo.SellTypeSellShort
+		}
+		switch sec.securityType {
+		case SecurityTypeMutualFund:
+			t = ofxgo.SellMF{InvSell: sell, SellType: sellType}
+		case SecurityTypeBond:
+			t = ofxgo.SellDebt{InvSell: sell}
+		case SecurityTypeStock, "":
+			t = ofxgo.SellStock{InvSell: sell, SellType: sellType}
+		default:
+			t = ofxgo.SellOther{InvSell: sell}
+		}
+	case "div", "intinc", "cglong", "cgmid", "cgshort", "miscinc":
+		if err := requireSecurity(); err != nil {
+			return err
+		}
+		incomeType, _ := ofxgo.NewIncomeType(incomeTypes[action])
+		t = ofxgo.Income{
+			InvTran:     tran,
+			SecID:       sec.id(),
+			IncomeType:  incomeType,
+			Total:       total,
+			SubAcctSec:  ofxgo.SubAcctTypeCash,
+			SubAcctFund: ofxgo.SubAcctTypeCash,
+		}
+	case "reinvdiv", "reinvint", "reinvlg", "reinvmd", "reinvsh":
+		if err := requireSecurity(); err != nil {
+			return err
+		}
+		incomeType, _ := ofxgo.NewIncomeType(reinvestActions[action])
+		t = ofxgo.Reinvest{
+			InvTran:    tran,
+			SecID:      sec.id(),
+			IncomeType: incomeType,
+			Total:      negate(total),
+			SubAcctSec: ofxgo.SubAcctTypeCash,
+			Units:      units,
+			UnitPrice:  price,
+			Commission: commission,
+		}
+	case "shrsin", "shrsout":
+		if err := requireSecurity(); err != nil {
+			return err
+		}
+		tferAction := ofxgo.TferActionIn
+		if action == "shrsout" {
+			tferAction = ofxgo.TferActionOut
+		}
+		t = ofxgo.Transfer{
+			InvTran:    tran,
+			SecID:      sec.id(),
+			SubAcctSec: ofxgo.SubAcctTypeCash,
+			Units:      units,
+			TferAction: tferAction,
+			PosType:    ofxgo.PosTypeLong,
+			UnitPrice:  price,
+		}
+	case "stksplit":
+		if err := requireSecurity(); err != nil {
+			return err
+		}
+		// QIF records the ratio of new shares to old, times ten
+		ratio := new(big.Rat).Quo(&units.Rat, big.NewRat(10, 1))
+		if ratio.Sign() <= 0 || !ratio.Num().IsInt64() || !ratio.Denom().IsInt64() {
+			return fmt.Errorf("line %d: invalid split ratio %s", r.line, units.String())
+		}
+		t = ofxgo.Split{
+			InvTran:     tran,
+			SecID:       sec.id(),
+			SubAcctSec:  ofxgo.SubAcctTypeCash,
+			Numerator:   ofxgo.Int(ratio.Num().Int64()),
+			Denominator: ofxgo.Int(ratio.Denom().Int64()),
+		}
+	case "margint":
+		t = ofxgo.MarginInterest{
+			InvTran:     tran,
+			Total:       negate(total),
+			SubAcctFund: ofxgo.SubAcctTypeCash,
+		}
+	case "miscexp":
+		if err := requireSecurity(); err != nil {
+			return err
+		}
+		t = ofxgo.InvExpense{
+			InvTran:     tran,
+			SecID:       sec.id(),
+			Total:       negate(total),
+			SubAcctSec:  ofxgo.SubAcctTypeCash,
+			SubAcctFund: ofxgo.SubAcctTypeCash,
+		}
+	case "rtrncap":
+		if err := requireSecurity(); err != nil {
+			return err
+		}
+		t = ofxgo.RetOfCap{
+			InvTran:     tran,
+			SecID:       sec.id(),
+			Total:       total,
+			SubAcctSec:  ofxgo.SubAcctTypeCash,
+			SubAcctFund: ofxgo.SubAcctTypeCash,
+		}
+	case "exercise", "expire":
+		if err := requireSecurity(); err != nil {
+			return err
+		}
+		optAction := ofxgo.OptActionExercise
+		if action == "expire" {
+			optAction = ofxgo.OptActionExpire
+		}
+		t = ofxgo.ClosureOpt{
+			InvTran:    tran,
+			SecID:      sec.id(),
+			OptAction:  optAction,
+			Units:      units,
+			ShPerCtrct: 100,
+			SubAcctSec: ofxgo.SubAcctTypeCash,
+		}
+	default:
+		return fmt.Errorf("line %d: unsupported investment action %q", r.line, r.get('N'))
+	}
+	s.InvTransactions = append(s.InvTransactions, t)
+	return nil
+}
+
+// dateRange returns the earliest and latest of dates, which must not be empty
+func dateRange(dates []ofxgo.Date) (start, end ofxgo.Date) {
+	start, end = dates[0], dates[0]
+	for _, d := range dates[1:] {
+		if d.Before(start.Time) {
+			start = d
+		}
+		if d.After(end.Time) {
+			end = d
+		}
+	}
+	return start, end
+}
+
+// TransactionList returns the transactions in s as a TransactionList for a
+// StatementResponse or CCStatementResponse, with DtStart and DtEnd set to the
+// dates of the first and last transactions
+func (s *Section) TransactionList() *ofxgo.TransactionList {
+	l := &ofxgo.TransactionList{Transactions: s.Transactions}
+	var dates []ofxgo.Date
+	for _, t := range s.Transactions {
+		dates = append(dates, t.DtPosted)
+	}
+	if len(dates) > 0 {
+		l.DtStart, l.DtEnd = dateRange(dates)
+	}
+	return l
+}
+
+// InvTranList returns the transactions in s as an InvTranList for an
+// InvStatementResponse, with DtStart and DtEnd set to the dates of the first
+// and last transactions
+func (s *Section) InvTranList() *ofxgo.InvTranList {
+	l := &ofxgo.InvTranList{InvTransactions: s.InvTransactions}
+	var dates []ofxgo.Date
+	for _, t := range s.InvTransactions {
+		dates = append(dates, t.InvTransaction().DtTrade)
+	}
+	if len(s.Transactions) > 0 {
+		l.BankTransactions = []ofxgo.InvBankTransaction{{
+			Transactions: s.Transactions,
+			SubAcctFund:  ofxgo.SubAcctTypeCash,
+		}}
+		for _, t := range s.Transactions {
+			dates = append(dates, t.DtPosted)
+		}
+	}
+	if len(dates) > 0 {
+		l.DtStart, l.DtEnd = dateRange(dates)
+	}
+	return l
+}
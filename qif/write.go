@@ -0,0 +1,409 @@
+package qif
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/aclindsa/ofxgo"
+)
+
+// WriteOptions control how QIF is written
+type WriteOptions struct {
+	// DateFormat is the layout (as expected by time.Format) to write dates
+	// in. DefaultDateFormat is used if it's empty.
+	DateFormat string
+	// Securities is used to name the securities investment transactions
+	// refer to. Write uses the response's security list if it's nil.
+	Securities *ofxgo.SecurityMaster
+}
+
+// securityTypes maps the type of each OFX Security to its QIF security type
+var securityTypes = map[string]string{
+	"DEBTINFO":  SecurityTypeBond,
+	"MFINFO":    SecurityTypeMutualFund,
+	"OPTINFO":   SecurityTypeOption,
+	"OTHERINFO": SecurityTypeOther,
+	"STOCKINFO": SecurityTypeStock,
+}
+
+type writer struct {
+	w          *bufio.Writer
+	dateFormat string
+	securities *ofxgo.SecurityMaster
+}
+
+func newWriter(w io.Writer, options *WriteOptions) *writer {
+	wr := &writer{w: bufio.NewWriter(w), dateFormat: DefaultDateFormat}
+	if options != nil {
+		if len(options.DateFormat) > 0 {
+			wr.dateFormat = options.DateFormat
+		}
+		wr.securities = options.Securities
+	}
+	return wr
+}
+
+func (w *writer) line(s string) {
+	w.w.WriteString(s)
+	w.w.WriteString("\n")
+}
+
+// field writes a field of a record, unless value is empty
+func (w *writer) field(code byte, value string) {
+	value = strings.TrimSpace(strings.Replace(strings.Replace(value, "\r", " ", -1), "\n", " ", -1))
+	if len(value) == 0 {
+		return
+	}
+	w.w.WriteByte(code)
+	w.line(value)
+}
+
+func (w *writer) date(d ofxgo.Date) {
+	w.field('D', d.Format(w.dateFormat))
+}
+
+func (w *writer) amount(code byte, a *big.Rat) {
+	w.field(code, formatAmount(a))
+}
+
+// optionalAmount writes a, unless it's zero
+func (w *writer) optionalAmount(code byte, a *big.Rat) {
+	if a.Sign() != 0 {
+		w.amount(code, a)
+	}
+}
+
+func (w *writer) end() {
+	w.line("^")
+}
+
+// securityName returns the name used in QIF for the security identified by
+// id: its name if known, otherwise its ticker or UNIQUEID
+func (w *writer) securityName(id ofxgo.SecurityID) string {
+	if w.securities != nil {
+		if s, ok := w.securities.Lookup(id); ok {
+			info := s.SecurityInfo()
+			if len(info.SecName) > 0 {
+				return string(info.SecName)
+			} else if len(info.Ticker) > 0 {
+				return string(info.Ticker)
+			}
+		}
+	}
+	return string(id.UniqueID)
+}
+
+// Write writes QIF for all the bank, credit card, and investment statements in
+// response. If there is more than one, each is preceded by an !Account record
+// naming it with its ACCTID so that importers can tell them apart. The
+// securities investment transactions refer to are described in a
+// !Type:Security list first, so that importers can match their names to
+// ticker symbols.
+func Write(w io.Writer, response *ofxgo.Response, options *WriteOptions) error {
+	wr := newWriter(w, options)
+	if wr.securities == nil {
+		wr.securities = ofxgo.NewSecurityMaster(response)
+	}
+
+	var statements []ofxgo.Message
+	for _, messages := range [][]ofxgo.Message{response.Bank, response.CreditCard, response.InvStmt} {
+		for _, m := range messages {
+			switch m.(type) {
+			case *ofxgo.StatementResponse, *ofxgo.CCStatementResponse, *ofxgo.InvStatementResponse:
+				statements = append(statements, m)
+			}
+		}
+	}
+
+	wr.writeSecurities(statements)
+	for _, m := range statements {
+		if len(statements) > 1 {
+			wr.writeAccount(m)
+		}
+		if err := wr.writeStatement(m); err != nil {
+			return err
+		}
+	}
+	return wr.w.Flush()
+}
+
+// WriteStatement writes QIF for m, which must be a *StatementResponse,
+// *CCStatementResponse, or *InvStatementResponse
+func WriteStatement(w io.Writer, m ofxgo.Message, options *WriteOptions) error {
+	wr := newWriter(w, options)
+	if err := wr.writeStatement(m); err != nil {
+		return err
+	}
+	return wr.w.Flush()
+}
+
+func (w *writer) writeSecurities(statements []ofxgo.Message) {
+	written := make(map[ofxgo.SecurityID]bool)
+	first := true
+	for _, m := range statements {
+		stmt, ok := m.(*ofxgo.InvStatementResponse)
+		if !ok || stmt.InvTranList == nil {
+			continue
+		}
+		for _, t := range stmt.InvTranList.InvTransactions {
+			security, ok := w.securities.LookupTransaction(t)
+			if !ok {
+				continue
+			}
+			info := security.SecurityInfo()
+			if written[info.SecID] {
+				continue
+			}
+			written[info.SecID] = true
+			if first {
+				w.line("!Type:Security")
+				first = false
+			}
+			w.field('N', w.securityName(info.SecID))
+			w.field('S', string(info.Ticker))
+			w.field('T', securityTypes[security.SecurityType()])
+			w.end()
+		}
+	}
+}
+
+func (w *writer) writeAccount(m ofxgo.Message) {
+	w.line("!Account")
+	switch stmt := m.(type) {
+	case *ofxgo.StatementResponse:
+		w.field('N', string(stmt.BankAcctFrom.AcctID))
+		w.field('T', TypeBank)
+	case *ofxgo.CCStatementResponse:
+		w.field('N', string(stmt.CCAcctFrom.AcctID))
+		w.field('T', TypeCreditCard)
+	case *ofxgo.InvStatementResponse:
+		w.field('N', string(stmt.InvAcctFrom.AcctID))
+		w.field('T', TypeInvestment)
+	}
+	w.end()
+}
+
+func (w *writer) writeStatement(m ofxgo.Message) error {
+	switch stmt := m.(type) {
+	case *ofxgo.StatementResponse:
+		w.line("!Type:" + TypeBank)
+		if stmt.BankTranList != nil {
+			for i := range stmt.BankTranList.Transactions {
+				w.writeTransaction(&stmt.BankTranList.Transactions[i])
+			}
+		}
+	case *ofxgo.CCStatementResponse:
+		w.line("!Type:" + TypeCreditCard)
+		if stmt.BankTranList != nil {
+			for i := range stmt.BankTranList.Transactions {
+				w.writeTransaction(&stmt.BankTranList.Transactions[i])
+			}
+		}
+	case *ofxgo.InvStatementResponse:
+		w.line("!Type:" + TypeInvestment)
+		if stmt.InvTranList != nil {
+			for _, t := range stmt.InvTranList.InvTransactions {
+				w.writeInvTransaction(t)
+			}
+			for _, b := range stmt.InvTranList.BankTransactions {
+				for i := range b.Transactions {
+					w.writeInvBankTransaction(&b.Transactions[i])
+				}
+			}
+		}
+	default:
+		return errors.New("Message is not a bank, credit card, or investment statement")
+	}
+	return nil
+}
+
+func transactionName(t *ofxgo.Transaction) string {
+	if len(t.Name) > 0 {
+		return string(t.Name)
+	} else if t.Payee != nil {
+		return string(t.Payee.Name)
+	}
+	return ""
+}
+
+func (w *writer) writeTransaction(t *ofxgo.Transaction) {
+	w.date(t.DtPosted)
+	w.amount('T', &t.TrnAmt.Rat)
+	if len(t.CheckNum) > 0 {
+		w.field('N', string(t.CheckNum))
+	} else {
+		w.field('N', string(t.RefNum))
+	}
+	w.field('P', transactionName(t))
+	w.field('M', string(t.Memo))
+	w.end()
+}
+
+// writeInvBankTransaction writes a cash transaction in an investment account
+// as a transfer in or out of it
+func (w *writer) writeInvBankTransaction(t *ofxgo.Transaction) {
+	w.date(t.DtPosted)
+	if t.TrnAmt.Sign() < 0 {
+		w.field('N', ActionXOut)
+	} else {
+		w.field('N', ActionXIn)
+	}
+	w.amount('T', new(big.Rat).Abs(&t.TrnAmt.Rat))
+	w.field('P', transactionName(t))
+	w.field('M', string(t.Memo))
+	w.end()
+}
+
+func (w *writer) writeBuy(action string, b *ofxgo.InvBuy) {
+	w.field('N', action)
+	w.field('Y', w.securityName(b.SecID))
+	w.amount('I', new(big.Rat).Abs(&b.UnitPrice.Rat))
+	w.amount('Q', new(big.Rat).Abs(&b.Units.Rat))
+	w.amount('T', new(big.Rat).Abs(&b.Total.Rat))
+	fees := new(big.Rat).Add(&b.Commission.Rat, &b.Fees.Rat)
+	fees.Add(fees, &b.Taxes.Rat).Add(fees, &b.Load.Rat)
+	w.optionalAmount('O', fees)
+}
+
+func (w *writer) writeSell(action string, s *ofxgo.InvSell) {
+	w.field('N', action)
+	w.field('Y', w.securityName(s.SecID))
+	w.amount('I', new(big.Rat).Abs(&s.UnitPrice.Rat))
+	w.amount('Q', new(big.Rat).Abs(&s.Units.Rat))
+	w.amount('T', new(big.Rat).Abs(&s.Total.Rat))
+	fees := new(big.Rat).Add(&s.Commission.Rat, &s.Fees.Rat)
+	fees.Add(fees, &s.Taxes.Rat).Add(fees, &s.Load.Rat)
+	w.optionalAmount('O', fees)
+}
+
+// writeInvTransaction writes t with the QIF action corresponding to its type.
+// Journal transactions, which only move securities or cash between
+// sub-accounts, and option assignments have no QIF equivalent and are
+// skipped.
+func (w *writer) writeInvTransaction(t ofxgo.InvTransaction) {
+	tran := t.InvTransaction()
+	switch t := t.(type) {
+	case ofxgo.BuyDebt:
+		w.date(tran.DtTrade)
+		w.writeBuy(ActionBuy, &t.InvBuy)
+	case ofxgo.BuyMF:
+		w.date(tran.DtTrade)
+		if t.BuyType == ofxgo.BuyTypeBuyToCover {
+			w.writeBuy(ActionBuyToCover, &t.InvBuy)
+		} else {
+			w.writeBuy(ActionBuy, &t.InvBuy)
+		}
+	case ofxgo.BuyOpt:
+		w.date(tran.DtTrade)
+		w.writeBuy(ActionBuy, &t.InvBuy)
+	case ofxgo.BuyOther:
+		w.date(tran.DtTrade)
+		w.writeBuy(ActionBuy, &t.InvBuy)
+	case ofxgo.BuyStock:
+		w.date(tran.DtTrade)
+		if t.BuyType == ofxgo.BuyTypeBuyToCover {
+			w.writeBuy(ActionBuyToCover, &t.InvBuy)
+		} else {
+			w.writeBuy(ActionBuy, &t.InvBuy)
+		}
+	case ofxgo.SellDebt:
+		w.date(tran.DtTrade)
+		w.writeSell(ActionSell, &t.InvSell)
+	case ofxgo.SellMF:
+		w.date(tran.DtTrade)
+		if t.SellType == ofxgo.SellTypeSellShort {
+			w.writeSell(ActionSellShort, &t.InvSell)
+		} else {
+			w.writeSell(ActionSell, &t.InvSell)
+		}
+	case ofxgo.SellOpt:
+		w.date(tran.DtTrade)
+		w.writeSell(ActionSell, &t.InvSell)
+	case ofxgo.SellOther:
+		w.date(tran.DtTrade)
+		w.writeSell(ActionSell, &t.InvSell)
+	case ofxgo.SellStock:
+		w.date(tran.DtTrade)
+		if t.SellType == ofxgo.SellTypeSellShort {
+			w.writeSell(ActionSellShort, &t.InvSell)
+		} else {
+			w.writeSell(ActionSell, &t.InvSell)
+		}
+	case ofxgo.Income:
+		actions, ok := incomeActions[t.IncomeType.String()]
+		if !ok {
+			actions = incomeActions["MISC"]
+		}
+		w.date(tran.DtTrade)
+		w.field('N', actions[0])
+		w.field('Y', w.securityName(t.SecID))
+		w.amount('T', new(big.Rat).Abs(&t.Total.Rat))
+	case ofxgo.Reinvest:
+		actions, ok := incomeActions[t.IncomeType.String()]
+		if !ok {
+			actions = incomeActions["MISC"]
+		}
+		w.date(tran.DtTrade)
+		w.field('N', actions[1])
+		w.field('Y', w.securityName(t.SecID))
+		w.amount('I', new(big.Rat).Abs(&t.UnitPrice.Rat))
+		w.amount('Q', new(big.Rat).Abs(&t.Units.Rat))
+		w.amount('T', new(big.Rat).Abs(&t.Total.Rat))
+		fees := new(big.Rat).Add(&t.Commission.Rat, &t.Fees.Rat)
+		fees.Add(fees, &t.Taxes.Rat).Add(fees, &t.Load.Rat)
+		w.optionalAmount('O', fees)
+	case ofxgo.Transfer:
+		w.date(tran.DtTrade)
+		if t.TferAction == ofxgo.TferActionOut {
+			w.field('N', ActionShrsOut)
+		} else {
+			w.field('N', ActionShrsIn)
+		}
+		w.field('Y', w.securityName(t.SecID))
+		w.optionalAmount('I', new(big.Rat).Abs(&t.UnitPrice.Rat))
+		w.amount('Q', new(big.Rat).Abs(&t.Units.Rat))
+	case ofxgo.Split:
+		if t.Numerator <= 0 || t.Denominator <= 0 {
+			return
+		}
+		w.date(tran.DtTrade)
+		w.field('N', ActionStkSplit)
+		w.field('Y', w.securityName(t.SecID))
+		// QIF records the ratio of new shares to old, times ten
+		w.amount('Q', big.NewRat(10*int64(t.Numerator), int64(t.Denominator)))
+	case ofxgo.MarginInterest:
+		w.date(tran.DtTrade)
+		w.field('N', ActionMargInt)
+		w.amount('T', new(big.Rat).Abs(&t.Total.Rat))
+	case ofxgo.InvExpense:
+		w.date(tran.DtTrade)
+		w.field('N', ActionMiscExp)
+		w.field('Y', w.securityName(t.SecID))
+		w.amount('T', new(big.Rat).Abs(&t.Total.Rat))
+	case ofxgo.RetOfCap:
+		w.date(tran.DtTrade)
+		w.field('N', ActionRtrnCap)
+		w.field('Y', w.securityName(t.SecID))
+		w.amount('T', new(big.Rat).Abs(&t.Total.Rat))
+	case ofxgo.ClosureOpt:
+		switch t.OptAction {
+		case ofxgo.OptActionExercise:
+			w.date(tran.DtTrade)
+			w.field('N', ActionExercise)
+		case ofxgo.OptActionExpire:
+			w.date(tran.DtTrade)
+			w.field('N', ActionExpire)
+		default:
+			return
+		}
+		w.field('Y', w.securityName(t.SecID))
+		w.amount('Q', new(big.Rat).Abs(&t.Units.Rat))
+	default:
+		return
+	}
+	w.field('M', string(tran.Memo))
+	w.end()
+}
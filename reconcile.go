@@ -0,0 +1,368 @@
+package ofxgo
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+type discrepancyKind uint
+
+// Discrepancy* constants represent the likely causes of a difference between
+// the units of a security reported by an FI and those expected from its
+// transactions
+const (
+	DiscrepancyRounding discrepancyKind = 1 + iota // The difference is within the tolerance, i.e. from rounding fractional units
+	DiscrepancySplit                               // The reported units are a simple multiple of those expected, as if a SPLIT transaction is missing
+	DiscrepancyTransfer                            // Any other difference, i.e. from a transfer in or out which wasn't reported
+)
+
+var discrepancyKinds = [...]string{"rounding", "missing split", "unreported transfer"}
+
+func (k discrepancyKind) String() string {
+	if k >= DiscrepancyRounding && k <= DiscrepancyTransfer {
+		return discrepancyKinds[k-1]
+	}
+	return fmt.Sprintf("invalid discrepancyKind (%d)", k)
+}
+
+// PositionDiscrepancy describes a security whose units in a PositionList
+// differ from those expected from an earlier PositionList and the
+// transactions since
+type PositionDiscrepancy struct {
+	SecID      SecurityID
+	Expected   Amount // Units expected from the earlier positions and the transactions since (negative for short positions)
+	Reported   Amount // Units reported in the new PositionList (negative for short positions)
+	Difference Amount // Reported - Expected
+	Kind       discrepancyKind
+}
+
+func (d PositionDiscrepancy) String() string {
+	return fmt.Sprintf("%s %s: expected %s units, reported %s (%s)", d.SecID.UniqueIDType, d.SecID.UniqueID, d.Expected.String(), d.Reported.String(), d.Kind)
+}
+
+// ReconcilePositionsOptions controls how ReconcilePositions classifies the
+// discrepancies it finds
+type ReconcilePositionsOptions struct {
+	// Tolerance is the largest difference in units attributed to rounding.
+	// Defaults to DefaultPositionTolerance if nil.
+	Tolerance *Amount
+	// MaxSplitRatio is the largest n for which a discrepancy where the
+	// reported units are n times, or 1/n of, those expected is attributed to
+	// a missing split. Defaults to 20 if zero.
+	MaxSplitRatio int64
+}
+
+// DefaultPositionTolerance is the tolerance used by ReconcilePositions if
+// none is specified: one thousandth of a unit
+var DefaultPositionTolerance = big.NewRat(1, 1000)
+
+// positionUnits returns the units held in p, negated for short positions
+func positionUnits(p Position) *big.Rat {
+	pos := p.InvPosition()
+	units := new(big.Rat).Abs(&pos.Units.Rat)
+	if pos.PosType == PosTypeShort {
+		units.Neg(units)
+	}
+	return units
+}
+
+// transactionUnits returns the change in units of its security caused by
+// investment transaction t, which is negative for transactions reducing long
+// positions or increasing short positions
+func transactionUnits(t InvTransaction) *big.Rat {
+	var units *Amount
+	negate := false
+	switch tran := t.(type) {
+	case BuyDebt:
+		units = &tran.InvBuy.Units
+	case BuyMF:
+		units = &tran.InvBuy.Units
+	case BuyOpt:
+		units = &tran.InvBuy.Units
+	case BuyOther:
+		units = &tran.InvBuy.Units
+	case BuyStock:
+		units = &tran.InvBuy.Units
+	case Reinvest:
+		units = &tran.Units
+	case SellDebt:
+		units, negate = &tran.InvSell.Units, true
+	case SellMF:
+		units, negate = &tran.InvSell.Units, true
+	case SellOpt:
+		units, negate = &tran.InvSell.Units, true
+	case SellOther:
+		units, negate = &tran.InvSell.Units, true
+	case SellStock:
+		units, negate = &tran.InvSell.Units, true
+	case ClosureOpt:
+		// Closing a written option reduces a short position
+		units, negate = &tran.Units, tran.SubAcctSec != SubAcctTypeShort
+	case Transfer:
+		units = &tran.Units
+		negate = tran.TferAction == TferActionOut
+		if tran.PosType == PosTypeShort {
+			negate = !negate
+		}
+	default:
+		return new(big.Rat)
+	}
+	r := new(big.Rat).Abs(&units.Rat)
+	if negate {
+		r.Neg(r)
+	}
+	return r
+}
+
+// ReconcilePositions computes the units of each security expected to be held
+// after applying the transactions in tranList to the positions in prior, and
+// compares them with those reported in current. It returns a
+// PositionDiscrepancy for each security whose units differ, ordered by
+// SecurityID. Positions held in different sub-accounts are combined.
+//
+// tranList should contain exactly the transactions between prior and current;
+// they are applied in order of trade date, so that splits only affect the
+// units held before them. options may be nil to use the defaults.
+func ReconcilePositions(prior PositionList, tranList *InvTranList, current PositionList, options *ReconcilePositionsOptions) []PositionDiscrepancy {
+	tolerance := DefaultPositionTolerance
+	maxSplitRatio := int64(20)
+	if options != nil {
+		if options.Tolerance != nil {
+			tolerance = &options.Tolerance.Rat
+		}
+		if options.MaxSplitRatio > 0 {
+			maxSplitRatio = options.MaxSplitRatio
+		}
+	}
+
+	ids := make(map[securityKey]SecurityID)
+	expected := make(map[securityKey]*big.Rat)
+	reported := make(map[securityKey]*big.Rat)
+	add := func(m map[securityKey]*big.Rat, id SecurityID, units *big.Rat) {
+		key := newSecurityKey(id)
+		if _, ok := ids[key]; !ok {
+			ids[key] = id.Normalize()
+		}
+		if m[key] == nil {
+			m[key] = new(big.Rat)
+		}
+		m[key].Add(m[key], units)
+	}
+
+	for _, p := range prior {
+		add(expected, p.InvPosition().SecID, positionUnits(p))
+	}
+	if tranList != nil {
+		transactions := make([]InvTransaction, len(tranList.InvTransactions))
+		copy(transactions, tranList.InvTransactions)
+		sort.SliceStable(transactions, func(i, j int) bool {
+			return transactions[i].InvTransaction().DtTrade.Before(transactions[j].InvTransaction().DtTrade.Time)
+		})
+		for _, t := range transactions {
+			id, ok := transactionSecurityID(t)
+			if !ok {
+				continue
+			}
+			if split, ok := t.(Split); ok {
+				add(expected, id, new(big.Rat))
+				if split.Numerator > 0 && split.Denominator > 0 {
+					units := expected[newSecurityKey(id)]
+					units.Mul(units, big.NewRat(int64(split.Numerator), int64(split.Denominator)))
+				}
+				continue
+			}
+			add(expected, id, transactionUnits(t))
+		}
+	}
+	for _, p := range current {
+		add(reported, p.InvPosition().SecID, positionUnits(p))
+	}
+
+	keys := make([]securityKey, 0, len(ids))
+	for key := range ids {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].uniqueIDType != keys[j].uniqueIDType {
+			return keys[i].uniqueIDType < keys[j].uniqueIDType
+		}
+		return keys[i].uniqueID < keys[j].uniqueID
+	})
+
+	var discrepancies []PositionDiscrepancy
+	for _, key := range keys {
+		d := PositionDiscrepancy{SecID: ids[key]}
+		if e := expected[key]; e != nil {
+			d.Expected.Set(e)
+		}
+		if r := reported[key]; r != nil {
+			d.Reported.Set(r)
+		}
+		d.Difference.Sub(&d.Reported.Rat, &d.Expected.Rat)
+		if d.Difference.Sign() == 0 {
+			continue
+		}
+
+		if new(big.Rat).Abs(&d.Difference.Rat).Cmp(tolerance) <= 0 {
+			d.Kind = DiscrepancyRounding
+		} else if d.Expected.Sign() != 0 && d.Reported.Sign() != 0 {
+			ratio := new(big.Rat).Quo(&d.Reported.Rat, &d.Expected.Rat)
+			// Only n:1 and 1:n ratios are likely splits; 11:10 or 7:3 are
+			// much more likely to be a few units transferred in or out
+			num, denom := ratio.Num(), ratio.Denom()
+			if ratio.Sign() > 0 && num.IsInt64() && denom.IsInt64() && (num.Int64() == 1 || denom.Int64() == 1) && num.Int64() <= maxSplitRatio && denom.Int64() <= maxSplitRatio {
+				d.Kind = DiscrepancySplit
+			}
+		}
+		if d.Kind == 0 {
+			d.Kind = DiscrepancyTransfer
+		}
+		discrepancies = append(discrepancies, d)
+	}
+	return discrepancies
+}
+
+// DateGap is a period between two successive statements which neither of
+// their transaction lists covers, so transactions posted during it may never
+// have been downloaded
+type DateGap struct {
+	Start Date
+	End   Date
+}
+
+func (g DateGap) String() string {
+	return fmt.Sprintf("%s to %s", g.Start.String(), g.End.String())
+}
+
+// BalanceReconciliation is the result of reconciling the ledger balances of
+// two successive bank or credit card statements against the transactions
+// posted between them
+type BalanceReconciliation struct {
+	OpeningBalance Amount        // LEDGERBAL of the previous statement
+	ClosingBalance Amount        // LEDGERBAL of the current statement
+	Net            Amount        // Sum of TRNAMT for Transactions
+	Transactions   []Transaction // Transactions posted after the opening balance, up to and including the closing balance
+	Difference     Amount        // ClosingBalance - (OpeningBalance + Net)
+	Gaps           []DateGap     // Periods between the two balances covered by neither statement's BANKTRANLIST
+}
+
+// Balanced returns true if the closing balance is exactly the opening balance
+// plus the transactions posted between them
+func (r *BalanceReconciliation) Balanced() bool {
+	return r.Difference.Sign() == 0
+}
+
+// MissingTransactionsSuspected returns true if transactions may have been
+// posted between the two statements without being downloaded: either the
+// balances don't reconcile, or the transaction lists leave gaps between them
+// (in which case offsetting transactions could be missing even if the
+// balances do reconcile)
+func (r *BalanceReconciliation) MissingTransactionsSuspected() bool {
+	return !r.Balanced() || len(r.Gaps) > 0
+}
+
+// bankStatement holds the fields of StatementResponse and CCStatementResponse
+// needed to reconcile them
+type bankStatement struct {
+	acctID   String
+	balAmt   Amount
+	dtAsOf   Date
+	tranList *TransactionList
+}
+
+func newBankStatement(m Message) (*bankStatement, error) {
+	switch stmt := m.(type) {
+	case *StatementResponse:
+		return &bankStatement{acctID: stmt.BankAcctFrom.AcctID, balAmt: stmt.BalAmt, dtAsOf: stmt.DtAsOf, tranList: stmt.BankTranList}, nil
+	case *CCStatementResponse:
+		return &bankStatement{acctID: stmt.CCAcctFrom.AcctID, balAmt: stmt.BalAmt, dtAsOf: stmt.DtAsOf, tranList: stmt.BankTranList}, nil
+	}
+	return nil, fmt.Errorf("Can't reconcile balances of %s", m.Name())
+}
+
+// ReconcileBalances verifies that the ledger balance of current (a
+// *StatementResponse or *CCStatementResponse) equals that of previous (the
+// last statement downloaded for the same account) plus the transactions
+// posted between them. Transactions are taken from both statements'
+// transaction lists, since downloads often overlap, with duplicate FITIDs
+// counted once. It also reports any gaps between the two balances' dates that
+// neither transaction list covers.
+func ReconcileBalances(previous, current Message) (*BalanceReconciliation, error) {
+	prev, err := newBankStatement(previous)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := newBankStatement(current)
+	if err != nil {
+		return nil, err
+	}
+	if previous.Name() != current.Name() || prev.acctID != cur.acctID {
+		return nil, fmt.Errorf("Can't reconcile balances of different accounts (%s and %s)", prev.acctID, cur.acctID)
+	} else if cur.dtAsOf.Before(prev.dtAsOf.Time) {
+		return nil, fmt.Errorf("Current statement's balance (as of %s) is older than the previous one's (as of %s)", cur.dtAsOf.String(), prev.dtAsOf.String())
+	}
+
+	var r BalanceReconciliation
+	r.OpeningBalance.Set(&prev.balAmt.Rat)
+	r.ClosingBalance.Set(&cur.balAmt.Rat)
+
+	seen := make(map[String]bool)
+	for _, stmt := range []*bankStatement{cur, prev} {
+		if stmt.tranList == nil {
+			continue
+		}
+		for _, t := range stmt.tranList.Transactions {
+			if !t.DtPosted.After(prev.dtAsOf.Time) || t.DtPosted.After(cur.dtAsOf.Time) || seen[t.FiTID] {
+				continue
+			}
+			seen[t.FiTID] = true
+			r.Transactions = append(r.Transactions, t)
+			r.Net.Add(&r.Net.Rat, &t.TrnAmt.Rat)
+		}
+	}
+	sort.SliceStable(r.Transactions, func(i, j int) bool {
+		return r.Transactions[i].DtPosted.Before(r.Transactions[j].DtPosted.Time)
+	})
+
+	r.Difference.Sub(&r.ClosingBalance.Rat, &r.OpeningBalance.Rat)
+	r.Difference.Sub(&r.Difference.Rat, &r.Net.Rat)
+	r.Gaps = coverageGaps(prev.dtAsOf, cur.dtAsOf, prev.tranList, cur.tranList)
+	return &r, nil
+}
+
+// coverageGaps returns the periods between start and end not covered by the
+// DTSTART to DTEND ranges of any of lists
+func coverageGaps(start, end Date, lists ...*TransactionList) []DateGap {
+	var covered []DateGap
+	for _, l := range lists {
+		if l != nil && !l.DtEnd.Before(l.DtStart.Time) {
+			covered = append(covered, DateGap{Start: l.DtStart, End: l.DtEnd})
+		}
+	}
+	sort.Slice(covered, func(i, j int) bool {
+		return covered[i].Start.Before(covered[j].Start.Time)
+	})
+
+	var gaps []DateGap
+	next := start
+	for _, c := range covered {
+		if !next.Before(end.Time) {
+			break
+		}
+		if c.Start.After(next.Time) {
+			gapEnd := c.Start
+			if gapEnd.After(end.Time) {
+				gapEnd = end
+			}
+			gaps = append(gaps, DateGap{Start: next, End: gapEnd})
+		}
+		if c.End.After(next.Time) {
+			next = c.End
+		}
+	}
+	if next.Before(end.Time) {
+		gaps = append(gaps, DateGap{Start: next, End: end})
+	}
+	return gaps
+}
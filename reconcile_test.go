@@ -0,0 +1,287 @@
+package ofxgo
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func reconcileFrac(num, denom int64) Amount {
+	var a Amount
+	a.SetFrac64(num, denom)
+	return a
+}
+
+func reconcileStock(cusip string, units Amount, posType posType) Position {
+	return StockPosition{InvPos: InvPosition{
+		SecID:      SecurityID{UniqueID: String(cusip), UniqueIDType: "CUSIP"},
+		HeldInAcct: SubAcctTypeCash,
+		PosType:    posType,
+		Units:      units,
+	}}
+}
+
+func reconcileInvTran(fitid string, day int) InvTran {
+	return InvTran{FiTID: String(fitid), DtTrade: *NewDateGMT(2017, 3, day, 0, 0, 0, 0)}
+}
+
+func TestReconcilePositions(t *testing.T) {
+	spy := SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}
+	xom := SecurityID{UniqueID: "30231G102", UniqueIDType: "CUSIP"}
+	vti := SecurityID{UniqueID: "922908769", UniqueIDType: "CUSIP"}
+	opt := SecurityID{UniqueID: "SPY170317C00240000", UniqueIDType: "CUSIP"}
+
+	prior := PositionList{
+		reconcileStock("78462F103", reconcileFrac(100, 1), PosTypeLong),
+		reconcileStock("037833100", reconcileFrac(50, 1), PosTypeLong),
+		reconcileStock("30231G102", reconcileFrac(10, 1), PosTypeLong),
+		reconcileStock("922908769", reconcileFrac(10, 1), PosTypeLong),
+		reconcileStock("38259P508", reconcileFrac(20, 1), PosTypeLong),
+		OptPosition{InvPos: InvPosition{SecID: opt, PosType: PosTypeShort, Units: reconcileFrac(2, 1)}},
+	}
+	tranList := InvTranList{InvTransactions: []InvTransaction{
+		// Listed before the split, but traded after it, so it shouldn't be
+		// multiplied by it
+		BuyStock{InvBuy: InvBuy{InvTran: reconcileInvTran("1", 20), SecID: xom, Units: reconcileFrac(5, 1)}, BuyType: BuyTypeBuy},
+		Split{InvTran: reconcileInvTran("2", 10), SecID: xom, Numerator: 3, Denominator: 1},
+		BuyStock{InvBuy: InvBuy{InvTran: reconcileInvTran("3", 2), SecID: spy, Units: reconcileFrac(20, 1)}, BuyType: BuyTypeBuy},
+		SellStock{InvSell: InvSell{InvTran: reconcileInvTran("4", 3), SecID: spy, Units: reconcileFrac(-5, 1)}, SellType: SellTypeSell},
+		Reinvest{InvTran: reconcileInvTran("5", 4), SecID: vti, Units: reconcileFrac(5, 10)},
+		ClosureOpt{InvTran: reconcileInvTran("6", 17), SecID: opt, OptAction: OptActionAssign, Units: reconcileFrac(1, 1), SubAcctSec: SubAcctTypeShort},
+		MarginInterest{InvTran: reconcileInvTran("7", 31)},
+	}}
+	current := PositionList{
+		reconcileStock("78462F103", reconcileFrac(115, 1), PosTypeLong),
+		// Reported by ISIN rather than CUSIP, after a 2:1 split which wasn't
+		// included in the transactions
+		StockPosition{InvPos: InvPosition{
+			SecID:   SecurityID{UniqueID: "US0378331005", UniqueIDType: "ISIN"},
+			PosType: PosTypeLong,
+			Units:   reconcileFrac(100, 1),
+		}},
+		reconcileStock("30231G102", reconcileFrac(35, 1), PosTypeLong),
+		reconcileStock("922908769", reconcileFrac(105004, 10000), PosTypeLong),
+		OptPosition{InvPos: InvPosition{SecID: opt, PosType: PosTypeShort, Units: reconcileFrac(1, 1)}},
+		reconcileStock("594918104", reconcileFrac(7, 1), PosTypeLong),
+	}
+
+	expected := []struct {
+		uniqueID string
+		expected Amount
+		reported Amount
+		kind     discrepancyKind
+	}{
+		{"037833100", reconcileFrac(50, 1), reconcileFrac(100, 1), DiscrepancySplit},
+		{"38259P508", reconcileFrac(20, 1), reconcileFrac(0, 1), DiscrepancyTransfer},
+		{"594918104", reconcileFrac(0, 1), reconcileFrac(7, 1), DiscrepancyTransfer},
+		{"922908769", reconcileFrac(105, 10), reconcileFrac(105004, 10000), DiscrepancyRounding},
+	}
+
+	discrepancies := ReconcilePositions(prior, &tranList, current, nil)
+	if len(discrepancies) != len(expected) {
+		t.Fatalf("Expected %d discrepancies, got %d: %v\n", len(expected), len(discrepancies), discrepancies)
+	}
+	for i, e := range expected {
+		d := discrepancies[i]
+		if string(d.SecID.UniqueID) != e.uniqueID || !d.Expected.Equal(e.expected) || !d.Reported.Equal(e.reported) || d.Kind != e.kind {
+			t.Errorf("Expected discrepancy %d for %s (expected %s, reported %s, %s), got %s\n", i, e.uniqueID, e.expected, e.reported, e.kind, d)
+		}
+		var difference Amount
+		difference.Sub(&d.Reported.Rat, &d.Expected.Rat)
+		if d.Difference.Cmp(&difference.Rat) != 0 {
+			t.Errorf("Expected difference of %s for %s, got %s\n", difference.String(), e.uniqueID, d.Difference.String())
+		}
+	}
+
+	// With a tighter tolerance, the VTI discrepancy can no longer be explained
+	// by rounding, and with a lower maximum ratio the AAPL one is no longer a
+	// likely split
+	tolerance := reconcileFrac(1, 10000)
+	discrepancies = ReconcilePositions(prior, &tranList, current, &ReconcilePositionsOptions{Tolerance: &tolerance, MaxSplitRatio: 1})
+	if len(discrepancies) != len(expected) {
+		t.Fatalf("Expected %d discrepancies, got %d: %v\n", len(expected), len(discrepancies), discrepancies)
+	}
+	if discrepancies[0].Kind != DiscrepancyTransfer || discrepancies[3].Kind != DiscrepancyTransfer {
+		t.Errorf("Expected options to change discrepancy kinds, got %v\n", discrepancies)
+	}
+}
+
+func TestReconcilePositionsSplitRatios(t *testing.T) {
+	tests := []struct {
+		expected, reported int64
+		kind               discrepancyKind
+	}{
+		{10, 20, DiscrepancySplit},
+		{30, 10, DiscrepancySplit},
+		{10, 11, DiscrepancyTransfer},
+		{15, 35, DiscrepancyTransfer},
+		{20, 30, DiscrepancyTransfer},
+		{1, 21, DiscrepancyTransfer},
+	}
+	for _, test := range tests {
+		prior := PositionList{reconcileStock("78462F103", reconcileFrac(test.expected, 1), PosTypeLong)}
+		current := PositionList{reconcileStock("78462F103", reconcileFrac(test.reported, 1), PosTypeLong)}
+		discrepancies := ReconcilePositions(prior, nil, current, nil)
+		if len(discrepancies) != 1 {
+			t.Fatalf("Expected 1 discrepancy from %d to %d units, got %v\n", test.expected, test.reported, discrepancies)
+		}
+		if discrepancies[0].Kind != test.kind {
+			t.Errorf("Expected %s from %d to %d units, got %s\n", test.kind, test.expected, test.reported, discrepancies[0].Kind)
+		}
+	}
+}
+
+func TestReconcilePositionsSample(t *testing.T) {
+	file, err := os.Open("samples/valid_responses/inv_v202.ofx")
+	if err != nil {
+		t.Fatalf("Unexpected error opening sample: %s\n", err)
+	}
+	defer file.Close()
+	response, err := ParseResponse(file)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing sample: %s\n", err)
+	}
+	stmt := response.InvStmt[0].(*InvStatementResponse)
+
+	// The sample's positions are consistent with themselves and no
+	// transactions
+	if discrepancies := ReconcilePositions(stmt.InvPosList, nil, stmt.InvPosList, nil); len(discrepancies) != 0 {
+		t.Errorf("Unexpected discrepancies: %v\n", discrepancies)
+	}
+}
+
+func reconcileStatement(acctID string, balance Amount, dtAsOf *Date, dtStart, dtEnd *Date, transactions ...Transaction) *StatementResponse {
+	return &StatementResponse{
+		BankAcctFrom: BankAcct{BankID: "318398732", AcctID: String(acctID), AcctType: AcctTypeChecking},
+		BankTranList: &TransactionList{DtStart: *dtStart, DtEnd: *dtEnd, Transactions: transactions},
+		BalAmt:       balance,
+		DtAsOf:       *dtAsOf,
+	}
+}
+
+func reconcileTransaction(fitid string, dtPosted *Date, amount Amount) Transaction {
+	return Transaction{TrnType: TrnTypeOther, DtPosted: *dtPosted, TrnAmt: amount, FiTID: String(fitid)}
+}
+
+func TestReconcileBalances(t *testing.T) {
+	day := func(month, day int) *Date {
+		return NewDateGMT(2017, time.Month(month), day, 12, 0, 0, 0)
+	}
+	previous := reconcileStatement("1234", reconcileFrac(1000, 1), day(1, 31), day(1, 1), day(1, 31),
+		reconcileTransaction("A", day(1, 28), reconcileFrac(-25, 1)),
+	)
+
+	tests := []struct {
+		name       string
+		current    *StatementResponse
+		net        Amount
+		difference Amount
+		gaps       []DateGap
+	}{
+		{
+			name: "balanced with overlap",
+			current: reconcileStatement("1234", reconcileFrac(1150, 1), day(2, 28), day(1, 25), day(2, 28),
+				reconcileTransaction("A", day(1, 28), reconcileFrac(-25, 1)),
+				reconcileTransaction("B", day(2, 5), reconcileFrac(200, 1)),
+				reconcileTransaction("C", day(2, 10), reconcileFrac(-50, 1)),
+			),
+			net:        reconcileFrac(150, 1),
+			difference: reconcileFrac(0, 1),
+		},
+		{
+			name: "gap with missing transaction",
+			current: reconcileStatement("1234", reconcileFrac(1150, 1), day(2, 28), day(2, 10), day(2, 28),
+				reconcileTransaction("C", day(2, 10), reconcileFrac(-50, 1)),
+			),
+			net:        reconcileFrac(-50, 1),
+			difference: reconcileFrac(200, 1),
+			gaps:       []DateGap{{Start: *day(1, 31), End: *day(2, 10)}},
+		},
+		{
+			name: "balanced with gaps",
+			current: reconcileStatement("1234", reconcileFrac(1150, 1), day(3, 1), day(2, 5), day(2, 20),
+				reconcileTransaction("B", day(2, 5), reconcileFrac(200, 1)),
+				reconcileTransaction("C", day(2, 10), reconcileFrac(-50, 1)),
+			),
+			net:        reconcileFrac(150, 1),
+			difference: reconcileFrac(0, 1),
+			gaps: []DateGap{
+				{Start: *day(1, 31), End: *day(2, 5)},
+				{Start: *day(2, 20), End: *day(3, 1)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		r, err := ReconcileBalances(previous, test.current)
+		if err != nil {
+			t.Fatalf("%s: Unexpected error: %s\n", test.name, err)
+		}
+		net, difference := test.net, test.difference
+		if !r.Net.Equal(net) {
+			t.Errorf("%s: Expected net %s, got %s\n", test.name, test.net, r.Net.String())
+		}
+		if !r.Difference.Equal(difference) {
+			t.Errorf("%s: Expected difference %s, got %s\n", test.name, test.difference, r.Difference.String())
+		}
+		if r.Balanced() != (difference.Sign() == 0) {
+			t.Errorf("%s: Expected Balanced() to be %t\n", test.name, difference.Sign() == 0)
+		}
+		if r.MissingTransactionsSuspected() != (difference.Sign() != 0 || len(test.gaps) > 0) {
+			t.Errorf("%s: Unexpected MissingTransactionsSuspected() %t\n", test.name, r.MissingTransactionsSuspected())
+		}
+		if len(r.Gaps) != len(test.gaps) {
+			t.Errorf("%s: Expected gaps %v, got %v\n", test.name, test.gaps, r.Gaps)
+			continue
+		}
+		for i, gap := range test.gaps {
+			if !r.Gaps[i].Start.Equal(gap.Start) || !r.Gaps[i].End.Equal(gap.End) {
+				t.Errorf("%s: Expected gap %s, got %s\n", test.name, gap, r.Gaps[i])
+			}
+		}
+	}
+}
+
+func TestReconcileBalancesCreditCard(t *testing.T) {
+	previous := &CCStatementResponse{
+		CCAcctFrom: CCAcct{AcctID: "4321"},
+		BalAmt:     reconcileFrac(-100, 1),
+		DtAsOf:     *NewDateGMT(2017, 1, 31, 0, 0, 0, 0),
+	}
+	current := &CCStatementResponse{
+		CCAcctFrom: CCAcct{AcctID: "4321"},
+		BankTranList: &TransactionList{
+			DtStart: *NewDateGMT(2017, 1, 31, 0, 0, 0, 0),
+			DtEnd:   *NewDateGMT(2017, 2, 28, 0, 0, 0, 0),
+			Transactions: []Transaction{
+				reconcileTransaction("1", NewDateGMT(2017, 2, 3, 0, 0, 0, 0), reconcileFrac(-4567, 100)),
+				reconcileTransaction("2", NewDateGMT(2017, 2, 14, 0, 0, 0, 0), reconcileFrac(100, 1)),
+			},
+		},
+		BalAmt: reconcileFrac(-4567, 100),
+		DtAsOf: *NewDateGMT(2017, 2, 28, 0, 0, 0, 0),
+	}
+	r, err := ReconcileBalances(previous, current)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if !r.Balanced() || r.MissingTransactionsSuspected() || len(r.Transactions) != 2 {
+		t.Errorf("Expected credit card statements to reconcile, got difference %s and gaps %v\n", r.Difference.String(), r.Gaps)
+	}
+
+	bank := reconcileStatement("4321", reconcileFrac(0, 1), NewDateGMT(2017, 2, 28, 0, 0, 0, 0), NewDateGMT(2017, 2, 1, 0, 0, 0, 0), NewDateGMT(2017, 2, 28, 0, 0, 0, 0))
+	if _, err := ReconcileBalances(previous, bank); err == nil {
+		t.Errorf("Expected error reconciling credit card and bank statements\n")
+	}
+	other := *current
+	other.CCAcctFrom.AcctID = "9999"
+	if _, err := ReconcileBalances(previous, &other); err == nil {
+		t.Errorf("Expected error reconciling statements for different accounts\n")
+	}
+	if _, err := ReconcileBalances(current, previous); err == nil {
+		t.Errorf("Expected error reconciling statements out of order\n")
+	}
+	if _, err := ReconcileBalances(previous, &InvStatementResponse{}); err == nil {
+		t.Errorf("Expected error reconciling investment statement\n")
+	}
+}
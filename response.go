@@ -35,18 +35,31 @@ type Response struct {
 	Image      []Message      //<IMAGEMSGSETV1>
 }
 
-func (or *Response) readSGMLHeaders(r *bufio.Reader) error {
+// readSGMLHeaderString reads the SGML headers from r, leaving r positioned at
+// the first '<' of the body. The returned string includes the trailing '<'.
+func readSGMLHeaderString(r *bufio.Reader) (string, error) {
 	b, err := r.ReadSlice('<')
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	s := string(b)
 	err = r.UnreadByte()
+	if err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+func (or *Response) readSGMLHeaders(r *bufio.Reader) error {
+	s, err := readSGMLHeaderString(r)
 	if err != nil {
 		return err
 	}
+	return or.parseSGMLHeaders(s)
+}
 
+func (or *Response) parseSGMLHeaders(s string) error {
 	// According to the latest OFX SGML spec (1.6), headers should be CRLF-separated
 	// and written as KEY:VALUE. However, some banks include a whitespace after the
 	// colon (KEY: VALUE), while others include no line breaks at all. The spec doesn't
@@ -263,43 +276,61 @@ func decodeMessageSet(d *xml.Decoder, start xml.StartElement, msgs *[]Message, v
 	}
 }
 
-// ParseResponse parses and validates an OFX response in SGML or XML into a
-// Response object from the given io.Reader
-//
-// It is commonly used as part of Client.Request(), but may be used on its own
-// to parse already-downloaded OFX files (such as those from 'Web Connect'). It
-// performs version autodetection if it can and attempts to be as forgiving as
-// possible about the input format.
-func ParseResponse(reader io.Reader) (*Response, error) {
-	resp, err := DecodeResponse(reader)
-	if err != nil {
-		return nil, err
-	}
-	_, err = resp.Valid()
-	return resp, err
+// responseDecoder is an xml.Decoder positioned at the start of an OFX
+// response's body, along with what was learned from its headers
+type responseDecoder struct {
+	*xml.Decoder
+	version     ofxVersion
+	sgml        bool
+	headerLen   int64        // Number of bytes of SGML headers preceding the Decoder's input
+	diagnostics []Diagnostic // Header problems repaired in lenient mode
+	position    *positionReader
 }
 
-// DecodeResponse parses an OFX response in SGML or XML into a Response object
-// from the given io.Reader
-func DecodeResponse(reader io.Reader) (*Response, error) {
+// newResponseDecoder reads the SGML or XML headers from reader, returning an
+// xml.Decoder configured appropriately for the rest of the response along with
+// the OFX version the headers specify. If lenient is true, malformed SGML
+// headers are ignored (with a Diagnostic) rather than causing an error.
+func newResponseDecoder(reader io.Reader, lenient bool) (*responseDecoder, error) {
 	var or Response
+	var rd responseDecoder
 
 	r := bufio.NewReaderSize(reader, guessVersionCheckBytes)
 	xmlVersion, err := guessVersion(r)
 	if err != nil {
 		return nil, err
 	}
+	rd.sgml = !xmlVersion
+	line := 1
 
 	// parse SGML headers before creating XML decoder
 	if !xmlVersion {
-		if err := or.readSGMLHeaders(r); err != nil {
+		s, err := readSGMLHeaderString(r)
+		if err != nil {
 			return nil, err
 		}
+		rd.headerLen = int64(len(s) - 1)
+		line = 1 + strings.Count(s, "\n")
+		if err := or.parseSGMLHeaders(s); err != nil {
+			if !lenient {
+				return nil, err
+			}
+			if or.Version == 0 || or.Version > OfxVersion160 {
+				or.Version = OfxVersion102
+			}
+			rd.diagnostics = append(rd.diagnostics, Diagnostic{
+				Offset:  0,
+				Message: err.Error() + "; assuming OFX version " + or.Version.String(),
+			})
+		}
 	}
 
-	decoder := xml.NewDecoder(r)
-	if !xmlVersion {
+	rd.position = newPositionReader(r, line)
+	decoder := xml.NewDecoder(rd.position)
+	if !xmlVersion || lenient {
 		decoder.Strict = false
+	}
+	if !xmlVersion {
 		decoder.AutoCloseAfterCharData = ofxLeafElements
 	}
 	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
@@ -312,31 +343,72 @@ func DecodeResponse(reader io.Reader) (*Response, error) {
 			return nil, err
 		}
 	}
+	rd.Decoder = decoder
+	rd.version = or.Version
+	return &rd, nil
+}
 
-	tok, err := nextNonWhitespaceToken(decoder)
+// ParseResponse parses and validates an OFX response in SGML or XML into a
+// Response object from the given io.Reader
+//
+// It is commonly used as part of Client.Request(), but may be used on its own
+// to parse already-downloaded OFX files (such as those from 'Web Connect'). It
+// performs version autodetection if it can and attempts to be as forgiving as
+// possible about the input format.
+func ParseResponse(reader io.Reader) (*Response, error) {
+	resp, err := DecodeResponse(reader)
+	if err != nil {
+		return nil, err
+	}
+	_, err = resp.Valid()
+	return resp, err
+}
+
+// DecodeResponse parses an OFX response in SGML or XML into a Response object
+// from the given io.Reader. If the response body can't be decoded, the error
+// returned is a *ParseError identifying where decoding failed.
+func DecodeResponse(reader io.Reader) (*Response, error) {
+	var or Response
+
+	decoder, err := newResponseDecoder(reader, false)
 	if err != nil {
 		return nil, err
+	}
+	or.Version = decoder.version
+
+	if err := decodeResponseBody(decoder.Decoder, &or); err != nil {
+		return nil, decoder.position.wrap(err)
+	}
+	return &or, nil
+}
+
+// decodeResponseBody decodes everything from the opening OFX element through
+// the closing one into or
+func decodeResponseBody(decoder *xml.Decoder, or *Response) error {
+	tok, err := nextNonWhitespaceToken(decoder)
+	if err != nil {
+		return err
 	} else if ofxStart, ok := tok.(xml.StartElement); !ok || ofxStart.Name.Local != "OFX" {
-		return nil, errors.New("Missing opening OFX xml element")
+		return errors.New("Missing opening OFX xml element")
 	}
 
 	// Unmarshal the signon message
 	tok, err = nextNonWhitespaceToken(decoder)
 	if err != nil {
-		return nil, err
+		return err
 	} else if signonStart, ok := tok.(xml.StartElement); ok && signonStart.Name.Local == SignonRs.String() {
 		if err := decoder.Decode(&or.Signon); err != nil {
-			return nil, err
+			return err
 		}
 	} else {
-		return nil, errors.New("Missing opening SIGNONMSGSRSV1 xml element")
+		return errors.New("Missing opening SIGNONMSGSRSV1 xml element")
 	}
 
 	tok, err = nextNonWhitespaceToken(decoder)
 	if err != nil {
-		return nil, err
+		return err
 	} else if signonEnd, ok := tok.(xml.EndElement); !ok || signonEnd.Name.Local != SignonRs.String() {
-		return nil, errors.New("Missing closing SIGNONMSGSRSV1 xml element")
+		return errors.New("Missing closing SIGNONMSGSRSV1 xml element")
 	}
 
 	var messageSlices = map[string]*[]Message{
@@ -359,19 +431,19 @@ func DecodeResponse(reader io.Reader) (*Response, error) {
 	for {
 		tok, err = nextNonWhitespaceToken(decoder)
 		if err != nil {
-			return nil, err
+			return err
 		} else if ofxEnd, ok := tok.(xml.EndElement); ok && ofxEnd.Name.Local == "OFX" {
-			return &or, nil // found closing XML element, so we're done
+			return nil // found closing XML element, so we're done
 		} else if start, ok := tok.(xml.StartElement); ok {
 			slice, ok := messageSlices[start.Name.Local]
 			if !ok {
-				return nil, errors.New("Invalid message set: " + start.Name.Local)
+				return errors.New("Invalid message set: " + start.Name.Local)
 			}
 			if err := decodeMessageSet(decoder, start, slice, or.Version); err != nil {
-				return nil, err
+				return err
 			}
 		} else {
-			return nil, errors.New("Found unexpected token")
+			return errors.New("Found unexpected token")
 		}
 	}
 }
@@ -419,6 +491,10 @@ func (or *Response) Marshal() (*bytes.Buffer, error) {
 
 	encoder := xml.NewEncoder(&b)
 	encoder.Indent("", "    ")
+	if or.Version < OfxVersion200 {
+		// OFX 100 series versions should avoid element close tags for compatibility
+		encoder.SetDisableAutoClose(ofxLeafElements...)
+	}
 
 	ofxElement := xml.StartElement{Name: xml.Name{Local: "OFX"}}
 
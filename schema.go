@@ -0,0 +1,572 @@
+package ofxgo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aclindsa/xml"
+)
+
+// ValidateSchema checks the OFX request or response read from reader against
+// the element grammar of the OFX specification (see schemaGrammar) for the
+// version given in its headers, returning a Diagnostic for each violation
+// found: elements which aren't allowed where they appear, are out of order or
+// repeated, require a later version of OFX, or which are missing. Only the
+// structure of the document is checked, not the values of its elements.
+//
+// A non-nil error is only returned if the document couldn't be read at all.
+func ValidateSchema(reader io.Reader) ([]Diagnostic, error) {
+	rd, err := newResponseDecoder(reader, false)
+	if err != nil {
+		return nil, err
+	}
+	v := schemaValidator{
+		d:         rd.Decoder,
+		version:   rd.version,
+		sgml:      rd.sgml,
+		headerLen: rd.headerLen,
+	}
+	if err := v.run(); err != nil {
+		return v.diagnostics, rd.position.wrap(err)
+	}
+	return v.diagnostics, nil
+}
+
+// ValidateSchema marshals the Request and checks the result against the OFX
+// element grammar for its version, returning a Diagnostic for each violation
+// found. See ValidateSchema.
+func (oq *Request) ValidateSchema() ([]Diagnostic, error) {
+	b, err := oq.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return ValidateSchema(b)
+}
+
+// ValidateSchema marshals the Response and checks the result against the OFX
+// element grammar for its version, returning a Diagnostic for each violation
+// found. See ValidateSchema.
+func (or *Response) ValidateSchema() ([]Diagnostic, error) {
+	b, err := or.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return ValidateSchema(b)
+}
+
+// schemaEdge is a transition between states of a schemaModel, consuming one
+// child element
+type schemaEdge struct {
+	name  string
+	since ofxVersion // First version the element is allowed in, or 0 if it is allowed in all
+	to    int
+}
+
+// schemaState is one state of a schemaModel
+type schemaState struct {
+	edges   []schemaEdge
+	epsilon []int // States reachable without consuming a child element
+}
+
+// schemaModel is a content model compiled into a nondeterministic finite
+// automaton over the names of an aggregate's child elements
+type schemaModel struct {
+	states   []schemaState
+	start    int
+	accept   int
+	mentions map[string]bool // Names of all the elements appearing in the model
+}
+
+// schemaModels holds the compiled form of each content model in schemaGrammar
+var schemaModels = func() map[string]*schemaModel {
+	models := make(map[string]*schemaModel, len(schemaGrammar))
+	for name, model := range schemaGrammar {
+		m, err := compileSchemaModel(model)
+		if err != nil {
+			panic("Invalid content model for " + name + ": " + err.Error())
+		}
+		models[name] = m
+	}
+	return models
+}()
+
+// schemaFragment is a partially-built piece of a schemaModel with a single
+// entry and exit state
+type schemaFragment struct {
+	start, end int
+}
+
+// schemaParser compiles a content model using Thompson's construction
+type schemaParser struct {
+	tokens []string
+	pos    int
+	model  *schemaModel
+}
+
+func compileSchemaModel(model string) (*schemaModel, error) {
+	p := schemaParser{
+		tokens: tokenizeSchemaModel(model),
+		model:  &schemaModel{mentions: make(map[string]bool)},
+	}
+	f, err := p.alternation()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	p.model.start = f.start
+	p.model.accept = f.end
+	return p.model, nil
+}
+
+func tokenizeSchemaModel(model string) []string {
+	var tokens []string
+	start := -1
+	for i, c := range model {
+		if strings.ContainsRune("()|?*+ ", c) {
+			if start >= 0 {
+				tokens = append(tokens, model[start:i])
+				start = -1
+			}
+			if c != ' ' {
+				tokens = append(tokens, string(c))
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, model[start:])
+	}
+	return tokens
+}
+
+func (p *schemaParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *schemaParser) newState() int {
+	p.model.states = append(p.model.states, schemaState{})
+	return len(p.model.states) - 1
+}
+
+func (p *schemaParser) epsilon(from, to int) {
+	p.model.states[from].epsilon = append(p.model.states[from].epsilon, to)
+}
+
+func (p *schemaParser) alternation() (schemaFragment, error) {
+	f, err := p.sequence()
+	if err != nil {
+		return f, err
+	}
+	if p.peek() != "|" {
+		return f, nil
+	}
+	alt := schemaFragment{start: p.newState(), end: p.newState()}
+	p.epsilon(alt.start, f.start)
+	p.epsilon(f.end, alt.end)
+	for p.peek() == "|" {
+		p.pos++
+		f, err = p.sequence()
+		if err != nil {
+			return f, err
+		}
+		p.epsilon(alt.start, f.start)
+		p.epsilon(f.end, alt.end)
+	}
+	return alt, nil
+}
+
+func (p *schemaParser) sequence() (schemaFragment, error) {
+	var seq schemaFragment
+	first := true
+	for {
+		if tok := p.peek(); tok == "" || tok == "|" || tok == ")" {
+			break
+		}
+		f, err := p.repetition()
+		if err != nil {
+			return f, err
+		}
+		if first {
+			seq = f
+			first = false
+		} else {
+			p.epsilon(seq.end, f.start)
+			seq.end = f.end
+		}
+	}
+	if first {
+		return seq, fmt.Errorf("empty sequence at token %d", p.pos)
+	}
+	return seq, nil
+}
+
+func (p *schemaParser) repetition() (schemaFragment, error) {
+	f, err := p.atom()
+	if err != nil {
+		return f, err
+	}
+	switch p.peek() {
+	case "?":
+		p.pos++
+		p.epsilon(f.start, f.end)
+	case "*":
+		p.pos++
+		p.epsilon(f.start, f.end)
+		p.epsilon(f.end, f.start)
+	case "+":
+		p.pos++
+		p.epsilon(f.end, f.start)
+	}
+	return f, nil
+}
+
+func (p *schemaParser) atom() (schemaFragment, error) {
+	tok := p.peek()
+	p.pos++
+	if tok == "(" {
+		f, err := p.alternation()
+		if err != nil {
+			return f, err
+		}
+		if p.peek() != ")" {
+			return f, fmt.Errorf("missing ')' at token %d", p.pos)
+		}
+		p.pos++
+		// Wrap the group so repetition applies to it as a whole
+		wrapped := schemaFragment{start: p.newState(), end: p.newState()}
+		p.epsilon(wrapped.start, f.start)
+		p.epsilon(f.end, wrapped.end)
+		return wrapped, nil
+	} else if tok == "" || strings.ContainsAny(tok, ")|?*+") {
+		return schemaFragment{}, fmt.Errorf("unexpected %q at token %d", tok, p.pos-1)
+	}
+
+	name := tok
+	var since ofxVersion
+	if i := strings.Index(tok, "@"); i >= 0 {
+		name = tok[:i]
+		if err := since.FromString(tok[i+1:]); err != nil {
+			return schemaFragment{}, err
+		}
+	}
+	f := schemaFragment{start: p.newState(), end: p.newState()}
+	p.model.states[f.start].edges = append(p.model.states[f.start].edges, schemaEdge{name: name, since: since, to: f.end})
+	p.model.mentions[name] = true
+	return f, nil
+}
+
+// closure returns states along with all the states reachable from them
+// without consuming a child element. If skip is true, states reachable by
+// consuming any children allowed in version are also included.
+func (m *schemaModel) closure(states []int, skip bool, version ofxVersion) []int {
+	seen := make(map[int]bool, len(states))
+	var result []int
+	stack := append([]int(nil), states...)
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		result = append(result, s)
+		stack = append(stack, m.states[s].epsilon...)
+		if skip {
+			for _, e := range m.states[s].edges {
+				if e.since <= version {
+					stack = append(stack, e.to)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// step returns the states reached from states by consuming the child element
+// name, if it is allowed in version
+func (m *schemaModel) step(states []int, name string, version ofxVersion) []int {
+	var next []int
+	for _, s := range states {
+		for _, e := range m.states[s].edges {
+			if e.name == name && e.since <= version {
+				next = append(next, e.to)
+			}
+		}
+	}
+	return m.closure(next, false, version)
+}
+
+// since returns the earliest version in which name may follow states, or 0
+// if it can't
+func (m *schemaModel) since(states []int, name string) ofxVersion {
+	var since ofxVersion
+	for _, s := range states {
+		for _, e := range m.states[s].edges {
+			if e.name == name && (since == 0 || e.since < since) {
+				since = e.since
+			}
+		}
+	}
+	return since
+}
+
+// expected returns the names of the child elements which may follow states
+// in version, in the order they appear in the model
+func (m *schemaModel) expected(states []int, version ofxVersion) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for s := range m.states {
+		if !containsState(states, s) {
+			continue
+		}
+		for _, e := range m.states[s].edges {
+			if e.since <= version && !seen[e.name] {
+				seen[e.name] = true
+				names = append(names, e.name)
+			}
+		}
+	}
+	return names
+}
+
+func (m *schemaModel) accepts(states []int) bool {
+	return containsState(states, m.accept)
+}
+
+func containsState(states []int, s int) bool {
+	for _, state := range states {
+		if state == s {
+			return true
+		}
+	}
+	return false
+}
+
+// specVersion formats an OFX version the way the specification does (i.e.
+// 1.0.2 or 2.2)
+func specVersion(version ofxVersion) string {
+	s := version.String()
+	if len(s) != 3 {
+		return s
+	}
+	return strings.TrimSuffix(s[:1]+"."+s[1:2]+"."+s[2:], ".0")
+}
+
+// schemaEntry is one open element tracked by schemaValidator
+type schemaEntry struct {
+	name   string
+	model  *schemaModel // nil if this element's contents aren't checked
+	states []int
+	leaf   bool // Has had text, or is a known leaf element
+	parent bool // Has had child elements
+}
+
+// schemaValidator walks the raw tokens of an OFX document, tracking open
+// elements (inferring where SGML leaf elements end) and simulating each
+// aggregate's content model over its children
+type schemaValidator struct {
+	d           *xml.Decoder
+	version     ofxVersion
+	sgml        bool
+	headerLen   int64
+	offset      int64 // Offset of the token currently being checked
+	stack       []schemaEntry
+	done        bool // The closing OFX element has been seen
+	diagnostics []Diagnostic
+}
+
+func (v *schemaValidator) run() error {
+	for {
+		v.offset = v.headerLen + v.d.InputOffset()
+		tok, err := v.d.RawToken()
+		if err == io.EOF {
+			if len(v.stack) > 0 {
+				v.diagnose(v.path(), "Unexpected end of document")
+				for len(v.stack) > 0 {
+					v.pop(true)
+				}
+			} else if !v.done {
+				v.diagnose("", "Missing OFX element")
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			v.start(t.Name.Local)
+		case xml.EndElement:
+			v.end(t.Name.Local)
+		case xml.CharData:
+			v.charData(t)
+		}
+	}
+}
+
+func (v *schemaValidator) diagnose(path, message string) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		Path:    path,
+		Offset:  v.offset,
+		Message: message,
+	})
+}
+
+// path returns the slash-separated names of the open elements, followed by
+// name if it is non-empty
+func (v *schemaValidator) path(name ...string) string {
+	names := make([]string, 0, len(v.stack)+len(name))
+	for i := range v.stack {
+		names = append(names, v.stack[i].name)
+	}
+	return strings.Join(append(names, name...), "/")
+}
+
+func (v *schemaValidator) top() *schemaEntry {
+	if len(v.stack) == 0 {
+		return nil
+	}
+	return &v.stack[len(v.stack)-1]
+}
+
+func (v *schemaValidator) start(name string) {
+	// Leaf elements are not closed in SGML, so any element beginning closes
+	// the open leaf element
+	if top := v.top(); top != nil && top.leaf {
+		v.pop(true)
+	}
+
+	parent := v.top()
+	if parent == nil {
+		if v.done || name != "OFX" {
+			v.diagnose(name, "Unexpected element "+name+" outside of the OFX element")
+		}
+	} else {
+		parent.parent = true
+		if parent.model != nil && !strings.Contains(name, ".") {
+			v.child(parent, name)
+		}
+	}
+
+	entry := schemaEntry{
+		name: name,
+		leaf: ofxLeafElementSet[name],
+	}
+	if model, ok := schemaModels[name]; ok && !strings.Contains(v.path(), ".") {
+		entry.model = model
+		entry.states = model.closure([]int{model.start}, false, v.version)
+	}
+	v.stack = append(v.stack, entry)
+}
+
+// child advances parent's content model over its child element name,
+// diagnosing the child if it isn't allowed there
+func (v *schemaValidator) child(parent *schemaEntry, name string) {
+	m := parent.model
+	if next := m.step(parent.states, name, v.version); len(next) > 0 {
+		parent.states = next
+		return
+	}
+
+	path := v.path(name)
+	if !m.mentions[name] {
+		v.diagnose(path, "Element "+name+" is not allowed in "+parent.name)
+		return
+	}
+	if since := m.since(parent.states, name); since > v.version {
+		v.diagnose(path, "Element "+name+" in "+parent.name+" requires OFX "+specVersion(since)+", but the document is OFX "+specVersion(v.version))
+		var states []int
+		for _, s := range parent.states {
+			for _, e := range m.states[s].edges {
+				if e.name == name {
+					states = append(states, e.to)
+				}
+			}
+		}
+		parent.states = m.closure(states, false, v.version)
+		return
+	}
+
+	v.diagnose(path, "Unexpected element "+name+" in "+parent.name+" ("+v.expectation(parent)+")")
+	// Resynchronize by skipping over the children missing before this one,
+	// if it appears later in the model. Otherwise it is out of order or
+	// repeated, and is ignored.
+	skipped := m.closure(parent.states, true, v.version)
+	if next := m.step(skipped, name, v.version); len(next) > 0 {
+		parent.states = next
+	}
+}
+
+// expectation describes the children which may come next in e
+func (v *schemaValidator) expectation(e *schemaEntry) string {
+	names := e.model.expected(e.states, v.version)
+	if e.model.accepts(e.states) {
+		names = append(names, "end of "+e.name)
+	}
+	if len(names) == 1 {
+		return "expected " + names[0]
+	}
+	return "expected one of " + strings.Join(names, ", ")
+}
+
+// pop closes the innermost open element, diagnosing any required children
+// missing from it. If implicit is true, the element wasn't closed in the
+// document, which is diagnosed unless it is an SGML leaf element.
+func (v *schemaValidator) pop(implicit bool) {
+	e := v.top()
+	if implicit && (!e.leaf || !v.sgml) {
+		v.diagnose(v.path(), "Element "+e.name+" is not closed")
+	}
+	if e.model != nil && !e.leaf && !e.model.accepts(e.states) {
+		names := e.model.expected(e.states, v.version)
+		if len(names) == 1 {
+			v.diagnose(v.path(), "Missing element "+names[0]+" in "+e.name)
+		} else {
+			v.diagnose(v.path(), "Missing element in "+e.name+" (expected one of "+strings.Join(names, ", ")+")")
+		}
+	}
+	v.stack = v.stack[:len(v.stack)-1]
+	if len(v.stack) == 0 && e.name == "OFX" {
+		v.done = true
+	}
+}
+
+func (v *schemaValidator) end(name string) {
+	i := len(v.stack) - 1
+	for ; i >= 0; i-- {
+		if v.stack[i].name == name {
+			break
+		}
+	}
+	if i < 0 {
+		v.diagnose(v.path(), "Closing tag for element "+name+" which isn't open")
+		return
+	}
+	for len(v.stack) > i+1 {
+		v.pop(true)
+	}
+	v.pop(false)
+}
+
+func (v *schemaValidator) charData(t xml.CharData) {
+	top := v.top()
+	if top == nil || len(bytes.TrimSpace(t)) == 0 {
+		return
+	}
+	if top.parent {
+		v.diagnose(v.path(), "Unexpected text in "+top.name)
+		return
+	}
+	if top.model != nil && !top.leaf {
+		v.diagnose(v.path(), "Element "+top.name+" contains text rather than elements")
+	}
+	top.leaf = true
+}
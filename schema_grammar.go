@@ -0,0 +1,215 @@
+package ofxgo
+
+import (
+	"strings"
+)
+
+// schemaGrammar holds the content models, transcribed from the OFX 1.0.3 DTD
+// and 2.x XSD, of the aggregates this package implements (along with those
+// enclosing them). Models use DTD-like syntax: space-separated elements form a
+// sequence, '|' separates alternatives, parentheses group, and '?', '*', and
+// '+' mark optional and repeated elements. An element only allowed in later
+// versions of the spec is followed by '@' and the first version it's allowed
+// in (i.e. BANKTRANLISTP@220).
+//
+// Aggregates without an entry (and leaf elements) are not checked, and
+// extension elements (whose names contain a '.') are ignored wherever they
+// appear.
+var schemaGrammar = map[string]string{
+	"OFX": "(" + messageSetsModel(SignonRq, ImageRq) + ") | (" + messageSetsModel(SignonRs, ImageRs) + ")",
+
+	// Message sets
+	"SIGNONMSGSRQV1":     "SONRQ (PINCHTRNRQ | CHALLENGETRNRQ | MFACHALLENGETRNRQ)*",
+	"SIGNONMSGSRSV1":     "SONRS (PINCHTRNRS | CHALLENGETRNRS | MFACHALLENGETRNRS)*",
+	"SIGNUPMSGSRQV1":     "(ENROLLTRNRQ | ACCTINFOTRNRQ | ACCTTRNRQ | ACCTSYNCRQ | CHGUSERINFOTRNRQ | CHGUSERINFOSYNCRQ)*",
+	"SIGNUPMSGSRSV1":     "(ENROLLTRNRS | ACCTINFOTRNRS | ACCTTRNRS | ACCTSYNCRS | CHGUSERINFOTRNRS | CHGUSERINFOSYNCRS)*",
+	"BANKMSGSRQV1":       "(STMTTRNRQ | STMTENDTRNRQ | STPCHKTRNRQ | INTRATRNRQ | RECINTRATRNRQ | BANKMAILTRNRQ | STPCHKSYNCRQ | INTRASYNCRQ | RECINTRASYNCRQ | BANKMAILSYNCRQ)*",
+	"BANKMSGSRSV1":       "(STMTTRNRS | STMTENDTRNRS | STPCHKTRNRS | INTRATRNRS | RECINTRATRNRS | BANKMAILTRNRS | STPCHKSYNCRS | INTRASYNCRS | RECINTRASYNCRS | BANKMAILSYNCRS)*",
+	"CREDITCARDMSGSRQV1": "(CCSTMTTRNRQ | CCSTMTENDTRNRQ)*",
+	"CREDITCARDMSGSRSV1": "(CCSTMTTRNRS | CCSTMTENDTRNRS)*",
+	"INVSTMTMSGSRQV1":    "(INVSTMTTRNRQ | INVMAILTRNRQ | INVMAILSYNCRQ)*",
+	"INVSTMTMSGSRSV1":    "(INVSTMTTRNRS | INVMAILTRNRS | INVMAILSYNCRS)*",
+	"SECLISTMSGSRQV1":    "SECLISTTRNRQ*",
+	"SECLISTMSGSRSV1":    "SECLISTTRNRS* SECLIST*",
+	"PROFMSGSRQV1":       "PROFTRNRQ*",
+	"PROFMSGSRSV1":       "PROFTRNRS*",
+
+	// Transaction wrappers
+	"ACCTINFOTRNRQ": transactionRequestModel("ACCTINFORQ"),
+	"ACCTINFOTRNRS": transactionResponseModel("ACCTINFORS"),
+	"STMTTRNRQ":     transactionRequestModel("STMTRQ"),
+	"STMTTRNRS":     transactionResponseModel("STMTRS"),
+	"CCSTMTTRNRQ":   transactionRequestModel("CCSTMTRQ"),
+	"CCSTMTTRNRS":   transactionResponseModel("CCSTMTRS"),
+	"INVSTMTTRNRQ":  transactionRequestModel("INVSTMTRQ"),
+	"INVSTMTTRNRS":  transactionResponseModel("INVSTMTRS"),
+	"SECLISTTRNRQ":  transactionRequestModel("SECLISTRQ"),
+	"SECLISTTRNRS":  transactionResponseModel("SECLISTRS"),
+	"PROFTRNRQ":     transactionRequestModel("PROFRQ"),
+	"PROFTRNRS":     transactionResponseModel("PROFRS"),
+
+	// Common aggregates
+	"STATUS":       "CODE SEVERITY MESSAGE?",
+	"CURRENCY":     "CURRATE CURSYM",
+	"ORIGCURRENCY": "CURRATE CURSYM",
+	"BANKACCTFROM": "BANKID BRANCHID? ACCTID ACCTTYPE ACCTKEY?",
+	"BANKACCTTO":   "BANKID BRANCHID? ACCTID ACCTTYPE ACCTKEY?",
+	"CCACCTFROM":   "ACCTID ACCTKEY?",
+	"CCACCTTO":     "ACCTID ACCTKEY?",
+	"INVACCTFROM":  "BROKERID ACCTID",
+	"BALLIST":      "BAL*",
+	"BAL":          "NAME DESC BALTYPE VALUE DTASOF? CURRENCY?",
+	"INCTRAN":      "DTSTART? DTEND? INCLUDE",
+
+	// Signon
+	"SONRQ": "DTCLIENT ((USERID USERPASS) | USERKEY)? ACCESSTOKEN@220? GENUSERKEY? LANGUAGE FI? SESSCOOKIE? APPID APPVER APPKEY? CLIENTUID? USERCRED1? USERCRED2? AUTHTOKEN? ACCESSKEY?",
+	"SONRS": "STATUS DTSERVER USERKEY? TSKEYEXPIRE? LANGUAGE DTPROFUP? DTACCTUP? FI? SESSCOOKIE? ACCESSKEY?",
+	"FI":    "ORG FID?",
+
+	// Signup
+	"ACCTINFORQ":      "DTACCTUP",
+	"ACCTINFORS":      "DTACCTUP ACCTINFO*",
+	"ACCTINFO":        "NAME? DESC? PHONE? HOLDERINFO? (BANKACCTINFO | CCACCTINFO | INVACCTINFO | LOANACCTINFO | BPACCTINFO | PRESACCTINFO)+",
+	"HOLDERINFO":      "PRIMARYHOLDER SECONDARYHOLDER?",
+	"PRIMARYHOLDER":   "FIRSTNAME MIDDLENAME? LASTNAME ADDR1 ADDR2? ADDR3? CITY STATE POSTALCODE COUNTRY? DAYPHONE? EVEPHONE? EMAIL? HOLDERTYPE?",
+	"SECONDARYHOLDER": "FIRSTNAME MIDDLENAME? LASTNAME ADDR1 ADDR2? ADDR3? CITY STATE POSTALCODE COUNTRY? DAYPHONE? EVEPHONE? EMAIL? HOLDERTYPE?",
+	"BANKACCTINFO":    "BANKACCTFROM SUPTXDL XFERSRC XFERDEST MATURITYDATE? MATURITYAMOUNT? MINBALREQ? ACCTCLASSIFICATION? OVERDRAFTLIMIT? SVCSTATUS",
+	"CCACCTINFO":      "CCACCTFROM SUPTXDL XFERSRC XFERDEST ACCTCLASSIFICATION? SVCSTATUS",
+	"INVACCTINFO":     "INVACCTFROM USPRODUCTTYPE CHECKING SVCSTATUS INVACCTTYPE? OPTIONLEVEL?",
+
+	// Bank and credit card statements
+	"STMTRQ":        "BANKACCTFROM INCTRAN? INCLUDEPENDING@220? INCTRANIMG@210?",
+	"STMTRS":        "CURDEF BANKACCTFROM BANKTRANLIST? BANKTRANLISTP@220? LEDGERBAL AVAILBAL? CASHADVBALAMT? INTRATE? BALLIST? MKTGINFO?",
+	"CCSTMTRQ":      "CCACCTFROM INCTRAN? INCLUDEPENDING@220? INCTRANIMG@210?",
+	"CCSTMTRS":      "CURDEF CCACCTFROM BANKTRANLIST? BANKTRANLISTP@220? LEDGERBAL AVAILBAL? CASHADVBALAMT? INTRATEPURCH? INTRATECASH? INTRATEXFER? REWARDINFO? BALLIST? MKTGINFO?",
+	"REWARDINFO":    "NAME REWARDBAL REWARDEARNED?",
+	"LEDGERBAL":     "BALAMT DTASOF",
+	"AVAILBAL":      "BALAMT DTASOF",
+	"BANKTRANLIST":  "DTSTART DTEND STMTTRN*",
+	"BANKTRANLISTP": "DTASOF STMTTRNP*",
+	"STMTTRN":       "TRNTYPE DTPOSTED DTUSER? DTAVAIL? TRNAMT FITID CORRECTFITID? CORRECTACTION? SRVRTID? CHECKNUM? REFNUM? SIC? PAYEEID? (NAME | PAYEE)? EXTDNAME? (BANKACCTTO | CCACCTTO)? MEMO? IMAGEDATA@220* (CURRENCY | ORIGCURRENCY)? INV401KSOURCE@151?",
+	"STMTTRNP":      "TRNTYPE DTTRAN DTEXPIRE? TRNAMT REFNUM? NAME? EXTDNAME? MEMO? IMAGEDATA@220* (CURRENCY | ORIGCURRENCY)?",
+	"PAYEE":         "NAME ADDR1 ADDR2? ADDR3? CITY STATE POSTALCODE COUNTRY? PHONE",
+	"IMAGEDATA":     "IMAGETYPE IMAGEREF IMAGEREFTYPE IMAGEDELAY? DTIMAGEAVAIL? IMAGETTL? CHECKSUP?",
+
+	// Investment statements
+	"INVSTMTRQ":   "INVACCTFROM INCTRAN? INCOO INCPOS INCBAL INC401K@151? INC401KBAL@151? INCTRANIMAGE@210?",
+	"INCPOS":      "DTASOF? INCLUDE",
+	"INVSTMTRS":   "DTASOF CURDEF INVACCTFROM INVTRANLIST? INVPOSLIST? INVBAL? INVOOLIST? MKTGINFO? INV401K@151? INV401KBAL@151?",
+	"INVTRANLIST": "DTSTART DTEND (INVBANKTRAN | BUYDEBT | BUYMF | BUYOPT | BUYOTHER | BUYSTOCK | CLOSUREOPT | INCOME | INVEXPENSE | JRNLFUND | JRNLSEC | MARGININTEREST | REINVEST | RETOFCAP | SELLDEBT | SELLMF | SELLOPT | SELLOTHER | SELLSTOCK | SPLIT | TRANSFER)*",
+	"INVBANKTRAN": "STMTTRN SUBACCTFUND",
+	"INVTRAN":     "FITID SRVRTID? DTTRADE DTSETTLE? REVERSALFITID? MEMO?",
+	"INVBUY":      "INVTRAN SECID UNITS UNITPRICE MARKUP? COMMISSION? TAXES? FEES? LOAD? TOTAL (CURRENCY | ORIGCURRENCY)? SUBACCTSEC SUBACCTFUND LOANID@151? LOANPRINCIPAL@151? LOANINTEREST@151? INV401KSOURCE@151? DTPAYROLL@151? PRIORYEARCONTRIB@151?",
+	"INVSELL":     "INVTRAN SECID UNITS UNITPRICE MARKDOWN? COMMISSION? TAXES? FEES? LOAD? WITHHOLDING? TAXEXEMPT? TOTAL GAIN? (CURRENCY | ORIGCURRENCY)? SUBACCTSEC SUBACCTFUND LOANID@151? STATEWITHHOLDING? PENALTY? INV401KSOURCE@151?",
+
+	"BUYDEBT":        "INVBUY ACCRDINT?",
+	"BUYMF":          "INVBUY BUYTYPE RELFITID?",
+	"BUYOPT":         "INVBUY OPTBUYTYPE SHPERCTRCT",
+	"BUYOTHER":       "INVBUY",
+	"BUYSTOCK":       "INVBUY BUYTYPE",
+	"CLOSUREOPT":     "INVTRAN SECID OPTACTION UNITS SHPERCTRCT SUBACCTSEC RELFITID? GAIN?",
+	"INCOME":         "INVTRAN SECID INCOMETYPE TOTAL SUBACCTSEC SUBACCTFUND TAXEXEMPT? WITHHOLDING? (CURRENCY | ORIGCURRENCY)? INV401KSOURCE@151?",
+	"INVEXPENSE":     "INVTRAN SECID TOTAL SUBACCTSEC SUBACCTFUND (CURRENCY | ORIGCURRENCY)? INV401KSOURCE@151?",
+	"JRNLFUND":       "INVTRAN SUBACCTTO SUBACCTFROM TOTAL",
+	"JRNLSEC":        "INVTRAN SECID SUBACCTTO SUBACCTFROM UNITS",
+	"MARGININTEREST": "INVTRAN TOTAL SUBACCTFUND (CURRENCY | ORIGCURRENCY)?",
+	"REINVEST":       "INVTRAN SECID INCOMETYPE TOTAL SUBACCTSEC UNITS UNITPRICE COMMISSION? TAXES? FEES? LOAD? TAXEXEMPT? (CURRENCY | ORIGCURRENCY)? INV401KSOURCE@151?",
+	"RETOFCAP":       "INVTRAN SECID TOTAL SUBACCTSEC SUBACCTFUND (CURRENCY | ORIGCURRENCY)? INV401KSOURCE@151?",
+	"SELLDEBT":       "INVSELL SELLREASON ACCRDINT?",
+	"SELLMF":         "INVSELL SELLTYPE AVGCOSTBASIS? RELFITID?",
+	"SELLOPT":        "INVSELL OPTSELLTYPE SHPERCTRCT RELFITID? RELTYPE? SECURED?",
+	"SELLOTHER":      "INVSELL",
+	"SELLSTOCK":      "INVSELL SELLTYPE",
+	"SPLIT":          "INVTRAN SECID SUBACCTSEC OLDUNITS NEWUNITS NUMERATOR DENOMINATOR (CURRENCY | ORIGCURRENCY)? FRACCASH? SUBACCTFUND? INV401KSOURCE@151?",
+	"TRANSFER":       "INVTRAN SECID SUBACCTSEC UNITS TFERACTION POSTYPE INVACCTFROM? AVGCOSTBASIS? UNITPRICE? DTPURCHASE? INV401KSOURCE@151?",
+
+	"INVPOSLIST": "(POSDEBT | POSMF | POSOPT | POSOTHER | POSSTOCK)*",
+	"INVPOS":     "SECID HELDINACCT POSTYPE UNITS UNITPRICE MKTVAL AVGCOSTBASIS? DTPRICEASOF CURRENCY? MEMO? INV401KSOURCE@151?",
+	"POSDEBT":    "INVPOS",
+	"POSMF":      "INVPOS UNITSSTREET? UNITSUSER? REINVDIV? REINVCG?",
+	"POSOPT":     "INVPOS SECURED?",
+	"POSOTHER":   "INVPOS",
+	"POSSTOCK":   "INVPOS UNITSSTREET? UNITSUSER? REINVDIV?",
+	"INVBAL":     "AVAILCASH MARGINBALANCE SHORTBALANCE BUYPOWER? BALLIST?",
+
+	"INVOOLIST":   "(OOBUYDEBT | OOBUYMF | OOBUYOPT | OOBUYOTHER | OOBUYSTOCK | OOSELLDEBT | OOSELLMF | OOSELLOPT | OOSELLOTHER | OOSELLSTOCK | SWITCHMF)*",
+	"OO":          "FITID SRVRTID? SECID DTPLACED UNITS SUBACCT DURATION RESTRICTION MINUNITS? LIMITPRICE? STOPPRICE? MEMO? (CURRENCY | ORIGCURRENCY)? INV401KSOURCE@151?",
+	"OOBUYDEBT":   "OO AUCTION DTAUCTION?",
+	"OOBUYMF":     "OO BUYTYPE UNITTYPE",
+	"OOBUYOPT":    "OO OPTBUYTYPE",
+	"OOBUYOTHER":  "OO UNITTYPE",
+	"OOBUYSTOCK":  "OO BUYTYPE",
+	"OOSELLDEBT":  "OO",
+	"OOSELLMF":    "OO SELLTYPE UNITTYPE SELLALL",
+	"OOSELLOPT":   "OO OPTSELLTYPE",
+	"OOSELLOTHER": "OO UNITTYPE",
+	"OOSELLSTOCK": "OO SELLTYPE",
+	"SWITCHMF":    "OO SECID UNITTYPE SWITCHALL",
+
+	"INV401K":         "EMPLOYERNAME PLANID? PLANJOINDATE? EMPLOYERCONTACTINFO? BROKERCONTACTINFO? DEFERPCTPRETAX? DEFERPCTAFTERTAX? MATCHINFO? CONTRIBINFO? CURRENTVESTPCT? VESTINFO* LOANINFO* INV401KSUMMARY?",
+	"MATCHINFO":       "MATCHPCT MAXMATCHAMT? MAXMATCHPCT? STARTOFYEAR? BASEMATCHAMT BASEMATCHPCT",
+	"CONTRIBINFO":     "CONTRIBSECURITY+",
+	"CONTRIBSECURITY": "SECID (PRETAXCONTRIBPCT | PRETAXCONTRIBAMT)? (AFTERTAXCONTRIBPCT | AFTERTAXCONTRIBAMT)? (MATCHCONTRIBPCT | MATCHCONTRIBAMT)? (PROFITSHARINGCONTRIBPCT | PROFITSHARINGCONTRIBAMT)? (ROLLOVERCONTRIBPCT | ROLLOVERCONTRIBAMT)? (OTHERVESTPCT | OTHERVESTAMT)? (OTHERNONVESTPCT | OTHERNONVESTAMT)?",
+	"VESTINFO":        "VESTDATE? VESTPCT",
+	"LOANINFO":        "LOANID LOANDESC? INITIALLOANBAL? LOANSTARTDATE? CURRENTLOANBAL DTASOF LOANRATE? LOANPMTAMT? LOANPMTFREQ? LOANPMTSINITIAL? LOANPMTSREMAINING? LOANMATURITYDATE? LOANTOTALPROJINTEREST? LOANINTERESTTODATE? LOANNEXTPMTDATE?",
+	"INV401KSUMMARY":  "YEARTODATE INCEPTODATE? PERIODTODATE?",
+	"YEARTODATE":      inv401KSummaryPeriodModel,
+	"INCEPTODATE":     inv401KSummaryPeriodModel,
+	"PERIODTODATE":    inv401KSummaryPeriodModel,
+	"CONTRIBUTIONS":   inv401KSummaryAggregateModel,
+	"WITHDRAWALS":     inv401KSummaryAggregateModel,
+	"EARNINGS":        inv401KSummaryAggregateModel,
+	"INV401KBAL":      "CASHBAL? PRETAX? AFTERTAX? MATCH? PROFITSHARING? ROLLOVER? OTHERVEST? OTHERNONVEST? TOTAL BALLIST?",
+
+	// Security lists
+	"SECLISTRQ":      "SECRQ+",
+	"SECRQ":          "SECID | TICKER | FIID",
+	"SECID":          "UNIQUEID UNIQUEIDTYPE",
+	"SECLIST":        "(DEBTINFO | MFINFO | OPTINFO | OTHERINFO | STOCKINFO)*",
+	"SECINFO":        "SECID SECNAME TICKER? FIID? RATING? UNITPRICE? DTASOF? CURRENCY? MEMO?",
+	"DEBTINFO":       "SECINFO PARVALUE DEBTTYPE DEBTCLASS? COUPONRT? DTCOUPON? COUPONFREQ? CALLPRICE? YIELDTOCALL? DTCALL? CALLTYPE? YIELDTOMAT? DTMAT? ASSETCLASS? FIASSETCLASS?",
+	"MFINFO":         "SECINFO MFTYPE YIELD? DTYIELDASOF? MFASSETCLASS? FIMFASSETCLASS?",
+	"MFASSETCLASS":   "PORTION*",
+	"FIMFASSETCLASS": "FIPORTION*",
+	"PORTION":        "ASSETCLASS PERCENT",
+	"FIPORTION":      "FIASSETCLASS PERCENT",
+	"OPTINFO":        "SECINFO OPTTYPE STRIKEPRICE DTEXPIRE SHPERCTRCT SECID? ASSETCLASS? FIASSETCLASS?",
+	"OTHERINFO":      "SECINFO TYPEDESC? ASSETCLASS? FIASSETCLASS?",
+	"STOCKINFO":      "SECINFO STOCKTYPE? YIELD? DTYIELDASOF? ASSETCLASS? FIASSETCLASS?",
+
+	// Profiles
+	"PROFRQ":         "CLIENTROUTING DTPROFUP",
+	"PROFRS":         "MSGSETLIST SIGNONINFOLIST DTPROFUP FINAME ADDR1 ADDR2? ADDR3? CITY STATE POSTALCODE COUNTRY CSPHONE? TSPHONE? FAXPHONE? URL? EMAIL?",
+	"SIGNONINFOLIST": "SIGNONINFO+",
+	"SIGNONINFO":     "SIGNONREALM MIN MAX CHARTYPE CASESEN SPECIAL SPACES PINCH CHGPINFIRST USERCRED1LABEL? USERCRED2LABEL? CLIENTUIDREQ? AUTHTOKENFIRST? AUTHTOKENLABEL? AUTHTOKENINFOURL? MFACHALLENGESUPT? MFACHALLENGEFIRST? ACCESSTOKENREQ@220?",
+	"MSGSETCORE":     "VER URL OFXSEC TRANSPSEC SIGNONREALM LANGUAGE+ SYNCMODE REFRESHSUPT? RESPFILEER SPNAME? OFXEXTENSION?",
+}
+
+const (
+	inv401KSummaryPeriodModel    = "DTSTART DTEND CONTRIBUTIONS? WITHDRAWALS? EARNINGS?"
+	inv401KSummaryAggregateModel = "PRETAX? AFTERTAX? MATCH? PROFITSHARING? ROLLOVER? OTHERVEST? OTHERNONVEST? TOTAL"
+)
+
+// messageSetsModel returns the content model of the OFX element for the
+// message sets from first to last, of which only the signon message set is
+// required
+func messageSetsModel(first, last messageType) string {
+	sets := []string{first.String()}
+	for t := first + 1; t <= last; t++ {
+		sets = append(sets, t.String()+"?")
+	}
+	return strings.Join(sets, " ")
+}
+
+// transactionRequestModel returns the content model of a *TRNRQ transaction
+// wrapper around the request aggregate named body
+func transactionRequestModel(body string) string {
+	return "TRNUID CLTCOOKIE? TAN? OFXEXTENSION? " + body
+}
+
+// transactionResponseModel returns the content model of a *TRNRS transaction
+// wrapper around the response aggregate named body, which is omitted when the
+// request failed
+func transactionResponseModel(body string) string {
+	return "TRNUID STATUS CLTCOOKIE? OFXEXTENSION? " + body + "?"
+}
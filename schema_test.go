@@ -0,0 +1,292 @@
+package ofxgo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateSchemaSamples(t *testing.T) {
+	fn := func(path string, info os.FileInfo, err error) error {
+		if info.IsDir() {
+			return nil
+		} else if ext := filepath.Ext(path); ext != ".ofx" && ext != ".qfx" {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Unexpected error opening %s: %s\n", path, err)
+		}
+		defer file.Close()
+		diagnostics, err := ValidateSchema(file)
+		if err != nil {
+			t.Fatalf("Unexpected error validating %s: %s\n", path, err)
+		}
+		for _, d := range diagnostics {
+			t.Errorf("Unexpected diagnostic validating %s: %s\n", path, d)
+		}
+		return nil
+	}
+	filepath.Walk("samples/valid_responses", fn)
+	filepath.Walk("samples/busted_responses", fn)
+}
+
+func TestValidateSchemaViolations(t *testing.T) {
+	ledgerBal := "\n<LEDGERBAL>\n<BALAMT>100.00\n<DTASOF>20170201\n</LEDGERBAL>"
+	stmtrs := "OFX/BANKMSGSRSV1/STMTTRNRS/STMTRS"
+
+	type violation struct {
+		path    string
+		message string
+	}
+	tests := []struct {
+		name       string
+		response   string
+		violations []violation
+	}{
+		{
+			name:     "valid",
+			response: lenientTestResponse("BANKMSGSRSV1", "20170117", ""),
+		},
+		{
+			name:     "unknown element",
+			response: lenientTestResponse("BANKMSGSRSV1", "20170117", "\n<FOO>bar"),
+			violations: []violation{
+				{stmtrs + "/FOO", "Element FOO is not allowed in STMTRS"},
+			},
+		},
+		{
+			name:     "extension element",
+			response: lenientTestResponse("BANKMSGSRSV1", "20170117", "\n<INTU.BID>1234"),
+		},
+		{
+			name:     "out of order",
+			response: lenientTestResponse("BANKMSGSRSV1", "20170117", ledgerBal),
+			violations: []violation{
+				{stmtrs + "/BANKTRANLIST", "Unexpected element BANKTRANLIST in STMTRS (expected one of AVAILBAL, CASHADVBALAMT, INTRATE, BALLIST, MKTGINFO, end of STMTRS)"},
+				{stmtrs + "/LEDGERBAL", "Unexpected element LEDGERBAL in STMTRS"},
+			},
+		},
+		{
+			name:     "repeated",
+			response: lenientTestResponse("BANKMSGSRSV1", "20170117", "\n<CURDEF>USD"),
+			violations: []violation{
+				{stmtrs + "/CURDEF", "Unexpected element CURDEF in STMTRS (expected one of BANKTRANLIST, LEDGERBAL)"},
+			},
+		},
+		{
+			name:     "requires later version",
+			response: lenientTestResponse("BANKMSGSRSV1", "20170117", "\n<BANKTRANLISTP>\n<DTASOF>20170201\n</BANKTRANLISTP>"),
+			violations: []violation{
+				{stmtrs + "/BANKTRANLISTP", "Element BANKTRANLISTP in STMTRS requires OFX 2.2, but the document is OFX 1.0.2"},
+				{stmtrs + "/BANKTRANLIST", "Unexpected element BANKTRANLIST in STMTRS"},
+			},
+		},
+		{
+			name:     "missing",
+			response: strings.Replace(lenientTestResponse("BANKMSGSRSV1", "20170117", ""), ledgerBal[1:], "", 1),
+			violations: []violation{
+				{stmtrs, "Missing element LEDGERBAL in STMTRS"},
+			},
+		},
+		{
+			name:     "missing before",
+			response: strings.Replace(lenientTestResponse("BANKMSGSRSV1", "20170117", ""), "<FITID>2017011701\n", "", 1),
+			violations: []violation{
+				{stmtrs + "/BANKTRANLIST/STMTTRN/NAME", "Unexpected element NAME in STMTTRN (expected FITID)"},
+			},
+		},
+		{
+			name:     "multiple",
+			response: strings.Replace(lenientTestResponse("CREDITCARDMSGSRSV1", "20170117", "\n<FOO>bar"), ledgerBal[1:], "", 1),
+			violations: []violation{
+				{"OFX/CREDITCARDMSGSRSV1/STMTTRNRS", "Element STMTTRNRS is not allowed in CREDITCARDMSGSRSV1"},
+				{stmtrs[:4] + "CREDITCARDMSGSRSV1/STMTTRNRS/STMTRS/FOO", "Element FOO is not allowed in STMTRS"},
+				{stmtrs[:4] + "CREDITCARDMSGSRSV1/STMTTRNRS/STMTRS", "Missing element LEDGERBAL in STMTRS"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		diagnostics, err := ValidateSchema(strings.NewReader(test.response))
+		if err != nil {
+			t.Fatalf("%s: Unexpected error: %s\n", test.name, err)
+		}
+		if len(diagnostics) != len(test.violations) {
+			t.Errorf("%s: Expected %d diagnostics, got %d: %v\n", test.name, len(test.violations), len(diagnostics), diagnostics)
+			continue
+		}
+		for i, v := range test.violations {
+			d := diagnostics[i]
+			if d.Path != v.path {
+				t.Errorf("%s: Expected diagnostic path %s, got %s\n", test.name, v.path, d.Path)
+			}
+			if !strings.HasPrefix(d.Message, v.message) {
+				t.Errorf("%s: Expected diagnostic %q, got %q\n", test.name, v.message, d.Message)
+			}
+			if d.Offset <= 0 || d.Offset >= int64(len(test.response)) {
+				t.Errorf("%s: Diagnostic offset %d out of range\n", test.name, d.Offset)
+			}
+		}
+	}
+}
+
+func TestValidateSchemaUnclosed(t *testing.T) {
+	response := `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="203" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+	<SIGNONMSGSRSV1>
+		<SONRS>
+			<STATUS>
+				<CODE>0
+				<SEVERITY>INFO</SEVERITY>
+			</STATUS>
+			<DTSERVER>20170407001840</DTSERVER>
+			<LANGUAGE>ENG</LANGUAGE>
+		</SONRS>
+	</SIGNONMSGSRSV1>
+</OFX>`
+	diagnostics, err := ValidateSchema(strings.NewReader(response))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Message != "Element CODE is not closed" || diagnostics[0].Path != "OFX/SIGNONMSGSRSV1/SONRS/STATUS/CODE" {
+		t.Fatalf("Expected one diagnostic for the unclosed CODE element, got %v\n", diagnostics)
+	}
+}
+
+func TestRequestValidateSchema(t *testing.T) {
+	for _, version := range []ofxVersion{OfxVersion102, OfxVersion160, OfxVersion203, OfxVersion220} {
+		client := BasicClient{
+			AppID:       "OFXGO",
+			AppVer:      "0001",
+			SpecVersion: version,
+		}
+
+		var request Request
+		request.Signon.UserID = "myusername"
+		request.Signon.UserPass = "Pa$$word"
+		request.Signon.Org = "BNK"
+		request.Signon.Fid = "1987"
+		request.Bank = append(request.Bank, &StatementRequest{
+			TrnUID: "123",
+			BankAcctFrom: BankAcct{
+				BankID:   "318398732",
+				AcctID:   "78346129",
+				AcctType: AcctTypeChecking,
+			},
+			Include: true,
+		})
+		request.InvStmt = append(request.InvStmt, &InvStatementRequest{
+			TrnUID: "456",
+			InvAcctFrom: InvAcct{
+				BrokerID: "example.com",
+				AcctID:   "12341234",
+			},
+			DtStart:        NewDateGMT(2016, 1, 1, 0, 0, 0, 0),
+			Include:        true,
+			IncludeOO:      true,
+			IncludePos:     true,
+			IncludeBalance: true,
+		})
+		request.SetClientFields(&client)
+
+		diagnostics, err := request.ValidateSchema()
+		if err != nil {
+			t.Fatalf("Unexpected error validating %s request: %s\n", version, err)
+		}
+		for _, d := range diagnostics {
+			t.Errorf("Unexpected diagnostic validating %s request: %s\n", version, d)
+		}
+	}
+}
+
+func TestResponseValidateSchema(t *testing.T) {
+	EST := time.FixedZone("EST", -5*60*60)
+	dtAsOf := NewDate(2017, 4, 1, 0, 0, 0, 0, EST)
+	var availBal Amount
+	availBal.SetFrac64(9000, 100)
+	var total, units Amount
+	total.SetFrac64(-2500, 100)
+	units.SetInt64(10)
+	invTran := InvTran{FiTID: "1234", DtTrade: *dtAsOf}
+	usd, err := NewCurrSymbol("USD")
+	if err != nil {
+		t.Fatalf("Unexpected error creating CurrSymbol for USD: %s\n", err)
+	}
+
+	response := Response{
+		Version: OfxVersion203,
+		Signon: SignonResponse{
+			Status:   Status{Code: 0, Severity: "INFO"},
+			DtServer: *dtAsOf,
+			Language: "ENG",
+			Org:      "BNK",
+			Fid:      "1987",
+		},
+		Bank: []Message{&StatementResponse{
+			TrnUID: "123",
+			Status: Status{Code: 0, Severity: "INFO"},
+			CurDef: *usd,
+			BankAcctFrom: BankAcct{
+				BankID:   "318398732",
+				AcctID:   "78346129",
+				AcctType: AcctTypeChecking,
+			},
+			BankTranList: &TransactionList{
+				DtStart: *dtAsOf,
+				DtEnd:   *dtAsOf,
+				Transactions: []Transaction{{
+					TrnType:  TrnTypeDebit,
+					DtPosted: *dtAsOf,
+					TrnAmt:   total,
+					FiTID:    "5678",
+					Name:     "Groceries",
+				}},
+			},
+			DtAsOf:      *dtAsOf,
+			AvailBalAmt: &availBal,
+			AvailDtAsOf: dtAsOf,
+		}},
+		InvStmt: []Message{&InvStatementResponse{
+			TrnUID: "456",
+			Status: Status{Code: 0, Severity: "INFO"},
+			DtAsOf: *dtAsOf,
+			CurDef: *usd,
+			InvAcctFrom: InvAcct{
+				BrokerID: "example.com",
+				AcctID:   "12341234",
+			},
+			InvTranList: &InvTranList{
+				DtStart: *dtAsOf,
+				DtEnd:   *dtAsOf,
+				InvTransactions: []InvTransaction{
+					BuyStock{
+						InvBuy: InvBuy{
+							InvTran:     invTran,
+							SecID:       SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"},
+							Units:       units,
+							UnitPrice:   units,
+							Total:       total,
+							SubAcctSec:  SubAcctTypeCash,
+							SubAcctFund: SubAcctTypeCash,
+						},
+						BuyType: BuyTypeBuy,
+					},
+					JrnlFund{InvTran: invTran, SubAcctTo: SubAcctTypeMargin, SubAcctFrom: SubAcctTypeCash, Total: total},
+					JrnlSec{InvTran: invTran, SecID: SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}, SubAcctTo: SubAcctTypeMargin, SubAcctFrom: SubAcctTypeCash, Units: units},
+				},
+			},
+		}},
+	}
+
+	diagnostics, err := response.ValidateSchema()
+	if err != nil {
+		t.Fatalf("Unexpected error validating response: %s\n", err)
+	}
+	for _, d := range diagnostics {
+		t.Errorf("Unexpected diagnostic validating response: %s\n", d)
+	}
+}
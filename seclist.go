@@ -13,6 +13,16 @@ type SecurityID struct {
 	UniqueIDType String   `xml:"UNIQUEIDTYPE"` // Should always be "CUSIP" for US FI's
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (s SecurityID) Valid() (bool, error) {
+	if len(s.UniqueID) == 0 {
+		return false, errors.New("SecurityID.UniqueID empty")
+	} else if len(s.UniqueIDType) == 0 {
+		return false, errors.New("SecurityID.UniqueIDType empty")
+	}
+	return true, nil
+}
+
 // SecurityRequest represents a request for one security. It is specified with
 // a SECID aggregate, a ticker symbol, or an FI assigned identifier (but no
 // more than one of them at a time)
@@ -24,6 +34,27 @@ type SecurityRequest struct {
 	FiID   String      `xml:"FIID,omitempty"`
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (r SecurityRequest) Valid() (bool, error) {
+	count := 0
+	if r.SecID != nil {
+		if ok, err := r.SecID.Valid(); !ok {
+			return false, err
+		}
+		count++
+	}
+	if len(r.Ticker) > 0 {
+		count++
+	}
+	if len(r.FiID) > 0 {
+		count++
+	}
+	if count != 1 {
+		return false, errors.New("Exactly one of SecurityRequest.SecID, Ticker, and FiID must be specified")
+	}
+	return true, nil
+}
+
 // SecListRequest represents a request for information (namely price) about one
 // or more securities
 type SecListRequest struct {
@@ -46,7 +77,14 @@ func (r *SecListRequest) Valid(version ofxVersion) (bool, error) {
 	if ok, err := r.TrnUID.Valid(); !ok {
 		return false, err
 	}
-	// TODO implement
+	if len(r.Securities) == 0 {
+		return false, errors.New("SecListRequest must request at least one security")
+	}
+	for _, s := range r.Securities {
+		if ok, err := s.Valid(); !ok {
+			return false, err
+		}
+	}
 	return true, nil
 }
 
@@ -142,6 +180,12 @@ func (i DebtInfo) SecurityInfo() SecInfo {
 	return i.SecInfo
 }
 
+// SecurityAssetClass returns the asset class of this security, or 0 if the FI
+// didn't provide one
+func (i DebtInfo) SecurityAssetClass() assetClass {
+	return i.AssetClass
+}
+
 // AssetPortion represents the percentage of a mutual fund with the given asset
 // classification
 type AssetPortion struct {
@@ -180,6 +224,20 @@ func (i MFInfo) SecurityInfo() SecInfo {
 	return i.SecInfo
 }
 
+// SecurityAssetClass returns the asset class making up the largest portion of
+// this mutual fund, or 0 if the FI didn't provide any
+func (i MFInfo) SecurityAssetClass() assetClass {
+	var class assetClass
+	var largest Amount
+	for _, portion := range i.AssetClasses {
+		if class == 0 || portion.Percent.Cmp(&largest.Rat) > 0 {
+			class = portion.AssetClass
+			largest = portion.Percent
+		}
+	}
+	return class
+}
+
 // OptInfo provides information about an option
 type OptInfo struct {
 	XMLName      xml.Name    `xml:"OPTINFO"`
@@ -203,6 +261,12 @@ func (i OptInfo) SecurityInfo() SecInfo {
 	return i.SecInfo
 }
 
+// SecurityAssetClass returns the asset class of this security, or 0 if the FI
+// didn't provide one
+func (i OptInfo) SecurityAssetClass() assetClass {
+	return i.AssetClass
+}
+
 // OtherInfo provides information about a security type not covered by the
 // other *Info elements
 type OtherInfo struct {
@@ -223,6 +287,12 @@ func (i OtherInfo) SecurityInfo() SecInfo {
 	return i.SecInfo
 }
 
+// SecurityAssetClass returns the asset class of this security, or 0 if the FI
+// didn't provide one
+func (i OtherInfo) SecurityAssetClass() assetClass {
+	return i.AssetClass
+}
+
 // StockInfo provides information about a security type
 type StockInfo struct {
 	XMLName      xml.Name   `xml:"STOCKINFO"`
@@ -244,6 +314,12 @@ func (i StockInfo) SecurityInfo() SecInfo {
 	return i.SecInfo
 }
 
+// SecurityAssetClass returns the asset class of this security, or 0 if the FI
+// didn't provide one
+func (i StockInfo) SecurityAssetClass() assetClass {
+	return i.AssetClass
+}
+
 // SecurityList is a container for Security objects containaing information
 // about securities
 type SecurityList struct {
@@ -268,6 +344,44 @@ func (r *SecurityList) Type() messageType {
 	return SecListRs
 }
 
+// decodeSecurity decodes the Security starting with start
+func decodeSecurity(d *xml.Decoder, start xml.StartElement) (Security, error) {
+	switch start.Name.Local {
+	case "DEBTINFO":
+		var security DebtInfo
+		if err := d.DecodeElement(&security, &start); err != nil {
+			return nil, err
+		}
+		return Security(security), nil
+	case "MFINFO":
+		var security MFInfo
+		if err := d.DecodeElement(&security, &start); err != nil {
+			return nil, err
+		}
+		return Security(security), nil
+	case "OPTINFO":
+		var security OptInfo
+		if err := d.DecodeElement(&security, &start); err != nil {
+			return nil, err
+		}
+		return Security(security), nil
+	case "OTHERINFO":
+		var security OtherInfo
+		if err := d.DecodeElement(&security, &start); err != nil {
+			return nil, err
+		}
+		return Security(security), nil
+	case "STOCKINFO":
+		var security StockInfo
+		if err := d.DecodeElement(&security, &start); err != nil {
+			return nil, err
+		}
+		return Security(security), nil
+	default:
+		return nil, errors.New("Invalid SECLIST child tag: " + start.Name.Local)
+	}
+}
+
 // UnmarshalXML handles unmarshalling a SecurityList from an SGML/XML string
 func (r *SecurityList) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	for {
@@ -278,40 +392,11 @@ func (r *SecurityList) UnmarshalXML(d *xml.Decoder, start xml.StartElement) erro
 			// If we found the end of our starting element, we're done parsing
 			return nil
 		} else if startElement, ok := tok.(xml.StartElement); ok {
-			switch startElement.Name.Local {
-			case "DEBTINFO":
-				var info DebtInfo
-				if err := d.DecodeElement(&info, &startElement); err != nil {
-					return err
-				}
-				r.Securities = append(r.Securities, Security(info))
-			case "MFINFO":
-				var info MFInfo
-				if err := d.DecodeElement(&info, &startElement); err != nil {
-					return err
-				}
-				r.Securities = append(r.Securities, Security(info))
-			case "OPTINFO":
-				var info OptInfo
-				if err := d.DecodeElement(&info, &startElement); err != nil {
-					return err
-				}
-				r.Securities = append(r.Securities, Security(info))
-			case "OTHERINFO":
-				var info OtherInfo
-				if err := d.DecodeElement(&info, &startElement); err != nil {
-					return err
-				}
-				r.Securities = append(r.Securities, Security(info))
-			case "STOCKINFO":
-				var info StockInfo
-				if err := d.DecodeElement(&info, &startElement); err != nil {
-					return err
-				}
-				r.Securities = append(r.Securities, Security(info))
-			default:
-				return errors.New("Invalid SECLIST child tag: " + startElement.Name.Local)
+			security, err := decodeSecurity(d, startElement)
+			if err != nil {
+				return err
 			}
+			r.Securities = append(r.Securities, security)
 		} else {
 			return errors.New("Didn't find an opening element")
 		}
@@ -0,0 +1,273 @@
+package ofxgo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type securityIDKind uint
+
+// SecurityIDKind* constants represent the kinds of UNIQUEIDTYPE whose
+// identifiers can be checked and converted. SecurityIDKindOther is used for
+// all other UNIQUEIDTYPEs (i.e. FI-assigned identifiers), whose UNIQUEIDs are
+// treated as opaque strings.
+const (
+	SecurityIDKindOther securityIDKind = iota
+	SecurityIDKindCUSIP
+	SecurityIDKindISIN
+	SecurityIDKindSEDOL
+)
+
+var securityIDKinds = [...]string{"", "CUSIP", "ISIN", "SEDOL"}
+
+func (k securityIDKind) String() string {
+	if k > SecurityIDKindOther && int(k) < len(securityIDKinds) {
+		return securityIDKinds[k]
+	}
+	return "OTHER"
+}
+
+// Kind returns the kind of identifier this SecurityID contains, according to
+// its UniqueIDType (case and surrounding whitespace are ignored)
+func (s SecurityID) Kind() securityIDKind {
+	uniqueIDType := strings.ToUpper(strings.TrimSpace(string(s.UniqueIDType)))
+	for i, kind := range securityIDKinds {
+		if i > 0 && kind == uniqueIDType {
+			return securityIDKind(i)
+		}
+	}
+	return SecurityIDKindOther
+}
+
+// Normalize returns a copy of this SecurityID with surrounding whitespace
+// removed from both fields and UniqueIDType upper-cased. For CUSIPs, ISINs,
+// and SEDOLs, UniqueID is also upper-cased, since FIs are inconsistent about
+// the case of the letters they contain.
+func (s SecurityID) Normalize() SecurityID {
+	s.UniqueIDType = String(strings.ToUpper(strings.TrimSpace(string(s.UniqueIDType))))
+	s.UniqueID = String(strings.TrimSpace(string(s.UniqueID)))
+	if s.Kind() != SecurityIDKindOther {
+		s.UniqueID = String(strings.ToUpper(string(s.UniqueID)))
+	}
+	return s
+}
+
+// CheckSecurityID returns an error if s is a CUSIP, ISIN, or SEDOL whose
+// UniqueID (once normalized) is malformed or has an incorrect check digit.
+// SecurityIDs of other kinds are not checked. It may be used as
+// ParseOptions.SecurityIDCheck to check the SecurityIDs in a response.
+func CheckSecurityID(s SecurityID) error {
+	s = s.Normalize()
+	id := string(s.UniqueID)
+	switch s.Kind() {
+	case SecurityIDKindCUSIP:
+		return CheckCUSIP(id)
+	case SecurityIDKindISIN:
+		return CheckISIN(id)
+	case SecurityIDKindSEDOL:
+		return CheckSEDOL(id)
+	}
+	return nil
+}
+
+// identifierCharValue returns the value of c used when computing CUSIP, ISIN,
+// and SEDOL check digits, or -1 if c is not a digit or upper-case letter
+func identifierCharValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10
+	}
+	return -1
+}
+
+// cusipCheckDigit returns the check digit for the first 8 characters of a
+// CUSIP
+func cusipCheckDigit(cusip string) (byte, error) {
+	sum := 0
+	for i := 0; i < 8; i++ {
+		var v int
+		switch cusip[i] {
+		case '*':
+			v = 36
+		case '@':
+			v = 37
+		case '#':
+			v = 38
+		default:
+			v = identifierCharValue(cusip[i])
+			if v < 0 {
+				return 0, fmt.Errorf("Invalid character %q in CUSIP %s", cusip[i], cusip)
+			}
+		}
+		if i%2 == 1 {
+			v *= 2
+		}
+		sum += v/10 + v%10
+	}
+	return byte('0' + (10-sum%10)%10), nil
+}
+
+// CheckCUSIP returns nil if cusip is a 9-character CUSIP with a correct check
+// digit, or an error describing what is wrong with it
+func CheckCUSIP(cusip string) error {
+	if len(cusip) != 9 {
+		return fmt.Errorf("CUSIP %s is not 9 characters long", cusip)
+	}
+	check, err := cusipCheckDigit(cusip)
+	if err != nil {
+		return err
+	} else if cusip[8] != check {
+		return fmt.Errorf("CUSIP %s has an incorrect check digit (expected %c)", cusip, check)
+	}
+	return nil
+}
+
+// isinCheckDigit returns the check digit for the first 11 characters of an
+// ISIN
+func isinCheckDigit(isin string) (byte, error) {
+	var digits []int
+	for i := 0; i < 11; i++ {
+		v := identifierCharValue(isin[i])
+		if v < 0 || (i < 2 && v < 10) {
+			return 0, fmt.Errorf("Invalid character %q in ISIN %s", isin[i], isin)
+		}
+		if v >= 10 {
+			digits = append(digits, v/10)
+		}
+		digits = append(digits, v%10)
+	}
+	// Luhn algorithm, doubling every other digit starting with the one which
+	// will be immediately to the left of the check digit
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		v := digits[i]
+		if (len(digits)-1-i)%2 == 0 {
+			v *= 2
+		}
+		sum += v/10 + v%10
+	}
+	return byte('0' + (10-sum%10)%10), nil
+}
+
+// CheckISIN returns nil if isin is a 12-character ISIN with a correct check
+// digit, or an error describing what is wrong with it
+func CheckISIN(isin string) error {
+	if len(isin) != 12 {
+		return fmt.Errorf("ISIN %s is not 12 characters long", isin)
+	}
+	check, err := isinCheckDigit(isin)
+	if err != nil {
+		return err
+	} else if isin[11] != check {
+		return fmt.Errorf("ISIN %s has an incorrect check digit (expected %c)", isin, check)
+	}
+	return nil
+}
+
+var sedolWeights = [...]int{1, 3, 1, 7, 3, 9}
+
+// CheckSEDOL returns nil if sedol is a 7-character SEDOL with a correct check
+// digit, or an error describing what is wrong with it
+func CheckSEDOL(sedol string) error {
+	if len(sedol) != 7 {
+		return fmt.Errorf("SEDOL %s is not 7 characters long", sedol)
+	}
+	sum := 0
+	for i, weight := range sedolWeights {
+		v := identifierCharValue(sedol[i])
+		if v < 0 || strings.IndexByte("AEIOU", sedol[i]) >= 0 {
+			return fmt.Errorf("Invalid character %q in SEDOL %s", sedol[i], sedol)
+		}
+		sum += v * weight
+	}
+	if check := byte('0' + (10-sum%10)%10); sedol[6] != check {
+		return fmt.Errorf("SEDOL %s has an incorrect check digit (expected %c)", sedol, check)
+	}
+	return nil
+}
+
+// cusipCountries are the ISIN country codes whose ISINs are formed from a
+// CUSIP
+var cusipCountries = []string{"US", "CA"}
+
+func isCUSIPCountry(country string) bool {
+	for _, c := range cusipCountries {
+		if c == country {
+			return true
+		}
+	}
+	return false
+}
+
+// CUSIPToISIN returns the ISIN for the security identified by cusip in
+// country, which must be either "US" or "CA"
+func CUSIPToISIN(cusip, country string) (string, error) {
+	if !isCUSIPCountry(country) {
+		return "", errors.New("ISINs are only formed from CUSIPs for US and CA securities")
+	} else if err := CheckCUSIP(cusip); err != nil {
+		return "", err
+	}
+	isin := country + cusip
+	check, err := isinCheckDigit(isin)
+	if err != nil {
+		return "", err
+	}
+	return isin + string(check), nil
+}
+
+// ISINToCUSIP returns the CUSIP embedded in isin, which must be the ISIN of a
+// US or CA security
+func ISINToCUSIP(isin string) (string, error) {
+	if err := CheckISIN(isin); err != nil {
+		return "", err
+	} else if !isCUSIPCountry(isin[:2]) {
+		return "", fmt.Errorf("ISIN %s does not contain a CUSIP", isin)
+	}
+	return isin[2:11], nil
+}
+
+// CUSIP returns this SecurityID as one of type CUSIP, converting it from an
+// ISIN if necessary. It returns an error if the SecurityID is neither a CUSIP
+// nor the ISIN of a US or CA security, or if the identifier is invalid.
+func (s SecurityID) CUSIP() (SecurityID, error) {
+	s = s.Normalize()
+	switch s.Kind() {
+	case SecurityIDKindCUSIP:
+		if err := CheckCUSIP(string(s.UniqueID)); err != nil {
+			return s, err
+		}
+		return s, nil
+	case SecurityIDKindISIN:
+		cusip, err := ISINToCUSIP(string(s.UniqueID))
+		if err != nil {
+			return s, err
+		}
+		return SecurityID{XMLName: s.XMLName, UniqueID: String(cusip), UniqueIDType: "CUSIP"}, nil
+	}
+	return s, fmt.Errorf("Can't convert %s SecurityID to a CUSIP", s.UniqueIDType)
+}
+
+// ISIN returns this SecurityID as one of type ISIN, converting it from a
+// CUSIP for a security in country ("US" or "CA") if necessary. It returns an
+// error if the SecurityID is neither an ISIN nor a CUSIP, or if the identifier
+// is invalid.
+func (s SecurityID) ISIN(country string) (SecurityID, error) {
+	s = s.Normalize()
+	switch s.Kind() {
+	case SecurityIDKindISIN:
+		if err := CheckISIN(string(s.UniqueID)); err != nil {
+			return s, err
+		}
+		return s, nil
+	case SecurityIDKindCUSIP:
+		isin, err := CUSIPToISIN(string(s.UniqueID), country)
+		if err != nil {
+			return s, err
+		}
+		return SecurityID{XMLName: s.XMLName, UniqueID: String(isin), UniqueIDType: "ISIN"}, nil
+	}
+	return s, fmt.Errorf("Can't convert %s SecurityID to an ISIN", s.UniqueIDType)
+}
@@ -0,0 +1,187 @@
+package ofxgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckSecurityIdentifiers(t *testing.T) {
+	tests := []struct {
+		check func(string) error
+		id    string
+		valid bool
+	}{
+		{CheckCUSIP, "037833100", true},
+		{CheckCUSIP, "78462F103", true},
+		{CheckCUSIP, "780087102", true},
+		{CheckCUSIP, "78462F104", false},
+		{CheckCUSIP, "78462F10", false},
+		{CheckCUSIP, "78462f103", false},
+		{CheckCUSIP, "SPY161216C00226000", false},
+		{CheckISIN, "US0378331005", true},
+		{CheckISIN, "US78462F1030", true},
+		{CheckISIN, "CA7800871021", true},
+		{CheckISIN, "GB0002634946", true},
+		{CheckISIN, "US0378331006", false},
+		{CheckISIN, "1S0378331005", false},
+		{CheckISIN, "US037833100", false},
+		{CheckSEDOL, "0263494", true},
+		{CheckSEDOL, "B0YBKJ7", true},
+		{CheckSEDOL, "0263495", false},
+		{CheckSEDOL, "A0YBKJ7", false},
+		{CheckSEDOL, "026349", false},
+	}
+	for _, test := range tests {
+		if err := test.check(test.id); (err == nil) != test.valid {
+			t.Errorf("Expected validity of %s to be %t, got error %v\n", test.id, test.valid, err)
+		}
+	}
+}
+
+func TestCUSIPISINConversion(t *testing.T) {
+	tests := []struct {
+		cusip, country, isin string
+	}{
+		{"037833100", "US", "US0378331005"},
+		{"78462F103", "US", "US78462F1030"},
+		{"780087102", "CA", "CA7800871021"},
+	}
+	for _, test := range tests {
+		isin, err := CUSIPToISIN(test.cusip, test.country)
+		if err != nil {
+			t.Errorf("Unexpected error converting %s to ISIN: %s\n", test.cusip, err)
+		} else if isin != test.isin {
+			t.Errorf("Expected %s to convert to %s, got %s\n", test.cusip, test.isin, isin)
+		}
+		cusip, err := ISINToCUSIP(test.isin)
+		if err != nil {
+			t.Errorf("Unexpected error converting %s to CUSIP: %s\n", test.isin, err)
+		} else if cusip != test.cusip {
+			t.Errorf("Expected %s to convert to %s, got %s\n", test.isin, test.cusip, cusip)
+		}
+	}
+
+	if _, err := CUSIPToISIN("037833100", "GB"); err == nil {
+		t.Errorf("Expected error converting CUSIP to GB ISIN\n")
+	}
+	if _, err := CUSIPToISIN("037833101", "US"); err == nil {
+		t.Errorf("Expected error converting invalid CUSIP to ISIN\n")
+	}
+	if _, err := ISINToCUSIP("GB0002634946"); err == nil {
+		t.Errorf("Expected error converting GB ISIN to CUSIP\n")
+	}
+
+	id := SecurityID{UniqueID: " us0378331005", UniqueIDType: "isin "}
+	cusip, err := id.CUSIP()
+	if err != nil {
+		t.Fatalf("Unexpected error converting SecurityID to CUSIP: %s\n", err)
+	}
+	if cusip.UniqueID != "037833100" || cusip.UniqueIDType != "CUSIP" {
+		t.Errorf("Expected CUSIP 037833100, got %s %s\n", cusip.UniqueIDType, cusip.UniqueID)
+	}
+	isin, err := cusip.ISIN("US")
+	if err != nil {
+		t.Fatalf("Unexpected error converting SecurityID to ISIN: %s\n", err)
+	}
+	if isin.UniqueID != "US0378331005" || isin.UniqueIDType != "ISIN" {
+		t.Errorf("Expected ISIN US0378331005, got %s %s\n", isin.UniqueIDType, isin.UniqueID)
+	}
+	if _, err := (SecurityID{UniqueID: "1234", UniqueIDType: "FIID"}).CUSIP(); err == nil {
+		t.Errorf("Expected error converting FIID SecurityID to CUSIP\n")
+	}
+}
+
+func TestSecurityIDNormalize(t *testing.T) {
+	tests := []struct {
+		id, normalized SecurityID
+		kind           securityIDKind
+	}{
+		{SecurityID{UniqueID: " 78462f103 ", UniqueIDType: " cusip"}, SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}, SecurityIDKindCUSIP},
+		{SecurityID{UniqueID: "b0ybkj7", UniqueIDType: "Sedol"}, SecurityID{UniqueID: "B0YBKJ7", UniqueIDType: "SEDOL"}, SecurityIDKindSEDOL},
+		{SecurityID{UniqueID: " abc123", UniqueIDType: "fiid"}, SecurityID{UniqueID: "abc123", UniqueIDType: "FIID"}, SecurityIDKindOther},
+	}
+	for _, test := range tests {
+		if kind := test.id.Kind(); kind != test.kind {
+			t.Errorf("Expected kind %s for %s, got %s\n", test.kind, test.id.UniqueIDType, kind)
+		}
+		if normalized := test.id.Normalize(); normalized != test.normalized {
+			t.Errorf("Expected %v to normalize to %v, got %v\n", test.id, test.normalized, normalized)
+		}
+	}
+}
+
+func TestParseOptionsSecurityIDCheck(t *testing.T) {
+	stockInfo := func(cusip, name string) string {
+		return "<STOCKINFO>\n<SECINFO>\n<SECID>\n<UNIQUEID>" + cusip + "\n<UNIQUEIDTYPE>CUSIP\n</SECID>\n<SECNAME>" + name + "\n</SECINFO>\n</STOCKINFO>\n"
+	}
+	input := lenientTestHeader + lenientTestSignon + "<SECLISTMSGSRSV1>\n<SECLIST>\n" +
+		stockInfo("78462f103", "SPDR S&amp;P 500 ETF") + stockInfo("78462f104", "Typo") +
+		"</SECLIST>\n</SECLISTMSGSRSV1>\n</OFX>\n"
+
+	if _, diagnostics, err := ParseResponseWithOptions(strings.NewReader(input), ParseOptions{}); err != nil || len(diagnostics) != 0 {
+		t.Errorf("Expected SecurityIDs to be valid without SecurityIDCheck: %v %v\n", err, diagnostics)
+	}
+
+	for _, lenient := range []bool{false, true} {
+		options := ParseOptions{Lenient: lenient, SecurityIDCheck: CheckSecurityID}
+		response, diagnostics, err := ParseResponseWithOptions(strings.NewReader(input), options)
+		if lenient && err != nil {
+			t.Errorf("Unexpected error parsing leniently: %s\n", err)
+		} else if !lenient && err == nil {
+			t.Errorf("Expected error for CUSIP with incorrect check digit\n")
+		}
+		if response == nil {
+			t.Fatalf("Expected response despite invalid SecurityID\n")
+		}
+		// The lower-case CUSIP is valid once normalized
+		if len(diagnostics) != 1 || diagnostics[0].Path != "OFX/SECLISTMSGSRSV1/SECLIST" || !strings.Contains(diagnostics[0].Message, "78462F104") {
+			t.Errorf("Expected one diagnostic for the incorrect CUSIP, got %v\n", diagnostics)
+		}
+	}
+}
+
+func TestBasicClientSecurityIDCheck(t *testing.T) {
+	input := lenientTestHeader + lenientTestSignon + "<SECLISTMSGSRSV1>\n<SECLIST>\n" +
+		"<STOCKINFO>\n<SECINFO>\n<SECID>\n<UNIQUEID>78462F104\n<UNIQUEIDTYPE>CUSIP\n</SECID>\n<SECNAME>Typo\n</SECINFO>\n</STOCKINFO>\n" +
+		"</SECLIST>\n</SECLISTMSGSRSV1>\n</OFX>\n"
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(input))
+	}))
+	defer server.Close()
+
+	request := func(c *BasicClient) error {
+		_, err := c.Request(&Request{
+			URL:    server.URL,
+			Signon: SignonRequest{UserID: "myusername", UserPass: "Pa$$word"},
+		})
+		return err
+	}
+
+	if err := request(&BasicClient{HTTPClient: server.Client()}); err != nil {
+		t.Errorf("Expected SecurityIDs to be valid without SecurityIDCheck: %s\n", err)
+	}
+	for _, hooks := range []Hooks{nil, &recordingHooks{}} {
+		c := &BasicClient{HTTPClient: server.Client(), Hooks: hooks, SecurityIDCheck: CheckSecurityID}
+		if err := request(c); err == nil || !strings.Contains(err.Error(), "78462F104") {
+			t.Errorf("Expected error for CUSIP with incorrect check digit, got %v\n", err)
+		}
+	}
+}
+
+func TestSecurityMasterISIN(t *testing.T) {
+	sm := NewSecurityMaster()
+	sm.Add(StockInfo{SecInfo: SecInfo{
+		SecID:   SecurityID{UniqueID: "US78462F1030", UniqueIDType: "ISIN"},
+		SecName: "SPDR S&P 500 ETF",
+		Ticker:  "SPY",
+	}})
+	security, ok := sm.Lookup(SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"})
+	if !ok {
+		t.Fatalf("Failed to look up security by CUSIP after adding it by ISIN\n")
+	}
+	if ticker := security.SecurityInfo().Ticker; ticker != "SPY" {
+		t.Errorf("Expected ticker SPY, got %s\n", ticker)
+	}
+}
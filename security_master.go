@@ -0,0 +1,188 @@
+package ofxgo
+
+import (
+	"sort"
+)
+
+// securityKey identifies a security in a SecurityMaster. SecurityIDs are not
+// compared directly because their XMLName differs depending on whether they
+// were unmarshalled, and FIs vary in the case and whitespace of their fields.
+// ISINs of US and CA securities are keyed by their CUSIP, so securities are
+// matched no matter which of the two a broker uses.
+type securityKey struct {
+	uniqueIDType string
+	uniqueID     string
+}
+
+func newSecurityKey(id SecurityID) securityKey {
+	id = id.Normalize()
+	if id.Kind() == SecurityIDKindISIN {
+		if cusip, err := id.CUSIP(); err == nil {
+			id = cusip
+		}
+	}
+	return securityKey{
+		uniqueIDType: string(id.UniqueIDType),
+		uniqueID:     string(id.UniqueID),
+	}
+}
+
+// SecurityMaster resolves the SecurityIDs referenced by investment
+// transactions and positions to the Securities describing them, which FIs
+// return separately in a SecurityList. A SecurityMaster may be built from
+// several Responses (i.e. successive downloads, or downloads for different
+// accounts), in which case the most recently priced information about each
+// security is kept.
+type SecurityMaster struct {
+	securities map[securityKey]Security
+}
+
+// NewSecurityMaster returns a SecurityMaster containing the securities from
+// all the SecurityLists in responses
+func NewSecurityMaster(responses ...*Response) *SecurityMaster {
+	sm := &SecurityMaster{securities: make(map[securityKey]Security)}
+	for _, response := range responses {
+		sm.AddResponse(response)
+	}
+	return sm
+}
+
+// AddResponse adds the securities from all the SecurityLists in response,
+// merging them with those already present (see Add)
+func (sm *SecurityMaster) AddResponse(response *Response) {
+	for _, message := range response.SecList {
+		if list, ok := message.(*SecurityList); ok {
+			sm.Add(list.Securities...)
+		}
+	}
+}
+
+// Add adds securities to the SecurityMaster. If a security with the same
+// SecurityID is already present, it is replaced unless it was priced more
+// recently (according to SecInfo.DtAsOf) than the one being added.
+func (sm *SecurityMaster) Add(securities ...Security) {
+	for _, security := range securities {
+		info := security.SecurityInfo()
+		key := newSecurityKey(info.SecID)
+		if existing, ok := sm.securities[key]; ok {
+			existingInfo := existing.SecurityInfo()
+			if existingInfo.DtAsOf != nil && (info.DtAsOf == nil || info.DtAsOf.Before(existingInfo.DtAsOf.Time)) {
+				continue
+			}
+		}
+		sm.securities[key] = security
+	}
+}
+
+// Len returns the number of securities in the SecurityMaster
+func (sm *SecurityMaster) Len() int {
+	return len(sm.securities)
+}
+
+// Lookup returns the Security identified by id, or ok=false if there is none
+func (sm *SecurityMaster) Lookup(id SecurityID) (security Security, ok bool) {
+	security, ok = sm.securities[newSecurityKey(id)]
+	return
+}
+
+// LookupTransaction returns the Security the investment transaction t was
+// for, or ok=false if t isn't for a security or the security is unknown
+func (sm *SecurityMaster) LookupTransaction(t InvTransaction) (Security, bool) {
+	id, ok := transactionSecurityID(t)
+	if !ok {
+		return nil, false
+	}
+	return sm.Lookup(id)
+}
+
+// LookupPosition returns the Security held in position p, or ok=false if the
+// security is unknown
+func (sm *SecurityMaster) LookupPosition(p Position) (Security, bool) {
+	return sm.Lookup(p.InvPosition().SecID)
+}
+
+// AssetClass returns the asset class of the security identified by id, or 0
+// if the security is unknown or the FI didn't provide its asset class
+func (sm *SecurityMaster) AssetClass(id SecurityID) assetClass {
+	security, ok := sm.Lookup(id)
+	if !ok {
+		return 0
+	}
+	return securityAssetClass(security)
+}
+
+// securityAssetClass returns the asset class of security, or 0 if it's unknown
+// (including for Security implementations which don't provide it)
+func securityAssetClass(security Security) assetClass {
+	if s, ok := security.(interface {
+		SecurityAssetClass() assetClass
+	}); ok {
+		return s.SecurityAssetClass()
+	}
+	return 0
+}
+
+// Securities returns all the securities in the SecurityMaster, ordered by
+// their SecurityIDs
+func (sm *SecurityMaster) Securities() []Security {
+	keys := make([]securityKey, 0, len(sm.securities))
+	for key := range sm.securities {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].uniqueIDType != keys[j].uniqueIDType {
+			return keys[i].uniqueIDType < keys[j].uniqueIDType
+		}
+		return keys[i].uniqueID < keys[j].uniqueID
+	})
+	securities := make([]Security, len(keys))
+	for i, key := range keys {
+		securities[i] = sm.securities[key]
+	}
+	return securities
+}
+
+// transactionSecurityID returns the SecurityID of the security investment
+// transaction t was for, or ok=false if it wasn't for a security (i.e.
+// JrnlFund and MarginInterest)
+func transactionSecurityID(t InvTransaction) (SecurityID, bool) {
+	switch tran := t.(type) {
+	case BuyDebt:
+		return tran.InvBuy.SecID, true
+	case BuyMF:
+		return tran.InvBuy.SecID, true
+	case BuyOpt:
+		return tran.InvBuy.SecID, true
+	case BuyOther:
+		return tran.InvBuy.SecID, true
+	case BuyStock:
+		return tran.InvBuy.SecID, true
+	case ClosureOpt:
+		return tran.SecID, true
+	case Income:
+		return tran.SecID, true
+	case InvExpense:
+		return tran.SecID, true
+	case JrnlSec:
+		return tran.SecID, true
+	case Reinvest:
+		return tran.SecID, true
+	case RetOfCap:
+		return tran.SecID, true
+	case SellDebt:
+		return tran.InvSell.SecID, true
+	case SellMF:
+		return tran.InvSell.SecID, true
+	case SellOpt:
+		return tran.InvSell.SecID, true
+	case SellOther:
+		return tran.InvSell.SecID, true
+	case SellStock:
+		return tran.InvSell.SecID, true
+	case Split:
+		return tran.SecID, true
+	case Transfer:
+		return tran.SecID, true
+	}
+	return SecurityID{}, false
+}
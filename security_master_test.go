@@ -0,0 +1,129 @@
+package ofxgo
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSecurityMasterSample(t *testing.T) {
+	file, err := os.Open("samples/valid_responses/inv_v202.ofx")
+	if err != nil {
+		t.Fatalf("Unexpected error opening sample: %s\n", err)
+	}
+	defer file.Close()
+	response, err := ParseResponse(file)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing sample: %s\n", err)
+	}
+
+	sm := NewSecurityMaster(response)
+	if sm.Len() == 0 {
+		t.Fatalf("Expected securities from sample\n")
+	}
+	if len(sm.Securities()) != sm.Len() {
+		t.Errorf("Expected Securities() to return %d securities, got %d\n", sm.Len(), len(sm.Securities()))
+	}
+
+	stmt := response.InvStmt[0].(*InvStatementResponse)
+	for _, tran := range stmt.InvTranList.InvTransactions {
+		if _, ok := transactionSecurityID(tran); !ok {
+			continue
+		}
+		if _, ok := sm.LookupTransaction(tran); !ok {
+			t.Errorf("Failed to look up security for %s transaction %s\n", tran.TransactionType(), tran.InvTransaction().FiTID)
+		}
+	}
+	for _, pos := range stmt.InvPosList {
+		security, ok := sm.LookupPosition(pos)
+		if !ok {
+			t.Errorf("Failed to look up security for position in %s\n", pos.InvPosition().SecID.UniqueID)
+		} else if security.SecurityInfo().SecID.UniqueID != pos.InvPosition().SecID.UniqueID {
+			t.Errorf("Looked up wrong security for position in %s\n", pos.InvPosition().SecID.UniqueID)
+		}
+	}
+}
+
+func TestSecurityMasterMerge(t *testing.T) {
+	older := NewDateGMT(2017, 1, 1, 0, 0, 0, 0)
+	newer := NewDateGMT(2017, 2, 1, 0, 0, 0, 0)
+	stock := func(uniqueIDType, ticker string, dtAsOf *Date) Security {
+		return StockInfo{
+			SecInfo: SecInfo{
+				SecID:   SecurityID{UniqueID: " 78462F103", UniqueIDType: String(uniqueIDType)},
+				SecName: "SPDR S&P 500 ETF",
+				Ticker:  String(ticker),
+				DtAsOf:  dtAsOf,
+			},
+		}
+	}
+	response := func(securities ...Security) *Response {
+		return &Response{SecList: []Message{&SecurityList{Securities: securities}}}
+	}
+
+	tests := []struct {
+		first, second Security
+		ticker        string
+	}{
+		{stock("CUSIP", "OLD", older), stock("CUSIP", "NEW", newer), "NEW"},
+		{stock("CUSIP", "NEW", newer), stock("CUSIP", "OLD", older), "NEW"},
+		{stock("CUSIP", "OLD", nil), stock("cusip", "NEW", nil), "NEW"},
+		{stock("CUSIP", "NEW", newer), stock("CUSIP", "UNPRICED", nil), "NEW"},
+		{stock("CUSIP", "UNPRICED", nil), stock("CUSIP", "NEW", newer), "NEW"},
+	}
+	for i, test := range tests {
+		sm := NewSecurityMaster(response(test.first), response(test.second))
+		if sm.Len() != 1 {
+			t.Errorf("%d: Expected securities to be merged, got %d\n", i, sm.Len())
+		}
+		security, ok := sm.Lookup(SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"})
+		if !ok {
+			t.Errorf("%d: Failed to look up security\n", i)
+		} else if ticker := security.SecurityInfo().Ticker; ticker != String(test.ticker) {
+			t.Errorf("%d: Expected ticker %s, got %s\n", i, test.ticker, ticker)
+		}
+	}
+
+	sm := NewSecurityMaster()
+	if _, ok := sm.Lookup(SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}); ok {
+		t.Errorf("Unexpectedly looked up security in empty SecurityMaster\n")
+	}
+	if _, ok := sm.LookupTransaction(JrnlFund{}); ok {
+		t.Errorf("Unexpectedly looked up security for JrnlFund\n")
+	}
+}
+
+func TestSecurityAssetClass(t *testing.T) {
+	var small, large Amount
+	small.SetInt64(20)
+	large.SetInt64(80)
+
+	tests := []struct {
+		security Security
+		expected assetClass
+	}{
+		{StockInfo{AssetClass: AssetClassLargeStock}, AssetClassLargeStock},
+		{DebtInfo{}, 0},
+		{MFInfo{}, 0},
+		{MFInfo{AssetClasses: []AssetPortion{
+			{AssetClass: AssetClassDomesticBond, Percent: small},
+			{AssetClass: AssetClassIntlStock, Percent: large},
+		}}, AssetClassIntlStock},
+	}
+	for _, test := range tests {
+		if class := securityAssetClass(test.security); class != test.expected {
+			t.Errorf("Expected %s asset class %s, got %s\n", test.security.SecurityType(), test.expected, class)
+		}
+	}
+
+	sm := NewSecurityMaster()
+	sm.Add(StockInfo{
+		SecInfo:    SecInfo{SecID: SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}},
+		AssetClass: AssetClassLargeStock,
+	})
+	if class := sm.AssetClass(SecurityID{UniqueID: "78462F103", UniqueIDType: "CUSIP"}); class != AssetClassLargeStock {
+		t.Errorf("Expected SecurityMaster asset class %s, got %s\n", AssetClassLargeStock, class)
+	}
+	if class := sm.AssetClass(SecurityID{UniqueID: "922908769", UniqueIDType: "CUSIP"}); class != 0 {
+		t.Errorf("Expected no asset class for unknown security, got %s\n", class)
+	}
+}
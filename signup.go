@@ -1,6 +1,7 @@
 package ofxgo
 
 import (
+	"errors"
 	"fmt"
 	"github.com/aclindsa/xml"
 )
@@ -73,6 +74,18 @@ type BankAcctInfo struct {
 	SvcStatus          svcStatus          `xml:"SVCSTATUS"` // One of AVAIL (available, but not yet requested), PEND (requested, but not yet available), ACTIVE
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (bai BankAcctInfo) Valid() (bool, error) {
+	if ok, err := bai.BankAcctFrom.Valid(); !ok {
+		return false, err
+	} else if !bai.SvcStatus.Valid() {
+		return false, errors.New("BankAcctInfo.SvcStatus invalid")
+	} else if bai.AcctClassification != 0 && !bai.AcctClassification.Valid() {
+		return false, errors.New("BankAcctInfo.AcctClassification invalid")
+	}
+	return true, nil
+}
+
 // String makes pointers to BankAcctInfo structs print nicely
 func (bai *BankAcctInfo) String() string {
 	return fmt.Sprintf("%+v", *bai)
@@ -91,6 +104,18 @@ type CCAcctInfo struct {
 	SvcStatus          svcStatus          `xml:"SVCSTATUS"`                    // One of AVAIL (available, but not yet requested), PEND (requested, but not yet available), ACTIVE
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (ci CCAcctInfo) Valid() (bool, error) {
+	if ok, err := ci.CCAcctFrom.Valid(); !ok {
+		return false, err
+	} else if !ci.SvcStatus.Valid() {
+		return false, errors.New("CCAcctInfo.SvcStatus invalid")
+	} else if ci.AcctClassification != 0 && !ci.AcctClassification.Valid() {
+		return false, errors.New("CCAcctInfo.AcctClassification invalid")
+	}
+	return true, nil
+}
+
 // String makes pointers to CCAcctInfo structs print nicely
 func (ci *CCAcctInfo) String() string {
 	return fmt.Sprintf("%+v", *ci)
@@ -109,6 +134,20 @@ type InvAcctInfo struct {
 	OptionLevel   String        `xml:"OPTIONLEVEL,omitempty"` // Text desribing option trading privileges
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (iai InvAcctInfo) Valid() (bool, error) {
+	if ok, err := iai.InvAcctFrom.Valid(); !ok {
+		return false, err
+	} else if !iai.UsProductType.Valid() {
+		return false, errors.New("InvAcctInfo.UsProductType invalid")
+	} else if !iai.SvcStatus.Valid() {
+		return false, errors.New("InvAcctInfo.SvcStatus invalid")
+	} else if iai.InvAcctType != 0 && !iai.InvAcctType.Valid() {
+		return false, errors.New("InvAcctInfo.InvAcctType invalid")
+	}
+	return true, nil
+}
+
 // String makes pointers to InvAcctInfo structs print nicely
 func (iai *InvAcctInfo) String() string {
 	return fmt.Sprintf("%+v", *iai)
@@ -133,6 +172,33 @@ type AcctInfo struct {
 	// TODO BPACCTINFO?
 }
 
+// Valid returns (true, nil) if this struct is valid OFX
+func (ai AcctInfo) Valid() (bool, error) {
+	count := 0
+	if ai.BankAcctInfo != nil {
+		if ok, err := ai.BankAcctInfo.Valid(); !ok {
+			return false, err
+		}
+		count++
+	}
+	if ai.CCAcctInfo != nil {
+		if ok, err := ai.CCAcctInfo.Valid(); !ok {
+			return false, err
+		}
+		count++
+	}
+	if ai.InvAcctInfo != nil {
+		if ok, err := ai.InvAcctInfo.Valid(); !ok {
+			return false, err
+		}
+		count++
+	}
+	if count != 1 {
+		return false, errors.New("Exactly one of AcctInfo.BankAcctInfo, CCAcctInfo, and InvAcctInfo must be specified")
+	}
+	return true, nil
+}
+
 // AcctInfoResponse contains the information about all a user's accounts
 // accessible from this FI
 type AcctInfoResponse struct {
@@ -152,10 +218,24 @@ func (air *AcctInfoResponse) Name() string {
 
 // Valid returns (true, nil) if this struct was valid OFX when unmarshalled
 func (air *AcctInfoResponse) Valid(version ofxVersion) (bool, error) {
+	var emptyDate Date
 	if ok, err := air.TrnUID.Valid(); !ok {
 		return false, err
+	} else if ok, err := air.Status.Valid(); !ok {
+		return false, err
+	}
+	// Servers omit ACCTINFORS when they fail to process the request
+	if air.Status.Code != 0 && air.DtAcctUp.Equal(emptyDate) {
+		return true, nil
+	}
+	if air.DtAcctUp.Equal(emptyDate) {
+		return false, errors.New("AcctInfoResponse.DtAcctUp not filled")
+	}
+	for _, ai := range air.AcctInfo {
+		if ok, err := ai.Valid(); !ok {
+			return false, err
+		}
 	}
-	//TODO implement
 	return true, nil
 }
 
@@ -156,3 +156,65 @@ func TestUnmarshalAcctInfoResponse(t *testing.T) {
 	checkResponsesEqual(t, &expected, response)
 	checkResponseRoundTrip(t, response)
 }
+
+func TestAcctInfoResponseValid(t *testing.T) {
+	air := AcctInfoResponse{
+		TrnUID:   "10938754",
+		Status:   Status{Code: 0, Severity: "INFO"},
+		DtAcctUp: *NewDateGMT(2005, 2, 28, 0, 0, 0, 0),
+		AcctInfo: []AcctInfo{{
+			Desc: "Brokerage",
+			InvAcctInfo: &InvAcctInfo{
+				InvAcctFrom:   InvAcct{BrokerID: "example.com", AcctID: "12345"},
+				UsProductType: UsProductTypeNormal,
+				SvcStatus:     SvcStatusActive,
+			},
+		}},
+	}
+	if ok, err := air.Valid(OfxVersion203); !ok {
+		t.Fatalf("Unexpected error from calling Valid: %s\n", err)
+	}
+
+	// ACCTINFORS may be omitted when the request failed
+	failed := AcctInfoResponse{
+		TrnUID: "10938754",
+		Status: Status{Code: 2000, Severity: "ERROR"},
+	}
+	if ok, err := failed.Valid(OfxVersion203); !ok {
+		t.Errorf("Unexpected error from calling Valid on failed response: %s\n", err)
+	}
+
+	bad := air
+	bad.DtAcctUp = Date{}
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with empty DtAcctUp\n")
+	}
+
+	bad = air
+	bad.AcctInfo = []AcctInfo{{Desc: "Nothing"}}
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with no *AcctInfo\n")
+	}
+
+	bad = air
+	bad.AcctInfo = []AcctInfo{{
+		InvAcctInfo: air.AcctInfo[0].InvAcctInfo,
+		CCAcctInfo: &CCAcctInfo{
+			CCAcctFrom: CCAcct{AcctID: "4321"},
+			SvcStatus:  SvcStatusActive,
+		},
+	}}
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with multiple *AcctInfo\n")
+	}
+
+	bad = air
+	bad.AcctInfo = []AcctInfo{{
+		BankAcctInfo: &BankAcctInfo{
+			BankAcctFrom: BankAcct{BankID: "8367556009", AcctID: "000999847", AcctType: AcctTypeChecking},
+		},
+	}}
+	if ok, err := bad.Valid(OfxVersion203); ok || err == nil {
+		t.Errorf("Expected error from calling Valid with empty SvcStatus\n")
+	}
+}
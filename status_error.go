@@ -0,0 +1,119 @@
+package ofxgo
+
+import (
+	"fmt"
+)
+
+// StatusError is returned by Client.Request when the server indicates the
+// signon or one of the transactions in a Request failed (with a Status of
+// SEVERITY ERROR). The Response is still returned alongside it, so the
+// remainder of the response may be inspected.
+type StatusError struct {
+	Code     Int
+	Severity String // One of INFO, WARN, ERROR
+	Message  String // Server-supplied message, if any
+	Meaning  string // Meaning of Code according to the OFX spec, or empty if Code is unknown
+	Element  string // Element containing the STATUS (i.e. SONRS or STMTTRNRS)
+	TrnUID   UID    // TRNUID of the failing transaction, empty for signon failures
+}
+
+// NewStatusError returns a StatusError describing status, which was found in
+// the element named element
+func NewStatusError(status *Status, element string, trnUID UID) *StatusError {
+	meaning, _ := status.CodeMeaning()
+	return &StatusError{
+		Code:     status.Code,
+		Severity: status.Severity,
+		Message:  status.Message,
+		Meaning:  meaning,
+		Element:  element,
+		TrnUID:   trnUID,
+	}
+}
+
+func (e *StatusError) Error() string {
+	meaning := e.Meaning
+	if len(meaning) == 0 {
+		meaning = "Unknown OFX status code"
+	}
+	s := fmt.Sprintf("%s status %d (%s)", e.Element, e.Code, meaning)
+	if len(e.TrnUID) > 0 {
+		s = fmt.Sprintf("%s %s status %d (%s)", e.Element, e.TrnUID, e.Code, meaning)
+	}
+	if len(e.Message) > 0 {
+		s += ": " + e.Message.String()
+	}
+	return s
+}
+
+// Signon returns true if the signon itself failed, as opposed to one of the
+// transactions in the request
+func (e *StatusError) Signon() bool {
+	return e.Element == "SONRS"
+}
+
+// Retryable returns true if the same request might succeed if it is retried
+// later without changes
+func (e *StatusError) Retryable() bool {
+	switch e.Code {
+	case 2000, // General error
+		15501: // Customer account already in use
+		return true
+	}
+	return false
+}
+
+// AuthFailure returns true if the error indicates the user's credentials (or
+// other authentication information, such as CLIENTUID or AUTHTOKEN) were
+// rejected or are insufficient
+func (e *StatusError) AuthFailure() bool {
+	switch e.Code {
+	case 3000, // MFA Challenge authentication required
+		3001,  // MFA Challenge information is invalid
+		15500, // Signon invalid
+		15502, // USERPASS lockout
+		15506, // Empty signon not supported
+		15507, // Signon invalid without supporting pin change request
+		15510, // CLIENTUID error
+		15511, // MFA error
+		15512, // AUTHTOKEN required
+		15513: // AUTHTOKEN invalid
+		return true
+	}
+	return false
+}
+
+// AccountLocked returns true if the server has locked the user out, usually
+// after too many failed signon attempts. Further attempts should not be made
+// until the user has contacted their FI.
+func (e *StatusError) AccountLocked() bool {
+	return e.Code == 15502
+}
+
+// Err returns a *StatusError describing the Status if its SEVERITY is ERROR,
+// or nil otherwise
+func (s *Status) Err(element string, trnUID UID) error {
+	if s.Severity != "ERROR" {
+		return nil
+	}
+	return NewStatusError(s, element, trnUID)
+}
+
+// statusErr returns a *StatusError for the signon or the first transaction in
+// response which failed, or nil if none did
+func (or *Response) statusErr() error {
+	if err := or.Signon.Status.Err(or.Signon.Name(), ""); err != nil {
+		return err
+	}
+	for _, set := range responseMessageSets(or) {
+		for _, message := range *set {
+			if status, ok := transactionStatus(message); ok {
+				trnUID, _, _ := transactionIDs(message)
+				if err := status.Err(message.Name(), trnUID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package ofxgo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStatusErrorClassification(t *testing.T) {
+	tests := []struct {
+		code                                   Int
+		retryable, authFailure, locked, signon bool
+	}{
+		{2000, true, false, false, false},
+		{2003, false, false, false, false},
+		{15500, false, true, false, true},
+		{15501, true, false, false, true},
+		{15502, false, true, true, true},
+		{15510, false, true, false, true},
+	}
+	for _, test := range tests {
+		meanings := statusMeanings[test.code]
+		status := Status{Code: test.code, Severity: String(meanings[1])}
+		element := "STMTTRNRS"
+		if test.signon {
+			element = "SONRS"
+		}
+		err := status.Err(element, "")
+		statusErr, ok := err.(*StatusError)
+		if !ok {
+			t.Fatalf("Expected *StatusError for code %d, got %T\n", test.code, err)
+		}
+		if statusErr.Meaning != meanings[0] {
+			t.Errorf("Expected meaning %q for code %d, got %q\n", meanings[0], test.code, statusErr.Meaning)
+		}
+		if statusErr.Retryable() != test.retryable || statusErr.AuthFailure() != test.authFailure || statusErr.AccountLocked() != test.locked || statusErr.Signon() != test.signon {
+			t.Errorf("Unexpected classification for code %d: retryable=%t authfailure=%t locked=%t signon=%t\n", test.code, statusErr.Retryable(), statusErr.AuthFailure(), statusErr.AccountLocked(), statusErr.Signon())
+		}
+	}
+
+	for _, status := range []Status{{Code: 0, Severity: "INFO"}, {Code: 2028, Severity: "WARN"}} {
+		if err := status.Err("SONRS", ""); err != nil {
+			t.Errorf("Expected no error for status %d, got %s\n", status.Code, err)
+		}
+	}
+}
+
+func TestTransactionErrorAs(t *testing.T) {
+	var err error = &TransactionError{
+		Request:  &StatementRequest{TrnUID: "1001"},
+		Response: &StatementResponse{TrnUID: "1001"},
+		Status:   Status{Code: 2004, Severity: "ERROR"},
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Expected errors.As to find *StatusError in TransactionError\n")
+	}
+	if statusErr.Code != 2004 || statusErr.TrnUID != "1001" || statusErr.Element != "STMTTRNRS" {
+		t.Errorf("Unexpected StatusError: %+v\n", statusErr)
+	}
+}
+
+func TestClientRequestStatusError(t *testing.T) {
+	var response Response
+	response.Version = OfxVersion203
+	response.Signon.Status = Status{Code: 15500, Severity: "ERROR", Message: "Bad password"}
+	response.Signon.DtServer = *NewDateGMT(2017, 4, 3, 9, 34, 58, 0)
+	response.Signon.Language = "ENG"
+	b, err := response.Marshal()
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling response: %s\n", err)
+	}
+	body := b.Bytes()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	for _, hooks := range []Hooks{nil, NopHooks{}} {
+		c := &BasicClient{HTTPClient: server.Client(), Hooks: hooks}
+		parsed, err := c.Request(&Request{
+			URL: server.URL,
+			Signon: SignonRequest{
+				UserID:   "myusername",
+				UserPass: "Pa$$word",
+			},
+		})
+		if parsed == nil {
+			t.Fatalf("Expected Response to be returned along with StatusError\n")
+		}
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("Expected *StatusError, got %T: %v\n", err, err)
+		}
+		if !statusErr.Signon() || !statusErr.AuthFailure() || statusErr.Code != 15500 {
+			t.Errorf("Unexpected StatusError: %+v\n", statusErr)
+		}
+		if !strings.Contains(err.Error(), "Signon invalid") || !strings.Contains(err.Error(), "Bad password") {
+			t.Errorf("Expected error message to include meaning and server message, got %q\n", err.Error())
+		}
+	}
+}
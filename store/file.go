@@ -0,0 +1,98 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Record kinds
+const (
+	KindAccount        = "account"        // Record.Account describes an account
+	KindTransaction    = "transaction"    // Record.OFX is a STMTTRN aggregate, keyed by FITID
+	KindInvTransaction = "invtransaction" // Record.OFX is an investment transaction aggregate (i.e. BUYSTOCK), keyed by FITID
+	KindPositions      = "positions"      // Record.OFX is the latest INVPOSLIST downloaded for the account
+	KindSecurity       = "security"       // Record.OFX is a security aggregate (i.e. STOCKINFO), keyed by SecurityID
+)
+
+// Record is the unit of data persisted by a Backend. Records are only ever
+// appended; a later Record with the same Kind, AccountKey, and Key supersedes
+// an earlier one, and a Record with Deleted set removes it.
+type Record struct {
+	Kind       string   `json:"kind"`
+	AccountKey string   `json:"accountkey,omitempty"`
+	Key        string   `json:"key,omitempty"`
+	Deleted    bool     `json:"deleted,omitempty"`
+	OFX        string   `json:"ofx,omitempty"`     // The record's OFX aggregate, marshalled as XML
+	Account    *Account `json:"account,omitempty"` // Only for KindAccount
+}
+
+// Backend persists the Records making up a Store. Implement it to keep a
+// Store somewhere other than a local file.
+type Backend interface {
+	// Load returns all the Records appended so far, in the order they were
+	// appended
+	Load() ([]Record, error)
+	// Append durably appends records
+	Append(records []Record) error
+}
+
+// FileBackend is the default Backend, storing Records in a file as JSON, one
+// per line
+type FileBackend struct {
+	Path string
+}
+
+// Load reads all the Records in the file, which need not exist yet
+func (b *FileBackend) Load() ([]Record, error) {
+	f, err := os.Open(b.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", b.Path, line, err)
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// Append writes records to the end of the file, creating it if necessary
+func (b *FileBackend) Append(records []Record) error {
+	f, err := os.OpenFile(b.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for i := range records {
+		if err := encoder.Encode(&records[i]); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,585 @@
+// Package store persists the accounts, transactions, positions, and
+// securities from parsed OFX responses, so that they can be queried later and
+// so that subsequent downloads only need to request transactions posted since
+// the last one (see Store.DtStart).
+//
+// Stores are kept by a pluggable Backend; FileBackend, the default, appends
+// records to a file as JSON lines. Transactions are keyed by FITID, and
+// overlapping downloads are merged with ofxgo.MergeTransactions and
+// ofxgo.MergeInvTransactions, so duplicates are stored once and corrections
+// and reversals are applied to previously-stored transactions.
+package store
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/xml"
+)
+
+// Account types
+const (
+	AccountTypeBank       = "bank"
+	AccountTypeCreditCard = "creditcard"
+	AccountTypeInvestment = "investment"
+)
+
+// Account describes an account data has been stored for
+type Account struct {
+	Key      string      `json:"key"`
+	Type     string      `json:"type"` // One of AccountTypeBank, AccountTypeCreditCard, AccountTypeInvestment
+	BankID   string      `json:"bankid,omitempty"`
+	BrokerID string      `json:"brokerid,omitempty"`
+	AcctID   string      `json:"acctid"`
+	AcctType string      `json:"accttype,omitempty"` // For bank accounts, i.e. CHECKING
+	DtEnd    *ofxgo.Date `json:"dtend,omitempty"`    // The latest DTEND of the transaction lists downloaded for this account
+}
+
+// BankAccountKey returns the key identifying a bank account in a Store
+func BankAccountKey(acct ofxgo.BankAcct) string {
+	return AccountTypeBank + ":" + strings.TrimSpace(string(acct.BankID)) + ":" + strings.TrimSpace(string(acct.AcctID))
+}
+
+// CCAccountKey returns the key identifying a credit card account in a Store
+func CCAccountKey(acct ofxgo.CCAcct) string {
+	return AccountTypeCreditCard + ":" + strings.TrimSpace(string(acct.AcctID))
+}
+
+// InvAccountKey returns the key identifying an investment account in a Store
+func InvAccountKey(acct ofxgo.InvAcct) string {
+	return AccountTypeInvestment + ":" + strings.TrimSpace(string(acct.BrokerID)) + ":" + strings.TrimSpace(string(acct.AcctID))
+}
+
+// securityKey returns the key identifying a security in a Store
+func securityKey(id ofxgo.SecurityID) string {
+	id = id.Normalize()
+	return string(id.UniqueIDType) + ":" + string(id.UniqueID)
+}
+
+// recordList holds the OFX of the records of one kind for an account, in the
+// order they were first stored
+type recordList struct {
+	keys []string
+	ofx  map[string]string
+}
+
+func newRecordList() *recordList {
+	return &recordList{ofx: make(map[string]string)}
+}
+
+func (l *recordList) put(key, ofx string) {
+	if _, ok := l.ofx[key]; !ok {
+		l.keys = append(l.keys, key)
+	}
+	l.ofx[key] = ofx
+}
+
+func (l *recordList) remove(key string) {
+	if _, ok := l.ofx[key]; !ok {
+		return
+	}
+	delete(l.ofx, key)
+	for i, k := range l.keys {
+		if k == key {
+			l.keys = append(l.keys[:i], l.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Store holds the data from OFX responses, persisting it with a Backend. It
+// is safe for concurrent use.
+type Store struct {
+	backend Backend
+
+	mu              sync.Mutex
+	accounts        map[string]*Account
+	accountKeys     []string
+	transactions    map[string]*recordList
+	invTransactions map[string]*recordList
+	positions       map[string]string
+	securities      *recordList
+}
+
+// Open returns a Store containing the Records previously persisted by backend
+func Open(backend Backend) (*Store, error) {
+	records, err := backend.Load()
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{
+		backend:         backend,
+		accounts:        make(map[string]*Account),
+		transactions:    make(map[string]*recordList),
+		invTransactions: make(map[string]*recordList),
+		positions:       make(map[string]string),
+		securities:      newRecordList(),
+	}
+	for i := range records {
+		if err := s.apply(&records[i]); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// OpenFile returns a Store kept in the file at path using a FileBackend. The
+// file is created the first time data is added if it doesn't already exist.
+func OpenFile(path string) (*Store, error) {
+	return Open(&FileBackend{Path: path})
+}
+
+// apply updates the in-memory state of the Store with r
+func (s *Store) apply(r *Record) error {
+	list := func(lists map[string]*recordList) *recordList {
+		l, ok := lists[r.AccountKey]
+		if !ok {
+			l = newRecordList()
+			lists[r.AccountKey] = l
+		}
+		return l
+	}
+
+	switch r.Kind {
+	case KindAccount:
+		if r.Account == nil {
+			return fmt.Errorf("store: account record %s has no account", r.AccountKey)
+		}
+		if _, ok := s.accounts[r.Account.Key]; !ok {
+			s.accountKeys = append(s.accountKeys, r.Account.Key)
+		}
+		account := *r.Account
+		s.accounts[account.Key] = &account
+	case KindTransaction, KindInvTransaction:
+		l := list(s.transactions)
+		if r.Kind == KindInvTransaction {
+			l = list(s.invTransactions)
+		}
+		if r.Deleted {
+			l.remove(r.Key)
+		} else {
+			l.put(r.Key, r.OFX)
+		}
+	case KindPositions:
+		if r.Deleted {
+			delete(s.positions, r.AccountKey)
+		} else {
+			s.positions[r.AccountKey] = r.OFX
+		}
+	case KindSecurity:
+		if r.Deleted {
+			s.securities.remove(r.Key)
+		} else {
+			s.securities.put(r.Key, r.OFX)
+		}
+	default:
+		return fmt.Errorf("store: unknown record kind %q", r.Kind)
+	}
+	return nil
+}
+
+func marshalOFX(v interface{}) (string, error) {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func decodeTransaction(ofx string) (ofxgo.Transaction, error) {
+	var t ofxgo.Transaction
+	err := xml.Unmarshal([]byte(ofx), &t)
+	return t, err
+}
+
+func decodeInvTransaction(ofx string) (ofxgo.InvTransaction, error) {
+	var l ofxgo.InvTranList
+	if err := xml.Unmarshal([]byte("<INVTRANLIST>"+ofx+"</INVTRANLIST>"), &l); err != nil {
+		return nil, err
+	} else if len(l.InvTransactions) != 1 {
+		return nil, fmt.Errorf("store: expected one investment transaction, found %d", len(l.InvTransactions))
+	}
+	return l.InvTransactions[0], nil
+}
+
+func decodeSecurity(ofx string) (ofxgo.Security, error) {
+	var l ofxgo.SecurityList
+	if err := xml.Unmarshal([]byte("<SECLIST>"+ofx+"</SECLIST>"), &l); err != nil {
+		return nil, err
+	} else if len(l.Securities) != 1 {
+		return nil, fmt.Errorf("store: expected one security, found %d", len(l.Securities))
+	}
+	return l.Securities[0], nil
+}
+
+// Accounts returns all the accounts data has been stored for, in the order
+// they were first stored
+func (s *Store) Accounts() []Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	accounts := make([]Account, len(s.accountKeys))
+	for i, key := range s.accountKeys {
+		accounts[i] = *s.accounts[key]
+	}
+	return accounts
+}
+
+// Account returns the account identified by key, or ok=false if nothing has
+// been stored for it
+func (s *Store) Account(key string) (account Account, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.accounts[key]; ok {
+		return *a, true
+	}
+	return Account{}, false
+}
+
+// DtStart returns the DTSTART to use when requesting transactions for the
+// account identified by key so that only those not yet stored are returned
+// (the DTEND of the last transaction list stored for it), or nil if none have
+// been stored
+func (s *Store) DtStart(key string) *ofxgo.Date {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.accounts[key]; ok && a.DtEnd != nil {
+		dtStart := *a.DtEnd
+		return &dtStart
+	}
+	return nil
+}
+
+// Transactions returns the bank, credit card, or investment account bank
+// transactions stored for the account identified by key
+func (s *Store) Transactions(key string) ([]ofxgo.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.storedTransactions(key)
+}
+
+func (s *Store) storedTransactions(key string) ([]ofxgo.Transaction, error) {
+	l, ok := s.transactions[key]
+	if !ok {
+		return nil, nil
+	}
+	transactions := make([]ofxgo.Transaction, 0, len(l.keys))
+	for _, fitid := range l.keys {
+		t, err := decodeTransaction(l.ofx[fitid])
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, nil
+}
+
+// InvTransactions returns the investment transactions stored for the account
+// identified by key
+func (s *Store) InvTransactions(key string) ([]ofxgo.InvTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.storedInvTransactions(key)
+}
+
+func (s *Store) storedInvTransactions(key string) ([]ofxgo.InvTransaction, error) {
+	l, ok := s.invTransactions[key]
+	if !ok {
+		return nil, nil
+	}
+	transactions := make([]ofxgo.InvTransaction, 0, len(l.keys))
+	for _, fitid := range l.keys {
+		t, err := decodeInvTransaction(l.ofx[fitid])
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, nil
+}
+
+// Positions returns the most recent positions stored for the investment
+// account identified by key
+func (s *Store) Positions(key string) (ofxgo.PositionList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ofx, ok := s.positions[key]
+	if !ok {
+		return nil, nil
+	}
+	var positions ofxgo.PositionList
+	err := xml.Unmarshal([]byte(ofx), &positions)
+	return positions, err
+}
+
+// Securities returns all the securities stored, with the most recently
+// priced information for each
+func (s *Store) Securities() ([]ofxgo.Security, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.storedSecurities()
+}
+
+func (s *Store) storedSecurities() ([]ofxgo.Security, error) {
+	securities := make([]ofxgo.Security, 0, len(s.securities.keys))
+	for _, key := range s.securities.keys {
+		security, err := decodeSecurity(s.securities.ofx[key])
+		if err != nil {
+			return nil, err
+		}
+		securities = append(securities, security)
+	}
+	return securities, nil
+}
+
+// SecurityMaster returns a SecurityMaster containing all the securities
+// stored
+func (s *Store) SecurityMaster() (*ofxgo.SecurityMaster, error) {
+	securities, err := s.Securities()
+	if err != nil {
+		return nil, err
+	}
+	sm := ofxgo.NewSecurityMaster()
+	sm.Add(securities...)
+	return sm, nil
+}
+
+// AddResponse stores the transactions, positions, and securities from the
+// bank, credit card, investment statement, and security list messages in
+// response, returning the number of transactions which hadn't been stored
+// before. Nothing is stored if an error is returned.
+func (s *Store) AddResponse(response *ofxgo.Response) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := update{store: s}
+	for _, m := range response.Bank {
+		if stmt, ok := m.(*ofxgo.StatementResponse); ok {
+			account := Account{
+				Key:      BankAccountKey(stmt.BankAcctFrom),
+				Type:     AccountTypeBank,
+				BankID:   string(stmt.BankAcctFrom.BankID),
+				AcctID:   string(stmt.BankAcctFrom.AcctID),
+				AcctType: stmt.BankAcctFrom.AcctType.String(),
+			}
+			if stmt.BankTranList != nil {
+				if err := u.addTransactions(account.Key, stmt.BankTranList.Transactions); err != nil {
+					return 0, err
+				}
+				u.addAccount(account, &stmt.BankTranList.DtEnd)
+			} else {
+				u.addAccount(account, nil)
+			}
+		}
+	}
+	for _, m := range response.CreditCard {
+		if stmt, ok := m.(*ofxgo.CCStatementResponse); ok {
+			account := Account{
+				Key:    CCAccountKey(stmt.CCAcctFrom),
+				Type:   AccountTypeCreditCard,
+				AcctID: string(stmt.CCAcctFrom.AcctID),
+			}
+			if stmt.BankTranList != nil {
+				if err := u.addTransactions(account.Key, stmt.BankTranList.Transactions); err != nil {
+					return 0, err
+				}
+				u.addAccount(account, &stmt.BankTranList.DtEnd)
+			} else {
+				u.addAccount(account, nil)
+			}
+		}
+	}
+	for _, m := range response.InvStmt {
+		if stmt, ok := m.(*ofxgo.InvStatementResponse); ok {
+			account := Account{
+				Key:      InvAccountKey(stmt.InvAcctFrom),
+				Type:     AccountTypeInvestment,
+				BrokerID: string(stmt.InvAcctFrom.BrokerID),
+				AcctID:   string(stmt.InvAcctFrom.AcctID),
+			}
+			var dtEnd *ofxgo.Date
+			if stmt.InvTranList != nil {
+				if err := u.addInvTransactions(account.Key, stmt.InvTranList.InvTransactions); err != nil {
+					return 0, err
+				}
+				var bankTransactions []ofxgo.Transaction
+				for _, b := range stmt.InvTranList.BankTransactions {
+					bankTransactions = append(bankTransactions, b.Transactions...)
+				}
+				if err := u.addTransactions(account.Key, bankTransactions); err != nil {
+					return 0, err
+				}
+				dtEnd = &stmt.InvTranList.DtEnd
+			}
+			if len(stmt.InvPosList) > 0 {
+				ofx, err := marshalOFX(stmt.InvPosList)
+				if err != nil {
+					return 0, err
+				}
+				if s.positions[account.Key] != ofx {
+					u.records = append(u.records, Record{Kind: KindPositions, AccountKey: account.Key, OFX: ofx})
+				}
+			}
+			u.addAccount(account, dtEnd)
+		}
+	}
+	for _, m := range response.SecList {
+		if list, ok := m.(*ofxgo.SecurityList); ok {
+			if err := u.addSecurities(list.Securities); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if len(u.records) == 0 {
+		return 0, nil
+	}
+	if err := s.backend.Append(u.records); err != nil {
+		return 0, err
+	}
+	for i := range u.records {
+		if err := s.apply(&u.records[i]); err != nil {
+			return 0, err
+		}
+	}
+	return u.added, nil
+}
+
+// update accumulates the Records needed to add a Response to a Store
+type update struct {
+	store   *Store
+	records []Record
+	added   int
+}
+
+// addAccount records account, along with the later of its stored DtEnd and
+// dtEnd, if either has changed
+func (u *update) addAccount(account Account, dtEnd *ofxgo.Date) {
+	existing, ok := u.store.accounts[account.Key]
+	if ok {
+		account.DtEnd = existing.DtEnd
+	}
+	dtEndChanged := false
+	if dtEnd != nil && !dtEnd.IsZero() && (account.DtEnd == nil || dtEnd.After(account.DtEnd.Time)) {
+		d := *dtEnd
+		account.DtEnd = &d
+		dtEndChanged = true
+	}
+	if ok && !dtEndChanged {
+		unchanged := *existing
+		unchanged.DtEnd = account.DtEnd
+		if unchanged == account {
+			return
+		}
+	}
+	u.records = append(u.records, Record{Kind: KindAccount, AccountKey: account.Key, Account: &account})
+}
+
+// diff appends the Records needed to change the stored records of kind for
+// key (in stored) to merged, where each merged record's key and OFX are given
+// by the corresponding entries of keys and ofx
+func (u *update) diff(kind, key string, stored *recordList, keys, ofx []string) {
+	present := make(map[string]bool)
+	for i, k := range keys {
+		present[k] = true
+		existing, ok := "", false
+		if stored != nil {
+			existing, ok = stored.ofx[k]
+		}
+		if !ok {
+			u.added++
+		}
+		if !ok || existing != ofx[i] {
+			u.records = append(u.records, Record{Kind: kind, AccountKey: key, Key: k, OFX: ofx[i]})
+		}
+	}
+	if stored != nil {
+		for _, k := range stored.keys {
+			if !present[k] {
+				u.records = append(u.records, Record{Kind: kind, AccountKey: key, Key: k, Deleted: true})
+			}
+		}
+	}
+}
+
+func (u *update) addTransactions(key string, transactions []ofxgo.Transaction) error {
+	var downloaded []ofxgo.Transaction
+	for _, t := range transactions {
+		if len(t.FiTID) > 0 {
+			downloaded = append(downloaded, t)
+		}
+	}
+	if len(downloaded) == 0 {
+		return nil
+	}
+	stored, err := u.store.storedTransactions(key)
+	if err != nil {
+		return err
+	}
+	merged, _ := ofxgo.MergeTransactions(stored, downloaded)
+
+	keys := make([]string, len(merged))
+	ofx := make([]string, len(merged))
+	for i := range merged {
+		keys[i] = string(merged[i].FiTID)
+		if ofx[i], err = marshalOFX(&merged[i]); err != nil {
+			return err
+		}
+	}
+	u.diff(KindTransaction, key, u.store.transactions[key], keys, ofx)
+	return nil
+}
+
+func (u *update) addInvTransactions(key string, transactions []ofxgo.InvTransaction) error {
+	var downloaded []ofxgo.InvTransaction
+	for _, t := range transactions {
+		if len(t.InvTransaction().FiTID) > 0 {
+			downloaded = append(downloaded, t)
+		}
+	}
+	if len(downloaded) == 0 {
+		return nil
+	}
+	stored, err := u.store.storedInvTransactions(key)
+	if err != nil {
+		return err
+	}
+	merged, _ := ofxgo.MergeInvTransactions(stored, downloaded)
+
+	keys := make([]string, len(merged))
+	ofx := make([]string, len(merged))
+	for i, t := range merged {
+		keys[i] = string(t.InvTransaction().FiTID)
+		if ofx[i], err = marshalOFX(t); err != nil {
+			return err
+		}
+	}
+	u.diff(KindInvTransaction, key, u.store.invTransactions[key], keys, ofx)
+	return nil
+}
+
+// addSecurities stores securities, unless more recently priced information
+// about them is already stored
+func (u *update) addSecurities(securities []ofxgo.Security) error {
+	stored, err := u.store.storedSecurities()
+	if err != nil {
+		return err
+	}
+	sm := ofxgo.NewSecurityMaster()
+	sm.Add(stored...)
+	sm.Add(securities...)
+	for _, s := range securities {
+		id := s.SecurityInfo().SecID
+		latest, _ := sm.Lookup(id)
+		ofx, err := marshalOFX(latest)
+		if err != nil {
+			return err
+		}
+		key := securityKey(id)
+		if existing, ok := u.store.securities.ofx[key]; !ok || existing != ofx {
+			u.records = append(u.records, Record{Kind: KindSecurity, Key: key, OFX: ofx})
+		}
+	}
+	return nil
+}
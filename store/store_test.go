@@ -0,0 +1,221 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aclindsa/ofxgo"
+	"github.com/aclindsa/ofxgo/internal/ofxtest"
+)
+
+// memoryBackend keeps Records in memory
+type memoryBackend struct {
+	records []Record
+	appends int
+}
+
+func (b *memoryBackend) Load() ([]Record, error) {
+	return b.records, nil
+}
+
+func (b *memoryBackend) Append(records []Record) error {
+	b.records = append(b.records, records...)
+	b.appends++
+	return nil
+}
+
+func storeTransaction(fitid, memo string, day int) ofxgo.Transaction {
+	return ofxgo.Transaction{
+		TrnType:  ofxgo.TrnTypeDebit,
+		DtPosted: *ofxgo.NewDateGMT(2017, 3, day, 0, 0, 0, 0),
+		FiTID:    ofxgo.String(fitid),
+		Memo:     ofxgo.String(memo),
+	}
+}
+
+func bankResponse(dtEnd int, transactions ...ofxgo.Transaction) *ofxgo.Response {
+	return &ofxgo.Response{
+		Bank: []ofxgo.Message{&ofxgo.StatementResponse{
+			BankAcctFrom: ofxgo.BankAcct{BankID: "318398732", AcctID: "78346129", AcctType: ofxgo.AcctTypeChecking},
+			BankTranList: &ofxgo.TransactionList{
+				DtStart:      *ofxgo.NewDateGMT(2017, 3, 1, 0, 0, 0, 0),
+				DtEnd:        *ofxgo.NewDateGMT(2017, 3, dtEnd, 0, 0, 0, 0),
+				Transactions: transactions,
+			},
+		}},
+	}
+}
+
+func checkFiTIDs(t *testing.T, transactions []ofxgo.Transaction, expected ...string) {
+	if len(transactions) != len(expected) {
+		t.Fatalf("Expected %d transactions, got %d: %v\n", len(expected), len(transactions), transactions)
+	}
+	for i, fitid := range expected {
+		if string(transactions[i].FiTID) != fitid {
+			t.Errorf("Expected transaction %d to be %s, got %s\n", i, fitid, transactions[i].FiTID)
+		}
+	}
+}
+
+func TestStoreIncremental(t *testing.T) {
+	backend := &memoryBackend{}
+	s, err := Open(backend)
+	if err != nil {
+		t.Fatalf("Unexpected error opening store: %s\n", err)
+	}
+	key := BankAccountKey(ofxgo.BankAcct{BankID: "318398732", AcctID: "78346129"})
+	if dtStart := s.DtStart(key); dtStart != nil {
+		t.Errorf("Expected no DtStart for a new account, got %s\n", dtStart)
+	}
+
+	added, err := s.AddResponse(bankResponse(10,
+		storeTransaction("1", "first", 2),
+		storeTransaction("2", "first", 5),
+		storeTransaction("", "no FITID", 6),
+	))
+	if err != nil {
+		t.Fatalf("Unexpected error adding response: %s\n", err)
+	}
+	if added != 2 {
+		t.Errorf("Expected 2 transactions added, got %d\n", added)
+	}
+	if dtStart := s.DtStart(key); dtStart == nil || !dtStart.Equal(*ofxgo.NewDateGMT(2017, 3, 10, 0, 0, 0, 0)) {
+		t.Errorf("Expected DtStart to be the last DtEnd, got %v\n", dtStart)
+	}
+
+	// Overlaps the first download, and corrects one of its transactions
+	correction := storeTransaction("3", "corrected", 5)
+	correction.CorrectFiTID = "2"
+	correction.CorrectAction = ofxgo.CorrectActionReplace
+	added, err = s.AddResponse(bankResponse(20,
+		storeTransaction("1", "second", 2),
+		correction,
+		storeTransaction("4", "second", 15),
+	))
+	if err != nil {
+		t.Fatalf("Unexpected error adding response: %s\n", err)
+	}
+	if added != 2 {
+		t.Errorf("Expected 2 transactions added, got %d\n", added)
+	}
+
+	transactions, err := s.Transactions(key)
+	if err != nil {
+		t.Fatalf("Unexpected error reading transactions: %s\n", err)
+	}
+	checkFiTIDs(t, transactions, "1", "3", "4")
+	if transactions[0].Memo != "second" {
+		t.Errorf("Expected the latest copy of a transaction to be stored, got %s\n", transactions[0].Memo)
+	}
+
+	// An older download changes nothing, including DtStart
+	appends := backend.appends
+	if added, err = s.AddResponse(bankResponse(10, storeTransaction("1", "second", 2))); err != nil || added != 0 {
+		t.Errorf("Expected nothing added, got %d, %v\n", added, err)
+	}
+	if backend.appends != appends {
+		t.Errorf("Expected no records appended for data already stored\n")
+	}
+	if dtStart := s.DtStart(key); !dtStart.Equal(*ofxgo.NewDateGMT(2017, 3, 20, 0, 0, 0, 0)) {
+		t.Errorf("Expected DtStart not to move backwards, got %s\n", dtStart)
+	}
+
+	// Reopening the store replays the same state
+	reopened, err := Open(backend)
+	if err != nil {
+		t.Fatalf("Unexpected error reopening store: %s\n", err)
+	}
+	transactions, err = reopened.Transactions(key)
+	if err != nil {
+		t.Fatalf("Unexpected error reading transactions: %s\n", err)
+	}
+	checkFiTIDs(t, transactions, "1", "3", "4")
+	accounts := reopened.Accounts()
+	if len(accounts) != 1 || accounts[0].Key != key || accounts[0].Type != AccountTypeBank || accounts[0].AcctType != "CHECKING" {
+		t.Errorf("Unexpected accounts: %v\n", accounts)
+	}
+}
+
+func TestStoreFileSample(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ofxgo-store")
+	if err != nil {
+		t.Fatalf("Unexpected error creating directory: %s\n", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.jsonl")
+
+	s, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening store: %s\n", err)
+	}
+	response := ofxtest.ParseSample(t, "../samples/valid_responses/inv_v202.ofx")
+	stmt := response.InvStmt[0].(*ofxgo.InvStatementResponse)
+	if _, err := s.AddResponse(response); err != nil {
+		t.Fatalf("Unexpected error adding response: %s\n", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reading store file: %s\n", err)
+	}
+	if added, err := s.AddResponse(response); err != nil || added != 0 {
+		t.Errorf("Expected nothing added the second time, got %d, %v\n", added, err)
+	}
+	if again, _ := os.Stat(path); again.Size() != info.Size() {
+		t.Errorf("Expected adding the same response twice not to grow the store\n")
+	}
+
+	s, err = OpenFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reopening store: %s\n", err)
+	}
+	key := InvAccountKey(stmt.InvAcctFrom)
+	if dtStart := s.DtStart(key); dtStart == nil || !dtStart.Equal(stmt.InvTranList.DtEnd) {
+		t.Errorf("Expected DtStart to be %s, got %v\n", stmt.InvTranList.DtEnd, dtStart)
+	}
+
+	invTransactions, err := s.InvTransactions(key)
+	if err != nil {
+		t.Fatalf("Unexpected error reading investment transactions: %s\n", err)
+	}
+	if len(invTransactions) != len(stmt.InvTranList.InvTransactions) {
+		t.Fatalf("Expected %d investment transactions, got %d\n", len(stmt.InvTranList.InvTransactions), len(invTransactions))
+	}
+	for i, tran := range invTransactions {
+		if tran.TransactionType() != stmt.InvTranList.InvTransactions[i].TransactionType() ||
+			tran.InvTransaction().FiTID != stmt.InvTranList.InvTransactions[i].InvTransaction().FiTID {
+			t.Errorf("Expected investment transaction %d to round-trip, got %v\n", i, tran)
+		}
+	}
+
+	transactions, err := s.Transactions(key)
+	if err != nil {
+		t.Fatalf("Unexpected error reading transactions: %s\n", err)
+	}
+	bankTransactions := 0
+	for _, b := range stmt.InvTranList.BankTransactions {
+		bankTransactions += len(b.Transactions)
+	}
+	if len(transactions) != bankTransactions {
+		t.Errorf("Expected the investment account's bank transactions to be stored, got %v\n", transactions)
+	}
+
+	positions, err := s.Positions(key)
+	if err != nil {
+		t.Fatalf("Unexpected error reading positions: %s\n", err)
+	}
+	if len(positions) != len(stmt.InvPosList) {
+		t.Errorf("Expected %d positions, got %d\n", len(stmt.InvPosList), len(positions))
+	}
+
+	sm, err := s.SecurityMaster()
+	if err != nil {
+		t.Fatalf("Unexpected error reading securities: %s\n", err)
+	}
+	for _, p := range positions {
+		if _, ok := sm.LookupPosition(p); !ok {
+			t.Errorf("Expected security for position %v to be stored\n", p)
+		}
+	}
+}
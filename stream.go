@@ -0,0 +1,171 @@
+package ofxgo
+
+import (
+	"errors"
+	"github.com/aclindsa/xml"
+	"io"
+)
+
+// StreamAccount identifies the account that transactions and positions passed
+// to StreamHandler callbacks belong to. Only one of BankAcctFrom, CCAcctFrom,
+// and InvAcctFrom will be non-nil.
+type StreamAccount struct {
+	BankAcctFrom *BankAcct
+	CCAcctFrom   *CCAcct
+	InvAcctFrom  *InvAcct
+	CurDef       CurrSymbol // Default currency for the statement
+}
+
+// StreamHandler contains the callbacks StreamResponse calls as it decodes each
+// part of a response. Any of them may be nil if the caller isn't interested in
+// that part. If a callback returns an error, decoding stops and that error is
+// returned from StreamResponse.
+//
+// The StreamAccount passed to callbacks is only valid for the duration of the
+// call, since it is reused for subsequent statements.
+type StreamHandler struct {
+	// Signon is called with the SignonResponse, which always comes first
+	Signon func(signon *SignonResponse) error
+	// Transaction is called for each bank or credit card transaction (STMTTRN
+	// in BANKTRANLIST)
+	Transaction func(account *StreamAccount, tran *Transaction) error
+	// InvTransaction is called for each investment transaction in INVTRANLIST
+	InvTransaction func(account *StreamAccount, tran InvTransaction) error
+	// InvBankTransaction is called for each INVBANKTRAN in INVTRANLIST
+	InvBankTransaction func(account *StreamAccount, tran *InvBankTransaction) error
+	// Position is called for each position in INVPOSLIST
+	Position func(account *StreamAccount, position Position) error
+	// Security is called for each security in SECLIST
+	Security func(security Security) error
+}
+
+// StreamResponse decodes an OFX response in SGML or XML from reader, calling
+// handler's callbacks for each transaction, position, and security as they are
+// encountered, rather than building a Response containing all of them. This
+// keeps memory use bounded for very large responses, such as multi-year
+// investment histories. Aggregates for which there is no callback are skipped
+// over, and no validation is performed. Errors decoding the response are
+// returned as *ParseErrors.
+//
+// The OFX version of the response is returned.
+func StreamResponse(reader io.Reader, handler *StreamHandler) (ofxVersion, error) {
+	rd, err := newResponseDecoder(reader, false)
+	if err != nil {
+		return 0, err
+	}
+	decoder, version, position := rd.Decoder, rd.version, rd.position
+
+	var account StreamAccount
+	var stack []string
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			if len(stack) > 0 {
+				return version, position.wrap(errors.New("Unexpected EOF inside " + stack[len(stack)-1]))
+			}
+			return version, nil
+		} else if err != nil {
+			return version, position.wrap(err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var parent string
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			handled, err := streamElement(decoder, position, t, parent, &account, handler)
+			if err != nil {
+				return version, err
+			} else if !handled {
+				stack = append(stack, t.Name.Local)
+			}
+		case xml.EndElement:
+			// Pop up to and including the matching start element, tolerating
+			// any unclosed elements in between
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] == t.Name.Local {
+					stack = stack[:i]
+					break
+				}
+			}
+			switch t.Name.Local {
+			case "STMTRS", "CCSTMTRS", "INVSTMTRS":
+				account = StreamAccount{}
+			}
+		}
+	}
+}
+
+// streamElement decodes the element beginning with start if it is one
+// StreamResponse is interested in, returning true if it did so. Decoding
+// errors are wrapped using position, but errors returned by handler's
+// callbacks are returned unchanged.
+func streamElement(d *xml.Decoder, position *positionReader, start xml.StartElement, parent string, account *StreamAccount, handler *StreamHandler) (bool, error) {
+	name := start.Name.Local
+	switch {
+	case name == "SONRS" && handler.Signon != nil:
+		var signon SignonResponse
+		if err := d.DecodeElement(&signon, &start); err != nil {
+			return true, position.wrap(err)
+		}
+		return true, handler.Signon(&signon)
+	case parent == "STMTRS" || parent == "CCSTMTRS" || parent == "INVSTMTRS":
+		switch name {
+		case "CURDEF":
+			return true, position.wrap(d.DecodeElement(&account.CurDef, &start))
+		case "BANKACCTFROM":
+			account.BankAcctFrom = &BankAcct{}
+			return true, position.wrap(d.DecodeElement(account.BankAcctFrom, &start))
+		case "CCACCTFROM":
+			account.CCAcctFrom = &CCAcct{}
+			return true, position.wrap(d.DecodeElement(account.CCAcctFrom, &start))
+		case "INVACCTFROM":
+			account.InvAcctFrom = &InvAcct{}
+			return true, position.wrap(d.DecodeElement(account.InvAcctFrom, &start))
+		}
+	case parent == "BANKTRANLIST" && name == "STMTTRN" && handler.Transaction != nil:
+		var tran Transaction
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return true, position.wrap(err)
+		}
+		return true, handler.Transaction(account, &tran)
+	case parent == "INVTRANLIST" && name == "INVBANKTRAN":
+		if handler.InvBankTransaction == nil {
+			return true, position.wrap(d.Skip())
+		}
+		var tran InvBankTransaction
+		if err := d.DecodeElement(&tran, &start); err != nil {
+			return true, position.wrap(err)
+		}
+		return true, handler.InvBankTransaction(account, &tran)
+	case parent == "INVTRANLIST" && name != "DTSTART" && name != "DTEND":
+		if handler.InvTransaction == nil {
+			return true, position.wrap(d.Skip())
+		}
+		tran, err := decodeInvTransaction(d, start)
+		if err != nil {
+			return true, position.wrap(err)
+		}
+		return true, handler.InvTransaction(account, tran)
+	case parent == "INVPOSLIST":
+		if handler.Position == nil {
+			return true, position.wrap(d.Skip())
+		}
+		pos, err := decodePosition(d, start)
+		if err != nil {
+			return true, position.wrap(err)
+		}
+		return true, handler.Position(account, pos)
+	case parent == "SECLIST":
+		if handler.Security == nil {
+			return true, position.wrap(d.Skip())
+		}
+		security, err := decodeSecurity(d, start)
+		if err != nil {
+			return true, position.wrap(err)
+		}
+		return true, handler.Security(security)
+	}
+	return false, nil
+}
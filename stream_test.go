@@ -0,0 +1,159 @@
+package ofxgo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// streamedItems collects everything passed to StreamHandler callbacks
+type streamedItems struct {
+	signon           *SignonResponse
+	transactions     []Transaction
+	invTransactions  []InvTransaction
+	bankTransactions []InvBankTransaction
+	positions        []Position
+	securities       []Security
+	accounts         []StreamAccount
+}
+
+func (s *streamedItems) handler() *StreamHandler {
+	return &StreamHandler{
+		Signon: func(signon *SignonResponse) error {
+			s.signon = signon
+			return nil
+		},
+		Transaction: func(account *StreamAccount, tran *Transaction) error {
+			s.transactions = append(s.transactions, *tran)
+			s.accounts = append(s.accounts, *account)
+			return nil
+		},
+		InvTransaction: func(account *StreamAccount, tran InvTransaction) error {
+			s.invTransactions = append(s.invTransactions, tran)
+			s.accounts = append(s.accounts, *account)
+			return nil
+		},
+		InvBankTransaction: func(account *StreamAccount, tran *InvBankTransaction) error {
+			s.bankTransactions = append(s.bankTransactions, *tran)
+			return nil
+		},
+		Position: func(account *StreamAccount, position Position) error {
+			s.positions = append(s.positions, position)
+			s.accounts = append(s.accounts, *account)
+			return nil
+		},
+		Security: func(security Security) error {
+			s.securities = append(s.securities, security)
+			return nil
+		},
+	}
+}
+
+// expectedItems collects the same items from a fully-decoded Response
+func expectedItems(response *Response) *streamedItems {
+	var s streamedItems
+	s.signon = &response.Signon
+	for _, messages := range [][]Message{response.Bank, response.CreditCard} {
+		for _, message := range messages {
+			switch stmt := message.(type) {
+			case *StatementResponse:
+				if stmt.BankTranList != nil {
+					s.transactions = append(s.transactions, stmt.BankTranList.Transactions...)
+				}
+			case *CCStatementResponse:
+				if stmt.BankTranList != nil {
+					s.transactions = append(s.transactions, stmt.BankTranList.Transactions...)
+				}
+			}
+		}
+	}
+	for _, message := range response.InvStmt {
+		stmt := message.(*InvStatementResponse)
+		if stmt.InvTranList != nil {
+			s.invTransactions = append(s.invTransactions, stmt.InvTranList.InvTransactions...)
+			s.bankTransactions = append(s.bankTransactions, stmt.InvTranList.BankTransactions...)
+		}
+		s.positions = append(s.positions, stmt.InvPosList...)
+	}
+	for _, message := range response.SecList {
+		if seclist, ok := message.(*SecurityList); ok {
+			s.securities = append(s.securities, seclist.Securities...)
+		}
+	}
+	return &s
+}
+
+func TestStreamResponse(t *testing.T) {
+	fn := func(path string, info os.FileInfo, err error) error {
+		if info.IsDir() {
+			return nil
+		} else if ext := filepath.Ext(path); ext != ".ofx" && ext != ".qfx" {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Unexpected error opening %s: %s\n", path, err)
+		}
+		defer file.Close()
+		response, err := DecodeResponse(file)
+		if err != nil {
+			t.Fatalf("Unexpected error decoding %s: %s\n", path, err)
+		}
+		expected := expectedItems(response)
+
+		file.Seek(0, 0)
+		var streamed streamedItems
+		version, err := StreamResponse(file, streamed.handler())
+		if err != nil {
+			t.Fatalf("Unexpected error streaming %s: %s\n", path, err)
+		}
+		if version != response.Version {
+			t.Errorf("%s: expected version %s, got %s\n", path, response.Version, version)
+		}
+		checkEqual(t, path+" Signon", reflect.ValueOf(expected.signon), reflect.ValueOf(streamed.signon))
+		checkEqual(t, path+" Transactions", reflect.ValueOf(expected.transactions), reflect.ValueOf(streamed.transactions))
+		checkEqual(t, path+" InvTransactions", reflect.ValueOf(expected.invTransactions), reflect.ValueOf(streamed.invTransactions))
+		checkEqual(t, path+" InvBankTransactions", reflect.ValueOf(expected.bankTransactions), reflect.ValueOf(streamed.bankTransactions))
+		checkEqual(t, path+" Positions", reflect.ValueOf(expected.positions), reflect.ValueOf(streamed.positions))
+		checkEqual(t, path+" Securities", reflect.ValueOf(expected.securities), reflect.ValueOf(streamed.securities))
+		for _, account := range streamed.accounts {
+			if account.BankAcctFrom == nil && account.CCAcctFrom == nil && account.InvAcctFrom == nil {
+				t.Errorf("%s: item streamed without its account\n", path)
+			}
+		}
+
+		// Streaming with no callbacks at all should skip everything cleanly
+		file.Seek(0, 0)
+		if _, err := StreamResponse(file, &StreamHandler{}); err != nil {
+			t.Fatalf("Unexpected error streaming %s without callbacks: %s\n", path, err)
+		}
+		return nil
+	}
+	filepath.Walk("samples/valid_responses", fn)
+	filepath.Walk("samples/busted_responses", fn)
+}
+
+func TestStreamResponseCallbackError(t *testing.T) {
+	file, err := os.Open("samples/valid_responses/401k_v203.ofx")
+	if err != nil {
+		t.Fatalf("Unexpected error opening sample: %s\n", err)
+	}
+	defer file.Close()
+
+	stop := errors.New("stop")
+	var count int
+	_, err = StreamResponse(file, &StreamHandler{
+		InvTransaction: func(account *StreamAccount, tran InvTransaction) error {
+			count++
+			return stop
+		},
+	})
+	if err != stop {
+		t.Errorf("Expected callback error to be returned, got %v\n", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected streaming to stop after first callback error, got %d calls\n", count)
+	}
+}
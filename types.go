@@ -104,12 +104,23 @@ var ofxDateZoneRegex = regexp.MustCompile(`^([+-]?[0-9]+)(\.([0-9]{2}))?(:([A-Z]
 // and defaults to GMT if a time zone is not provided, as per the OFX spec.
 // Leading and trailing whitespace is ignored.
 func (od *Date) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-	var value, zone, zoneFormat string
+	var value string
 	err := d.DecodeElement(&value, &start)
 	if err != nil {
 		return err
 	}
 
+	t, err := parseDate(value)
+	if err != nil {
+		return err
+	}
+	od.Time = t
+	return nil
+}
+
+// parseDate parses an OFX date/time string as described for Date.UnmarshalXML
+func parseDate(value string) (time.Time, error) {
+	var zone, zoneFormat string
 	value = strings.SplitN(value, "]", 2)[0]
 	value = strings.TrimSpace(value)
 
@@ -122,18 +133,18 @@ func (od *Date) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 
 		matches := ofxDateZoneRegex.FindStringSubmatch(zone)
 		if matches == nil {
-			return errors.New("Invalid OFX Date timezone format: " + zone)
+			return time.Time{}, errors.New("Invalid OFX Date timezone format: " + zone)
 		}
 		var err error
 		var zonehours, zoneminutes int
 		zonehours, err = strconv.Atoi(matches[1])
 		if err != nil {
-			return err
+			return time.Time{}, err
 		}
 		if len(matches[3]) > 0 {
 			zoneminutes, err = strconv.Atoi(matches[3])
 			if err != nil {
-				return err
+				return time.Time{}, err
 			}
 			zoneminutes = zoneminutes * 60 / 100
 		}
@@ -158,11 +169,10 @@ func (od *Date) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	for _, format := range ofxDateFormats {
 		t, err := time.Parse(format+zoneFormat, value+zone)
 		if err == nil {
-			od.Time = t
-			return nil
+			return t, nil
 		}
 	}
-	return errors.New("OFX: Couldn't parse date:" + value)
+	return time.Time{}, errors.New("OFX: Couldn't parse date:" + value)
 }
 
 // String returns a string representation of the Date abiding by the OFX spec
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // VanguardClient provides a Client implementation which handles Vanguard's
@@ -116,7 +117,15 @@ func (c *VanguardClient) RequestNoParse(r *Request) (*http.Response, error) {
 		return nil, err
 	}
 
+	hooks := clientHooks(c)
+	if hooks != nil {
+		hooks.RequestMarshaled(redactedRequest(r), RedactCredentials(b.Bytes()))
+	}
+	start := time.Now()
 	response, err := c.RawRequest(r.URL, b)
+	if hooks != nil {
+		hooks.RequestSent(r.URL, response, time.Since(start), err)
+	}
 
 	// Some financial institutions (cough, Vanguard, cough), require a cookie
 	// to be set on the http request, or they return empty responses.
@@ -129,7 +138,11 @@ func (c *VanguardClient) RequestNoParse(r *Request) (*http.Response, error) {
 			return nil, err
 		}
 
-		return rawRequestCookiesInsecureCiphers(r.URL, b, response.Cookies())
+		start = time.Now()
+		response, err = rawRequestCookiesInsecureCiphers(r.URL, b, response.Cookies())
+		if hooks != nil {
+			hooks.RequestSent(r.URL, response, time.Since(start), err)
+		}
 	}
 
 	return response, err